@@ -0,0 +1,144 @@
+package archive
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/graymeta/stow"
+)
+
+// Kind is the kind of Location this package provides.
+const Kind = "archive"
+
+// ConfigKeys are the supported configuration items for archives.
+const (
+	// ConfigPath is the path of a local archive.
+	ConfigPath = "path"
+	// ConfigURL is the URL of an Item of another Location holding the
+	// archive, used when there is no path.
+	ConfigURL = "url"
+	// ConfigConfig is the configuration of the Location of the URL, as a
+	// JSON object of strings.
+	ConfigConfig = "config"
+	// ConfigLayout is how the archive is split into Containers, LayoutRoot
+	// or LayoutDirs. Defaults to LayoutRoot.
+	ConfigLayout = "layout"
+)
+
+// Layouts of the Containers of an archive.
+const (
+	// LayoutRoot puts every file in the RootContainer.
+	LayoutRoot = "root"
+	// LayoutDirs makes a Container of each top-level directory.
+	LayoutDirs = "dirs"
+)
+
+// RootContainer is the ID and name of the Container of the root layout.
+const RootContainer = "root"
+
+func init() {
+	validatefn := func(config stow.Config) error {
+		_, path := config.Config(ConfigPath)
+		_, u := config.Config(ConfigURL)
+		if !path && !u {
+			return errors.New("missing path or url config")
+		}
+		switch layout, _ := config.Config(ConfigLayout); layout {
+		case "", LayoutRoot, LayoutDirs:
+		default:
+			return errors.New("unknown layout " + layout)
+		}
+		return nil
+	}
+	makefn := func(config stow.Config) (stow.Location, error) {
+		if err := validatefn(config); err != nil {
+			return nil, err
+		}
+		src, err := openSource(config)
+		if err != nil {
+			return nil, err
+		}
+		a, err := readArchive(src.r, src.size)
+		if err != nil {
+			src.Close()
+			return nil, err
+		}
+		layout, _ := config.Config(ConfigLayout)
+		return newLocation(a, layout, src), nil
+	}
+	kindfn := func(u *url.URL) bool {
+		return u.Scheme == Kind
+	}
+	stow.Register(Kind, makefn, kindfn, validatefn)
+}
+
+// source is where the archive is read from.
+type source struct {
+	r       io.ReaderAt
+	size    int64
+	closers []io.Closer
+}
+
+func (s *source) Close() error {
+	var err error
+	for _, c := range s.closers {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// openSource opens the local file or the Item the config names.
+func openSource(config stow.Config) (*source, error) {
+	if path, ok := config.Config(ConfigPath); ok {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &source{r: f, size: info.Size(), closers: []io.Closer{f}}, nil
+	}
+	rawurl, _ := config.Config(ConfigURL)
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	kind, err := stow.KindByURL(u)
+	if err != nil {
+		return nil, err
+	}
+	inner := stow.ConfigMap{}
+	if raw, ok := config.Config(ConfigConfig); ok {
+		if err := json.Unmarshal([]byte(raw), &inner); err != nil {
+			return nil, errors.New("config must be a JSON object of strings: " + err.Error())
+		}
+	}
+	location, err := stow.Dial(kind, inner)
+	if err != nil {
+		return nil, err
+	}
+	item, err := location.ItemByURL(u)
+	if err != nil {
+		location.Close()
+		return nil, err
+	}
+	size, err := item.Size()
+	if err != nil {
+		location.Close()
+		return nil, err
+	}
+	r, err := stow.OpenReaderAt(item)
+	if err != nil {
+		location.Close()
+		return nil, err
+	}
+	return &source{r: r, size: size, closers: []io.Closer{r, location}}, nil
+}
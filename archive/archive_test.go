@@ -0,0 +1,343 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+	"github.com/graymeta/stow/archive"
+	_ "github.com/graymeta/stow/local"
+)
+
+var files = []struct {
+	name    string
+	content string
+}{
+	{"docs/a.txt", "alpha"},
+	{"docs/b.txt", "bravo bravo"},
+	{"readme", "top level"},
+	{"src/main.go", "package main"},
+}
+
+var modTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func writeZip(is is.I, path string, method uint16) {
+	f, err := os.Create(path)
+	is.NoErr(err)
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, file := range files {
+		h := &zip.FileHeader{Name: file.name, Method: method, Modified: modTime}
+		h.SetMode(0644)
+		w, err := zw.CreateHeader(h)
+		is.NoErr(err)
+		_, err = io.WriteString(w, file.content)
+		is.NoErr(err)
+	}
+	is.NoErr(zw.Close())
+}
+
+func writeTar(is is.I, path string, compress bool) {
+	f, err := os.Create(path)
+	is.NoErr(err)
+	defer f.Close()
+	var w io.Writer = f
+	if compress {
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	is.NoErr(tw.WriteHeader(&tar.Header{Name: "./docs/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: modTime}))
+	for _, file := range files {
+		is.NoErr(tw.WriteHeader(&tar.Header{
+			Name:     "./" + file.name,
+			Typeflag: tar.TypeReg,
+			Mode:     0644,
+			Size:     int64(len(file.content)),
+			ModTime:  modTime,
+		}))
+		_, err := io.WriteString(tw, file.content)
+		is.NoErr(err)
+	}
+	is.NoErr(tw.WriteHeader(&tar.Header{Name: "docs/c.txt", Typeflag: tar.TypeLink, Linkname: "docs/a.txt", Mode: 0644, ModTime: modTime}))
+	is.NoErr(tw.Close())
+}
+
+func read(is is.I, rc io.ReadCloser) string {
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	is.NoErr(err)
+	return string(b)
+}
+
+func open(is is.I, item stow.Item) io.ReadCloser {
+	rc, err := item.Open()
+	is.NoErr(err)
+	return rc
+}
+
+func names(is is.I, container stow.Container) []string {
+	var names []string
+	is.NoErr(stow.Walk(container, stow.NoPrefix, 2, func(item stow.Item, err error) error {
+		names = append(names, item.Name())
+		return err
+	}))
+	return names
+}
+
+func TestArchives(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stow-archive")
+	is.New(t).NoErr(err)
+	defer os.RemoveAll(dir)
+
+	for _, tt := range []struct {
+		name       string
+		write      func(is is.I, path string)
+		compressed bool
+	}{
+		{"stored.zip", func(is is.I, path string) { writeZip(is, path, zip.Store) }, false},
+		{"deflated.zip", func(is is.I, path string) { writeZip(is, path, zip.Deflate) }, true},
+		{"plain.tar", func(is is.I, path string) { writeTar(is, path, false) }, false},
+		{"compressed.tar.gz", func(is is.I, path string) { writeTar(is, path, true) }, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			path := filepath.Join(dir, tt.name)
+			tt.write(is, path)
+			location, err := stow.Dial(archive.Kind, stow.ConfigMap{archive.ConfigPath: path})
+			is.NoErr(err)
+			defer location.Close()
+
+			container, err := location.Container(archive.RootContainer)
+			is.NoErr(err)
+			want := []string{"docs/a.txt", "docs/b.txt", "readme", "src/main.go"}
+			if filepath.Ext(path) != ".zip" {
+				want = []string{"docs/a.txt", "docs/b.txt", "docs/c.txt", "readme", "src/main.go"}
+			}
+			is.Equal(names(is, container), want)
+
+			item, err := container.Item("docs/b.txt")
+			is.NoErr(err)
+			size, err := item.Size()
+			is.NoErr(err)
+			is.Equal(size, 11)
+			lastMod, err := item.LastMod()
+			is.NoErr(err)
+			is.True(lastMod.Equal(modTime))
+			is.Equal(read(is, open(is, item)), "bravo bravo")
+			item, err = location.ItemByURL(item.URL())
+			is.NoErr(err)
+			is.Equal(item.Name(), "docs/b.txt")
+
+			rc, err := item.(stow.ItemRanger).OpenRange(2, 6)
+			if tt.compressed {
+				is.True(stow.IsNotSupported(err))
+			} else {
+				is.NoErr(err)
+				is.Equal(read(is, rc), "avo b")
+			}
+
+			_, err = container.Item("missing")
+			is.Equal(err, stow.ErrNotFound)
+			_, err = container.Put("new", nil, 0, nil)
+			is.True(stow.IsNotSupported(err))
+			is.True(stow.IsNotSupported(container.RemoveItem(item.ID())))
+			_, err = location.CreateContainer("new")
+			is.True(stow.IsNotSupported(err))
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	is := is.New(t)
+	dir, err := ioutil.TempDir("", "stow-archive")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "a.tar")
+	writeTar(is, path, false)
+
+	location, err := stow.Dial(archive.Kind, stow.ConfigMap{archive.ConfigPath: path})
+	is.NoErr(err)
+	defer location.Close()
+	container, err := location.Container(archive.RootContainer)
+	is.NoErr(err)
+	item, err := container.Item("docs/c.txt")
+	is.NoErr(err)
+	size, err := item.Size()
+	is.NoErr(err)
+	is.Equal(size, 5)
+	is.Equal(read(is, open(is, item)), "alpha")
+	md, err := item.Metadata()
+	is.NoErr(err)
+	is.Equal(md["link"], "docs/a.txt")
+}
+
+// dialTar writes a tar archive with the entries, which are headers
+// followed by their contents, and gets its root Container.
+func dialTar(t *testing.T, entries ...interface{}) stow.Container {
+	is := is.New(t)
+	dir, err := ioutil.TempDir("", "stow-archive")
+	is.NoErr(err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "a.tar")
+	f, err := os.Create(path)
+	is.NoErr(err)
+	tw := tar.NewWriter(f)
+	for _, e := range entries {
+		switch e := e.(type) {
+		case *tar.Header:
+			is.NoErr(tw.WriteHeader(e))
+		case string:
+			_, err := io.WriteString(tw, e)
+			is.NoErr(err)
+		}
+	}
+	is.NoErr(tw.Close())
+	is.NoErr(f.Close())
+
+	location, err := stow.Dial(archive.Kind, stow.ConfigMap{archive.ConfigPath: path})
+	is.NoErr(err)
+	t.Cleanup(func() { location.Close() })
+	container, err := location.Container(archive.RootContainer)
+	is.NoErr(err)
+	return container
+}
+
+func TestAppendedCopies(t *testing.T) {
+	is := is.New(t)
+	// tar -r appends updated copies of a file after the first
+	container := dialTar(t,
+		&tar.Header{Name: "a", Typeflag: tar.TypeReg, Mode: 0644, Size: 3, ModTime: modTime}, "old",
+		&tar.Header{Name: "b", Typeflag: tar.TypeReg, Mode: 0644, Size: 1, ModTime: modTime}, "b",
+		&tar.Header{Name: "a", Typeflag: tar.TypeReg, Mode: 0644, Size: 7, ModTime: modTime}, "updated",
+	)
+	is.Equal(names(is, container), []string{"a", "b"})
+	item, err := container.Item("a")
+	is.NoErr(err)
+	size, err := item.Size()
+	is.NoErr(err)
+	is.Equal(size, 7)
+	is.Equal(read(is, open(is, item)), "updated")
+}
+
+func TestLinkCycle(t *testing.T) {
+	is := is.New(t)
+	container := dialTar(t,
+		&tar.Header{Name: "a", Typeflag: tar.TypeLink, Linkname: "b", Mode: 0644, ModTime: modTime},
+		&tar.Header{Name: "b", Typeflag: tar.TypeLink, Linkname: "a", Mode: 0644, ModTime: modTime},
+		&tar.Header{Name: "c", Typeflag: tar.TypeLink, Linkname: "c", Mode: 0644, ModTime: modTime},
+	)
+	for _, name := range []string{"a", "b", "c"} {
+		item, err := container.Item(name)
+		is.NoErr(err)
+		_, err = item.Open()
+		is.Err(err)
+	}
+}
+
+func TestDirsLayout(t *testing.T) {
+	is := is.New(t)
+	dir, err := ioutil.TempDir("", "stow-archive")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "a.zip")
+	writeZip(is, path, zip.Store)
+
+	location, err := stow.Dial(archive.Kind, stow.ConfigMap{
+		archive.ConfigPath:   path,
+		archive.ConfigLayout: archive.LayoutDirs,
+	})
+	is.NoErr(err)
+	defer location.Close()
+
+	containers, cursor, err := location.Containers(stow.NoPrefix, stow.CursorStart, 1)
+	is.NoErr(err)
+	is.Equal(len(containers), 1)
+	is.Equal(containers[0].Name(), "docs")
+	containers, cursor, err = location.Containers(stow.NoPrefix, cursor, 1)
+	is.NoErr(err)
+	is.Equal(containers[0].Name(), "src")
+	is.True(stow.IsCursorEnd(cursor))
+
+	container, err := location.Container("docs")
+	is.NoErr(err)
+	is.Equal(names(is, container), []string{"a.txt", "b.txt"})
+	item, err := container.Item("a.txt")
+	is.NoErr(err)
+	is.Equal(read(is, open(is, item)), "alpha")
+	_, err = location.Container("readme")
+	is.Equal(err, stow.ErrNotFound)
+}
+
+func TestDirsLayoutPrefixedNames(t *testing.T) {
+	is := is.New(t)
+	dir, err := ioutil.TempDir("", "stow-archive")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logs.zip")
+	f, err := os.Create(path)
+	is.NoErr(err)
+	zw := zip.NewWriter(f)
+	// "logs-2024/x" sorts before "logs/y", though "logs" sorts first
+	for _, name := range []string{"logs/y", "logs-2024/x", "logs/z"} {
+		w, err := zw.Create(name)
+		is.NoErr(err)
+		_, err = io.WriteString(w, name)
+		is.NoErr(err)
+	}
+	is.NoErr(zw.Close())
+	is.NoErr(f.Close())
+
+	location, err := stow.Dial(archive.Kind, stow.ConfigMap{
+		archive.ConfigPath:   path,
+		archive.ConfigLayout: archive.LayoutDirs,
+	})
+	is.NoErr(err)
+	defer location.Close()
+
+	containers, cursor, err := location.Containers(stow.NoPrefix, stow.CursorStart, 10)
+	is.NoErr(err)
+	is.True(stow.IsCursorEnd(cursor))
+	is.Equal(len(containers), 2)
+	is.Equal(containers[0].Name(), "logs")
+	is.Equal(containers[1].Name(), "logs-2024")
+
+	container, err := location.Container("logs")
+	is.NoErr(err)
+	is.Equal(names(is, container), []string{"y", "z"})
+	container, err = location.Container("logs-2024")
+	is.NoErr(err)
+	is.Equal(names(is, container), []string{"x"})
+}
+
+func TestDialURL(t *testing.T) {
+	is := is.New(t)
+	dir, err := ioutil.TempDir("", "stow-archive")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "a.tar")
+	writeTar(is, path, false)
+
+	location, err := stow.Dial(archive.Kind, stow.ConfigMap{
+		archive.ConfigURL:    "file://" + filepath.ToSlash(path),
+		archive.ConfigConfig: `{"path":"/"}`,
+	})
+	is.NoErr(err)
+	defer location.Close()
+	container, err := location.Container(archive.RootContainer)
+	is.NoErr(err)
+	item, err := container.Item("src/main.go")
+	is.NoErr(err)
+	rc, err := item.(stow.ItemRanger).OpenRange(8, 100)
+	is.NoErr(err)
+	is.Equal(read(is, rc), "main")
+}
@@ -0,0 +1,75 @@
+package archive
+
+import (
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/graymeta/stow"
+)
+
+type container struct {
+	name    string
+	archive *archive
+	// prefix is the directory of the Container in the archive, empty with
+	// the root layout.
+	prefix string
+}
+
+func (c *container) ID() string {
+	return c.name
+}
+
+func (c *container) Name() string {
+	return c.name
+}
+
+// Items lists the files with the prefix in name order. The cursor is the
+// name of the next file.
+func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	entries := c.archive.entries
+	start := c.prefix + prefix
+	if cursor != stow.CursorStart {
+		start = c.prefix + cursor
+		e := c.archive.lookup(start)
+		if e == nil || !strings.HasPrefix(cursor, prefix) {
+			return nil, "", stow.ErrBadCursor
+		}
+	}
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].name >= start
+	})
+	var items []stow.Item
+	for ; i < len(entries) && strings.HasPrefix(entries[i].name, c.prefix+prefix); i++ {
+		if len(items) == count {
+			return items, c.itemName(entries[i]), nil
+		}
+		items = append(items, c.item(entries[i]))
+	}
+	return items, "", nil
+}
+
+// Item gets the file with the name in the Container.
+func (c *container) Item(id string) (stow.Item, error) {
+	e := c.archive.lookup(c.prefix + id)
+	if e == nil {
+		return nil, stow.ErrNotFound
+	}
+	return c.item(e), nil
+}
+
+func (c *container) item(e *entry) *item {
+	return &item{container: c, entry: e}
+}
+
+func (c *container) itemName(e *entry) string {
+	return strings.TrimPrefix(e.name, c.prefix)
+}
+
+func (c *container) RemoveItem(id string) error {
+	return stow.NotSupported("removing items from archives")
+}
+
+func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return nil, stow.NotSupported("putting items in archives")
+}
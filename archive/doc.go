@@ -0,0 +1,12 @@
+/*
+Package archive provides a read-only abstraction of a tar, tar.gz or zip archive, which is either a local file or a Stow Item of another Location. A file in the archive is a Stow Item.
+
+stow.Dial requires the Kind ("archive") and a stow.Config instance with either a key of archive.ConfigPath with the path of a local archive, or a key of archive.ConfigURL with the URL of an Item holding the archive. The Location of that Item is dialed with the configuration given as a JSON object under archive.ConfigConfig.
+
+With the root layout, the default, the archive is a single Container named "root" holding every file. With the dirs layout (archive.ConfigLayout set to "dirs"), each top-level directory is a Container holding the files below it, and files at the top level are left out.
+
+Items have a size, a last modified time and their mode and link target as metadata, and can be opened. Those kept uncompressed, in plain tar archives or stored in zip archives, can also be opened in ranges. Creating and removing Containers and Items gives errors satisfying stow.IsNotSupported.
+
+Export and Import stream the Items of any Container to and from an archive without going through local disk. Export keeps the metadata of the Items in PAX records of tar archives and in an extra field of zip entries, and Import puts the files it reads into the Container several at a time.
+*/
+package archive
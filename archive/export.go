@@ -0,0 +1,233 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/graymeta/stow"
+)
+
+// Format is the format of an archive.
+type Format string
+
+const (
+	// FormatTar is an uncompressed tar archive.
+	FormatTar Format = "tar"
+	// FormatTarGz is a gzip compressed tar archive.
+	FormatTarGz Format = "tar.gz"
+	// FormatZip is a zip archive.
+	FormatZip Format = "zip"
+)
+
+// detectFormat tells the format of an archive from its first bytes.
+func detectFormat(magic []byte) Format {
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")) || bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		return FormatZip
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return FormatTarGz
+	}
+	return FormatTar
+}
+
+// paxMetadataPrefix starts the keys of the PAX records holding the
+// metadata of an Item.
+const paxMetadataPrefix = "STOW.metadata."
+
+// zipMetadataExtra is the ID of the zip extra field holding the metadata
+// of an Item as a JSON object.
+const zipMetadataExtra = 0x5354
+
+// ExportOptions configure Export.
+type ExportOptions struct {
+	// Prefix limits the export to the Items with the prefix.
+	Prefix string
+	// TrimPrefix names the files in the archive without the prefix.
+	TrimPrefix bool
+	// Format is the format of the archive. Defaults to FormatTar.
+	Format Format
+}
+
+// Export writes the Items with the prefix to w as an archive, reading each
+// Item as it is written, and returns the number of Items written. The
+// metadata of the Items is kept in PAX records in tar archives, and in an
+// extra field of the entries in zip archives, which Import restores.
+func Export(container stow.Container, w io.Writer, options *ExportOptions) (int, error) {
+	if options == nil {
+		options = &ExportOptions{}
+	}
+	var aw archiveWriter
+	switch options.Format {
+	case "", FormatTar:
+		aw = &tarWriter{tw: tar.NewWriter(w)}
+	case FormatTarGz:
+		gz := gzip.NewWriter(w)
+		aw = &tarWriter{tw: tar.NewWriter(gz), gz: gz}
+	case FormatZip:
+		aw = &zipWriter{zw: zip.NewWriter(w)}
+	default:
+		return 0, errors.New("unknown archive format " + string(options.Format))
+	}
+	n := 0
+	err := stow.Walk(container, options.Prefix, 1000, func(item stow.Item, err error) error {
+		if err != nil {
+			return err
+		}
+		name := item.Name()
+		if options.TrimPrefix {
+			name = strings.TrimPrefix(name, options.Prefix)
+		}
+		if err := exportItem(aw, name, item); err != nil {
+			return fmt.Errorf("exporting %s: %v", item.Name(), err)
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, aw.Close()
+}
+
+func exportItem(aw archiveWriter, name string, item stow.Item) error {
+	size, err := item.Size()
+	if err != nil {
+		return err
+	}
+	lastMod, err := item.LastMod()
+	if err != nil {
+		return err
+	}
+	metadata, err := item.Metadata()
+	if err != nil {
+		return err
+	}
+	h := fileHeader{name: name, size: size, modTime: lastMod}
+	// archives keep metadata as strings
+	for key, value := range metadata {
+		if h.metadata == nil {
+			h.metadata = make(map[string]string, len(metadata))
+		}
+		h.metadata[key] = fmt.Sprint(value)
+	}
+	w, err := aw.Create(h)
+	if err != nil {
+		return err
+	}
+	r, err := item.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("read %d bytes of %d", n, size)
+	}
+	return nil
+}
+
+// fileHeader describes a file of an archive stream.
+type fileHeader struct {
+	name string
+	// size is -1 when it is not known until the contents are read.
+	size     int64
+	modTime  time.Time
+	metadata map[string]string
+}
+
+// archiveWriter writes files to an archive.
+type archiveWriter interface {
+	// Create starts a file, giving the writer of its contents.
+	Create(h fileHeader) (io.Writer, error)
+	// Close finishes the archive, leaving the underlying writer open.
+	Close() error
+}
+
+type tarWriter struct {
+	tw *tar.Writer
+	gz *gzip.Writer
+}
+
+func (t *tarWriter) Create(h fileHeader) (io.Writer, error) {
+	th := &tar.Header{
+		Name:     h.name,
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     h.size,
+		ModTime:  h.modTime,
+	}
+	if len(h.metadata) > 0 {
+		th.Format = tar.FormatPAX
+		th.PAXRecords = make(map[string]string, len(h.metadata))
+		for key, value := range h.metadata {
+			th.PAXRecords[paxMetadataPrefix+key] = value
+		}
+	}
+	if err := t.tw.WriteHeader(th); err != nil {
+		return nil, err
+	}
+	return t.tw, nil
+}
+
+func (t *tarWriter) Close() error {
+	if err := t.tw.Close(); err != nil {
+		return err
+	}
+	if t.gz != nil {
+		return t.gz.Close()
+	}
+	return nil
+}
+
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+func (z *zipWriter) Create(h fileHeader) (io.Writer, error) {
+	zh := &zip.FileHeader{
+		Name:     h.name,
+		Method:   zip.Deflate,
+		Modified: h.modTime,
+	}
+	zh.SetMode(0644)
+	if len(h.metadata) > 0 {
+		extra, err := metadataExtra(h.metadata)
+		if err != nil {
+			return nil, err
+		}
+		zh.Extra = extra
+	}
+	return z.zw.CreateHeader(zh)
+}
+
+func (z *zipWriter) Close() error {
+	return z.zw.Close()
+}
+
+// metadataExtra makes the zip extra field holding the metadata.
+func metadataExtra(metadata map[string]string) ([]byte, error) {
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	// zip.Writer adds extra fields of its own
+	if len(b) > 0xf000 {
+		return nil, errors.New("metadata too large for a zip extra field")
+	}
+	extra := make([]byte, 4, 4+len(b))
+	binary.LittleEndian.PutUint16(extra, zipMetadataExtra)
+	binary.LittleEndian.PutUint16(extra[2:], uint16(len(b)))
+	return append(extra, b...), nil
+}
@@ -0,0 +1,278 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+	"github.com/graymeta/stow/archive"
+	"github.com/graymeta/stow/local"
+)
+
+// memContainer keeps what is put in memory.
+type memContainer struct {
+	stow.Container
+	mu       sync.Mutex
+	data     map[string]string
+	metadata map[string]map[string]interface{}
+}
+
+func newMemContainer() *memContainer {
+	return &memContainer{
+		data:     make(map[string]string),
+		metadata: make(map[string]map[string]interface{}),
+	}
+}
+
+func (c *memContainer) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[name] = string(b)
+	c.metadata[name] = metadata
+	return nil, nil
+}
+
+func (c *memContainer) Item(name string) (stow.Item, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.data[name]; !ok {
+		return nil, stow.ErrNotFound
+	}
+	return nil, nil
+}
+
+func (c *memContainer) RemoveItem(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, name)
+	return nil
+}
+
+// sizedContainer reads no more than the size of what is put, as many
+// Containers do.
+type sizedContainer struct {
+	*memContainer
+}
+
+func (c sizedContainer) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return c.memContainer.Put(name, bytes.NewReader(b), size, metadata)
+}
+
+// metaContainer adds metadata to the Items of a Container.
+type metaContainer struct {
+	stow.Container
+}
+
+func (c metaContainer) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	items, cursor, err := c.Container.Items(prefix, cursor, count)
+	for i, item := range items {
+		items[i] = metaItem{item}
+	}
+	return items, cursor, err
+}
+
+type metaItem struct {
+	stow.Item
+}
+
+func (i metaItem) Metadata() (map[string]interface{}, error) {
+	return map[string]interface{}{"owner": "ops", "name": i.Name()}, nil
+}
+
+func setupSource(is is.I) (stow.Container, func()) {
+	dir, err := ioutil.TempDir("", "stow-archive")
+	is.NoErr(err)
+	location, err := stow.Dial(local.Kind, stow.ConfigMap{local.ConfigKeyPath: dir})
+	is.NoErr(err)
+	container, err := location.CreateContainer("source")
+	is.NoErr(err)
+	for _, name := range []string{"site/index.html", "site/css/main.css", "site/big.bin", "other"} {
+		content := "content of " + name
+		if name == "site/big.bin" {
+			content = strings.Repeat("0123456789", 1000)
+		}
+		_, err := container.Put(name, strings.NewReader(content), int64(len(content)), nil)
+		is.NoErr(err)
+	}
+	return metaContainer{container}, func() { os.RemoveAll(dir) }
+}
+
+func TestExportImport(t *testing.T) {
+	is := is.New(t)
+	source, teardown := setupSource(is)
+	defer teardown()
+
+	for _, format := range []archive.Format{archive.FormatTar, archive.FormatTarGz, archive.FormatZip} {
+		var buf bytes.Buffer
+		n, err := archive.Export(source, &buf, &archive.ExportOptions{
+			Prefix:     "site/",
+			TrimPrefix: true,
+			Format:     format,
+		})
+		is.NoErr(err)
+		is.Equal(n, 3)
+
+		target := newMemContainer()
+		n, err = archive.Import(target, &buf, &archive.ImportOptions{
+			Prefix:      "copy/",
+			Metadata:    true,
+			Concurrency: 2,
+		})
+		is.NoErr(err)
+		is.Equal(n, 3)
+		var names []string
+		for name := range target.data {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		is.Equal(names, []string{"copy/big.bin", "copy/css/main.css", "copy/index.html"})
+		is.Equal(target.data["copy/css/main.css"], "content of site/css/main.css")
+		is.Equal(len(target.data["copy/big.bin"]), 10000)
+		is.Equal(target.metadata["copy/index.html"], map[string]interface{}{
+			"owner": "ops",
+			"name":  "site/index.html",
+		})
+	}
+}
+
+func TestExportArchiveLocation(t *testing.T) {
+	is := is.New(t)
+	source, teardown := setupSource(is)
+	defer teardown()
+	dir, err := ioutil.TempDir("", "stow-archive")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+
+	// exported archives can be dialed, with the metadata kept in them
+	path := filepath.Join(dir, "export.tar")
+	f, err := os.Create(path)
+	is.NoErr(err)
+	_, err = archive.Export(source, f, nil)
+	is.NoErr(err)
+	is.NoErr(f.Close())
+	f, err = os.Open(path)
+	is.NoErr(err)
+	defer f.Close()
+	h, err := tar.NewReader(f).Next()
+	is.NoErr(err)
+	is.Equal(h.Name, "other")
+	is.Equal(h.PAXRecords["STOW.metadata.owner"], "ops")
+
+	location, err := stow.Dial(archive.Kind, stow.ConfigMap{archive.ConfigPath: path})
+	is.NoErr(err)
+	defer location.Close()
+	container, err := location.Container(archive.RootContainer)
+	is.NoErr(err)
+	is.Equal(names(is, container), []string{"other", "site/big.bin", "site/css/main.css", "site/index.html"})
+}
+
+func TestImportBufferSize(t *testing.T) {
+	is := is.New(t)
+	source, teardown := setupSource(is)
+	defer teardown()
+
+	// files larger than the buffer are put as they are read from tar
+	// archives, which give their size first
+	var buf bytes.Buffer
+	_, err := archive.Export(source, &buf, &archive.ExportOptions{Format: archive.FormatTarGz})
+	is.NoErr(err)
+	target := newMemContainer()
+	n, err := archive.Import(target, &buf, &archive.ImportOptions{BufferSize: 100})
+	is.NoErr(err)
+	is.Equal(n, 4)
+	is.Equal(len(target.data["site/big.bin"]), 10000)
+
+	// zip.Writer gives sizes after the contents
+	buf.Reset()
+	_, err = archive.Export(source, &buf, &archive.ExportOptions{Format: archive.FormatZip})
+	is.NoErr(err)
+	_, err = archive.Import(newMemContainer(), &buf, &archive.ImportOptions{BufferSize: 100})
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "site/big.bin"))
+}
+
+func TestImportCorruptZip(t *testing.T) {
+	is := is.New(t)
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "a", Method: zip.Deflate})
+	is.NoErr(err)
+	_, err = io.WriteString(w, "some content")
+	is.NoErr(err)
+	is.NoErr(zw.Close())
+
+	b := buf.Bytes()
+	// the CRC-32 in the data descriptor follows the compressed contents
+	i := bytes.Index(b, []byte("PK\x07\x08"))
+	is.True(i > 0)
+	b[i+4] ^= 0xff
+	_, err = archive.Import(newMemContainer(), bytes.NewReader(b), nil)
+	is.Err(err)
+	is.True(strings.Contains(err.Error(), "checksum"))
+}
+
+func TestImportCorruptLargeZip(t *testing.T) {
+	is := is.New(t)
+	// a stored file with its sizes and a wrong CRC-32 in its local header,
+	// followed by the start of the central directory
+	content := strings.Repeat("x", 200)
+	var buf bytes.Buffer
+	header := make([]byte, 30)
+	binary.LittleEndian.PutUint32(header, 0x04034b50)
+	binary.LittleEndian.PutUint32(header[14:], crc32.ChecksumIEEE([]byte(content))^0xff)
+	binary.LittleEndian.PutUint32(header[18:], uint32(len(content)))
+	binary.LittleEndian.PutUint32(header[22:], uint32(len(content)))
+	binary.LittleEndian.PutUint16(header[26:], 3)
+	buf.Write(header)
+	buf.WriteString("big")
+	buf.WriteString(content)
+	buf.WriteString("PK\x01\x02")
+
+	for _, target := range []stow.Container{newMemContainer(), sizedContainer{newMemContainer()}} {
+		n, err := archive.Import(target, bytes.NewReader(buf.Bytes()), &archive.ImportOptions{BufferSize: 100})
+		is.Err(err)
+		is.True(strings.Contains(err.Error(), "checksum"))
+		is.Equal(n, 0)
+		var data map[string]string
+		switch c := target.(type) {
+		case *memContainer:
+			data = c.data
+		case sizedContainer:
+			data = c.data
+		}
+		_, ok := data["big"]
+		is.False(ok)
+	}
+
+	// an Item that was there before the import is not removed, and is
+	// left as it was by Containers that read to the end of what is put
+	mem, sized := newMemContainer(), newMemContainer()
+	for _, target := range []stow.Container{mem, sizedContainer{sized}} {
+		mem.data["big"], sized.data["big"] = "before", "before"
+		_, err := archive.Import(target, bytes.NewReader(buf.Bytes()), &archive.ImportOptions{BufferSize: 100})
+		is.Err(err)
+	}
+	is.Equal(mem.data["big"], "before")
+	_, ok := sized.data["big"]
+	is.True(ok)
+}
@@ -0,0 +1,521 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graymeta/stow"
+)
+
+// Defaults of ImportOptions.
+const (
+	defaultImportConcurrency = 8
+	defaultBufferSize        = 8 << 20
+)
+
+// ImportOptions configure Import.
+type ImportOptions struct {
+	// Prefix is put before the names of the files to make the names of
+	// the Items.
+	Prefix string
+	// Metadata puts the Items with the metadata Export kept in the
+	// archive. Not every Container supports putting metadata.
+	Metadata bool
+	// Concurrency is the number of Items put at once. Defaults to 8.
+	Concurrency int
+	// BufferSize is the size of the files held in memory to be put
+	// concurrently. Larger files are put one at a time as they are read
+	// from the archive. Defaults to 8 MiB.
+	BufferSize int64
+}
+
+// Import reads a tar, tar.gz or zip archive from r and puts each regular
+// file in it into the Container, returning the number of Items put. The
+// archive is read as a stream, so nothing is written to local disk.
+//
+// Zip archives are read by their local headers. Files whose size is only
+// given after their contents, as zip.Writer writes them, must fit in the
+// buffer size, and stored files of that kind cannot be read at all; such
+// archives can be read in full with the archive Location instead.
+func Import(container stow.Container, r io.Reader, options *ImportOptions) (int, error) {
+	if options == nil {
+		options = &ImportOptions{}
+	}
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultImportConcurrency
+	}
+	bufferSize := options.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	var ar archiveReader
+	switch detectFormat(magic) {
+	case FormatZip:
+		ar = &zipReader{r: br}
+	case FormatTarGz:
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return 0, err
+		}
+		ar = &tarReader{tr: tar.NewReader(gz)}
+	default:
+		ar = &tarReader{tr: tar.NewReader(br)}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		n        int
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+	// put puts the file, and removes the Item again when check, if any,
+	// finds the contents were not what was archived and there was no Item
+	// of the name before
+	put := func(h fileHeader, r io.Reader, size int64, check func() error) error {
+		var metadata map[string]interface{}
+		if options.Metadata && len(h.metadata) > 0 {
+			metadata = make(map[string]interface{}, len(h.metadata))
+			for key, value := range h.metadata {
+				metadata[key] = value
+			}
+		}
+		created := false
+		if check != nil {
+			_, err := container.Item(options.Prefix + h.name)
+			created = err == stow.ErrNotFound
+		}
+		_, err := container.Put(options.Prefix+h.name, r, size, metadata)
+		if check != nil {
+			if checkErr := check(); checkErr != nil {
+				if err == nil && created {
+					container.RemoveItem(options.Prefix + h.name)
+				}
+				return fmt.Errorf("importing %s: %v", h.name, checkErr)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("importing %s: %v", h.name, err)
+		}
+		mu.Lock()
+		n++
+		mu.Unlock()
+		return nil
+	}
+	type file struct {
+		h    fileHeader
+		data []byte
+	}
+	files := make(chan file)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range files {
+				if failed() {
+					continue
+				}
+				if err := put(f.h, bytes.NewReader(f.data), int64(len(f.data)), nil); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+	for !failed() {
+		h, r, err := ar.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fail(err)
+			break
+		}
+		if h.size > bufferSize {
+			cr := &checkedReader{r: r, left: h.size}
+			if err := put(h, cr, h.size, cr.check); err != nil {
+				fail(err)
+			}
+			continue
+		}
+		data, err := ioutil.ReadAll(io.LimitReader(r, bufferSize+1))
+		if err != nil {
+			fail(fmt.Errorf("reading %s: %v", h.name, err))
+			break
+		}
+		if int64(len(data)) > bufferSize {
+			fail(fmt.Errorf("reading %s: size not known and larger than the buffer size", h.name))
+			break
+		}
+		files <- file{h: h, data: data}
+	}
+	close(files)
+	wg.Wait()
+	return n, firstErr
+}
+
+// checkedReader reads a file of the given size from an archive, reading
+// on to the end of the file along with its last bytes, so that the CRC-32
+// of zip files is checked before a Put has all of the file.
+type checkedReader struct {
+	r    io.Reader
+	left int64
+	done bool
+	err  error
+}
+
+func (c *checkedReader) Read(p []byte) (int, error) {
+	if c.done {
+		if c.err != nil {
+			return 0, c.err
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > c.left {
+		p = p[:c.left]
+	}
+	n, err := c.r.Read(p)
+	c.left -= int64(n)
+	if err == io.EOF && c.left > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil && err != io.EOF {
+		c.done, c.err = true, err
+		return n, err
+	}
+	if c.left == 0 {
+		if err := c.check(); err != nil {
+			return n, err
+		}
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// check reads what is left of the file, giving the error of the file if
+// it is not what was archived.
+func (c *checkedReader) check() error {
+	if !c.done {
+		_, c.err = io.Copy(ioutil.Discard, c.r)
+		c.done = true
+	}
+	return c.err
+}
+
+// archiveReader reads the files of an archive stream in order.
+type archiveReader interface {
+	// Next gets the next regular file and the reader of its contents,
+	// or io.EOF at the end of the archive.
+	Next() (fileHeader, io.Reader, error)
+}
+
+type tarReader struct {
+	tr *tar.Reader
+}
+
+func (t *tarReader) Next() (fileHeader, io.Reader, error) {
+	for {
+		h, err := t.tr.Next()
+		if err != nil {
+			return fileHeader{}, nil, err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		fh := fileHeader{name: cleanName(h.Name), size: h.Size, modTime: h.ModTime}
+		for key, value := range h.PAXRecords {
+			if strings.HasPrefix(key, paxMetadataPrefix) {
+				if fh.metadata == nil {
+					fh.metadata = make(map[string]string)
+				}
+				fh.metadata[strings.TrimPrefix(key, paxMetadataPrefix)] = value
+			}
+		}
+		return fh, t.tr, nil
+	}
+}
+
+// Signatures of the records of zip archives.
+const (
+	zipLocalHeader     = 0x04034b50
+	zipDataDescriptor  = 0x08074b50
+	zipCentralHeader   = 0x02014b50
+	zipEndOfDirectory  = 0x06054b50
+	zipDescriptorFlag  = 0x8
+	zipExtraZip64      = 0x0001
+	zipExtraTimestamp  = 0x5455
+	zipSizeUnknown     = 0xffffffff
+	zipLocalHeaderSize = 30
+)
+
+// zipReader reads a zip archive by its local headers, from start to end.
+type zipReader struct {
+	r *bufio.Reader
+	// current is the file last returned by Next.
+	current *zipFile
+}
+
+func (z *zipReader) Next() (fileHeader, io.Reader, error) {
+	for {
+		if z.current != nil {
+			// the rest of the last file must be read to get to the next
+			if _, err := io.Copy(ioutil.Discard, z.current); err != nil {
+				return fileHeader{}, nil, err
+			}
+			z.current = nil
+		}
+		var sig uint32
+		if err := binary.Read(z.r, binary.LittleEndian, &sig); err != nil {
+			if err == io.EOF {
+				return fileHeader{}, nil, io.ErrUnexpectedEOF
+			}
+			return fileHeader{}, nil, err
+		}
+		switch sig {
+		case zipLocalHeader:
+		case zipCentralHeader, zipEndOfDirectory:
+			// the central directory repeats what the local headers said
+			return fileHeader{}, nil, io.EOF
+		default:
+			return fileHeader{}, nil, errors.New("zip: not a valid zip file")
+		}
+		f, err := z.readLocalHeader()
+		if err != nil {
+			return fileHeader{}, nil, err
+		}
+		z.current = f
+		if f.dir {
+			continue
+		}
+		return f.header, f, nil
+	}
+}
+
+// zipFile reads the contents of a file of a zip archive, checking its
+// CRC-32 at the end.
+type zipFile struct {
+	header     fileHeader
+	dir        bool
+	z          *zipReader
+	r          io.Reader
+	counter    *countingByteReader
+	descriptor bool
+	crc        uint32
+	hash       hash.Hash32
+	read       int64
+	err        error
+}
+
+func (z *zipReader) readLocalHeader() (*zipFile, error) {
+	var b [zipLocalHeaderSize - 4]byte
+	if _, err := io.ReadFull(z.r, b[:]); err != nil {
+		return nil, err
+	}
+	flags := binary.LittleEndian.Uint16(b[2:])
+	method := binary.LittleEndian.Uint16(b[4:])
+	dosTime := binary.LittleEndian.Uint16(b[6:])
+	dosDate := binary.LittleEndian.Uint16(b[8:])
+	crc := binary.LittleEndian.Uint32(b[10:])
+	compressed := int64(binary.LittleEndian.Uint32(b[14:]))
+	size := int64(binary.LittleEndian.Uint32(b[18:]))
+	name := make([]byte, binary.LittleEndian.Uint16(b[22:]))
+	extra := make([]byte, binary.LittleEndian.Uint16(b[24:]))
+	if _, err := io.ReadFull(z.r, name); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(z.r, extra); err != nil {
+		return nil, err
+	}
+	f := &zipFile{
+		header: fileHeader{
+			name:    cleanName(string(name)),
+			size:    size,
+			modTime: msDosTime(dosDate, dosTime),
+		},
+		dir:        strings.HasSuffix(string(name), "/"),
+		z:          z,
+		descriptor: flags&zipDescriptorFlag != 0,
+		crc:        crc,
+		hash:       crc32.NewIEEE(),
+	}
+	err := readExtra(extra, func(id uint16, data []byte) error {
+		switch id {
+		case zipExtraZip64:
+			// the sizes are here when they are too large for the header
+			for _, field := range []*int64{&size, &compressed} {
+				if *field != zipSizeUnknown || len(data) < 8 {
+					continue
+				}
+				*field = int64(binary.LittleEndian.Uint64(data))
+				data = data[8:]
+			}
+			f.header.size = size
+		case zipExtraTimestamp:
+			if len(data) >= 5 && data[0]&1 != 0 {
+				f.header.modTime = time.Unix(int64(binary.LittleEndian.Uint32(data[1:])), 0).UTC()
+			}
+		case zipMetadataExtra:
+			if err := json.Unmarshal(data, &f.header.metadata); err != nil {
+				return fmt.Errorf("zip: bad metadata of %s: %v", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	f.counter = &countingByteReader{r: z.r}
+	switch {
+	case method == 0 && f.descriptor:
+		return nil, fmt.Errorf("zip: stored file %s has no size in its header", name)
+	case method == 0:
+		f.r = io.LimitReader(f.counter, compressed)
+	case method == 8 && f.descriptor:
+		// the deflate stream ends by itself, and reading it a byte at a
+		// time leaves the data descriptor unread
+		f.header.size = -1
+		f.r = flate.NewReader(f.counter)
+	case method == 8:
+		f.r = flate.NewReader(io.LimitReader(f.counter, compressed))
+	default:
+		return nil, fmt.Errorf("zip: unsupported compression method %d of %s", method, name)
+	}
+	return f, nil
+}
+
+func (f *zipFile) Read(p []byte) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	n, err := f.r.Read(p)
+	f.hash.Write(p[:n])
+	f.read += int64(n)
+	if err == io.EOF {
+		err = f.finish()
+	}
+	if err != nil {
+		f.err = err
+	}
+	return n, err
+}
+
+// finish reads the data descriptor, if any, and checks the CRC-32 and
+// size, giving io.EOF when they match.
+func (f *zipFile) finish() error {
+	if f.descriptor {
+		if err := f.readDescriptor(); err != nil {
+			return err
+		}
+	}
+	if f.hash.Sum32() != f.crc || (f.header.size >= 0 && f.read != f.header.size) {
+		return fmt.Errorf("zip: checksum error in %s", f.header.name)
+	}
+	return io.EOF
+}
+
+// readDescriptor reads the CRC-32 and sizes that follow the contents. They
+// are 64 bit when either size is too large for 32 bits.
+func (f *zipFile) readDescriptor() error {
+	var b [4]byte
+	if _, err := io.ReadFull(f.z.r, b[:]); err != nil {
+		return err
+	}
+	// the signature is optional
+	if binary.LittleEndian.Uint32(b[:]) == zipDataDescriptor {
+		if _, err := io.ReadFull(f.z.r, b[:]); err != nil {
+			return err
+		}
+	}
+	f.crc = binary.LittleEndian.Uint32(b[:])
+	sizes := make([]byte, 8)
+	if f.read >= zipSizeUnknown || f.counter.n >= zipSizeUnknown {
+		sizes = make([]byte, 16)
+	}
+	if _, err := io.ReadFull(f.z.r, sizes); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readExtra calls fn with each field of a zip extra.
+func readExtra(extra []byte, fn func(id uint16, data []byte) error) error {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			return errors.New("zip: bad extra field")
+		}
+		if err := fn(id, extra[:size]); err != nil {
+			return err
+		}
+		extra = extra[size:]
+	}
+	return nil
+}
+
+// msDosTime converts an MS-DOS date and time, taking it as UTC as
+// archive/zip does.
+func msDosTime(dosDate, dosTime uint16) time.Time {
+	return time.Date(
+		int(dosDate>>9+1980),
+		time.Month(dosDate>>5&0xf),
+		int(dosDate&0x1f),
+		int(dosTime>>11),
+		int(dosTime>>5&0x3f),
+		int(dosTime&0x1f*2),
+		0,
+		time.UTC,
+	)
+}
+
+// countingByteReader counts the bytes read from a bufio.Reader, which is
+// also an io.ByteReader so that flate reads no further than it needs.
+type countingByteReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingByteReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingByteReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
@@ -0,0 +1,246 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// entry is a file in the archive.
+type entry struct {
+	name    string
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+	// link is the target of a hard link in a tar archive.
+	link string
+	// offset is where the contents start in the archive, or -1 when they
+	// are compressed.
+	offset int64
+	// crc is the CRC-32 of the contents of zip entries.
+	crc uint32
+	// number is the position of the entry among the headers of a tar
+	// archive.
+	number int
+	// file is the entry of a zip archive.
+	file *zip.File
+}
+
+// archive is the index of the files in an archive.
+type archive struct {
+	format  Format
+	r       io.ReaderAt
+	size    int64
+	entries []*entry
+}
+
+// readArchive reads the index of the archive, telling its format from its
+// first bytes.
+func readArchive(r io.ReaderAt, size int64) (*archive, error) {
+	magic := make([]byte, 4)
+	n, err := r.ReadAt(magic, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	a := &archive{r: r, size: size, format: detectFormat(magic[:n])}
+	if a.format == FormatZip {
+		err = a.readZip()
+	} else {
+		err = a.readTar()
+	}
+	if err != nil {
+		return nil, err
+	}
+	// a name may be in a tar archive more than once when updated copies
+	// were appended, and the last copy is the one extracted
+	sort.SliceStable(a.entries, func(i, j int) bool {
+		return a.entries[i].name < a.entries[j].name
+	})
+	entries := a.entries[:0]
+	for i, e := range a.entries {
+		if i+1 < len(a.entries) && a.entries[i+1].name == e.name {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	a.entries = entries
+	// hard links have the size of their target
+	for _, e := range a.entries {
+		if e.link == "" {
+			continue
+		}
+		if target, err := a.resolve(e); err == nil {
+			e.size = target.size
+		}
+	}
+	return a, nil
+}
+
+func (a *archive) readZip() error {
+	zr, err := zip.NewReader(a.r, a.size)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		e := &entry{
+			name:    cleanName(f.Name),
+			size:    int64(f.UncompressedSize64),
+			modTime: f.Modified,
+			mode:    f.Mode(),
+			offset:  -1,
+			crc:     f.CRC32,
+			file:    f,
+		}
+		if e.modTime.IsZero() {
+			e.modTime = f.ModTime()
+		}
+		if f.Method == zip.Store {
+			if e.offset, err = f.DataOffset(); err != nil {
+				return err
+			}
+		}
+		a.entries = append(a.entries, e)
+	}
+	return nil
+}
+
+func (a *archive) readTar() error {
+	tr, counter, closer, err := a.openTar()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	for number := 0; ; number++ {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg && h.Typeflag != tar.TypeLink {
+			continue
+		}
+		e := &entry{
+			name:    cleanName(h.Name),
+			size:    h.Size,
+			modTime: h.ModTime,
+			mode:    h.FileInfo().Mode(),
+			offset:  -1,
+			number:  number,
+		}
+		if h.Typeflag == tar.TypeLink {
+			e.link = cleanName(h.Linkname)
+		} else if a.format == FormatTar {
+			// the reader is at the contents once it has read the header
+			e.offset = counter.n
+		}
+		a.entries = append(a.entries, e)
+	}
+}
+
+// openTar reads the tar archive from the start, counting the bytes read
+// from the archive when it is not compressed.
+func (a *archive) openTar() (*tar.Reader, *countingReader, io.Closer, error) {
+	counter := &countingReader{r: io.NewSectionReader(a.r, 0, a.size)}
+	if a.format == FormatTar {
+		return tar.NewReader(counter), counter, ioutil.NopCloser(nil), nil
+	}
+	gz, err := gzip.NewReader(counter)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return tar.NewReader(gz), counter, gz, nil
+}
+
+// lookup gets the entry with the name.
+func (a *archive) lookup(name string) *entry {
+	i := sort.Search(len(a.entries), func(i int) bool {
+		return a.entries[i].name >= name
+	})
+	if i < len(a.entries) && a.entries[i].name == name {
+		return a.entries[i]
+	}
+	return nil
+}
+
+// resolve follows the hard links from the entry to the entry with the
+// contents.
+func (a *archive) resolve(e *entry) (*entry, error) {
+	seen := make(map[*entry]bool)
+	for e.link != "" {
+		if seen[e] {
+			return nil, errors.New("cycle of links at " + e.name)
+		}
+		seen[e] = true
+		target := a.lookup(e.link)
+		if target == nil {
+			return nil, errors.New("missing target of link " + e.name)
+		}
+		e = target
+	}
+	return e, nil
+}
+
+// open opens the contents of the entry.
+func (a *archive) open(e *entry) (io.ReadCloser, error) {
+	e, err := a.resolve(e)
+	if err != nil {
+		return nil, err
+	}
+	if e.offset >= 0 {
+		return ioutil.NopCloser(io.NewSectionReader(a.r, e.offset, e.size)), nil
+	}
+	if e.file != nil {
+		return e.file.Open()
+	}
+	// compressed tar entries are read from the start of the archive
+	tr, _, closer, err := a.openTar()
+	if err != nil {
+		return nil, err
+	}
+	for number := 0; ; number++ {
+		_, err := tr.Next()
+		if err != nil {
+			closer.Close()
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if number == e.number {
+			return struct {
+				io.Reader
+				io.Closer
+			}{tr, closer}, nil
+		}
+	}
+}
+
+// cleanName makes the name of an entry relative, with no dot segments.
+func cleanName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// countingReader counts the bytes read.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
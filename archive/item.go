@@ -0,0 +1,91 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/graymeta/stow"
+)
+
+var _ stow.ItemRanger = (*item)(nil)
+
+type item struct {
+	container *container
+	entry     *entry
+}
+
+func (i *item) ID() string {
+	return i.container.itemName(i.entry)
+}
+
+func (i *item) Name() string {
+	return i.container.itemName(i.entry)
+}
+
+func (i *item) URL() *url.URL {
+	return &url.URL{
+		Scheme: Kind,
+		Path:   "/" + i.container.name + "/" + i.Name(),
+	}
+}
+
+func (i *item) Size() (int64, error) {
+	return i.entry.size, nil
+}
+
+func (i *item) Open() (io.ReadCloser, error) {
+	return i.container.archive.open(i.entry)
+}
+
+// OpenRange opens bytes start to end, inclusive, of files kept
+// uncompressed. Compressed files can only be read from the start.
+func (i *item) OpenRange(start, end uint64) (io.ReadCloser, error) {
+	e := i.entry
+	if e.link != "" {
+		if e = i.container.archive.lookup(e.link); e == nil {
+			return nil, fmt.Errorf("missing target of link %s", i.entry.name)
+		}
+	}
+	if e.offset < 0 {
+		return nil, stow.NotSupported("ranges of compressed files")
+	}
+	if end < start {
+		return nil, fmt.Errorf("bad range %d-%d", start, end)
+	}
+	if start >= uint64(e.size) {
+		return nil, fmt.Errorf("range %d-%d starts past the end of the file", start, end)
+	}
+	if end >= uint64(e.size) {
+		end = uint64(e.size) - 1
+	}
+	r := io.NewSectionReader(i.container.archive.r, e.offset+int64(start), int64(end-start+1))
+	return ioutil.NopCloser(r), nil
+}
+
+// ETag is the CRC-32 of the file in zip archives, and its size and
+// modification time in tar archives.
+func (i *item) ETag() (string, error) {
+	if i.entry.file != nil {
+		return fmt.Sprintf("%08x", i.entry.crc), nil
+	}
+	return strconv.FormatInt(i.entry.size, 10) + "-" + strconv.FormatInt(i.entry.modTime.UnixNano(), 10), nil
+}
+
+func (i *item) LastMod() (time.Time, error) {
+	return i.entry.modTime, nil
+}
+
+// Metadata holds the mode of the file, and the target of hard links.
+func (i *item) Metadata() (map[string]interface{}, error) {
+	md := map[string]interface{}{
+		"mode": fmt.Sprintf("%o", i.entry.mode.Perm()),
+	}
+	if i.entry.link != "" {
+		md["link"] = i.entry.link
+	}
+	return md, nil
+}
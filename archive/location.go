@@ -0,0 +1,114 @@
+package archive
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/graymeta/stow"
+)
+
+type location struct {
+	archive *archive
+	layout  string
+	source  *source
+}
+
+func newLocation(a *archive, layout string, src *source) *location {
+	if layout == "" {
+		layout = LayoutRoot
+	}
+	return &location{archive: a, layout: layout, source: src}
+}
+
+func (l *location) Close() error {
+	return l.source.Close()
+}
+
+func (l *location) CreateContainer(name string) (stow.Container, error) {
+	return nil, stow.NotSupported("creating containers in archives")
+}
+
+func (l *location) RemoveContainer(id string) error {
+	return stow.NotSupported("removing containers from archives")
+}
+
+// containerNames gets the sorted names of the Containers.
+func (l *location) containerNames() []string {
+	if l.layout == LayoutRoot {
+		return []string{RootContainer}
+	}
+	// sorting the entries doesn't sort their directories, as "logs-2024/x"
+	// sorts before "logs/y"
+	seen := make(map[string]bool)
+	var names []string
+	for _, e := range l.archive.entries {
+		i := strings.Index(e.name, "/")
+		if i < 0 {
+			continue
+		}
+		if name := e.name[:i]; !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (l *location) Containers(prefix string, cursor string, count int) ([]stow.Container, string, error) {
+	names := l.containerNames()
+	if cursor != stow.CursorStart {
+		i := sort.SearchStrings(names, cursor)
+		if i == len(names) || names[i] != cursor {
+			return nil, "", stow.ErrBadCursor
+		}
+		names = names[i:]
+	}
+	var containers []stow.Container
+	for i, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if len(containers) == count {
+			return containers, names[i], nil
+		}
+		containers = append(containers, l.container(name))
+	}
+	return containers, "", nil
+}
+
+func (l *location) Container(id string) (stow.Container, error) {
+	names := l.containerNames()
+	i := sort.SearchStrings(names, id)
+	if i == len(names) || names[i] != id {
+		return nil, stow.ErrNotFound
+	}
+	return l.container(id), nil
+}
+
+func (l *location) container(name string) *container {
+	c := &container{name: name, archive: l.archive}
+	if l.layout == LayoutDirs {
+		c.prefix = name + "/"
+	}
+	return c
+}
+
+// ItemByURL gets the Item with a URL of the form
+// archive:///<container>/<name>.
+func (l *location) ItemByURL(u *url.URL) (stow.Item, error) {
+	if u.Scheme != Kind {
+		return nil, errors.New("not valid archive URL")
+	}
+	parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("not valid archive URL")
+	}
+	c, err := l.Container(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	return c.Item(parts[1])
+}
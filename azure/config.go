@@ -3,6 +3,7 @@ package azure
 import (
 	"errors"
 	"net/url"
+	"strings"
 
 	az "github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/graymeta/stow"
@@ -15,6 +16,28 @@ const (
 	ConfigKey     = "key"
 )
 
+// ConfigSubscriptionID, ConfigResourceGroup, ConfigTenantID, ConfigClientID
+// and ConfigClientSecret are optional configuration items identifying the
+// storage account and a service principal in Azure Resource Manager.
+// They are needed to manage lifecycle rules.
+const (
+	ConfigSubscriptionID = "subscription_id"
+	ConfigResourceGroup  = "resource_group"
+	ConfigTenantID       = "tenant_id"
+	ConfigClientID       = "client_id"
+	ConfigClientSecret   = "client_secret"
+)
+
+// managementConfigs are the configuration items needed to make Azure
+// Resource Manager requests.
+var managementConfigs = []string{
+	ConfigSubscriptionID,
+	ConfigResourceGroup,
+	ConfigTenantID,
+	ConfigClientID,
+	ConfigClientSecret,
+}
+
 // Kind is the kind of Location this package provides.
 const Kind = "azure"
 
@@ -28,14 +51,23 @@ func init() {
 		if !ok {
 			return errors.New("missing auth key")
 		}
+		var missing []string
+		for _, k := range managementConfigs {
+			if _, ok := config.Config(k); !ok {
+				missing = append(missing, k)
+			}
+		}
+		if len(missing) > 0 && len(missing) < len(managementConfigs) {
+			return errors.New("missing " + strings.Join(missing, ", "))
+		}
 		return nil
 	}
 	makefn := func(config stow.Config) (stow.Location, error) {
-		_, ok := config.Config(ConfigAccount)
+		account, ok := config.Config(ConfigAccount)
 		if !ok {
 			return nil, errors.New("missing account id")
 		}
-		_, ok = config.Config(ConfigKey)
+		key, ok := config.Config(ConfigKey)
 		if !ok {
 			return nil, errors.New("missing auth key")
 		}
@@ -47,6 +79,11 @@ func init() {
 		if err != nil {
 			return nil, err
 		}
+		l.rest, err = newRESTClient(account, key)
+		if err != nil {
+			return nil, err
+		}
+		l.management = newManagementClientFromConfig(account, config)
 		// test the connection
 		_, _, err = l.Containers("", stow.CursorStart, 1)
 		if err != nil {
@@ -60,6 +97,20 @@ func init() {
 	stow.Register(Kind, makefn, kindfn, validatefn)
 }
 
+// newManagementClientFromConfig gets a managementClient if the Azure Resource
+// Manager configuration is given, nil otherwise.
+func newManagementClientFromConfig(account string, cfg stow.Config) *managementClient {
+	values := make([]string, len(managementConfigs))
+	for i, k := range managementConfigs {
+		v, ok := cfg.Config(k)
+		if !ok {
+			return nil
+		}
+		values[i] = v
+	}
+	return newManagementClient(account, values[0], values[1], values[2], values[3], values[4])
+}
+
 func newBlobStorageClient(cfg stow.Config) (*az.BlobStorageClient, error) {
 	acc, ok := cfg.Config(ConfigAccount)
 	if !ok {
@@ -20,9 +20,14 @@ type container struct {
 	id         string
 	properties az.ContainerProperties
 	client     *az.BlobStorageClient
+	rest       *restClient
+	management *managementClient
 }
 
-var _ stow.Container = (*container)(nil)
+var (
+	_ stow.Container       = (*container)(nil)
+	_ stow.DelimiterLister = (*container)(nil)
+)
 
 func (c *container) ID() string {
 	return c.id
@@ -32,6 +37,44 @@ func (c *container) Name() string {
 	return c.id
 }
 
+// Stat describes the container. The Blob service does not tell when
+// containers were created, so Created is left zero. The region, default
+// access tier and versioning are settings of the storage account, which
+// are only known with the Azure Resource Manager configuration.
+func (c *container) Stat() (stow.ContainerInfo, error) {
+	var info stow.ContainerInfo
+	headers, err := c.rest.containerProperties(c.id)
+	if err != nil {
+		return info, err
+	}
+	// anonymous access is "container" or "blob", or not set
+	info.PublicAccess = headers.Get("x-ms-blob-public-access") != ""
+	info.Metadata = make(map[string]interface{})
+	for k := range headers {
+		if name := strings.ToLower(k); strings.HasPrefix(name, "x-ms-meta-") {
+			info.Metadata[strings.TrimPrefix(name, "x-ms-meta-")] = headers.Get(k)
+		}
+	}
+	if c.management == nil {
+		return info, nil
+	}
+	account, err := c.management.storageAccount()
+	if err != nil {
+		return info, err
+	}
+	info.Region = account.Location
+	info.StorageClass = stow.StorageClassFromNative(account.Properties.AccessTier, accessTiers)
+	versioning, err := c.management.versioningEnabled()
+	if err != nil {
+		return info, err
+	}
+	info.Versioning = stow.VersioningDisabled
+	if versioning {
+		info.Versioning = stow.VersioningEnabled
+	}
+	return info, nil
+}
+
 func (c *container) Item(id string) (stow.Item, error) {
 	blob := c.client.GetContainerReference(c.id).GetBlobReference(id)
 	err := blob.GetProperties(nil)
@@ -82,22 +125,86 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 	return items, listblobs.NextMarker, nil
 }
 
+// ItemsDelimited lists the blobs with the prefix that have no delimiter
+// after it, and the blob prefixes of the others.
+func (c *container) ItemsDelimited(prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	params := az.ListBlobsParameters{
+		Prefix:     prefix,
+		Delimiter:  delimiter,
+		MaxResults: uint(count),
+		Marker:     cursor,
+	}
+	listblobs, err := c.client.GetContainerReference(c.id).ListBlobs(params)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	items := make([]stow.Item, len(listblobs.Blobs))
+	for i, blob := range listblobs.Blobs {
+		blob.Properties.Etag = cleanEtag(blob.Properties.Etag)
+		items[i] = &item{
+			id:         blob.Name,
+			container:  c,
+			client:     c.client,
+			properties: blob.Properties,
+		}
+	}
+	return items, listblobs.BlobPrefixes, listblobs.NextMarker, nil
+}
+
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutWithOptions(name, r, size, metadata, nil)
+}
+
+// PutWithOptions creates or updates a blob like Put, also setting the
+// content headers given in the options.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, metadata map[string]interface{}, options *stow.PutOptions) (stow.Item, error) {
 	mdParsed, err := prepMetadata(metadata)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create or update Item, preparing metadata")
 	}
 
+	content, r, err := stow.PrepareContentProperties(name, r, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create or update Item, detecting content type")
+	}
+
+	var tier string
+	var tags map[string]string
+	if options != nil {
+		tier, err = stow.NativeStorageClass(options.StorageClass, accessTiers)
+		if err != nil {
+			return nil, err
+		}
+		if options.ACL != "" {
+			// access is set on the container, not on blobs
+			return nil, stow.NotSupported("ACL")
+		}
+		if e := options.Encryption; e != nil && e.Mode != stow.EncryptionProvider {
+			// blobs are always encrypted with Microsoft managed keys
+			return nil, stow.NotSupported("encryption mode " + string(e.Mode))
+		}
+		if tags, err = stow.TagValues(options.Tags); err != nil {
+			return nil, errors.Wrap(err, "unable to create or update Item, preparing tags")
+		}
+	}
+
 	name = strings.Replace(name, " ", "+", -1)
 
+	blob := c.client.GetContainerReference(c.id).GetBlobReference(name)
+	setContentProperties(&blob.Properties, content)
+
 	if size > maxPutSize {
 		// Do a multipart upload
-		err := c.multipartUpload(name, r, size)
+		var transfer stow.TransferOptions
+		if options != nil {
+			transfer = options.Transfer
+		}
+		err := c.multipartUpload(blob, r, size, transfer)
 		if err != nil {
 			return nil, errors.Wrap(err, "multipart upload")
 		}
 	} else {
-		err = c.client.GetContainerReference(c.id).GetBlobReference(name).CreateBlockBlobFromReader(r, nil)
+		err = blob.CreateBlockBlobFromReader(r, nil)
 		if err != nil {
 			return nil, errors.Wrap(err, "unable to create or update Item")
 		}
@@ -108,6 +215,18 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 		return nil, errors.Wrap(err, "unable to create or update item, setting Item metadata")
 	}
 
+	if tier != "" {
+		if err := c.rest.setBlobTier(c.id, name, tier); err != nil {
+			return nil, errors.Wrap(err, "unable to create or update item")
+		}
+	}
+
+	if len(tags) > 0 {
+		if err := c.rest.setBlobTags(c.id, name, tags); err != nil {
+			return nil, errors.Wrap(err, "unable to create or update item")
+		}
+	}
+
 	item := &item{
 		id:        name,
 		container: c,
@@ -117,10 +236,31 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 			Etag:          "",
 			ContentLength: size,
 		},
+		content: &content,
 	}
+	setContentProperties(&item.properties, content)
 	return item, nil
 }
 
+// accessTiers are the names Azure uses for the provider-neutral storage
+// classes.
+var accessTiers = map[stow.StorageClass]string{
+	stow.StorageClassHot:     "Hot",
+	stow.StorageClassCool:    "Cool",
+	stow.StorageClassCold:    "Cold",
+	stow.StorageClassArchive: "Archive",
+}
+
+// setContentProperties copies the content properties to the blob
+// properties, which the client sends as headers when creating the blob.
+func setContentProperties(props *az.BlobProperties, content stow.ContentProperties) {
+	props.ContentType = content.ContentType
+	props.ContentEncoding = content.ContentEncoding
+	props.ContentLanguage = content.ContentLanguage
+	props.CacheControl = content.CacheControl
+	props.ContentDisposition = content.ContentDisposition
+}
+
 func (c *container) SetItemMetadata(itemName string, md map[string]string) error {
 	blob := c.client.GetContainerReference(c.id).GetBlobReference(itemName)
 	blob.Metadata = md
@@ -148,7 +288,11 @@ func prepMetadata(md map[string]interface{}) (map[string]string, error) {
 }
 
 func (c *container) RemoveItem(id string) error {
-	return c.client.GetContainerReference(c.id).GetBlobReference(id).Delete(nil)
+	err := c.client.GetContainerReference(c.id).GetBlobReference(id).Delete(nil)
+	if serr, ok := err.(az.AzureStorageServiceError); ok && strings.HasPrefix(serr.Code, "BlobImmutable") {
+		return stow.ErrLocked
+	}
+	return err
 }
 
 // Remove quotation marks from beginning and end. This includes quotations that
@@ -20,11 +20,18 @@ type item struct {
 	metadata   map[string]interface{}
 	infoOnce   sync.Once
 	infoErr    error
+
+	// content is nil until the properties have been fetched, as listing
+	// blobs does not return all of them.
+	content     *stow.ContentProperties
+	contentOnce sync.Once
+	contentErr  error
 }
 
 var (
-	_ stow.Item       = (*item)(nil)
-	_ stow.ItemRanger = (*item)(nil)
+	_ stow.Item             = (*item)(nil)
+	_ stow.ItemRanger       = (*item)(nil)
+	_ stow.ContentDescriber = (*item)(nil)
 )
 
 func (i *item) ID() string {
@@ -47,7 +54,11 @@ func (i *item) Size() (int64, error) {
 }
 
 func (i *item) Open() (io.ReadCloser, error) {
-	return i.client.GetContainerReference(i.container.id).GetBlobReference(i.id).Get(nil)
+	r, err := i.client.GetContainerReference(i.container.id).GetBlobReference(i.id).Get(nil)
+	if err != nil {
+		return nil, i.openError(err)
+	}
+	return r, nil
 }
 
 func (i *item) ETag() (string, error) {
@@ -89,6 +100,32 @@ func (i *item) ensureInfo() error {
 	return i.infoErr
 }
 
+// ContentProperties returns the content headers of the blob.
+func (i *item) ContentProperties() (stow.ContentProperties, error) {
+	// listings set content, and the Once orders reads after that
+	i.contentOnce.Do(func() {
+		if i.content != nil {
+			return
+		}
+		blob := i.client.GetContainerReference(i.container.id).GetBlobReference(i.id)
+		if err := blob.GetProperties(nil); err != nil {
+			i.contentErr = err
+			return
+		}
+		i.content = &stow.ContentProperties{
+			ContentType:        blob.Properties.ContentType,
+			ContentEncoding:    blob.Properties.ContentEncoding,
+			ContentLanguage:    blob.Properties.ContentLanguage,
+			CacheControl:       blob.Properties.CacheControl,
+			ContentDisposition: blob.Properties.ContentDisposition,
+		}
+	})
+	if i.contentErr != nil {
+		return stow.ContentProperties{}, errors.Wrap(i.contentErr, "retrieving content properties")
+	}
+	return *i.content, nil
+}
+
 func (i *item) getInfo() (stow.Item, error) {
 	itemInfo, err := i.container.Item(i.ID())
 	if err != nil {
@@ -106,5 +143,9 @@ func (i *item) OpenRange(start, end uint64) (io.ReadCloser, error) {
 			End:   end,
 		},
 	}
-	return i.client.GetContainerReference(i.container.id).GetBlobReference(i.id).GetRange(opts)
+	r, err := i.client.GetContainerReference(i.container.id).GetBlobReference(i.id).GetRange(opts)
+	if err != nil {
+		return nil, i.openError(err)
+	}
+	return r, nil
 }
@@ -0,0 +1,254 @@
+package azure
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var _ stow.LifecycleManager = (*container)(nil)
+
+// LifecycleRules gets the lifecycle rules of the container from the
+// management policy of the storage account. The rules are those with a
+// single prefix within the container. Their ages count from the last
+// modification of blobs, and from the creation of noncurrent versions.
+// Policy rules with more than stow.LifecycleRule represents, such as
+// disabled rules or snapshot actions, are left out.
+func (c *container) LifecycleRules() ([]stow.LifecycleRule, error) {
+	if c.management == nil {
+		return nil, stow.NotSupported("lifecycle rules without management configuration")
+	}
+	policy, err := c.management.managementPolicy()
+	if err != nil {
+		return nil, err
+	}
+	var rules []stow.LifecycleRule
+	for _, raw := range policy.Properties.Policy.Rules {
+		if r, ok := lifecycleRules(raw, c.id); ok {
+			rules = append(rules, r...)
+		}
+	}
+	return rules, nil
+}
+
+// SetLifecycleRules replaces the lifecycle rules of the container in the
+// management policy of the storage account. Only the policy rules that
+// LifecycleRules gives are replaced, so the rules of other containers and
+// those stow does not represent are kept. Rule names are unique within the
+// account.
+func (c *container) SetLifecycleRules(rules []stow.LifecycleRule) error {
+	if c.management == nil {
+		return stow.NotSupported("lifecycle rules without management configuration")
+	}
+	policy, err := c.management.managementPolicy()
+	if err != nil {
+		return err
+	}
+	var kept []json.RawMessage
+	keptNames := map[string]bool{}
+	for _, raw := range policy.Properties.Policy.Rules {
+		if _, ok := lifecycleRules(raw, c.id); ok {
+			continue
+		}
+		kept = append(kept, raw)
+		var r struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(raw, &r); err == nil {
+			keptNames[r.Name] = true
+		}
+	}
+	policyRules, err := toPolicyRules(c.id, rules, keptNames)
+	if err != nil {
+		return err
+	}
+	for _, r := range policyRules {
+		raw, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		kept = append(kept, raw)
+	}
+	policy.Properties.Policy.Rules = kept
+	return c.management.setManagementPolicy(policy)
+}
+
+// lifecycleRules gets the lifecycle rules of the container a policy rule
+// stands for, and whether they say all that the policy rule does.
+func lifecycleRules(raw json.RawMessage, container string) ([]stow.LifecycleRule, bool) {
+	var r policyRule
+	d := json.NewDecoder(bytes.NewReader(raw))
+	// fields policyRule does not have are actions or filters stow does
+	// not know
+	d.DisallowUnknownFields()
+	if err := d.Decode(&r); err != nil {
+		return nil, false
+	}
+	prefix, ok := r.containerRule(container)
+	if !ok || !r.Enabled || r.Type != "Lifecycle" || len(r.Definition.Actions.Snapshot) > 0 {
+		return nil, false
+	}
+	for _, t := range r.Definition.Filters.BlobTypes {
+		if t != "blockBlob" {
+			return nil, false
+		}
+	}
+	if b := r.Definition.Actions.BaseBlob; b != nil {
+		for _, age := range []*policyAge{b.Delete, b.TierToCool, b.TierToCold, b.TierToArchive} {
+			if age != nil && (age.DaysAfterModificationGreaterThan == nil || age.DaysAfterCreationGreaterThan != nil || age.DaysAfterLastAccessTimeGreaterThan != nil) {
+				return nil, false
+			}
+		}
+	}
+	if v := r.Definition.Actions.Version; v != nil && v.Delete != nil {
+		if age := v.Delete; age.DaysAfterCreationGreaterThan == nil || age.DaysAfterModificationGreaterThan != nil || age.DaysAfterLastAccessTimeGreaterThan != nil {
+			return nil, false
+		}
+	}
+	rules := fromPolicyRule(r, prefix)
+	return rules, len(rules) > 0
+}
+
+func fromPolicyRule(r policyRule, prefix string) []stow.LifecycleRule {
+	var rules []stow.LifecycleRule
+	add := func(action stow.LifecycleAction, class stow.StorageClass, days *float64) {
+		if days == nil {
+			return
+		}
+		rules = append(rules, stow.LifecycleRule{
+			ID:           r.Name,
+			Prefix:       prefix,
+			Action:       action,
+			Age:          time.Duration(*days) * 24 * time.Hour,
+			StorageClass: class,
+		})
+	}
+	if b := r.Definition.Actions.BaseBlob; b != nil {
+		if b.Delete != nil {
+			add(stow.LifecycleDelete, "", b.Delete.DaysAfterModificationGreaterThan)
+		}
+		for _, t := range []struct {
+			class stow.StorageClass
+			age   *policyAge
+		}{
+			{stow.StorageClassCool, b.TierToCool},
+			{stow.StorageClassCold, b.TierToCold},
+			{stow.StorageClassArchive, b.TierToArchive},
+		} {
+			if t.age != nil {
+				add(stow.LifecycleTransition, t.class, t.age.DaysAfterModificationGreaterThan)
+			}
+		}
+	}
+	if v := r.Definition.Actions.Version; v != nil && v.Delete != nil {
+		add(stow.LifecycleDeleteNoncurrent, "", v.Delete.DaysAfterCreationGreaterThan)
+	}
+	return rules
+}
+
+// toPolicyRules gets the management policy rules for the lifecycle rules
+// of the container. Rules sharing an ID are combined into a single policy
+// rule, so they must share the prefix too. The names of the policy rules
+// that are kept cannot be used.
+func toPolicyRules(container string, rules []stow.LifecycleRule, taken map[string]bool) ([]policyRule, error) {
+	var policyRules []*policyRule
+	byName := map[string]*policyRule{}
+	n := 0
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+		name := rule.ID
+		if name == "" {
+			// generated names skip those in use
+			for name == "" || taken[name] || byName[name] != nil {
+				n++
+				name = ruleName(container, n)
+			}
+		} else if taken[name] {
+			return nil, errors.Errorf("lifecycle rule name %q is used by a policy rule stow does not manage", name)
+		}
+		r, ok := byName[name]
+		if !ok {
+			r = &policyRule{
+				Enabled: true,
+				Name:    name,
+				Type:    "Lifecycle",
+				Definition: policyDefinition{
+					Filters: policyFilters{
+						BlobTypes:   []string{"blockBlob"},
+						PrefixMatch: []string{container + "/" + rule.Prefix},
+					},
+				},
+			}
+			byName[name] = r
+			policyRules = append(policyRules, r)
+		}
+		if r.Definition.Filters.PrefixMatch[0] != container+"/"+rule.Prefix {
+			return nil, errors.Errorf("lifecycle rules with ID %q have different prefixes", name)
+		}
+		days := float64(rule.AgeDays())
+		age := &policyAge{DaysAfterModificationGreaterThan: &days}
+		actions := &r.Definition.Actions
+		if rule.Action == stow.LifecycleDelete || rule.Action == stow.LifecycleTransition {
+			if actions.BaseBlob == nil {
+				actions.BaseBlob = &baseBlobActions{}
+			}
+		}
+		var action **policyAge
+		switch rule.Action {
+		case stow.LifecycleDelete:
+			action = &actions.BaseBlob.Delete
+		case stow.LifecycleTransition:
+			tier, err := stow.NativeStorageClass(rule.StorageClass, accessTiers)
+			if err != nil {
+				return nil, err
+			}
+			switch tier {
+			case accessTiers[stow.StorageClassCool]:
+				action = &actions.BaseBlob.TierToCool
+			case accessTiers[stow.StorageClassCold]:
+				action = &actions.BaseBlob.TierToCold
+			case accessTiers[stow.StorageClassArchive]:
+				action = &actions.BaseBlob.TierToArchive
+			default:
+				return nil, stow.NotSupported("lifecycle transition to " + tier)
+			}
+		case stow.LifecycleDeleteNoncurrent:
+			if actions.Version == nil {
+				actions.Version = &versionActions{}
+			}
+			action = &actions.Version.Delete
+			age = &policyAge{DaysAfterCreationGreaterThan: &days}
+		default:
+			return nil, stow.NotSupported("lifecycle action " + string(rule.Action))
+		}
+		if *action != nil {
+			return nil, errors.Errorf("lifecycle rule %q has the same action more than once", name)
+		}
+		*action = age
+	}
+	result := make([]policyRule, len(policyRules))
+	for i, r := range policyRules {
+		result[i] = *r
+	}
+	return result, nil
+}
+
+// ruleName generates the name of the nth rule of a container. Rule names
+// are alphanumeric.
+func ruleName(container string, n int) string {
+	name := strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return -1
+		}
+		return r
+	}, container)
+	return name + "rule" + strconv.Itoa(n)
+}
@@ -13,6 +13,10 @@ import (
 type location struct {
 	config stow.Config
 	client *az.BlobStorageClient
+	rest   *restClient
+	// management is nil unless the Azure Resource Manager configuration
+	// is given.
+	management *managementClient
 }
 
 func (l *location) Close() error {
@@ -32,7 +36,9 @@ func (l *location) CreateContainer(name string) (stow.Container, error) {
 		properties: az.ContainerProperties{
 			LastModified: time.Now().Format(timeFormat),
 		},
-		client: l.client,
+		client:     l.client,
+		rest:       l.rest,
+		management: l.management,
 	}
 	time.Sleep(time.Second * 3)
 	return container, nil
@@ -56,6 +62,8 @@ func (l *location) Containers(prefix, cursor string, count int) ([]stow.Containe
 			id:         azureContainer.Name,
 			properties: azureContainer.Properties,
 			client:     l.client,
+			rest:       l.rest,
+			management: l.management,
 		}
 	}
 	return containers, response.NextMarker, nil
@@ -0,0 +1,284 @@
+package azure
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+// managementAPIVersion is the version of the Azure Resource Manager
+// storage API used by managementClient.
+const managementAPIVersion = "2023-01-01"
+
+// managementClient makes the Azure Resource Manager requests for settings
+// of the storage account that the Blob service does not expose, such as
+// the lifecycle management policy. Requests are authorized with a token
+// for a service principal.
+type managementClient struct {
+	account        string
+	subscriptionID string
+	resourceGroup  string
+	clientID       string
+	clientSecret   string
+	// baseURL is the Azure Resource Manager endpoint.
+	baseURL string
+	// tokenURL is the endpoint tokens are requested from.
+	tokenURL string
+	client   *http.Client
+
+	mu     sync.Mutex // protects token and expiry
+	token  string
+	expiry time.Time
+}
+
+func newManagementClient(account, subscriptionID, resourceGroup, tenantID, clientID, clientSecret string) *managementClient {
+	return &managementClient{
+		account:        account,
+		subscriptionID: subscriptionID,
+		resourceGroup:  resourceGroup,
+		clientID:       clientID,
+		clientSecret:   clientSecret,
+		baseURL:        "https://management.azure.com",
+		tokenURL:       "https://login.microsoftonline.com/" + url.PathEscape(tenantID) + "/oauth2/v2.0/token",
+		client:         http.DefaultClient,
+	}
+}
+
+// managementError is the error returned by Azure Resource Manager.
+type managementError struct {
+	StatusCode int
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *managementError) Error() string {
+	return fmt.Sprintf("azure: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// accessToken gets a token for the service principal, requesting a new one
+// shortly before the current one expires.
+func (m *managementClient) accessToken() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.token != "" && time.Now().Before(m.expiry) {
+		return m.token, nil
+	}
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {m.clientID},
+		"client_secret": {m.clientSecret},
+		"scope":         {"https://management.azure.com/.default"},
+	}
+	resp, err := m.client.PostForm(m.tokenURL, form)
+	if err != nil {
+		return "", errors.Wrap(err, "requesting token")
+	}
+	defer resp.Body.Close()
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&token); err != nil {
+		return "", errors.Wrapf(err, "decoding token response with status %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || token.AccessToken == "" {
+		return "", errors.Errorf("requesting token: %d %s: %s", resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+	m.token = token.AccessToken
+	m.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return m.token, nil
+}
+
+// do sends a request for the storage account resource at path, relative
+// to the account, encoding in as the JSON body and decoding the response
+// into out when they are not nil. A 404 response gives stow.ErrNotFound.
+func (m *managementClient) do(method, path string, in, out interface{}, expected ...int) error {
+	token, err := m.accessToken()
+	if err != nil {
+		return err
+	}
+	var body []byte
+	if in != nil {
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	u := fmt.Sprintf("%s/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Storage/storageAccounts/%s%s?api-version=%s",
+		m.baseURL, url.PathEscape(m.subscriptionID), url.PathEscape(m.resourceGroup), url.PathEscape(m.account), path, managementAPIVersion)
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			if out == nil {
+				return nil
+			}
+			return json.NewDecoder(resp.Body).Decode(out)
+		}
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return stow.ErrNotFound
+	}
+	var merr struct {
+		Error managementError `json:"error"`
+	}
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	json.Unmarshal(b, &merr)
+	merr.Error.StatusCode = resp.StatusCode
+	if merr.Error.Code == "" {
+		merr.Error.Code = http.StatusText(resp.StatusCode)
+	}
+	return &merr.Error
+}
+
+// managementPolicy is the lifecycle management policy of a storage
+// account. Rules are kept as JSON so the rules stow does not understand are
+// put back unchanged.
+type managementPolicy struct {
+	Properties struct {
+		Policy struct {
+			Rules []json.RawMessage `json:"rules"`
+		} `json:"policy"`
+	} `json:"properties"`
+}
+
+// policyRule is a rule of a management policy.
+type policyRule struct {
+	Enabled    bool             `json:"enabled"`
+	Name       string           `json:"name"`
+	Type       string           `json:"type"`
+	Definition policyDefinition `json:"definition"`
+}
+
+type policyDefinition struct {
+	Actions policyActions `json:"actions"`
+	Filters policyFilters `json:"filters"`
+}
+
+type policyFilters struct {
+	BlobTypes      []string          `json:"blobTypes"`
+	PrefixMatch    []string          `json:"prefixMatch,omitempty"`
+	BlobIndexMatch []json.RawMessage `json:"blobIndexMatch,omitempty"`
+}
+
+type policyActions struct {
+	BaseBlob *baseBlobActions `json:"baseBlob,omitempty"`
+	Snapshot json.RawMessage  `json:"snapshot,omitempty"`
+	Version  *versionActions  `json:"version,omitempty"`
+}
+
+type baseBlobActions struct {
+	TierToCool    *policyAge `json:"tierToCool,omitempty"`
+	TierToCold    *policyAge `json:"tierToCold,omitempty"`
+	TierToArchive *policyAge `json:"tierToArchive,omitempty"`
+	Delete        *policyAge `json:"delete,omitempty"`
+}
+
+type versionActions struct {
+	Delete *policyAge `json:"delete,omitempty"`
+}
+
+// policyAge is the condition of an action. Only one of the fields is set.
+type policyAge struct {
+	DaysAfterModificationGreaterThan   *float64 `json:"daysAfterModificationGreaterThan,omitempty"`
+	DaysAfterCreationGreaterThan       *float64 `json:"daysAfterCreationGreaterThan,omitempty"`
+	DaysAfterLastAccessTimeGreaterThan *float64 `json:"daysAfterLastAccessTimeGreaterThan,omitempty"`
+}
+
+// managementPolicy gets the lifecycle management policy of the account.
+// No policy gives an empty policy.
+func (m *managementClient) managementPolicy() (*managementPolicy, error) {
+	var policy managementPolicy
+	err := m.do(http.MethodGet, "/managementPolicies/default", nil, &policy, http.StatusOK)
+	if err == stow.ErrNotFound {
+		return &policy, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "getting management policy")
+	}
+	return &policy, nil
+}
+
+// setManagementPolicy replaces the lifecycle management policy of the
+// account. A policy without rules is deleted.
+func (m *managementClient) setManagementPolicy(policy *managementPolicy) error {
+	if len(policy.Properties.Policy.Rules) == 0 {
+		err := m.do(http.MethodDelete, "/managementPolicies/default", nil, nil, http.StatusOK, http.StatusNoContent)
+		if err != nil && err != stow.ErrNotFound {
+			return errors.Wrap(err, "deleting management policy")
+		}
+		return nil
+	}
+	err := m.do(http.MethodPut, "/managementPolicies/default", policy, nil, http.StatusOK)
+	if err != nil {
+		return errors.Wrap(err, "setting management policy")
+	}
+	return nil
+}
+
+// containerRule gets whether the rule is only about the container, with a
+// single prefix, so that it can be represented by stow.LifecycleRule.
+func (r *policyRule) containerRule(container string) (prefix string, ok bool) {
+	f := r.Definition.Filters
+	if len(f.PrefixMatch) != 1 || len(f.BlobIndexMatch) > 0 {
+		return "", false
+	}
+	if !strings.HasPrefix(f.PrefixMatch[0], container+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(f.PrefixMatch[0], container+"/"), true
+}
+
+// storageAccount describes the storage account.
+type storageAccount struct {
+	Location   string `json:"location"`
+	Properties struct {
+		// AccessTier is the default access tier of blobs.
+		AccessTier string `json:"accessTier"`
+	} `json:"properties"`
+}
+
+// storageAccount gets the storage account.
+func (m *managementClient) storageAccount() (*storageAccount, error) {
+	var account storageAccount
+	if err := m.do(http.MethodGet, "", nil, &account, http.StatusOK); err != nil {
+		return nil, errors.Wrap(err, "getting storage account")
+	}
+	return &account, nil
+}
+
+// versioningEnabled gets whether the Blob service of the account keeps
+// versions of blobs.
+func (m *managementClient) versioningEnabled() (bool, error) {
+	var service struct {
+		Properties struct {
+			IsVersioningEnabled bool `json:"isVersioningEnabled"`
+		} `json:"properties"`
+	}
+	if err := m.do(http.MethodGet, "/blobServices/default", nil, &service, http.StatusOK); err != nil {
+		return false, errors.Wrap(err, "getting blob service properties")
+	}
+	return service.Properties.IsVersioningEnabled, nil
+}
@@ -0,0 +1,150 @@
+package azure
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+const testPolicy = `{"properties":{"policy":{"rules":[
+{"enabled":true,"name":"logs","type":"Lifecycle","definition":{"filters":{"blobTypes":["blockBlob"],"prefixMatch":["container/logs/"]},"actions":{
+	"baseBlob":{"tierToCool":{"daysAfterModificationGreaterThan":30},"delete":{"daysAfterModificationGreaterThan":365}},
+	"version":{"delete":{"daysAfterCreationGreaterThan":7}}}}},
+{"enabled":true,"name":"other","type":"Lifecycle","definition":{"filters":{"blobTypes":["blockBlob"],"prefixMatch":["other/"]},"actions":{"snapshot":{"delete":{"daysAfterCreationGreaterThan":1}}}}},
+{"enabled":false,"name":"containerrule1","type":"Lifecycle","definition":{"filters":{"blobTypes":["blockBlob"],"prefixMatch":["container/tmp/"]},"actions":{"baseBlob":{"delete":{"daysAfterModificationGreaterThan":1}}}}},
+{"enabled":true,"name":"cold","type":"Lifecycle","definition":{"filters":{"blobTypes":["blockBlob"],"prefixMatch":["container/"]},"actions":{"baseBlob":{"tierToCool":{"daysAfterLastAccessTimeGreaterThan":10},"enableAutoTierToHotFromCool":true}}}}
+]}}}`
+
+func TestLifecycleRules(t *testing.T) {
+	is := is.New(t)
+
+	var tokens int
+	var method, body string
+	policy := testPolicy
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tenant/oauth2/v2.0/token" {
+			tokens++
+			is.Equal(r.FormValue("client_secret"), "secret")
+			w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+			return
+		}
+		is.Equal(r.Header.Get("Authorization"), "Bearer token")
+		is.Equal(r.URL.Path, "/subscriptions/sub/resourceGroups/group/providers/Microsoft.Storage/storageAccounts/account/managementPolicies/default")
+		is.Equal(r.URL.Query().Get("api-version"), managementAPIVersion)
+		method = r.Method
+		switch r.Method {
+		case http.MethodGet:
+			if policy == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(policy))
+		case http.MethodPut:
+			b, _ := ioutil.ReadAll(r.Body)
+			body = string(b)
+			w.Write(b)
+		}
+	}))
+	defer server.Close()
+
+	m := newManagementClient("account", "sub", "group", "tenant", "client", "secret")
+	m.baseURL = server.URL
+	m.tokenURL = server.URL + "/tenant/oauth2/v2.0/token"
+	c := &container{id: "container", management: m}
+
+	day := 24 * time.Hour
+	rules, err := c.LifecycleRules()
+	is.NoErr(err)
+	is.Equal(rules, []stow.LifecycleRule{
+		{ID: "logs", Prefix: "logs/", Action: stow.LifecycleDelete, Age: 365 * day},
+		{ID: "logs", Prefix: "logs/", Action: stow.LifecycleTransition, Age: 30 * day, StorageClass: stow.StorageClassCool},
+		{ID: "logs", Prefix: "logs/", Action: stow.LifecycleDeleteNoncurrent, Age: 7 * day},
+	})
+
+	// the rules of the container are replaced, other rules are kept, as
+	// are the disabled rule and the rule with actions stow does not know
+	is.NoErr(c.SetLifecycleRules([]stow.LifecycleRule{
+		{Action: stow.LifecycleTransition, Age: 90 * day, StorageClass: stow.StorageClassArchive},
+	}))
+	is.Equal(method, http.MethodPut)
+	var put managementPolicy
+	is.NoErr(json.Unmarshal([]byte(body), &put))
+	is.Equal(len(put.Properties.Policy.Rules), 4)
+	is.True(strings.Contains(string(put.Properties.Policy.Rules[0]), `"name":"other"`))
+	is.True(strings.Contains(string(put.Properties.Policy.Rules[0]), `"snapshot"`))
+	is.True(strings.Contains(string(put.Properties.Policy.Rules[1]), `"enabled":false`))
+	is.True(strings.Contains(string(put.Properties.Policy.Rules[2]), `"enableAutoTierToHotFromCool":true`))
+	var r policyRule
+	is.NoErr(json.Unmarshal(put.Properties.Policy.Rules[3], &r))
+	// the name of the disabled rule is taken
+	is.Equal(r.Name, "containerrule2")
+	is.Equal(r.Definition.Filters.PrefixMatch, []string{"container/"})
+	is.Equal(*r.Definition.Actions.BaseBlob.TierToArchive.DaysAfterModificationGreaterThan, 90)
+	is.Equal(tokens, 1)
+
+	err = c.SetLifecycleRules([]stow.LifecycleRule{{Action: stow.LifecycleAbortIncompleteUploads}})
+	is.True(stow.IsNotSupported(err))
+	err = c.SetLifecycleRules([]stow.LifecycleRule{{Action: stow.LifecycleTransition, StorageClass: stow.StorageClassHot}})
+	is.True(stow.IsNotSupported(err))
+	err = c.SetLifecycleRules([]stow.LifecycleRule{{ID: "cold", Action: stow.LifecycleDelete}})
+	is.Err(err)
+
+	// removing the last rules deletes the policy
+	policy = `{"properties":{"policy":{"rules":[{"enabled":true,"name":"x","type":"Lifecycle","definition":{"filters":{"prefixMatch":["container/"]},"actions":{"baseBlob":{"delete":{"daysAfterModificationGreaterThan":1}}}}}]}}}`
+	is.NoErr(c.SetLifecycleRules(nil))
+	is.Equal(method, http.MethodDelete)
+
+	policy = ""
+	rules, err = c.LifecycleRules()
+	is.NoErr(err)
+	is.Equal(len(rules), 0)
+
+	_, err = (&container{id: "container"}).LifecycleRules()
+	is.True(stow.IsNotSupported(err))
+}
+
+func TestContainerStat(t *testing.T) {
+	is := is.New(t)
+
+	rest, done := newTestRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.URL.Query().Get("restype"), "container")
+		w.Header().Set("x-ms-blob-public-access", "blob")
+		w.Header().Set("x-ms-meta-Team", "media")
+	})
+	defer done()
+	c := &container{id: "container", rest: rest}
+
+	info, err := stow.StatContainer(c)
+	is.NoErr(err)
+	is.True(info.PublicAccess)
+	is.Equal(info.Metadata, map[string]interface{}{"team": "media"})
+	is.Equal(info.Region, "")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/token"):
+			w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+		case strings.HasSuffix(r.URL.Path, "/blobServices/default"):
+			w.Write([]byte(`{"properties":{"isVersioningEnabled":true}}`))
+		default:
+			w.Write([]byte(`{"location":"westeurope","properties":{"accessTier":"Cool"}}`))
+		}
+	}))
+	defer server.Close()
+	c.management = newManagementClient("account", "sub", "group", "tenant", "client", "secret")
+	c.management.baseURL = server.URL
+	c.management.tokenURL = server.URL + "/token"
+
+	info, err = stow.StatContainer(c)
+	is.NoErr(err)
+	is.Equal(info.Region, "westeurope")
+	is.Equal(info.StorageClass, stow.StorageClassCool)
+	is.Equal(info.Versioning, stow.VersioningEnabled)
+}
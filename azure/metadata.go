@@ -0,0 +1,35 @@
+package azure
+
+import (
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var _ stow.MetadataSetter = (*item)(nil)
+
+// SetMetadata replaces the metadata of the blob.
+func (i *item) SetMetadata(metadata map[string]interface{}) error {
+	mdPrepped, err := prepMetadata(metadata)
+	if err != nil {
+		return errors.Wrap(err, "unable to update item metadata, preparing metadata")
+	}
+	if err := i.container.SetItemMetadata(i.id, mdPrepped); err != nil {
+		return errors.Wrap(err, "unable to update item metadata")
+	}
+	i.metadata, err = parseMetadata(mdPrepped)
+	return err
+}
+
+// MergeMetadata changes the given keys in the metadata of the blob. Azure
+// replaces all metadata at once, so the current metadata is read first.
+func (i *item) MergeMetadata(changes map[string]interface{}) error {
+	blob := i.client.GetContainerReference(i.container.id).GetBlobReference(i.id)
+	if err := blob.GetMetadata(nil); err != nil {
+		return errors.Wrap(err, "unable to update item metadata, getting metadata")
+	}
+	current, err := parseMetadata(blob.Metadata)
+	if err != nil {
+		return err
+	}
+	return i.SetMetadata(stow.MergedMetadata(current, changes))
+}
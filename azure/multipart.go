@@ -7,6 +7,7 @@ import (
 	"io"
 
 	az "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/graymeta/stow"
 )
 
 // constants related to multi-part uploads
@@ -14,6 +15,8 @@ const (
 	startChunkSize = 4 * 1024 * 1024
 	maxChunkSize   = 100 * 1024 * 1024
 	maxParts       = 50000
+	// defaultConcurrency is the number of blocks put at once.
+	defaultConcurrency = 4
 )
 
 // errMultiPartUploadTooBig is the error returned when a file is just too big to upload
@@ -55,42 +58,49 @@ func determineChunkSize(size int64) (int64, error) {
 	return chunkSize, nil
 }
 
-// multipartUpload performs a multi-part upload by chunking the data, putting each chunk, then
-// assembling the chunks into a blob
-func (c *container) multipartUpload(name string, r io.Reader, size int64) error {
-	chunkSize, err := determineChunkSize(size)
+// partSize gets the size of the blocks of an upload. The requested size
+// is used when it keeps to the limits on blocks, otherwise the smallest
+// size that does is used.
+func partSize(size, requested int64) (int64, error) {
+	if requested > 0 && requested <= maxChunkSize && (size+requested-1)/requested <= maxParts {
+		return requested, nil
+	}
+	return determineChunkSize(size)
+}
+
+// multipartUpload performs a multi-part upload by chunking the data, putting the chunks
+// concurrently, then assembling the chunks into a blob
+func (c *container) multipartUpload(blob *az.Blob, r io.Reader, size int64, transfer stow.TransferOptions) error {
+	chunkSize, err := partSize(size, transfer.PartSize)
 	if err != nil {
 		return err
 	}
-	var buf = make([]byte, chunkSize)
-
-	var blocks []az.Block
-	var rawID uint64
-	blob := c.client.GetContainerReference(c.id).GetBlobReference(name)
+	concurrency := transfer.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
 
-	// TODO: upload the parts in parallel
-	for {
-		n, err := r.Read(buf)
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return err
+	blocks := make([]az.Block, (size+chunkSize-1)/chunkSize)
+	n, err := stow.UploadParts(r, chunkSize, concurrency, func(number int, chunk []byte) error {
+		if number > len(blocks) {
+			return errors.New("more data than the given size")
 		}
-
-		blockID := encodedBlockID(rawID)
-		chunk := buf[:n]
-
-		if err := blob.PutBlock(blockID, chunk, nil); err != nil {
+		blockID := encodedBlockID(uint64(number - 1))
+		// PutBlock sets the properties of the blob, so each block is put
+		// with a copy of it
+		b := *blob
+		if err := b.PutBlock(blockID, chunk, nil); err != nil {
 			return err
 		}
-
-		blocks = append(blocks, az.Block{
+		blocks[number-1] = az.Block{
 			ID:     blockID,
 			Status: az.BlockStatusLatest,
-		})
-		rawID++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	return blob.PutBlockList(blocks, nil)
+	return blob.PutBlockList(blocks[:n], nil)
 }
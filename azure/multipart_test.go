@@ -1,12 +1,18 @@
 package azure
 
 import (
+	"bytes"
 	"crypto/md5"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	az "github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/cheekybits/is"
 	"github.com/graymeta/stow"
 )
@@ -79,7 +85,8 @@ func TestMultipartUpload(t *testing.T) {
 	name := "bigfile/thebigfile"
 	azc, ok := cont.(*container)
 	is.OK(ok)
-	is.NoErr(azc.multipartUpload(name, f, fi.Size()))
+	blob := azc.client.GetContainerReference(azc.id).GetBlobReference(name)
+	is.NoErr(azc.multipartUpload(blob, f, fi.Size(), stow.TransferOptions{}))
 
 	item, err := cont.Item(name)
 	is.NoErr(err)
@@ -101,3 +108,78 @@ func TestMultipartUpload(t *testing.T) {
 
 	is.Equal(fmt.Sprintf("%x", hashOld.Sum(nil)), fmt.Sprintf("%x", hashNew.Sum(nil)))
 }
+
+// latencySender answers the requests of the storage SDK after a delay, as
+// a distant service would.
+type latencySender struct {
+	latency time.Duration
+}
+
+func (s latencySender) Send(c *az.Client, req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(ioutil.Discard, req.Body)
+	}
+	time.Sleep(s.latency)
+	return &http.Response{
+		StatusCode: http.StatusCreated,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func TestParallelMultipartUpload(t *testing.T) {
+	is := is.New(t)
+
+	client, err := az.NewBasicClient("account", "a2V5")
+	is.NoErr(err)
+	sender := &blockSender{blocks: map[string]string{}, failBlock: "none"}
+	client.Sender = sender
+	blobs := client.GetBlobService()
+	c := &container{id: "container", client: &blobs}
+	blob := blobs.GetContainerReference("container").GetBlobReference("blob")
+
+	data := "abcdefghijklmnopqrstuvwxyz"
+	is.NoErr(c.multipartUpload(blob, strings.NewReader(data), int64(len(data)), stow.TransferOptions{PartSize: 4, Concurrency: 4}))
+	is.Equal(len(sender.blocks), 7)
+	last := -1
+	for n := 0; n < 7; n++ {
+		end := (n + 1) * 4
+		if end > len(data) {
+			end = len(data)
+		}
+		is.Equal(sender.blocks[encodedBlockID(uint64(n))], data[n*4:end])
+		// the blocks are committed in order, whatever order they were put in
+		i := strings.Index(sender.committed, encodedBlockID(uint64(n)))
+		is.True(i > last)
+		last = i
+	}
+
+	is.Err(c.multipartUpload(blob, strings.NewReader("abcdefghij"), 8, stow.TransferOptions{PartSize: 4}))
+}
+
+// BenchmarkMultipartUpload shows the throughput of block uploads to a
+// service taking 10ms to answer, with blocks put one at a time and at once.
+func BenchmarkMultipartUpload(b *testing.B) {
+	client, err := az.NewBasicClient("account", "a2V5")
+	if err != nil {
+		b.Fatal(err)
+	}
+	client.Sender = latencySender{latency: 10 * time.Millisecond}
+	blobs := client.GetBlobService()
+	c := &container{id: "container", client: &blobs}
+	blob := blobs.GetContainerReference("container").GetBlobReference("blob")
+
+	data := make([]byte, 32*startChunkSize)
+	for _, concurrency := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				transfer := stow.TransferOptions{Concurrency: concurrency}
+				if err := c.multipartUpload(blob, bytes.NewReader(data), int64(len(data)), transfer); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,388 @@
+package azure
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+// restAPIVersion is the version of the Blob service REST API used for the
+// requests made by restClient. The storage SDK is pinned to an older
+// version that lacks access tiers among other things.
+const restAPIVersion = "2021-12-02"
+
+// restClient makes the Blob service requests the storage SDK has no support
+// for. Requests are authorized with the account key.
+type restClient struct {
+	account string
+	key     []byte
+	// baseURL is the Blob service endpoint of the account.
+	baseURL string
+	client  *http.Client
+}
+
+func newRESTClient(account, key string) (*restClient, error) {
+	k, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, errors.New("bad credentials")
+	}
+	return &restClient{
+		account: account,
+		key:     k,
+		baseURL: "https://" + account + ".blob.core.windows.net",
+		client:  http.DefaultClient,
+	}, nil
+}
+
+// restError is the error returned by the Blob service.
+type restError struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *restError) Error() string {
+	return fmt.Sprintf("azure: %d %s: %s", e.StatusCode, e.Code, strings.TrimSpace(e.Message))
+}
+
+// do sends a request for the resource at path, which is the escaped path of
+// a container or blob, and checks the response has one of the expected
+// status codes. A 404 response gives stow.ErrNotFound. The caller must
+// close the body of the returned response.
+func (r *restClient) do(method, path string, query url.Values, header http.Header, body []byte, expected ...int) (*http.Response, error) {
+	u := r.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", restAPIVersion)
+	req.Header.Set("Authorization", "SharedKey "+r.account+":"+r.signature(req))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return resp, nil
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, stow.ErrNotFound
+	}
+	rerr := &restError{StatusCode: resp.StatusCode}
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	xml.Unmarshal(b, rerr)
+	if rerr.Code == "" {
+		rerr.Code = http.StatusText(resp.StatusCode)
+	}
+	return nil, rerr
+}
+
+// signature computes the Shared Key signature of the request.
+// See https://docs.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func (r *restClient) signature(req *http.Request) string {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+	var b strings.Builder
+	for _, s := range []string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, x-ms-date is used instead
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+	} {
+		b.WriteString(s)
+		b.WriteByte('\n')
+	}
+
+	// canonicalized headers
+	headers := map[string]string{}
+	var names []string
+	for k, v := range req.Header {
+		if name := strings.ToLower(k); strings.HasPrefix(name, "x-ms-") {
+			headers[name] = strings.TrimSpace(strings.Join(v, ","))
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	// canonicalized resource
+	b.WriteString("/" + r.account + req.URL.EscapedPath())
+	query := req.URL.Query()
+	var params []string
+	for k := range query {
+		params = append(params, k)
+	}
+	sort.Strings(params)
+	for _, k := range params {
+		values := query[k]
+		sort.Strings(values)
+		b.WriteString("\n" + strings.ToLower(k) + ":" + strings.Join(values, ","))
+	}
+
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(b.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// blobPath gets the escaped path of a blob.
+func blobPath(container, blob string) string {
+	u := url.URL{Path: "/" + container + "/" + blob}
+	return u.EscapedPath()
+}
+
+// setBlobTier sets the access tier of a blob.
+func (r *restClient) setBlobTier(container, blob, tier string) error {
+	header := http.Header{}
+	header.Set("x-ms-access-tier", tier)
+	resp, err := r.do(http.MethodPut, blobPath(container, blob), url.Values{"comp": {"tier"}}, header, nil, http.StatusOK, http.StatusAccepted)
+	if err != nil {
+		return errors.Wrap(err, "setting blob tier")
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// blobTier gets the access tier of a blob, and its archive status, which
+// tells whether an archived blob is being rehydrated.
+func (r *restClient) blobTier(container, blob string) (string, string, error) {
+	resp, err := r.do(http.MethodHead, blobPath(container, blob), nil, nil, nil, http.StatusOK)
+	if err != nil {
+		return "", "", errors.Wrap(err, "getting blob properties")
+	}
+	resp.Body.Close()
+	return resp.Header.Get("x-ms-access-tier"), resp.Header.Get("x-ms-archive-status"), nil
+}
+
+// rehydrateBlob moves an archived blob to the tier, with the priority
+// Azure gives the rehydration.
+func (r *restClient) rehydrateBlob(container, blob, tier, priority string) error {
+	header := http.Header{}
+	header.Set("x-ms-access-tier", tier)
+	header.Set("x-ms-rehydrate-priority", priority)
+	resp, err := r.do(http.MethodPut, blobPath(container, blob), url.Values{"comp": {"tier"}}, header, nil, http.StatusOK, http.StatusAccepted)
+	if err != nil {
+		return errors.Wrap(err, "rehydrating blob")
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// blobRetention gets the immutability policy and legal hold of a blob from
+// its properties.
+func (r *restClient) blobRetention(container, blob string) (stow.Retention, error) {
+	var retention stow.Retention
+	resp, err := r.do(http.MethodHead, blobPath(container, blob), nil, nil, nil, http.StatusOK)
+	if err != nil {
+		return retention, errors.Wrap(err, "getting blob properties")
+	}
+	resp.Body.Close()
+	if until := resp.Header.Get("x-ms-immutability-policy-until-date"); until != "" {
+		retention.RetainUntil, err = time.Parse(http.TimeFormat, until)
+		if err != nil {
+			return retention, errors.Wrap(err, "parsing immutability policy expiry")
+		}
+		retention.Mode = stow.RetentionGovernance
+		if strings.EqualFold(resp.Header.Get("x-ms-immutability-policy-mode"), "locked") {
+			retention.Mode = stow.RetentionCompliance
+		}
+	}
+	retention.LegalHold = resp.Header.Get("x-ms-legal-hold") == "true"
+	return retention, nil
+}
+
+// setBlobImmutabilityPolicy sets the immutability policy of a blob. A
+// locked policy can only be extended.
+func (r *restClient) setBlobImmutabilityPolicy(container, blob string, until time.Time, locked bool) error {
+	mode := "Unlocked"
+	if locked {
+		mode = "Locked"
+	}
+	header := http.Header{}
+	header.Set("x-ms-immutability-policy-until-date", until.UTC().Format(http.TimeFormat))
+	header.Set("x-ms-immutability-policy-mode", mode)
+	resp, err := r.do(http.MethodPut, blobPath(container, blob), url.Values{"comp": {"immutabilityPolicies"}}, header, nil, http.StatusOK)
+	if err != nil {
+		return errors.Wrap(err, "setting blob immutability policy")
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// deleteBlobImmutabilityPolicy removes the unlocked immutability policy of
+// a blob.
+func (r *restClient) deleteBlobImmutabilityPolicy(container, blob string) error {
+	resp, err := r.do(http.MethodDelete, blobPath(container, blob), url.Values{"comp": {"immutabilityPolicies"}}, nil, nil, http.StatusOK)
+	if err != nil {
+		return errors.Wrap(err, "deleting blob immutability policy")
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// setBlobLegalHold places or releases the legal hold of a blob.
+func (r *restClient) setBlobLegalHold(container, blob string, hold bool) error {
+	header := http.Header{}
+	header.Set("x-ms-legal-hold", strconv.FormatBool(hold))
+	resp, err := r.do(http.MethodPut, blobPath(container, blob), url.Values{"comp": {"legalhold"}}, header, nil, http.StatusOK)
+	if err != nil {
+		return errors.Wrap(err, "setting blob legal hold")
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// containerProperties gets the properties and metadata of a container.
+func (r *restClient) containerProperties(container string) (http.Header, error) {
+	u := url.URL{Path: "/" + container}
+	resp, err := r.do(http.MethodHead, u.EscapedPath(), url.Values{"restype": {"container"}}, nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting container properties")
+	}
+	resp.Body.Close()
+	return resp.Header, nil
+}
+
+// blobTags is the XML representation of the index tags of a blob.
+type blobTags struct {
+	XMLName xml.Name  `xml:"Tags"`
+	TagSet  []blobTag `xml:"TagSet>Tag"`
+}
+
+type blobTag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+func newBlobTags(tags map[string]string) blobTags {
+	var t blobTags
+	for key, value := range tags {
+		t.TagSet = append(t.TagSet, blobTag{Key: key, Value: value})
+	}
+	sort.Slice(t.TagSet, func(i, j int) bool { return t.TagSet[i].Key < t.TagSet[j].Key })
+	return t
+}
+
+func (t blobTags) values() map[string]interface{} {
+	values := make(map[string]interface{}, len(t.TagSet))
+	for _, tag := range t.TagSet {
+		values[tag.Key] = tag.Value
+	}
+	return values
+}
+
+// blobTags gets the index tags of a blob.
+func (r *restClient) blobTags(container, blob string) (map[string]interface{}, error) {
+	resp, err := r.do(http.MethodGet, blobPath(container, blob), url.Values{"comp": {"tags"}}, nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting blob tags")
+	}
+	defer resp.Body.Close()
+	var tags blobTags
+	if err := xml.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, errors.Wrap(err, "decoding blob tags")
+	}
+	return tags.values(), nil
+}
+
+// setBlobTags replaces the index tags of a blob. No tags removes them all.
+func (r *restClient) setBlobTags(container, blob string, tags map[string]string) error {
+	body, err := xml.Marshal(newBlobTags(tags))
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/xml")
+	resp, err := r.do(http.MethodPut, blobPath(container, blob), url.Values{"comp": {"tags"}}, header, body, http.StatusNoContent)
+	if err != nil {
+		return errors.Wrap(err, "setting blob tags")
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// tagQuery gets the expression finding blobs having all of the tags.
+func tagQuery(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	conditions := make([]string, len(keys))
+	for i, key := range keys {
+		conditions[i] = `"` + key + `"='` + tags[key] + `'`
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// findBlobsByTags gets the names of a page of blobs in the container
+// having all of the tags, and the marker of the next page.
+func (r *restClient) findBlobsByTags(container string, tags map[string]string, marker string, count int) ([]string, string, error) {
+	query := url.Values{
+		"restype": {"container"},
+		"comp":    {"blobs"},
+		"where":   {tagQuery(tags)},
+	}
+	if marker != "" {
+		query.Set("marker", marker)
+	}
+	if count > 0 {
+		query.Set("maxresults", strconv.Itoa(count))
+	}
+	u := url.URL{Path: "/" + container}
+	resp, err := r.do(http.MethodGet, u.EscapedPath(), query, nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "finding blobs by tags")
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Blobs []struct {
+			Name string `xml:"Name"`
+		} `xml:"Blobs>Blob"`
+		NextMarker string `xml:"NextMarker"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, "", errors.Wrap(err, "decoding blobs found by tags")
+	}
+	names := make([]string, len(result.Blobs))
+	for i, blob := range result.Blobs {
+		names[i] = blob.Name
+	}
+	return names, result.NextMarker, nil
+}
@@ -0,0 +1,198 @@
+package azure
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	az "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+// newTestRESTClient gets a restClient that sends its requests to the
+// handler.
+func newTestRESTClient(t *testing.T, handler http.HandlerFunc) (*restClient, func()) {
+	server := httptest.NewServer(handler)
+	r, err := newRESTClient("account", "a2V5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.baseURL = server.URL
+	r.client = server.Client()
+	return r, server.Close
+}
+
+func TestSetBlobTier(t *testing.T) {
+	is := is.New(t)
+
+	var req *http.Request
+	r, done := newTestRESTClient(t, func(w http.ResponseWriter, rq *http.Request) {
+		req = rq
+	})
+	defer done()
+
+	is.NoErr(r.setBlobTier("container", "dir/the item", "Cool"))
+	is.Equal(req.Method, http.MethodPut)
+	is.Equal(req.URL.EscapedPath(), "/container/dir/the%20item")
+	is.Equal(req.URL.Query().Get("comp"), "tier")
+	is.Equal(req.Header.Get("x-ms-access-tier"), "Cool")
+	is.Equal(req.Header.Get("x-ms-version"), restAPIVersion)
+	is.True(strings.HasPrefix(req.Header.Get("Authorization"), "SharedKey account:"))
+}
+
+func TestRESTErrors(t *testing.T) {
+	is := is.New(t)
+
+	status := http.StatusNotFound
+	r, done := newTestRESTClient(t, func(w http.ResponseWriter, rq *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?><Error><Code>BlobArchived</Code><Message>This operation is not permitted on an archived blob.</Message></Error>`))
+	})
+	defer done()
+
+	_, err := r.do(http.MethodGet, "/container/blob", nil, nil, nil, http.StatusOK)
+	is.Equal(err, stow.ErrNotFound)
+
+	status = http.StatusConflict
+	_, err = r.do(http.MethodGet, "/container/blob", nil, nil, nil, http.StatusOK)
+	rerr, ok := err.(*restError)
+	is.True(ok)
+	is.Equal(rerr.StatusCode, http.StatusConflict)
+	is.Equal(rerr.Code, "BlobArchived")
+}
+
+func TestSignature(t *testing.T) {
+	is := is.New(t)
+	r, err := newRESTClient("account", "a2V5")
+	is.NoErr(err)
+
+	req, err := http.NewRequest(http.MethodPut, "https://account.blob.core.windows.net/container/blob?comp=tier", nil)
+	is.NoErr(err)
+	req.Header.Set("x-ms-date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	req.Header.Set("x-ms-version", restAPIVersion)
+	req.Header.Set("x-ms-access-tier", "Cool")
+	sig := r.signature(req)
+
+	// the signature covers the x-ms- headers and the query
+	req.Header.Set("x-ms-access-tier", "Hot")
+	is.NotEqual(r.signature(req), sig)
+	req.Header.Set("x-ms-access-tier", "Cool")
+	is.Equal(r.signature(req), sig)
+	req.URL.RawQuery = "comp=metadata"
+	is.NotEqual(r.signature(req), sig)
+}
+
+// captureSender records the requests sent by the storage SDK.
+type captureSender struct {
+	req *http.Request
+}
+
+func (s *captureSender) Send(c *az.Client, req *http.Request) (*http.Response, error) {
+	s.req = req
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+func TestSignatureMatchesSDK(t *testing.T) {
+	is := is.New(t)
+
+	client, err := az.NewBasicClient("account", "a2V5")
+	is.NoErr(err)
+	sender := &captureSender{}
+	client.Sender = sender
+	blobs := client.GetBlobService()
+	blob := blobs.GetContainerReference("container").GetBlobReference("dir/the item")
+	blob.Metadata = map[string]string{"key": "value"}
+	is.NoErr(blob.SetMetadata(nil))
+
+	r, err := newRESTClient("account", "a2V5")
+	is.NoErr(err)
+	is.Equal("SharedKey account:"+r.signature(sender.req), sender.req.Header.Get("Authorization"))
+}
+
+func TestBlobRetention(t *testing.T) {
+	is := is.New(t)
+
+	var reqs []*http.Request
+	r, done := newTestRESTClient(t, func(w http.ResponseWriter, rq *http.Request) {
+		reqs = append(reqs, rq)
+		if rq.Method == http.MethodHead {
+			w.Header().Set("x-ms-immutability-policy-until-date", "Wed, 02 Jan 2030 03:04:05 GMT")
+			w.Header().Set("x-ms-immutability-policy-mode", "locked")
+			w.Header().Set("x-ms-legal-hold", "true")
+		}
+	})
+	defer done()
+
+	retention, err := r.blobRetention("container", "blob")
+	is.NoErr(err)
+	is.Equal(retention.Mode, stow.RetentionCompliance)
+	is.True(retention.RetainUntil.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)))
+	is.True(retention.LegalHold)
+
+	until := time.Date(2030, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	is.NoErr(r.setBlobImmutabilityPolicy("container", "blob", until, false))
+	req := reqs[len(reqs)-1]
+	is.Equal(req.Method, http.MethodPut)
+	is.Equal(req.URL.Query().Get("comp"), "immutabilityPolicies")
+	is.Equal(req.Header.Get("x-ms-immutability-policy-until-date"), "Wed, 02 Jan 2030 02:04:05 GMT")
+	is.Equal(req.Header.Get("x-ms-immutability-policy-mode"), "Unlocked")
+
+	is.NoErr(r.deleteBlobImmutabilityPolicy("container", "blob"))
+	req = reqs[len(reqs)-1]
+	is.Equal(req.Method, http.MethodDelete)
+	is.Equal(req.URL.Query().Get("comp"), "immutabilityPolicies")
+
+	is.NoErr(r.setBlobLegalHold("container", "blob", false))
+	req = reqs[len(reqs)-1]
+	is.Equal(req.URL.Query().Get("comp"), "legalhold")
+	is.Equal(req.Header.Get("x-ms-legal-hold"), "false")
+}
+
+func TestBlobTags(t *testing.T) {
+	is := is.New(t)
+
+	var req *http.Request
+	var body string
+	r, done := newTestRESTClient(t, func(w http.ResponseWriter, rq *http.Request) {
+		req = rq
+		b, _ := ioutil.ReadAll(rq.Body)
+		body = string(b)
+		switch {
+		case rq.Method == http.MethodPut:
+			w.WriteHeader(http.StatusNoContent)
+		case rq.URL.Query().Get("where") != "":
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?><EnumerationResults><Blobs><Blob><Name>a</Name></Blob><Blob><Name>dir/b</Name></Blob></Blobs><NextMarker>next</NextMarker></EnumerationResults>`))
+		default:
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?><Tags><TagSet><Tag><Key>team</Key><Value>media</Value></Tag></TagSet></Tags>`))
+		}
+	})
+	defer done()
+
+	tags, err := r.blobTags("container", "blob")
+	is.NoErr(err)
+	is.Equal(tags, map[string]interface{}{"team": "media"})
+	is.Equal(req.URL.Query().Get("comp"), "tags")
+
+	is.NoErr(r.setBlobTags("container", "blob", map[string]string{"team": "sound", "stage": "raw"}))
+	is.Equal(req.Method, http.MethodPut)
+	is.Equal(body, `<Tags><TagSet><Tag><Key>stage</Key><Value>raw</Value></Tag><Tag><Key>team</Key><Value>sound</Value></Tag></TagSet></Tags>`)
+
+	is.NoErr(r.setBlobTags("container", "blob", nil))
+	is.Equal(body, `<Tags><TagSet></TagSet></Tags>`)
+
+	names, marker, err := r.findBlobsByTags("container", map[string]string{"team": "sound", "stage": "raw"}, "", 10)
+	is.NoErr(err)
+	is.Equal(names, []string{"a", "dir/b"})
+	is.Equal(marker, "next")
+	is.Equal(req.URL.Path, "/container")
+	is.Equal(req.URL.Query().Get("where"), `"stage"='raw' AND "team"='sound'`)
+	is.Equal(req.URL.Query().Get("maxresults"), "10")
+}
@@ -0,0 +1,63 @@
+package azure
+
+import (
+	"strings"
+
+	az "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var _ stow.ItemRestorer = (*item)(nil)
+
+// rehydratePriorities are the priorities Azure rehydrates blobs with for
+// the restore tiers. There is nothing cheaper than standard priority.
+var rehydratePriorities = map[stow.RestoreTier]string{
+	stow.RestoreExpedited: "High",
+	stow.RestoreStandard:  "Standard",
+	stow.RestoreBulk:      "Standard",
+}
+
+// RestoreStatus gets the access tier of the blob, and whether it is being
+// rehydrated out of the archive tier.
+func (i *item) RestoreStatus() (stow.RestoreStatus, error) {
+	tier, archiveStatus, err := i.container.rest.blobTier(i.container.id, i.id)
+	if err != nil {
+		return stow.RestoreStatus{}, err
+	}
+	return stow.RestoreStatus{
+		StorageClass: stow.StorageClassFromNative(tier, accessTiers),
+		Archived:     tier == accessTiers[stow.StorageClassArchive],
+		Restoring:    strings.HasPrefix(archiveStatus, "rehydrate-pending-"),
+	}, nil
+}
+
+// Restore rehydrates the archived blob to the hot tier, where it stays, so
+// days are ignored. Rehydrating takes hours even with high priority.
+func (i *item) Restore(tier stow.RestoreTier, days int) error {
+	if tier == "" {
+		tier = stow.RestoreStandard
+	}
+	priority, ok := rehydratePriorities[tier]
+	if !ok {
+		return stow.NotSupported("restore tier " + string(tier))
+	}
+	err := i.container.rest.rehydrateBlob(i.container.id, i.id, accessTiers[stow.StorageClassHot], priority)
+	if rerr, ok := errors.Cause(err).(*restError); ok && rerr.Code == "BlobBeingRehydrated" {
+		return nil
+	}
+	return err
+}
+
+// openError gets the error of opening the blob, which is a
+// *stow.ArchivedError when the blob has to be rehydrated first.
+func (i *item) openError(err error) error {
+	if serr, ok := err.(az.AzureStorageServiceError); !ok || serr.Code != "BlobArchived" {
+		return err
+	}
+	status, serr := i.RestoreStatus()
+	if serr != nil {
+		status = stow.RestoreStatus{StorageClass: stow.StorageClassArchive, Archived: true}
+	}
+	return &stow.ArchivedError{Name: i.Name(), Status: status}
+}
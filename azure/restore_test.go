@@ -0,0 +1,50 @@
+package azure
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestRestore(t *testing.T) {
+	is := is.New(t)
+
+	archiveStatus := ""
+	var rehydrate *http.Request
+	r, done := newTestRESTClient(t, func(w http.ResponseWriter, rq *http.Request) {
+		switch rq.Method {
+		case http.MethodHead:
+			w.Header().Set("x-ms-access-tier", "Archive")
+			w.Header().Set("x-ms-archive-status", archiveStatus)
+		case http.MethodPut:
+			if archiveStatus != "" {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?><Error><Code>BlobBeingRehydrated</Code><Message>This operation is not permitted because the blob is being rehydrated.</Message></Error>`))
+				return
+			}
+			rehydrate = rq
+			archiveStatus = "rehydrate-pending-to-hot"
+			w.WriteHeader(http.StatusAccepted)
+		}
+	})
+	defer done()
+	i := &item{id: "blob", container: &container{id: "container", rest: r}}
+
+	status, err := i.RestoreStatus()
+	is.NoErr(err)
+	is.Equal(status, stow.RestoreStatus{StorageClass: stow.StorageClassArchive, Archived: true})
+
+	is.NoErr(i.Restore(stow.RestoreExpedited, 1))
+	is.Equal(rehydrate.URL.Query().Get("comp"), "tier")
+	is.Equal(rehydrate.Header.Get("x-ms-access-tier"), "Hot")
+	is.Equal(rehydrate.Header.Get("x-ms-rehydrate-priority"), "High")
+	// a blob being rehydrated can be restored again
+	is.NoErr(i.Restore(stow.RestoreExpedited, 1))
+
+	status, err = i.RestoreStatus()
+	is.NoErr(err)
+	is.True(status.Restoring)
+	is.False(status.Readable())
+}
@@ -0,0 +1,130 @@
+package azure
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	az "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var _ stow.ResumableUploader = (*container)(nil)
+
+// PutResumable puts the blob in blocks, which can be resumed from its
+// checkpoint when it fails. The blocks of a failed upload are kept
+// uncommitted by the service for a week.
+func (c *container) PutResumable(name string, r io.ReaderAt, size int64, metadata map[string]interface{}, options *stow.ResumableOptions) (stow.Item, error) {
+	if options == nil {
+		options = &stow.ResumableOptions{}
+	}
+	mdParsed, err := prepMetadata(metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create or update Item, preparing metadata")
+	}
+	content, _, err := stow.PrepareContentProperties(name, io.NewSectionReader(r, 0, size), &stow.PutOptions{
+		ContentProperties: options.ContentProperties,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create or update Item, detecting content type")
+	}
+	chunkSize, err := partSize(size, options.PartSize)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.Replace(name, " ", "+", -1)
+	u := &blockUploader{
+		container: c,
+		name:      name,
+		metadata:  mdParsed,
+		content:   content,
+	}
+	return stow.ResumeUpload(u, options.Store, stow.CheckpointKey(c, name), r, size, chunkSize)
+}
+
+// blockUploader uploads a block blob in blocks. The ID of an upload is a
+// random prefix of the IDs of its blocks, which tells its blocks apart
+// from those of other uploads to the same blob.
+type blockUploader struct {
+	container *container
+	name      string
+	metadata  map[string]string
+	content   stow.ContentProperties
+}
+
+var _ stow.MultipartUploader = (*blockUploader)(nil)
+
+// uploadBlockID gets the ID of block n of the upload. The IDs of all
+// blocks of a blob must have the same length.
+func uploadBlockID(uploadID string, n int) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s-%06d", uploadID, n)))
+}
+
+func (u *blockUploader) blob() *az.Blob {
+	return u.container.client.GetContainerReference(u.container.id).GetBlobReference(u.name)
+}
+
+func (u *blockUploader) Start() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (u *blockUploader) Parts(uploadID string, partSize int64) (map[int]string, error) {
+	list, err := u.blob().GetBlockList(az.BlockListTypeUncommitted, nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			return nil, stow.ErrNotFound
+		}
+		return nil, errors.Wrap(err, "getting block list")
+	}
+	parts := map[int]string{}
+	for _, block := range list.UncommittedBlocks {
+		id, err := base64.StdEncoding.DecodeString(block.Name)
+		if err != nil || !strings.HasPrefix(string(id), uploadID+"-") {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(string(id), uploadID+"-"))
+		if err != nil {
+			continue
+		}
+		parts[n] = block.Name
+	}
+	return parts, nil
+}
+
+func (u *blockUploader) UploadPart(uploadID string, number int, offset int64, data []byte) (string, error) {
+	blockID := uploadBlockID(uploadID, number)
+	if err := u.blob().PutBlock(blockID, data, nil); err != nil {
+		return "", errors.Wrapf(err, "putting block %d", number)
+	}
+	return blockID, nil
+}
+
+func (u *blockUploader) Complete(uploadID string, parts []stow.CompletedPart) (stow.Item, error) {
+	blocks := make([]az.Block, len(parts))
+	for i, part := range parts {
+		blocks[i] = az.Block{ID: part.ETag, Status: az.BlockStatusUncommitted}
+	}
+	blob := u.blob()
+	setContentProperties(&blob.Properties, u.content)
+	blob.Metadata = u.metadata
+	if err := blob.PutBlockList(blocks, nil); err != nil {
+		return nil, errors.Wrap(err, "putting block list")
+	}
+	return u.container.Item(u.name)
+}
+
+// Abort does nothing, as uncommitted blocks cannot be deleted. They are
+// discarded by the service after a week, or when a blob is committed.
+func (u *blockUploader) Abort(uploadID string) error {
+	return nil
+}
@@ -0,0 +1,112 @@
+package azure
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	az "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+// blockSender serves the block requests of the storage SDK from memory.
+type blockSender struct {
+	mu        sync.Mutex
+	blocks    map[string]string
+	uploaded  []string
+	failBlock string
+	blockList *http.Request
+	committed string
+}
+
+func (s *blockSender) Send(c *az.Client, req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q := req.URL.Query()
+	status := http.StatusOK
+	header := http.Header{}
+	var body string
+	switch {
+	case req.Method == http.MethodPut && q.Get("comp") == "block":
+		b, _ := ioutil.ReadAll(req.Body)
+		id, _ := base64.StdEncoding.DecodeString(q.Get("blockid"))
+		if strings.HasSuffix(string(id), s.failBlock) {
+			status = http.StatusInternalServerError
+			break
+		}
+		s.uploaded = append(s.uploaded, string(id[len(id)-6:]))
+		s.blocks[q.Get("blockid")] = string(b)
+		status = http.StatusCreated
+	case req.Method == http.MethodGet && q.Get("comp") == "blocklist":
+		var names []string
+		for name := range s.blocks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		body = "<BlockList><UncommittedBlocks>"
+		for _, name := range names {
+			body += fmt.Sprintf("<Block><Name>%s</Name><Size>%d</Size></Block>", name, len(s.blocks[name]))
+		}
+		body += "</UncommittedBlocks></BlockList>"
+	case req.Method == http.MethodPut && q.Get("comp") == "blocklist":
+		s.blockList = req
+		b, _ := ioutil.ReadAll(req.Body)
+		s.committed = string(b)
+		status = http.StatusCreated
+	case req.Method == http.MethodHead:
+		header.Set("Etag", `"etag"`)
+		header.Set("Content-Length", "10")
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestPutResumable(t *testing.T) {
+	is := is.New(t)
+
+	client, err := az.NewBasicClient("account", "a2V5")
+	is.NoErr(err)
+	sender := &blockSender{blocks: map[string]string{}, failBlock: "-000003"}
+	client.Sender = sender
+	blobs := client.GetBlobService()
+	c := &container{id: "container", client: &blobs}
+
+	store := stow.NewMemoryCheckpointStore()
+	options := &stow.ResumableOptions{Store: store, PartSize: 4}
+	metadata := map[string]interface{}{"key": "value"}
+	_, err = c.PutResumable("dir/item.txt", strings.NewReader("abcdefghij"), 10, metadata, options)
+	is.Err(err)
+	is.Equal(sender.uploaded, []string{"000001", "000002"})
+	checkpoint, err := store.Load("container/dir/item.txt")
+	is.NoErr(err)
+	is.Equal(len(checkpoint.Parts), 2)
+
+	// blocks of other uploads to the blob are left alone
+	other := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef-000001"))
+	sender.blocks[other] = "other"
+	sender.failBlock = "none"
+	sender.uploaded = nil
+	item, err := c.PutResumable("dir/item.txt", strings.NewReader("abcdefghij"), 10, metadata, options)
+	is.NoErr(err)
+	is.Equal(item.ID(), "dir/item.txt")
+	is.Equal(sender.uploaded, []string{"000003"})
+	// the SDK does not canonicalize header names
+	is.Equal(sender.blockList.Header["x-ms-blob-content-type"], []string{"text/plain; charset=utf-8"})
+	is.Equal(sender.blockList.Header["x-ms-meta-key"], []string{"value"})
+	for n := 1; n <= 3; n++ {
+		is.True(strings.Contains(sender.committed, uploadBlockID(checkpoint.UploadID, n)))
+	}
+	is.False(strings.Contains(sender.committed, other))
+	_, err = store.Load("container/dir/item.txt")
+	is.Equal(err, stow.ErrNotFound)
+}
@@ -0,0 +1,37 @@
+package azure
+
+import (
+	"time"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var _ stow.ItemRetainer = (*item)(nil)
+
+// Retention gets the version-level immutability policy and legal hold of
+// the blob. A locked policy is in compliance mode.
+func (i *item) Retention() (stow.Retention, error) {
+	return i.container.rest.blobRetention(i.container.id, i.id)
+}
+
+// SetRetention sets the immutability policy of the blob, which requires
+// version-level immutability support on the container. Compliance mode
+// locks the policy.
+func (i *item) SetRetention(mode stow.RetentionMode, retainUntil time.Time) error {
+	if retainUntil.IsZero() {
+		return i.container.rest.deleteBlobImmutabilityPolicy(i.container.id, i.id)
+	}
+	switch mode {
+	case stow.RetentionGovernance:
+		return i.container.rest.setBlobImmutabilityPolicy(i.container.id, i.id, retainUntil, false)
+	case stow.RetentionCompliance:
+		return i.container.rest.setBlobImmutabilityPolicy(i.container.id, i.id, retainUntil, true)
+	}
+	return errors.Errorf("unknown retention mode %q", mode)
+}
+
+// SetLegalHold places or releases the legal hold of the blob.
+func (i *item) SetLegalHold(hold bool) error {
+	return i.container.rest.setBlobLegalHold(i.container.id, i.id, hold)
+}
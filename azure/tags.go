@@ -0,0 +1,51 @@
+package azure
+
+import "github.com/graymeta/stow"
+
+var (
+	_ stow.Taggable  = (*item)(nil)
+	_ stow.TagSetter = (*item)(nil)
+	_ stow.TagLister = (*container)(nil)
+)
+
+// Tags gets the index tags of the blob.
+func (i *item) Tags() (map[string]interface{}, error) {
+	return i.container.rest.blobTags(i.container.id, i.id)
+}
+
+// SetTags replaces the index tags of the blob.
+func (i *item) SetTags(tags map[string]interface{}) error {
+	values, err := stow.TagValues(tags)
+	if err != nil {
+		return err
+	}
+	return i.container.rest.setBlobTags(i.container.id, i.id, values)
+}
+
+// DeleteTags removes the index tags of the blob.
+func (i *item) DeleteTags() error {
+	return i.container.rest.setBlobTags(i.container.id, i.id, nil)
+}
+
+// ItemsByTags finds the blobs in the container having all of the tags with
+// the blob index. The index is updated in the background, so blobs with
+// recently changed tags may be missing.
+func (c *container) ItemsByTags(tags map[string]string, cursor string, count int) ([]stow.Item, string, error) {
+	names, marker, err := c.rest.findBlobsByTags(c.id, tags, cursor, count)
+	if err != nil {
+		return nil, "", err
+	}
+	items := make([]stow.Item, 0, len(names))
+	for _, name := range names {
+		item, err := c.Item(name)
+		if err == stow.ErrNotFound {
+			// removed since it was found
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		items = append(items, item)
+	}
+	return items, marker, nil
+}
@@ -36,7 +36,8 @@ func init() {
 			return nil, err
 		}
 		l := &location{
-			config: config,
+			config:     config,
+			largeFiles: newLargeFileClient(config),
 		}
 		var err error
 		l.client, err = newB2Client(l.config)
@@ -11,7 +11,8 @@ import (
 )
 
 type container struct {
-	bucket *backblaze.Bucket
+	bucket     *backblaze.Bucket
+	largeFiles *largeFileClient
 }
 
 var _ stow.Container = (*container)(nil)
@@ -29,6 +30,21 @@ func (c *container) Name() string {
 	return c.bucket.Name
 }
 
+// Stat describes the bucket, as of when the container was got. B2 keeps
+// every version of files, and has neither creation times nor regions for
+// buckets. The bucket info is returned as metadata.
+func (c *container) Stat() (stow.ContainerInfo, error) {
+	info := stow.ContainerInfo{
+		Versioning:   stow.VersioningEnabled,
+		PublicAccess: c.bucket.BucketType == backblaze.AllPublic,
+		Metadata:     make(map[string]interface{}, len(c.bucket.Info)),
+	}
+	for k, v := range c.bucket.Info {
+		info.Metadata[k] = v
+	}
+	return info, nil
+}
+
 // Item returns a stow.Item given the item's ID
 func (c *container) Item(id string) (stow.Item, error) {
 	return c.getItem(id)
@@ -85,13 +101,50 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 
 // Put uploads a file
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutWithOptions(name, r, size, metadata, nil)
+}
+
+// PutWithOptions uploads a file like Put, also setting the content headers
+// given in the options. B2 keeps all but the content type in the file info,
+// which it sends back as headers on download.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, metadata map[string]interface{}, options *stow.PutOptions) (stow.Item, error) {
 	// Convert map[string]interface{} to map[string]string
 	mdPrepped, err := prepMetadata(metadata)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create or update item, preparing metadata")
 	}
 
-	file, err := c.bucket.UploadFile(name, mdPrepped, r)
+	if options != nil {
+		// B2 has a single storage class, sets access on the bucket, has
+		// no tags, and the client has no way to ask for server-side
+		// encryption.
+		switch {
+		case options.StorageClass != "":
+			return nil, stow.NotSupported("storage class")
+		case options.ACL != "":
+			return nil, stow.NotSupported("ACL")
+		case options.Encryption != nil:
+			return nil, stow.NotSupported("encryption")
+		case len(options.Tags) > 0:
+			return nil, stow.NotSupported("tags")
+		}
+	}
+
+	content, r, err := stow.PrepareContentProperties(name, r, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create or update item, detecting content type")
+	}
+	addContentInfo(mdPrepped, content)
+
+	var transfer stow.TransferOptions
+	if options != nil {
+		transfer = options.Transfer
+	}
+	if partSize := largeFilePartSize(size, transfer.PartSize); size > partSize {
+		return c.putLargeFile(name, r, size, content.ContentType, mdPrepped, partSize, transfer.Concurrency)
+	}
+
+	file, err := c.bucket.UploadTypedFile(name, content.ContentType, mdPrepped, r)
 	if err != nil {
 		return nil, err
 	}
@@ -104,6 +157,66 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 	}, nil
 }
 
+// putLargeFile uploads the parts of a large file concurrently. The file is
+// cancelled when a part fails, as there is no checkpoint to resume it from.
+func (c *container) putLargeFile(name string, r io.Reader, size int64, contentType string, info map[string]string, partSize int64, concurrency int) (stow.Item, error) {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	fileID, err := c.largeFiles.startLargeFile(c.bucket.ID, name, contentType, info)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting large file")
+	}
+	sha1s := make([]string, partCount(size, partSize))
+	n, err := stow.UploadParts(r, partSize, concurrency, func(number int, data []byte) error {
+		if number > len(sha1s) {
+			return errors.Errorf("more than %d bytes to upload", size)
+		}
+		sha1, err := c.largeFiles.uploadPart(fileID, number, data)
+		if err != nil {
+			return errors.Wrapf(err, "uploading part %d", number)
+		}
+		sha1s[number-1] = sha1
+		return nil
+	})
+	if err == nil && n < len(sha1s) {
+		err = errors.Errorf("fewer than %d bytes to upload", size)
+	}
+	if err == nil {
+		size, err = c.largeFiles.finishLargeFile(fileID, sha1s)
+		err = errors.Wrap(err, "finishing large file")
+	}
+	if err != nil {
+		c.largeFiles.cancelLargeFile(fileID)
+		return nil, err
+	}
+	return &item{
+		id:     fileID,
+		name:   name,
+		size:   size,
+		bucket: c.bucket,
+	}, nil
+}
+
+// partCount gets the number of parts of partSize in size bytes.
+func partCount(size, partSize int64) int {
+	return int((size + partSize - 1) / partSize)
+}
+
+// addContentInfo adds the content headers B2 keeps in the file info.
+func addContentInfo(info map[string]string, content stow.ContentProperties) {
+	for key, value := range map[string]string{
+		infoContentEncoding:    content.ContentEncoding,
+		infoContentLanguage:    content.ContentLanguage,
+		infoCacheControl:       content.CacheControl,
+		infoContentDisposition: content.ContentDisposition,
+	} {
+		if value != "" {
+			info[key] = value
+		}
+	}
+}
+
 // RemoveItem identifies the file by it's ID, then removes all versions of that file
 func (c *container) RemoveItem(id string) error {
 	item, err := c.getItem(id)
@@ -169,11 +282,34 @@ func prepMetadata(md map[string]interface{}) (map[string]string, error) {
 	return m, nil
 }
 
-// parseMetadata transforms a map[string]string to a map[string]interface{}
+// File info keys B2 uses for the content headers other than the type.
+const (
+	infoContentEncoding    = "b2-content-encoding"
+	infoContentLanguage    = "b2-content-language"
+	infoCacheControl       = "b2-cache-control"
+	infoContentDisposition = "b2-content-disposition"
+)
+
+// parseMetadata transforms a map[string]string to a map[string]interface{},
+// leaving out the content headers B2 keeps in the file info.
 func parseMetadata(md map[string]string) map[string]interface{} {
 	m := make(map[string]interface{}, len(md))
 	for key, value := range md {
+		if strings.HasPrefix(key, "b2-") {
+			continue
+		}
 		m[key] = value
 	}
 	return m
 }
+
+// parseContentProperties gets the content properties of a file.
+func parseContentProperties(f *backblaze.File) stow.ContentProperties {
+	return stow.ContentProperties{
+		ContentType:        f.ContentType,
+		ContentEncoding:    f.FileInfo[infoContentEncoding],
+		ContentLanguage:    f.FileInfo[infoContentLanguage],
+		CacheControl:       f.FileInfo[infoCacheControl],
+		ContentDisposition: f.FileInfo[infoContentDisposition],
+	}
+}
@@ -0,0 +1,61 @@
+package b2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	isi "github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+	backblaze "gopkg.in/kothar/go-backblaze.v0"
+)
+
+// roundTripFunc is an http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestItemsCursor(t *testing.T) {
+	is := isi.New(t)
+
+	s := &largeFileServer{files: []string{"a/1", "a/2", "a/2 b", "a/3", "b/1"}}
+	server := httptest.NewServer(s)
+	defer server.Close()
+	s.url = server.URL
+	// the client always authorizes with the B2 host, so its requests are
+	// sent to the server instead
+	transport := http.DefaultTransport
+	http.DefaultTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.URL.Scheme, r.URL.Host = "http", strings.TrimPrefix(server.URL, "http://")
+		return transport.RoundTrip(r)
+	})
+	defer func() { http.DefaultTransport = transport }()
+
+	client, err := backblaze.NewB2(backblaze.Credentials{KeyID: "key-id", ApplicationKey: "key"})
+	is.NoErr(err)
+	bucket, err := client.Bucket("bucket")
+	is.NoErr(err)
+	c := &container{bucket: bucket}
+
+	// a page filled before the end of the listing resumes after its last
+	// name, which B2 takes with a space appended
+	items, cursor, err := c.Items("a/", stow.CursorStart, 2)
+	is.NoErr(err)
+	is.Equal(len(items), 2)
+	is.Equal(cursor, "a/2 ")
+	items, _, err = c.Items("a/", cursor, 2)
+	is.NoErr(err)
+	is.Equal(items[0].Name(), "a/2 b")
+
+	var names []string
+	it := stow.NewItemIterator(c, "a/", stow.CursorStart, 2)
+	for it.Next() {
+		names = append(names, it.Item().Name())
+	}
+	is.NoErr(it.Err())
+	is.Equal(names, []string{"a/1", "a/2", "a/2 b", "a/3"})
+}
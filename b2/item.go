@@ -20,13 +20,15 @@ type item struct {
 	bucket       *backblaze.Bucket
 
 	metadata map[string]interface{}
+	content  stow.ContentProperties
 	infoOnce sync.Once
 	infoErr  error
 }
 
 var (
-	_ stow.Item       = (*item)(nil)
-	_ stow.ItemRanger = (*item)(nil)
+	_ stow.Item             = (*item)(nil)
+	_ stow.ItemRanger       = (*item)(nil)
+	_ stow.ContentDescriber = (*item)(nil)
 )
 
 // ID returns this item's ID
@@ -97,6 +99,14 @@ func (i *item) LastMod() (time.Time, error) {
 	return i.lastModified, nil
 }
 
+// ContentProperties returns the content headers of the file
+func (i *item) ContentProperties() (stow.ContentProperties, error) {
+	if err := i.ensureInfo(); err != nil {
+		return stow.ContentProperties{}, errors.Wrap(err, "retrieving content properties")
+	}
+	return i.content, nil
+}
+
 func (i *item) ensureInfo() error {
 	if i.metadata == nil || i.lastModified.IsZero() {
 		i.infoOnce.Do(func() {
@@ -108,6 +118,7 @@ func (i *item) ensureInfo() error {
 
 			i.lastModified = time.Unix(f.UploadTimestamp/1000, 0)
 			i.metadata = parseMetadata(f.FileInfo)
+			i.content = parseContentProperties(f)
 		})
 	}
 	return i.infoErr
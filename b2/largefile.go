@@ -0,0 +1,215 @@
+package b2
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+// authorizeURL is where accounts are authorized with the B2 API.
+const authorizeURL = "https://api.backblazeb2.com/b2api/v2/b2_authorize_account"
+
+// largeFileClient makes the large file requests the B2 client has no
+// support for. The client keeps its authorization to itself, so this one
+// authorizes the account again, when first used and whenever the
+// authorization expires.
+type largeFileClient struct {
+	keyID          string
+	applicationKey string
+	authorizeURL   string
+	client         *http.Client
+
+	mu     sync.Mutex
+	apiURL string
+	token  string
+}
+
+func newLargeFileClient(cfg stow.Config) *largeFileClient {
+	keyID, _ := cfg.Config(ConfigKeyID)
+	if keyID == "" {
+		keyID, _ = cfg.Config(ConfigAccountID)
+	}
+	applicationKey, _ := cfg.Config(ConfigApplicationKey)
+	return &largeFileClient{
+		keyID:          keyID,
+		applicationKey: applicationKey,
+		authorizeURL:   authorizeURL,
+		client:         http.DefaultClient,
+	}
+}
+
+// apiError is the error returned by the B2 API.
+type apiError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("b2: %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// do sends the request and decodes the JSON response into result.
+func (l *largeFileClient) do(req *http.Request, result interface{}) error {
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		aerr := &apiError{Status: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(aerr); err != nil || aerr.Code == "" {
+			aerr.Code = http.StatusText(resp.StatusCode)
+		}
+		return aerr
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// authorization gets the API URL and token, authorizing the account if
+// there are none or the token has expired.
+func (l *largeFileClient) authorization(expired bool) (string, string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.token != "" && !expired {
+		return l.apiURL, l.token, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, l.authorizeURL, nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.SetBasicAuth(l.keyID, l.applicationKey)
+	var auth struct {
+		APIURL             string `json:"apiUrl"`
+		AuthorizationToken string `json:"authorizationToken"`
+	}
+	if err := l.do(req, &auth); err != nil {
+		return "", "", errors.Wrap(err, "authorizing account")
+	}
+	l.apiURL, l.token = auth.APIURL, auth.AuthorizationToken
+	return l.apiURL, l.token, nil
+}
+
+// call calls the API operation, authorizing the account again once if
+// the token has expired.
+func (l *largeFileClient) call(operation string, request, result interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	for expired := false; ; expired = true {
+		apiURL, token, err := l.authorization(expired)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequest(http.MethodPost, apiURL+"/b2api/v2/"+operation, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", token)
+		err = l.do(req, result)
+		if aerr, ok := err.(*apiError); ok && aerr.Code == "expired_auth_token" && !expired {
+			continue
+		}
+		return err
+	}
+}
+
+// startLargeFile starts a large file and returns its ID.
+func (l *largeFileClient) startLargeFile(bucketID, name, contentType string, info map[string]string) (string, error) {
+	if contentType == "" {
+		contentType = "b2/x-auto"
+	}
+	var file struct {
+		FileID string `json:"fileId"`
+	}
+	err := l.call("b2_start_large_file", map[string]interface{}{
+		"bucketId":    bucketID,
+		"fileName":    name,
+		"contentType": contentType,
+		"fileInfo":    info,
+	}, &file)
+	return file.FileID, err
+}
+
+// largeFileParts gets the SHA1 of the parts of a large file by part
+// number. Files that are no longer being uploaded give stow.ErrNotFound.
+func (l *largeFileClient) largeFileParts(fileID string) (map[int]string, error) {
+	parts := map[int]string{}
+	request := map[string]interface{}{"fileId": fileID, "maxPartCount": 1000}
+	for {
+		var page struct {
+			Parts []struct {
+				PartNumber  int    `json:"partNumber"`
+				ContentSha1 string `json:"contentSha1"`
+			} `json:"parts"`
+			NextPartNumber *int `json:"nextPartNumber"`
+		}
+		if err := l.call("b2_list_parts", request, &page); err != nil {
+			// finished and cancelled files give bad requests
+			if aerr, ok := err.(*apiError); ok && (aerr.Status == http.StatusNotFound || aerr.Code == "bad_request") {
+				return nil, stow.ErrNotFound
+			}
+			return nil, err
+		}
+		for _, part := range page.Parts {
+			parts[part.PartNumber] = part.ContentSha1
+		}
+		if page.NextPartNumber == nil {
+			return parts, nil
+		}
+		request["startPartNumber"] = *page.NextPartNumber
+	}
+}
+
+// uploadPart uploads a part of a large file and returns its SHA1.
+func (l *largeFileClient) uploadPart(fileID string, number int, data []byte) (string, error) {
+	var target struct {
+		UploadURL          string `json:"uploadUrl"`
+		AuthorizationToken string `json:"authorizationToken"`
+	}
+	if err := l.call("b2_get_upload_part_url", map[string]string{"fileId": fileID}, &target); err != nil {
+		return "", err
+	}
+	sum := sha1.Sum(data)
+	req, err := http.NewRequest(http.MethodPost, target.UploadURL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", target.AuthorizationToken)
+	req.Header.Set("X-Bz-Part-Number", strconv.Itoa(number))
+	req.Header.Set("X-Bz-Content-Sha1", hex.EncodeToString(sum[:]))
+	var part struct {
+		ContentSha1 string `json:"contentSha1"`
+	}
+	if err := l.do(req, &part); err != nil {
+		return "", err
+	}
+	return part.ContentSha1, nil
+}
+
+// finishLargeFile assembles the parts into the file and gets its size.
+func (l *largeFileClient) finishLargeFile(fileID string, sha1s []string) (int64, error) {
+	var file struct {
+		ContentLength int64 `json:"contentLength"`
+	}
+	err := l.call("b2_finish_large_file", map[string]interface{}{
+		"fileId":        fileID,
+		"partSha1Array": sha1s,
+	}, &file)
+	return file.ContentLength, err
+}
+
+// cancelLargeFile deletes a large file and its parts.
+func (l *largeFileClient) cancelLargeFile(fileID string) error {
+	var file struct{}
+	return l.call("b2_cancel_large_file", map[string]string{"fileId": fileID}, &file)
+}
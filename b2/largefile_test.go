@@ -0,0 +1,187 @@
+package b2
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	isi "github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+	backblaze "gopkg.in/kothar/go-backblaze.v0"
+)
+
+// largeFileServer serves the large file API from memory, along with the
+// listing of the names of files in a bucket.
+type largeFileServer struct {
+	mu             sync.Mutex
+	url            string
+	authorizations int
+	parts          map[int]string
+	uploaded       []int
+	failPart       int
+	finished       []string
+	cancelled      []string
+	// files are the names of the files in the bucket, in order.
+	files []string
+}
+
+func (s *largeFileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reply := func(status int, v interface{}) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+	if r.URL.Path == "/b2api/v2/b2_authorize_account" || r.URL.Path == "/b2api/v1/b2_authorize_account" {
+		if user, key, _ := r.BasicAuth(); user != "key-id" || key != "key" {
+			reply(http.StatusUnauthorized, apiError{Status: 401, Code: "unauthorized"})
+			return
+		}
+		s.authorizations++
+		reply(http.StatusOK, map[string]string{
+			"apiUrl":             s.url,
+			"authorizationToken": fmt.Sprintf("token-%d", s.authorizations),
+		})
+		return
+	}
+	if r.URL.Path == "/upload" {
+		b, _ := ioutil.ReadAll(r.Body)
+		n, _ := strconv.Atoi(r.Header.Get("X-Bz-Part-Number"))
+		sum := sha1.Sum(b)
+		if n == s.failPart || r.Header.Get("X-Bz-Content-Sha1") != hex.EncodeToString(sum[:]) {
+			reply(http.StatusServiceUnavailable, apiError{Status: 503, Code: "service_unavailable"})
+			return
+		}
+		s.uploaded = append(s.uploaded, n)
+		s.parts[n] = hex.EncodeToString(sum[:])
+		reply(http.StatusOK, map[string]string{"contentSha1": s.parts[n]})
+		return
+	}
+	// the first token has expired
+	if r.Header.Get("Authorization") != fmt.Sprintf("token-%d", s.authorizations) || s.authorizations == 1 {
+		reply(http.StatusUnauthorized, apiError{Status: 401, Code: "expired_auth_token"})
+		return
+	}
+	var request map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&request)
+	switch r.URL.Path {
+	case "/b2api/v2/b2_start_large_file":
+		s.parts = map[int]string{}
+		reply(http.StatusOK, map[string]string{"fileId": "file-1"})
+	case "/b2api/v2/b2_get_upload_part_url":
+		reply(http.StatusOK, map[string]string{"uploadUrl": s.url + "/upload", "authorizationToken": "upload-token"})
+	case "/b2api/v2/b2_list_parts":
+		var parts []map[string]interface{}
+		for n, sum := range s.parts {
+			parts = append(parts, map[string]interface{}{"partNumber": n, "contentSha1": sum})
+		}
+		reply(http.StatusOK, map[string]interface{}{"parts": parts})
+	case "/b2api/v2/b2_cancel_large_file":
+		s.cancelled = append(s.cancelled, request["fileId"].(string))
+		reply(http.StatusOK, map[string]string{"fileId": request["fileId"].(string)})
+	case "/b2api/v2/b2_finish_large_file":
+		for _, sum := range request["partSha1Array"].([]interface{}) {
+			s.finished = append(s.finished, sum.(string))
+		}
+		reply(http.StatusOK, map[string]interface{}{"contentLength": 2*minPartSize + 10})
+	case "/b2api/v1/b2_list_buckets":
+		reply(http.StatusOK, map[string]interface{}{"buckets": []map[string]string{
+			{"bucketId": "bucket-id", "bucketName": "bucket", "bucketType": "allPrivate"},
+		}})
+	case "/b2api/v1/b2_list_file_names":
+		// the start name is included, and the next name is that of the
+		// first file left out
+		start, _ := request["startFileName"].(string)
+		count, _ := request["maxFileCount"].(float64)
+		files := []map[string]interface{}{}
+		var next interface{}
+		for _, name := range s.files {
+			if name < start {
+				continue
+			}
+			if len(files) == int(count) {
+				next = name
+				break
+			}
+			files = append(files, map[string]interface{}{"fileId": "id-" + name, "fileName": name, "action": "upload"})
+		}
+		reply(http.StatusOK, map[string]interface{}{"files": files, "nextFileName": next})
+	default:
+		reply(http.StatusBadRequest, apiError{Status: 400, Code: "bad_request"})
+	}
+}
+
+func TestPutResumable(t *testing.T) {
+	is := isi.New(t)
+
+	s := &largeFileServer{failPart: 2}
+	server := httptest.NewServer(s)
+	defer server.Close()
+	s.url = server.URL
+
+	largeFiles := newLargeFileClient(stow.ConfigMap{ConfigKeyID: "key-id", ConfigApplicationKey: "key"})
+	largeFiles.authorizeURL = server.URL + "/b2api/v2/b2_authorize_account"
+	c := &container{
+		bucket:     &backblaze.Bucket{BucketInfo: &backblaze.BucketInfo{ID: "bucket-id", Name: "bucket"}},
+		largeFiles: largeFiles,
+	}
+
+	data := bytes.Repeat([]byte("x"), 2*minPartSize+10)
+	store := stow.NewMemoryCheckpointStore()
+	options := &stow.ResumableOptions{Store: store, PartSize: 1}
+	_, err := c.PutResumable("big.bin", bytes.NewReader(data), int64(len(data)), nil, options)
+	is.Err(err)
+	is.Equal(s.authorizations, 2)
+	is.Equal(s.uploaded, []int{1})
+
+	s.failPart = 0
+	s.uploaded = nil
+	item, err := c.PutResumable("big.bin", bytes.NewReader(data), int64(len(data)), nil, options)
+	is.NoErr(err)
+	is.Equal(s.uploaded, []int{2, 3})
+	is.Equal(s.finished, []string{s.parts[1], s.parts[2], s.parts[3]})
+	is.Equal(item.ID(), "file-1")
+	is.Equal(item.Name(), "big.bin")
+	_, err = store.Load("bucket/big.bin")
+	is.Equal(err, stow.ErrNotFound)
+}
+
+func TestPutLargeFile(t *testing.T) {
+	is := isi.New(t)
+
+	s := &largeFileServer{failPart: 3}
+	server := httptest.NewServer(s)
+	defer server.Close()
+	s.url = server.URL
+
+	largeFiles := newLargeFileClient(stow.ConfigMap{ConfigKeyID: "key-id", ConfigApplicationKey: "key"})
+	largeFiles.authorizeURL = server.URL + "/b2api/v2/b2_authorize_account"
+	c := &container{
+		bucket:     &backblaze.Bucket{BucketInfo: &backblaze.BucketInfo{ID: "bucket-id", Name: "bucket"}},
+		largeFiles: largeFiles,
+	}
+
+	data := bytes.Repeat([]byte("x"), 2*minPartSize+10)
+	options := &stow.PutOptions{Transfer: stow.TransferOptions{PartSize: 1, Concurrency: 2}}
+	_, err := c.PutWithOptions("big.bin", bytes.NewReader(data), int64(len(data)), nil, options)
+	is.Err(err)
+	is.Equal(s.cancelled, []string{"file-1"})
+
+	s.failPart = 0
+	item, err := c.PutWithOptions("big.bin", bytes.NewReader(data), int64(len(data)), nil, options)
+	is.NoErr(err)
+	is.Equal(len(s.parts), 3)
+	is.Equal(s.finished, []string{s.parts[1], s.parts[2], s.parts[3]})
+	is.Equal(item.ID(), "file-1")
+	size, err := item.Size()
+	is.NoErr(err)
+	is.Equal(size, int64(2*minPartSize+10))
+}
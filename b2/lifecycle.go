@@ -0,0 +1,99 @@
+package b2
+
+import (
+	"time"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+	backblaze "gopkg.in/kothar/go-backblaze.v0"
+)
+
+var _ stow.LifecycleManager = (*container)(nil)
+
+// LifecycleRules gets the lifecycle rules of the bucket, as of when the
+// container was got. Hiding files is reported as deleting them, and
+// deleting hidden files as deleting noncurrent versions. B2 rules have
+// no IDs.
+func (c *container) LifecycleRules() ([]stow.LifecycleRule, error) {
+	return fromLifecycleRules(c.bucket.LifecycleRules), nil
+}
+
+// SetLifecycleRules replaces the lifecycle rules of the bucket. Only
+// delete and noncurrent delete rules are supported, at most one of each
+// per prefix, and the rules cannot all be removed.
+func (c *container) SetLifecycleRules(rules []stow.LifecycleRule) error {
+	b2rules, err := toLifecycleRules(rules)
+	if err != nil {
+		return err
+	}
+	if err := c.bucket.UpdateAll("", nil, b2rules, 0); err != nil {
+		return errors.Wrap(err, "updating bucket lifecycle rules")
+	}
+	c.bucket.LifecycleRules = b2rules
+	return nil
+}
+
+func toLifecycleRules(rules []stow.LifecycleRule) ([]backblaze.LifecycleRule, error) {
+	if len(rules) == 0 {
+		return nil, stow.NotSupported("removing all lifecycle rules")
+	}
+	var b2rules []backblaze.LifecycleRule
+	byPrefix := map[string]int{}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+		i, ok := byPrefix[rule.Prefix]
+		if !ok {
+			i = len(b2rules)
+			byPrefix[rule.Prefix] = i
+			b2rules = append(b2rules, backblaze.LifecycleRule{FileNamePrefix: rule.Prefix})
+		}
+		// B2 takes zero days to mean the action is not set
+		days := rule.AgeDays()
+		if days < 1 {
+			days = 1
+		}
+		switch rule.Action {
+		case stow.LifecycleDelete:
+			if b2rules[i].DaysFromUploadingToHiding != 0 {
+				return nil, errors.Errorf("more than one delete rule for prefix %q", rule.Prefix)
+			}
+			b2rules[i].DaysFromUploadingToHiding = days
+		case stow.LifecycleDeleteNoncurrent:
+			if b2rules[i].DaysFromHidingToDeleting != 0 {
+				return nil, errors.Errorf("more than one noncurrent delete rule for prefix %q", rule.Prefix)
+			}
+			b2rules[i].DaysFromHidingToDeleting = days
+		default:
+			return nil, stow.NotSupported("lifecycle action " + string(rule.Action))
+		}
+	}
+	return b2rules, nil
+}
+
+func fromLifecycleRules(b2rules []backblaze.LifecycleRule) []stow.LifecycleRule {
+	var rules []stow.LifecycleRule
+	for _, r := range b2rules {
+		if r.DaysFromUploadingToHiding > 0 {
+			rules = append(rules, stow.LifecycleRule{
+				Prefix: r.FileNamePrefix,
+				Action: stow.LifecycleDelete,
+				Age:    days(r.DaysFromUploadingToHiding),
+			})
+		}
+		if r.DaysFromHidingToDeleting > 0 {
+			rules = append(rules, stow.LifecycleRule{
+				Prefix: r.FileNamePrefix,
+				Action: stow.LifecycleDeleteNoncurrent,
+				Age:    days(r.DaysFromHidingToDeleting),
+			})
+		}
+	}
+	return rules
+}
+
+// days gets the duration of n days.
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
@@ -10,8 +10,9 @@ import (
 )
 
 type location struct {
-	config stow.Config
-	client *backblaze.B2
+	config     stow.Config
+	client     *backblaze.B2
+	largeFiles *largeFileClient
 }
 
 // Close closes the interface. It's a Noop for this B2 implementation
@@ -26,7 +27,8 @@ func (l *location) CreateContainer(name string) (stow.Container, error) {
 		return nil, err
 	}
 	return &container{
-		bucket: bucket,
+		bucket:     bucket,
+		largeFiles: l.largeFiles,
 	}, nil
 }
 
@@ -42,7 +44,8 @@ func (l *location) Containers(prefix string, cursor string, count int) ([]stow.C
 		// api and/or library don't seem to support prefixes, so do it ourself
 		if strings.HasPrefix(cont.Name, prefix) {
 			containers = append(containers, &container{
-				bucket: cont,
+				bucket:     cont,
+				largeFiles: l.largeFiles,
 			})
 		}
 	}
@@ -59,7 +62,8 @@ func (l *location) Container(id string) (stow.Container, error) {
 	}
 
 	return &container{
-		bucket: bucket,
+		bucket:     bucket,
+		largeFiles: l.largeFiles,
 	}, nil
 }
 
@@ -0,0 +1,117 @@
+package b2
+
+import (
+	"io"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+// limits of large files
+const (
+	minPartSize     = 5 * 1024 * 1024
+	defaultPartSize = 100 * 1024 * 1024
+	maxParts        = 10000
+)
+
+// defaultConcurrency is the number of parts of a large file uploaded at once
+// by Put.
+const defaultConcurrency = 4
+
+var _ stow.ResumableUploader = (*container)(nil)
+
+// PutResumable uploads the file as a large file, which can be resumed
+// from its checkpoint when it fails. Large files have at least two parts,
+// so files no larger than a part are uploaded with Put instead.
+func (c *container) PutResumable(name string, r io.ReaderAt, size int64, metadata map[string]interface{}, options *stow.ResumableOptions) (stow.Item, error) {
+	if options == nil {
+		options = &stow.ResumableOptions{}
+	}
+	partSize := largeFilePartSize(size, options.PartSize)
+	if size <= partSize {
+		return c.PutWithOptions(name, io.NewSectionReader(r, 0, size), size, metadata, &stow.PutOptions{
+			ContentProperties: options.ContentProperties,
+		})
+	}
+
+	mdPrepped, err := prepMetadata(metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create or update item, preparing metadata")
+	}
+	content, _, err := stow.PrepareContentProperties(name, io.NewSectionReader(r, 0, size), &stow.PutOptions{
+		ContentProperties: options.ContentProperties,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create or update item, detecting content type")
+	}
+	addContentInfo(mdPrepped, content)
+	u := &largeFileUploader{
+		container:   c,
+		name:        name,
+		contentType: content.ContentType,
+		info:        mdPrepped,
+	}
+	return stow.ResumeUpload(u, options.Store, stow.CheckpointKey(c, name), r, size, partSize)
+}
+
+// largeFilePartSize gets the size of the parts of a large file of the size,
+// using the requested size when it is in the limits of B2.
+func largeFilePartSize(size, requested int64) int64 {
+	partSize := int64(defaultPartSize)
+	if requested > 0 {
+		partSize = requested
+	}
+	if partSize < minPartSize {
+		partSize = minPartSize
+	}
+	if least := (size + maxParts - 1) / maxParts; partSize < least {
+		partSize = least
+	}
+	return partSize
+}
+
+// largeFileUploader uploads a large file, whose ID is the ID of the
+// upload. The ETags of parts are their SHA1.
+type largeFileUploader struct {
+	container   *container
+	name        string
+	contentType string
+	info        map[string]string
+}
+
+var _ stow.MultipartUploader = (*largeFileUploader)(nil)
+
+func (u *largeFileUploader) Start() (string, error) {
+	id, err := u.container.largeFiles.startLargeFile(u.container.bucket.ID, u.name, u.contentType, u.info)
+	return id, errors.Wrap(err, "starting large file")
+}
+
+func (u *largeFileUploader) Parts(uploadID string, partSize int64) (map[int]string, error) {
+	return u.container.largeFiles.largeFileParts(uploadID)
+}
+
+func (u *largeFileUploader) UploadPart(uploadID string, number int, offset int64, data []byte) (string, error) {
+	sha1, err := u.container.largeFiles.uploadPart(uploadID, number, data)
+	return sha1, errors.Wrapf(err, "uploading part %d", number)
+}
+
+func (u *largeFileUploader) Complete(uploadID string, parts []stow.CompletedPart) (stow.Item, error) {
+	sha1s := make([]string, len(parts))
+	for i, part := range parts {
+		sha1s[i] = part.ETag
+	}
+	size, err := u.container.largeFiles.finishLargeFile(uploadID, sha1s)
+	if err != nil {
+		return nil, errors.Wrap(err, "finishing large file")
+	}
+	return &item{
+		id:     uploadID,
+		name:   u.name,
+		size:   size,
+		bucket: u.container.bucket,
+	}, nil
+}
+
+func (u *largeFileUploader) Abort(uploadID string) error {
+	return errors.Wrap(u.container.largeFiles.cancelLargeFile(uploadID), "cancelling large file")
+}
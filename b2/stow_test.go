@@ -10,6 +10,7 @@ import (
 	isi "github.com/cheekybits/is"
 	"github.com/graymeta/stow"
 	"github.com/graymeta/stow/test"
+	backblaze "gopkg.in/kothar/go-backblaze.v0"
 )
 
 func TestStow(t *testing.T) {
@@ -115,3 +116,44 @@ var letters = []rune("abcdefghijklmnopqrstuvwxyz")
 func init() {
 	rand.Seed(int64(time.Now().Nanosecond()))
 }
+
+func TestLifecycleRules(t *testing.T) {
+	is := isi.New(t)
+
+	day := 24 * time.Hour
+	rules := []stow.LifecycleRule{
+		{Prefix: "logs/", Action: stow.LifecycleDelete, Age: 30 * day},
+		{Prefix: "logs/", Action: stow.LifecycleDeleteNoncurrent, Age: 7 * day},
+		{Action: stow.LifecycleDeleteNoncurrent, Age: day},
+	}
+	b2rules, err := toLifecycleRules(rules)
+	is.NoErr(err)
+	is.Equal(len(b2rules), 2)
+	is.Equal(b2rules[0].FileNamePrefix, "logs/")
+	is.Equal(b2rules[0].DaysFromUploadingToHiding, 30)
+	is.Equal(b2rules[0].DaysFromHidingToDeleting, 7)
+	is.Equal(b2rules[1].DaysFromUploadingToHiding, 0)
+	is.Equal(fromLifecycleRules(b2rules), rules)
+
+	_, err = toLifecycleRules([]stow.LifecycleRule{{Action: stow.LifecycleTransition, StorageClass: stow.StorageClassCool}})
+	is.True(stow.IsNotSupported(err))
+	_, err = toLifecycleRules([]stow.LifecycleRule{
+		{Action: stow.LifecycleDelete, Age: day},
+		{Action: stow.LifecycleDelete, Age: 2 * day},
+	})
+	is.Err(err)
+}
+
+func TestContainerStat(t *testing.T) {
+	is := isi.New(t)
+	c := &container{bucket: &backblaze.Bucket{BucketInfo: &backblaze.BucketInfo{
+		Name:       "bucket",
+		BucketType: backblaze.AllPublic,
+		Info:       map[string]string{"team": "media"},
+	}}}
+	info, err := stow.StatContainer(c)
+	is.NoErr(err)
+	is.True(info.PublicAccess)
+	is.Equal(info.Versioning, stow.VersioningEnabled)
+	is.Equal(info.Metadata, map[string]interface{}{"team": "media"})
+}
@@ -0,0 +1,241 @@
+// Package cas stores content addressed by its SHA-256 hash on top of any
+// stow.Container, so that identical content put under many names is kept
+// once.
+//
+// The content is kept in blobs/<hash>, and each name is a small ref object
+// refs/<name> holding the hash and size of its content. Removing a name
+// only removes its ref; blobs no ref points to are removed by GC.
+package cas
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/graymeta/stow"
+)
+
+// Prefixes of the blobs and refs in the Container.
+const (
+	BlobPrefix = "blobs/"
+	RefPrefix  = "refs/"
+)
+
+// ErrCorrupt is returned when content read does not have the hash or size
+// it was stored with.
+var ErrCorrupt = errors.New("cas: content does not match its hash")
+
+// Ref is what a name points to.
+type Ref struct {
+	// Hash is the hex encoded SHA-256 hash of the content.
+	Hash string `json:"sha256"`
+	// Size is the size of the content in bytes.
+	Size int64 `json:"size"`
+}
+
+// Store keeps content by hash in a Container.
+type Store struct {
+	container stow.Container
+}
+
+// New makes a Store keeping its blobs and refs in the Container.
+func New(container stow.Container) *Store {
+	return &Store{container: container}
+}
+
+// Container gets the Container of the Store.
+func (s *Store) Container() stow.Container {
+	return s.container
+}
+
+// Put stores the content read from r under the name, uploading it only if
+// no blob has the same hash. Readers that can seek are read twice rather
+// than copied to a temporary file first.
+func (s *Store) Put(name string, r io.Reader) (Ref, error) {
+	content, ref, err := spool(r)
+	if err != nil {
+		return Ref{}, err
+	}
+	defer content.Close()
+	if _, err := s.putBlob(ref, content); err != nil {
+		return Ref{}, err
+	}
+	return ref, s.putRef(name, ref)
+}
+
+// putBlob uploads the content of the blob unless it is already stored,
+// returning whether it was uploaded.
+func (s *Store) putBlob(ref Ref, content io.Reader) (bool, error) {
+	_, err := s.container.Item(blobName(ref.Hash))
+	if err == nil {
+		return false, nil
+	}
+	if err != stow.ErrNotFound {
+		return false, err
+	}
+	_, err = s.container.Put(blobName(ref.Hash), content, ref.Size, nil)
+	return err == nil, err
+}
+
+func (s *Store) putRef(name string, ref Ref) error {
+	b, err := json.Marshal(ref)
+	if err != nil {
+		return err
+	}
+	_, err = s.container.Put(RefPrefix+name, bytes.NewReader(b), int64(len(b)), nil)
+	return err
+}
+
+// Stat gets the Ref of the name, or stow.ErrNotFound.
+func (s *Store) Stat(name string) (Ref, error) {
+	item, err := s.container.Item(RefPrefix + name)
+	if err != nil {
+		return Ref{}, err
+	}
+	return readRef(item)
+}
+
+// Open opens the content of the name. Reading it through checks the
+// content against the hash it was stored with, and gives ErrCorrupt
+// instead of io.EOF when it does not match.
+func (s *Store) Open(name string) (io.ReadCloser, error) {
+	ref, err := s.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.OpenBlob(ref)
+}
+
+// OpenBlob opens the blob of the Ref, checking it as Open does.
+func (s *Store) OpenBlob(ref Ref) (io.ReadCloser, error) {
+	item, err := s.container.Item(blobName(ref.Hash))
+	if err != nil {
+		return nil, err
+	}
+	rc, err := item.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &verifiedReader{rc: rc, ref: ref, hash: sha256.New()}, nil
+}
+
+// Remove removes the name. Its blob is kept until GC finds no other name
+// points to it.
+func (s *Store) Remove(name string) error {
+	item, err := s.container.Item(RefPrefix + name)
+	if err != nil {
+		return err
+	}
+	return s.container.RemoveItem(item.ID())
+}
+
+// WalkRefs calls fn with each name with the prefix and its Ref.
+func (s *Store) WalkRefs(prefix string, fn func(name string, ref Ref) error) error {
+	return stow.Walk(s.container, RefPrefix+prefix, 1000, func(item stow.Item, err error) error {
+		if err != nil {
+			return err
+		}
+		ref, err := readRef(item)
+		if err != nil {
+			return err
+		}
+		return fn(strings.TrimPrefix(item.Name(), RefPrefix), ref)
+	})
+}
+
+func readRef(item stow.Item) (Ref, error) {
+	rc, err := item.Open()
+	if err != nil {
+		return Ref{}, err
+	}
+	defer rc.Close()
+	var ref Ref
+	if err := json.NewDecoder(rc).Decode(&ref); err != nil {
+		return Ref{}, fmt.Errorf("reading ref %s: %v", item.Name(), err)
+	}
+	return ref, nil
+}
+
+func blobName(hash string) string {
+	return BlobPrefix + hash
+}
+
+// spool hashes the content, giving a reader of it from the start. Content
+// that cannot be read again is copied to a temporary file as it is hashed.
+func spool(r io.Reader) (io.ReadCloser, Ref, error) {
+	h := sha256.New()
+	if rs, ok := r.(io.ReadSeeker); ok {
+		start, err := rs.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, Ref{}, err
+		}
+		size, err := io.Copy(h, rs)
+		if err != nil {
+			return nil, Ref{}, err
+		}
+		if _, err := rs.Seek(start, io.SeekStart); err != nil {
+			return nil, Ref{}, err
+		}
+		return ioutil.NopCloser(io.LimitReader(rs, size)), makeRef(h, size), nil
+	}
+	f, err := ioutil.TempFile("", "stow-cas")
+	if err != nil {
+		return nil, Ref{}, err
+	}
+	size, err := io.Copy(io.MultiWriter(f, h), r)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, Ref{}, err
+	}
+	return &tempFile{f}, makeRef(h, size), nil
+}
+
+func makeRef(h hash.Hash, size int64) Ref {
+	return Ref{Hash: hex.EncodeToString(h.Sum(nil)), Size: size}
+}
+
+// tempFile is a temporary file removed when it is closed.
+type tempFile struct {
+	*os.File
+}
+
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// verifiedReader hashes what it reads, checking the hash and size at the
+// end.
+type verifiedReader struct {
+	rc   io.ReadCloser
+	ref  Ref
+	hash hash.Hash
+	read int64
+}
+
+func (r *verifiedReader) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	r.hash.Write(p[:n])
+	r.read += int64(n)
+	if err == io.EOF && (r.read != r.ref.Size || hex.EncodeToString(r.hash.Sum(nil)) != r.ref.Hash) {
+		err = ErrCorrupt
+	}
+	return n, err
+}
+
+func (r *verifiedReader) Close() error {
+	return r.rc.Close()
+}
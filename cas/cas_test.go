@@ -0,0 +1,152 @@
+package cas_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+	"github.com/graymeta/stow/cas"
+	"github.com/graymeta/stow/local"
+)
+
+func setup(is is.I) (stow.Location, func()) {
+	dir, err := ioutil.TempDir("", "stow-cas")
+	is.NoErr(err)
+	location, err := stow.Dial(local.Kind, stow.ConfigMap{local.ConfigKeyPath: dir})
+	is.NoErr(err)
+	return location, func() { os.RemoveAll(dir) }
+}
+
+// count gets the number of Items with the prefix.
+func count(is is.I, container stow.Container, prefix string) int {
+	n := 0
+	is.NoErr(stow.Walk(container, prefix, 100, func(item stow.Item, err error) error {
+		n++
+		return err
+	}))
+	return n
+}
+
+func TestPutOpen(t *testing.T) {
+	is := is.New(t)
+	location, teardown := setup(is)
+	defer teardown()
+	container, err := location.CreateContainer("store")
+	is.NoErr(err)
+	store := cas.New(container)
+
+	// identical content is stored once, whether it can seek or not
+	ref, err := store.Put("builds/1/app", strings.NewReader("artifact"))
+	is.NoErr(err)
+	is.Equal(ref.Size, 8)
+	is.Equal(ref.Hash, fmt.Sprintf("%x", sha256.Sum256([]byte("artifact"))))
+	ref2, err := store.Put("builds/2/app", ioutil.NopCloser(strings.NewReader("artifact")))
+	is.NoErr(err)
+	is.Equal(ref2, ref)
+	_, err = store.Put("builds/2/other", bytes.NewReader([]byte("other")))
+	is.NoErr(err)
+	is.Equal(count(is, container, cas.BlobPrefix), 2)
+
+	got, err := store.Stat("builds/2/app")
+	is.NoErr(err)
+	is.Equal(got, ref)
+	rc, err := store.Open("builds/2/app")
+	is.NoErr(err)
+	b, err := ioutil.ReadAll(rc)
+	is.NoErr(err)
+	is.NoErr(rc.Close())
+	is.Equal(string(b), "artifact")
+
+	var names []string
+	is.NoErr(store.WalkRefs("builds/2/", func(name string, ref cas.Ref) error {
+		names = append(names, name)
+		return nil
+	}))
+	is.Equal(names, []string{"builds/2/app", "builds/2/other"})
+
+	_, err = store.Stat("missing")
+	is.Equal(err, stow.ErrNotFound)
+
+	// reads of content that changed fail
+	_, err = container.Put(cas.BlobPrefix+ref.Hash, strings.NewReader("artifacT"), 8, nil)
+	is.NoErr(err)
+	rc, err = store.Open("builds/1/app")
+	is.NoErr(err)
+	_, err = ioutil.ReadAll(rc)
+	is.Equal(err, cas.ErrCorrupt)
+	rc.Close()
+}
+
+func TestGC(t *testing.T) {
+	is := is.New(t)
+	location, teardown := setup(is)
+	defer teardown()
+	container, err := location.CreateContainer("store")
+	is.NoErr(err)
+	store := cas.New(container)
+
+	_, err = store.Put("a", strings.NewReader("shared"))
+	is.NoErr(err)
+	_, err = store.Put("b", strings.NewReader("shared"))
+	is.NoErr(err)
+	_, err = store.Put("c", strings.NewReader("only c"))
+	is.NoErr(err)
+	is.NoErr(store.Remove("a"))
+	is.NoErr(store.Remove("c"))
+
+	// new blobs are kept
+	stats, err := store.GC(nil)
+	is.NoErr(err)
+	is.Equal(stats, cas.GCStats{Kept: 2})
+
+	time.Sleep(10 * time.Millisecond)
+	stats, err = store.GC(&cas.GCOptions{GracePeriod: time.Millisecond, DryRun: true})
+	is.NoErr(err)
+	is.Equal(stats, cas.GCStats{Blobs: 1, Bytes: 6, Kept: 1})
+	is.Equal(count(is, container, cas.BlobPrefix), 2)
+
+	stats, err = store.GC(&cas.GCOptions{GracePeriod: time.Millisecond})
+	is.NoErr(err)
+	is.Equal(stats.Blobs, 1)
+	is.Equal(count(is, container, cas.BlobPrefix), 1)
+	rc, err := store.Open("b")
+	is.NoErr(err)
+	rc.Close()
+}
+
+func TestImport(t *testing.T) {
+	is := is.New(t)
+	location, teardown := setup(is)
+	defer teardown()
+	container, err := location.CreateContainer("artifacts")
+	is.NoErr(err)
+	for _, name := range []string{"v1/app", "v2/app", "v3/app"} {
+		_, err := container.Put(name, strings.NewReader("same build"), 10, nil)
+		is.NoErr(err)
+	}
+	_, err = container.Put("v3/notes", strings.NewReader("notes"), 5, nil)
+	is.NoErr(err)
+
+	// deduplicating a container in place
+	store := cas.New(container)
+	stats, err := store.Import(container, &cas.ImportOptions{RemoveSource: true})
+	is.NoErr(err)
+	is.Equal(stats, cas.ImportStats{Items: 4, Bytes: 35, Blobs: 2, SavedBytes: 20})
+	is.Equal(count(is, container, "v"), 0)
+	is.Equal(count(is, container, cas.BlobPrefix), 2)
+	is.Equal(count(is, container, cas.RefPrefix), 4)
+
+	rc, err := store.Open("v2/app")
+	is.NoErr(err)
+	b, err := ioutil.ReadAll(rc)
+	is.NoErr(err)
+	rc.Close()
+	is.Equal(string(b), "same build")
+}
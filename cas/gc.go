@@ -0,0 +1,94 @@
+package cas
+
+import (
+	"strings"
+	"time"
+
+	"github.com/graymeta/stow"
+)
+
+// defaultGracePeriod is how old a blob must be for GC to remove it.
+const defaultGracePeriod = time.Hour
+
+// GCOptions configure GC.
+type GCOptions struct {
+	// GracePeriod keeps blobs modified within it, so that blobs of Puts
+	// still writing their refs are not removed. Defaults to an hour.
+	GracePeriod time.Duration
+	// DryRun finds the blobs to remove without removing them.
+	DryRun bool
+}
+
+// GCStats describes what GC removed.
+type GCStats struct {
+	// Blobs is the number of blobs removed.
+	Blobs int
+	// Bytes is the size of the blobs removed.
+	Bytes int64
+	// Kept is the number of blobs still referenced, or within the grace
+	// period.
+	Kept int
+}
+
+// GC removes the blobs no ref points to. Blobs written within the grace
+// period are kept, as their refs may not be written yet. A Put of content
+// whose old blob GC is about to remove can still lose it, so GC is best
+// run while nothing is put.
+func (s *Store) GC(options *GCOptions) (GCStats, error) {
+	if options == nil {
+		options = &GCOptions{}
+	}
+	grace := options.GracePeriod
+	if grace <= 0 {
+		grace = defaultGracePeriod
+	}
+	// the cutoff is taken before listing the refs, so a blob written
+	// after the listing is within it
+	cutoff := time.Now().Add(-grace)
+	referenced := make(map[string]bool)
+	err := s.WalkRefs(stow.NoPrefix, func(name string, ref Ref) error {
+		referenced[ref.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return GCStats{}, err
+	}
+	var stats GCStats
+	var unreferenced []stow.Item
+	err = stow.Walk(s.container, BlobPrefix, 1000, func(item stow.Item, err error) error {
+		if err != nil {
+			return err
+		}
+		if referenced[strings.TrimPrefix(item.Name(), BlobPrefix)] {
+			stats.Kept++
+			return nil
+		}
+		lastMod, err := item.LastMod()
+		if err != nil {
+			return err
+		}
+		if lastMod.After(cutoff) {
+			stats.Kept++
+			return nil
+		}
+		unreferenced = append(unreferenced, item)
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	for _, item := range unreferenced {
+		size, err := item.Size()
+		if err != nil {
+			return stats, err
+		}
+		if !options.DryRun {
+			if err := s.container.RemoveItem(item.ID()); err != nil {
+				return stats, err
+			}
+		}
+		stats.Blobs++
+		stats.Bytes += size
+	}
+	return stats, nil
+}
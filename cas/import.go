@@ -0,0 +1,92 @@
+package cas
+
+import (
+	"strings"
+
+	"github.com/graymeta/stow"
+)
+
+// ImportOptions configure Import.
+type ImportOptions struct {
+	// Prefix limits the import to the Items with the prefix.
+	Prefix string
+	// RemoveSource removes each Item once it is in the Store.
+	RemoveSource bool
+}
+
+// ImportStats describes what Import did.
+type ImportStats struct {
+	// Items is the number of Items imported.
+	Items int
+	// Bytes is the size of the Items imported.
+	Bytes int64
+	// Blobs is the number of blobs uploaded.
+	Blobs int
+	// SavedBytes is the size of the Items whose content was already
+	// stored.
+	SavedBytes int64
+}
+
+// Import puts the Items of the Container into the Store under their
+// names, storing each distinct content once. The Container may be the
+// one of the Store, whose blobs and refs are left out.
+func (s *Store) Import(container stow.Container, options *ImportOptions) (ImportStats, error) {
+	if options == nil {
+		options = &ImportOptions{}
+	}
+	same := container.ID() == s.container.ID()
+	var stats ImportStats
+	// the Items are listed before importing any, so that the listing does
+	// not see what the import writes
+	var items []stow.Item
+	err := stow.Walk(container, options.Prefix, 1000, func(item stow.Item, err error) error {
+		if err != nil {
+			return err
+		}
+		if same && (strings.HasPrefix(item.Name(), BlobPrefix) || strings.HasPrefix(item.Name(), RefPrefix)) {
+			return nil
+		}
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	for _, item := range items {
+		uploaded, ref, err := s.importItem(item)
+		if err != nil {
+			return stats, err
+		}
+		stats.Items++
+		stats.Bytes += ref.Size
+		if uploaded {
+			stats.Blobs++
+		} else {
+			stats.SavedBytes += ref.Size
+		}
+		if options.RemoveSource {
+			if err := container.RemoveItem(item.ID()); err != nil {
+				return stats, err
+			}
+		}
+	}
+	return stats, nil
+}
+
+func (s *Store) importItem(item stow.Item) (bool, Ref, error) {
+	rc, err := item.Open()
+	if err != nil {
+		return false, Ref{}, err
+	}
+	defer rc.Close()
+	content, ref, err := spool(rc)
+	if err != nil {
+		return false, Ref{}, err
+	}
+	defer content.Close()
+	uploaded, err := s.putBlob(ref, content)
+	if err != nil {
+		return false, Ref{}, err
+	}
+	return uploaded, ref, s.putRef(item.Name(), ref)
+}
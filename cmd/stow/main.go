@@ -0,0 +1,163 @@
+// Command stow works with stow Locations from the command line.
+//
+// The serve command serves a Location through the S3 REST API:
+//
+//	stow serve -kind sftp -config '{"host":"host","port":"22","username":"u","password":"p"}' \
+//		-key AKIDEXAMPLE:secret -addr :9000
+//
+// Keys can also be given in the STOW_GATEWAY_ACCESS_KEY and
+// STOW_GATEWAY_SECRET_KEY environment variables, which keeps the secret
+// off the command line.
+//
+// The du command prints how many Items and bytes a Container holds under
+// each prefix, much like du:
+//
+//	stow du -kind local -config '{"path":"/data"}' -depth 2 photos 2020/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/graymeta/stow"
+	"github.com/graymeta/stow/gateway"
+
+	// the kinds of Location that can be served
+	_ "github.com/graymeta/stow/archive"
+	_ "github.com/graymeta/stow/azure"
+	_ "github.com/graymeta/stow/b2"
+	_ "github.com/graymeta/stow/ftp"
+	_ "github.com/graymeta/stow/google"
+	_ "github.com/graymeta/stow/local"
+	_ "github.com/graymeta/stow/oracle"
+	_ "github.com/graymeta/stow/s3"
+	_ "github.com/graymeta/stow/sftp"
+	_ "github.com/graymeta/stow/swift"
+	_ "github.com/graymeta/stow/webdav"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "serve":
+		if err := serve(os.Args[2:]); err != nil {
+			log.Fatalln("stow serve:", err)
+		}
+	case "du":
+		if err := du(os.Args[2:]); err != nil {
+			log.Fatalln("stow du:", err)
+		}
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: stow serve [flags]")
+	fmt.Fprintln(os.Stderr, "       stow du [flags] container [prefix]")
+	os.Exit(2)
+}
+
+// locationFlags adds the flags that say which Location to dial.
+func locationFlags(flags *flag.FlagSet) (kind, config *string) {
+	kind = flags.String("kind", "", "kind of Location ("+strings.Join(stow.Kinds(), ", ")+")")
+	config = flags.String("config", "{}", "configuration of the Location as a JSON object")
+	return kind, config
+}
+
+// dial dials the Location of the kind with the JSON configuration.
+func dial(kind, config string) (stow.Location, error) {
+	if kind == "" {
+		return nil, fmt.Errorf("missing -kind")
+	}
+	var configMap stow.ConfigMap
+	if err := json.Unmarshal([]byte(config), &configMap); err != nil {
+		return nil, fmt.Errorf("bad -config: %v", err)
+	}
+	return stow.Dial(kind, configMap)
+}
+
+// keys are the repeatable -key flags.
+type keys map[string]string
+
+func (k keys) String() string {
+	var ids []string
+	for id := range k {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+func (k keys) Set(value string) error {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("key %q is not ACCESS_KEY:SECRET_KEY", value)
+	}
+	k[parts[0]] = parts[1]
+	return nil
+}
+
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	kind, config := locationFlags(flags)
+	addr := flags.String("addr", ":9000", "address to listen on")
+	anonymous := flags.Bool("anonymous", false, "allow requests that are not signed")
+	partDir := flags.String("part-dir", "", "directory to keep the parts of multipart uploads in")
+	k := keys{}
+	flags.Var(k, "key", "ACCESS_KEY:SECRET_KEY that requests may be signed with (repeatable)")
+	flags.Parse(args)
+
+	if id, secret := os.Getenv("STOW_GATEWAY_ACCESS_KEY"), os.Getenv("STOW_GATEWAY_SECRET_KEY"); id != "" && secret != "" {
+		k[id] = secret
+	}
+	location, err := dial(*kind, *config)
+	if err != nil {
+		return err
+	}
+	defer location.Close()
+	server, err := gateway.New(location, &gateway.Options{
+		Keys:      k,
+		Anonymous: *anonymous,
+		PartDir:   *partDir,
+	})
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+	log.Printf("serving %s on %s", *kind, *addr)
+	return http.ListenAndServe(*addr, server)
+}
+
+func du(args []string) error {
+	flags := flag.NewFlagSet("du", flag.ExitOnError)
+	kind, config := locationFlags(flags)
+	depth := flags.Int("depth", 1, "number of slashes below the prefix to give the usage of prefixes down to")
+	flags.Parse(args)
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return fmt.Errorf("want a container and an optional prefix")
+	}
+	location, err := dial(*kind, *config)
+	if err != nil {
+		return err
+	}
+	defer location.Close()
+	container, err := location.Container(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	usage, err := stow.Usage(container, flags.Arg(1), *depth)
+	if err != nil {
+		return err
+	}
+	return stow.WriteUsage(os.Stdout, usage)
+}
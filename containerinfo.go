@@ -0,0 +1,50 @@
+package stow
+
+import "time"
+
+// VersioningState describes whether a Container keeps previous versions
+// of Items.
+type VersioningState string
+
+const (
+	// VersioningDisabled means the Container has never kept versions.
+	VersioningDisabled VersioningState = "disabled"
+	// VersioningEnabled means the Container keeps versions.
+	VersioningEnabled VersioningState = "enabled"
+	// VersioningSuspended means the Container kept versions, and still has
+	// them, but no longer keeps new ones.
+	VersioningSuspended VersioningState = "suspended"
+)
+
+// ContainerInfo describes a Container. Fields an implementation cannot
+// determine are left empty.
+type ContainerInfo struct {
+	// Created is the time the Container was created.
+	Created time.Time
+	// Region is the region or location the Container is stored in.
+	Region string
+	// StorageClass is the storage class Items are put in by default.
+	StorageClass StorageClass
+	// Versioning is whether the Container keeps versions of Items.
+	Versioning VersioningState
+	// PublicAccess is whether anyone can read the Items in the Container.
+	PublicAccess bool
+	// Metadata is the metadata, labels or tags of the Container.
+	Metadata map[string]interface{}
+}
+
+// ContainerStater represents a Container that can describe itself.
+type ContainerStater interface {
+	// Stat gets the current ContainerInfo of the Container.
+	Stat() (ContainerInfo, error)
+}
+
+// StatContainer gets the ContainerInfo of the container, or an error
+// satisfying IsNotSupported if the container cannot describe itself.
+func StatContainer(container Container) (ContainerInfo, error) {
+	s, ok := container.(ContainerStater)
+	if !ok {
+		return ContainerInfo{}, NotSupported("container stat")
+	}
+	return s.Stat()
+}
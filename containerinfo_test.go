@@ -0,0 +1,14 @@
+package stow_test
+
+import (
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestStatContainerNotSupported(t *testing.T) {
+	is := is.New(t)
+	_, err := stow.StatContainer(newPagedContainer(styleStartAfter, 0))
+	is.True(stow.IsNotSupported(err))
+}
@@ -0,0 +1,281 @@
+package stow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// defaults of downloads
+const (
+	defaultDownloadPartSize    = 8 * 1024 * 1024
+	defaultDownloadConcurrency = 4
+)
+
+// DownloadOptions are the options for Download.
+type DownloadOptions struct {
+	// Transfer sets the size of the ranges and how many are fetched at
+	// once.
+	Transfer TransferOptions
+	// Store keeps the progress of the download, so that a failed download
+	// can be resumed. Without a store, downloads start from the beginning.
+	Store CheckpointStore
+	// Key is the key the progress is saved with. It defaults to the URL of
+	// the Item.
+	Key string
+}
+
+// Download fetches the Item into w, and gets its size. Items that are
+// ItemRangers are fetched in ranges, several at a time; other Items, and
+// those whose ranges give an error satisfying IsNotSupported, are read with
+// a single Open.
+// With a store in the options, the ranges written are saved along with the
+// ETag of the Item, and downloading the same Item again only fetches the
+// ranges that are missing. The download starts over when the ETag has
+// changed. When w is also an io.ReaderAt, the saved ranges are checked
+// against what w holds, and any that differ are fetched again.
+func Download(item Item, w io.WriterAt, options *DownloadOptions) (int64, error) {
+	if options == nil {
+		options = &DownloadOptions{}
+	}
+	size, err := item.Size()
+	if err != nil {
+		return 0, err
+	}
+	ranger, ok := item.(ItemRanger)
+	if !ok || size == 0 {
+		return downloadAll(item, w)
+	}
+	etag, err := item.ETag()
+	if err != nil {
+		return 0, err
+	}
+	partSize := options.Transfer.PartSize
+	if partSize <= 0 {
+		partSize = defaultDownloadPartSize
+	}
+	concurrency := options.Transfer.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultDownloadConcurrency
+	}
+	key := options.Key
+	if key == "" {
+		key = item.URL().String()
+	}
+
+	var checkpoint *Checkpoint
+	if options.Store != nil {
+		checkpoint, err = options.Store.Load(key)
+		if err != nil && err != ErrNotFound {
+			return 0, err
+		}
+		if checkpoint != nil && !verifyDownload(checkpoint, w, etag, size) {
+			checkpoint = nil
+		}
+	}
+	if checkpoint == nil {
+		checkpoint = &Checkpoint{UploadID: etag, Size: size, PartSize: partSize}
+	}
+
+	d := &download{
+		ranger:     ranger,
+		w:          w,
+		store:      options.Store,
+		key:        key,
+		checkpoint: checkpoint,
+	}
+	err = d.run(concurrency)
+	if IsNotSupported(err) {
+		size, err = downloadAll(item, w)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if options.Store != nil {
+		if err := options.Store.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	return size, nil
+}
+
+// downloadAll copies the whole Item into w.
+func downloadAll(item Item, w io.WriterAt) (int64, error) {
+	r, err := item.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	return io.Copy(&offsetWriter{w: w}, r)
+}
+
+// offsetWriter writes to an io.WriterAt one write after the other.
+type offsetWriter struct {
+	w   io.WriterAt
+	off int64
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+	n, err := o.w.WriteAt(p, o.off)
+	o.off += int64(n)
+	return n, err
+}
+
+// verifyDownload keeps the ranges of the checkpoint that w still holds,
+// and gets whether the download can be resumed at all.
+func verifyDownload(checkpoint *Checkpoint, w io.WriterAt, etag string, size int64) bool {
+	if checkpoint.UploadID != etag || checkpoint.Size != size || checkpoint.PartSize <= 0 {
+		return false
+	}
+	r, ok := w.(io.ReaderAt)
+	if !ok {
+		return true
+	}
+	var parts []CompletedPart
+	var buf []byte
+	for _, part := range checkpoint.Parts {
+		var checksum string
+		var err error
+		buf, checksum, err = readPart(r, checkpoint, part.Number, buf)
+		if err != nil {
+			// a file cut short is missing the range
+			continue
+		}
+		if checksum == part.Checksum {
+			parts = append(parts, part)
+		}
+	}
+	checkpoint.Parts = parts
+	return true
+}
+
+// download fetches the ranges of an Item missing from its checkpoint.
+type download struct {
+	ranger     ItemRanger
+	w          io.WriterAt
+	store      CheckpointStore
+	key        string
+	checkpoint *Checkpoint
+
+	mu  sync.Mutex
+	err error
+}
+
+func (d *download) run(concurrency int) error {
+	done := make(map[int]bool, len(d.checkpoint.Parts))
+	for _, part := range d.checkpoint.Parts {
+		done[part.Number] = true
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for n := 1; n <= partCount(d.checkpoint.Size, d.checkpoint.PartSize); n++ {
+		if done[n] {
+			continue
+		}
+		sem <- struct{}{}
+		if d.failed() {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(n int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := d.fetch(n); err != nil {
+				d.fail(err)
+			}
+		}(n)
+	}
+	wg.Wait()
+	return d.err
+}
+
+// fetch writes range n of the Item and saves it in the checkpoint.
+func (d *download) fetch(n int) error {
+	off := int64(n-1) * d.checkpoint.PartSize
+	length := d.checkpoint.Size - off
+	if length > d.checkpoint.PartSize {
+		length = d.checkpoint.PartSize
+	}
+	buf := getPartBuffer(length)
+	defer partBuffers.Put(buf)
+
+	r, err := d.ranger.OpenRange(uint64(off), uint64(off+length-1))
+	if IsNotSupported(err) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("opening range %d: %v", n, err)
+	}
+	defer r.Close()
+	if _, err := io.ReadFull(r, *buf); err != nil {
+		return fmt.Errorf("reading range %d: %v", n, err)
+	}
+	if _, err := d.w.WriteAt(*buf, off); err != nil {
+		return err
+	}
+	if d.store == nil {
+		return nil
+	}
+	sum := sha256.Sum256(*buf)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	parts := append(d.checkpoint.Parts, CompletedPart{Number: n, Checksum: hex.EncodeToString(sum[:])})
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].Number < parts[j].Number
+	})
+	d.checkpoint.Parts = parts
+	return d.store.Save(d.key, d.checkpoint)
+}
+
+func (d *download) fail(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *download) failed() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err != nil
+}
+
+// DownloadFile downloads the Item into the file at path, resuming a
+// download into the same file that failed. Unless the options have a
+// store, the progress is kept in a file next to it, named after it with
+// ".download" added, which is removed once the download is complete.
+func DownloadFile(item Item, path string, options *DownloadOptions) (int64, error) {
+	if options == nil {
+		options = &DownloadOptions{}
+	}
+	if options.Store == nil {
+		withStore := *options
+		withStore.Store = fileCheckpointStore{file: path + ".download"}
+		options = &withStore
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	size, err := Download(item, f, options)
+	if err == nil {
+		// a file left from a larger Item is longer than this one
+		err = f.Truncate(size)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
+}
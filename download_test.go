@@ -0,0 +1,191 @@
+package stow_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+// dataItem is an Item holding data.
+type dataItem struct {
+	testItem
+	data []byte
+	etag string
+}
+
+func (i *dataItem) Size() (int64, error)  { return int64(len(i.data)), nil }
+func (i *dataItem) ETag() (string, error) { return i.etag, nil }
+func (i *dataItem) Open() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(i.data)), nil
+}
+
+// rangedItem is an Item that can be opened in ranges, recording the ranges
+// opened by their first byte.
+type rangedItem struct {
+	dataItem
+	mu        sync.Mutex
+	opened    []int
+	failStart int
+}
+
+func (i *rangedItem) OpenRange(start, end uint64) (io.ReadCloser, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if int(start) == i.failStart {
+		return nil, errors.New("failed range")
+	}
+	i.opened = append(i.opened, int(start))
+	return ioutil.NopCloser(bytes.NewReader(i.data[start : end+1])), nil
+}
+
+// waitOpened waits for the range starting at start to be opened.
+func (i *rangedItem) waitOpened(start int) bool {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		i.mu.Lock()
+		for _, opened := range i.opened {
+			if opened == start {
+				i.mu.Unlock()
+				return true
+			}
+		}
+		i.mu.Unlock()
+	}
+	return false
+}
+
+func (i *rangedItem) takeOpened() []int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	opened := i.opened
+	sort.Ints(opened)
+	i.opened = nil
+	return opened
+}
+
+// memoryWriterAt is an io.WriterAt writing into memory.
+type memoryWriterAt struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (m *memoryWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if end := int(off) + len(p); end > len(m.data) {
+		m.data = append(m.data, make([]byte, end-len(m.data))...)
+	}
+	return copy(m.data[off:], p), nil
+}
+
+func TestDownload(t *testing.T) {
+	is := is.New(t)
+
+	item := &rangedItem{dataItem: dataItem{testItem: testItem{name: "item"}, data: []byte("abcdefghij"), etag: "1"}, failStart: -1}
+	var w memoryWriterAt
+	n, err := stow.Download(item, &w, &stow.DownloadOptions{Transfer: stow.TransferOptions{PartSize: 3, Concurrency: 2}})
+	is.NoErr(err)
+	is.Equal(n, 10)
+	is.Equal(string(w.data), "abcdefghij")
+	is.Equal(item.takeOpened(), []int{0, 3, 6, 9})
+
+	// items that cannot be opened in ranges are read in one go
+	plain := &dataItem{testItem: testItem{name: "plain"}, data: []byte("abcdefghij")}
+	w = memoryWriterAt{}
+	n, err = stow.Download(plain, &w, nil)
+	is.NoErr(err)
+	is.Equal(n, 10)
+	is.Equal(string(w.data), "abcdefghij")
+}
+
+// unrangedItem is an Item whose ranges cannot be opened, as with the
+// compressed files of archives.
+type unrangedItem struct {
+	dataItem
+}
+
+func (i *unrangedItem) OpenRange(start, end uint64) (io.ReadCloser, error) {
+	return nil, stow.NotSupported("ranges")
+}
+
+func TestDownloadRangesNotSupported(t *testing.T) {
+	is := is.New(t)
+
+	item := &unrangedItem{dataItem{testItem: testItem{name: "item"}, data: []byte("abcdefghij"), etag: "1"}}
+	var w memoryWriterAt
+	n, err := stow.Download(item, &w, &stow.DownloadOptions{
+		Transfer: stow.TransferOptions{PartSize: 3, Concurrency: 2},
+		Store:    stow.NewMemoryCheckpointStore(),
+	})
+	is.NoErr(err)
+	is.Equal(n, 10)
+	is.Equal(string(w.data), "abcdefghij")
+}
+
+func TestDownloadFileResume(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "stow-download")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "item")
+
+	item := &rangedItem{dataItem: dataItem{testItem: testItem{name: "item"}, data: []byte("abcdefghij"), etag: "1"}, failStart: 6}
+	options := &stow.DownloadOptions{Transfer: stow.TransferOptions{PartSize: 3, Concurrency: 1}}
+	_, err = stow.DownloadFile(item, path, options)
+	is.Err(err)
+	is.Equal(item.takeOpened(), []int{0, 3})
+	_, err = os.Stat(path + ".download")
+	is.NoErr(err)
+
+	// a range that was changed on disk is fetched again
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	is.NoErr(err)
+	_, err = f.WriteAt([]byte("X"), 4)
+	is.NoErr(err)
+	is.NoErr(f.Close())
+
+	item.failStart = -1
+	n, err := stow.DownloadFile(item, path, options)
+	is.NoErr(err)
+	is.Equal(n, 10)
+	is.Equal(item.takeOpened(), []int{3, 6, 9})
+	b, err := ioutil.ReadFile(path)
+	is.NoErr(err)
+	is.Equal(string(b), "abcdefghij")
+	_, err = os.Stat(path + ".download")
+	is.True(os.IsNotExist(err))
+}
+
+func TestDownloadStartsOver(t *testing.T) {
+	is := is.New(t)
+
+	item := &rangedItem{dataItem: dataItem{testItem: testItem{name: "item"}, data: []byte("abcdefghij"), etag: "1"}, failStart: 6}
+	store := stow.NewMemoryCheckpointStore()
+	options := &stow.DownloadOptions{Transfer: stow.TransferOptions{PartSize: 3, Concurrency: 1}, Store: store}
+	var w memoryWriterAt
+	_, err := stow.Download(item, &w, options)
+	is.Err(err)
+	is.Equal(item.takeOpened(), []int{0, 3})
+
+	// the item changed, so what was written is of no use
+	item.data = []byte("ABCDEFGHIJKL")
+	item.etag = "2"
+	item.failStart = -1
+	n, err := stow.Download(item, &w, options)
+	is.NoErr(err)
+	is.Equal(n, 12)
+	is.Equal(item.takeOpened(), []int{0, 3, 6, 9})
+	is.Equal(string(w.data), "ABCDEFGHIJKL")
+	_, err = store.Load(item.URL().String())
+	is.Equal(err, stow.ErrNotFound)
+}
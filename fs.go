@@ -0,0 +1,332 @@
+package stow
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FS gets a read-only file system of the Items of the Container, with
+// slashes in their names as directories. It implements fs.ReadDirFS,
+// fs.StatFS and fs.ReadFileFS, listing directories a level at a time for
+// containers that are DelimiterListers.
+// The files it opens can seek: reads after a seek use OpenRange for
+// ItemRangers, and reopen the Item otherwise.
+func FS(container Container) fs.FS {
+	return &containerFS{container: container}
+}
+
+// HTTPFileSystem gets the Items of the Container as an http.FileSystem, to
+// serve them with http.FileServer.
+func HTTPFileSystem(container Container) http.FileSystem {
+	return http.FS(FS(container))
+}
+
+type containerFS struct {
+	container Container
+}
+
+var (
+	_ fs.ReadDirFS  = (*containerFS)(nil)
+	_ fs.StatFS     = (*containerFS)(nil)
+	_ fs.ReadFileFS = (*containerFS)(nil)
+)
+
+func (c *containerFS) Open(name string) (fs.File, error) {
+	item, info, err := c.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return &dirFile{fs: c, name: name, info: info}, nil
+	}
+	return &itemFile{item: item, info: info}, nil
+}
+
+func (c *containerFS) Stat(name string) (fs.FileInfo, error) {
+	_, info, err := c.stat("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (c *containerFS) ReadFile(name string) ([]byte, error) {
+	item, _, err := c.stat("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: errIsDir}
+	}
+	rc, err := item.Open()
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+func (c *containerFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	entries, err := c.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	return entries, nil
+}
+
+// errIsDir is returned when reading a directory as a file.
+var errIsDir = errors.New("is a directory")
+
+// stat gets the Item with the name, or a nil Item for a directory.
+func (c *containerFS) stat(op, name string) (Item, *fileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return nil, dirInfo(name), nil
+	}
+	item, err := c.container.Item(name)
+	if err == nil {
+		info, err := itemInfo(item)
+		if err != nil {
+			return nil, nil, &fs.PathError{Op: op, Path: name, Err: err}
+		}
+		return item, info, nil
+	}
+	// a name that is not an Item is a directory if Items start with it
+	ok, dirErr := c.isDir(name)
+	if dirErr != nil {
+		return nil, nil, &fs.PathError{Op: op, Path: name, Err: dirErr}
+	}
+	if ok {
+		return nil, dirInfo(name), nil
+	}
+	if err == ErrNotFound {
+		err = fs.ErrNotExist
+	}
+	return nil, nil, &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+func (c *containerFS) isDir(name string) (bool, error) {
+	if d, ok := c.container.(DelimiterLister); ok {
+		items, prefixes, _, err := d.ItemsDelimited(name+"/", "/", CursorStart, 1)
+		return len(items) > 0 || len(prefixes) > 0, err
+	}
+	items, _, err := c.container.Items(name+"/", CursorStart, 1)
+	return len(items) > 0, err
+}
+
+// readDir lists the directory, sorted by name.
+func (c *containerFS) readDir(name string) ([]fs.DirEntry, error) {
+	prefix := name + "/"
+	if name == "." {
+		prefix = NoPrefix
+	}
+	items, prefixes, err := ListDelimited(c.container, prefix, "/")
+	if err != nil {
+		return nil, err
+	}
+	var entries []fs.DirEntry
+	for _, item := range items {
+		// placeholders of empty directories have no name of their own
+		if item.Name() == prefix {
+			continue
+		}
+		info, err := itemInfo(item)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, info)
+	}
+	for _, p := range prefixes {
+		if strings.HasSuffix(p, "//") {
+			continue
+		}
+		entries = append(entries, dirInfo(strings.TrimSuffix(p, "/")))
+	}
+	if len(entries) == 0 && name != "." {
+		return nil, fs.ErrNotExist
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// fileInfo describes an Item or a directory, as an fs.FileInfo and an
+// fs.DirEntry.
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func itemInfo(item Item) (*fileInfo, error) {
+	size, err := item.Size()
+	if err != nil {
+		return nil, err
+	}
+	modTime, err := item.LastMod()
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{name: path.Base(item.Name()), size: size, modTime: modTime}, nil
+}
+
+func dirInfo(name string) *fileInfo {
+	return &fileInfo{name: path.Base(name), dir: true}
+}
+
+func (i *fileInfo) Name() string               { return i.name }
+func (i *fileInfo) Size() int64                { return i.size }
+func (i *fileInfo) ModTime() time.Time         { return i.modTime }
+func (i *fileInfo) IsDir() bool                { return i.dir }
+func (i *fileInfo) Sys() interface{}           { return nil }
+func (i *fileInfo) Type() fs.FileMode          { return i.Mode().Type() }
+func (i *fileInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+func (i *fileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// itemFile reads an Item, opening it on the first read after a seek.
+type itemFile struct {
+	item Item
+	info *fileInfo
+	// offset is where the next read starts, and pos is where rc is.
+	offset int64
+	rc     io.ReadCloser
+	pos    int64
+}
+
+func (f *itemFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *itemFile) Read(p []byte) (int, error) {
+	if f.offset >= f.info.size {
+		return 0, io.EOF
+	}
+	if err := f.position(); err != nil {
+		return 0, err
+	}
+	n, err := f.rc.Read(p)
+	f.offset += int64(n)
+	f.pos += int64(n)
+	return n, err
+}
+
+// position gets rc to the offset, skipping forward within the contents
+// already open or opening them again at the offset.
+func (f *itemFile) position() error {
+	if f.rc != nil && f.pos == f.offset {
+		return nil
+	}
+	ranger, isRanger := f.item.(ItemRanger)
+	if f.rc != nil && f.pos < f.offset && (!isRanger || f.offset-f.pos <= seekerBlockSize) {
+		n, err := io.CopyN(ioutil.Discard, f.rc, f.offset-f.pos)
+		f.pos += n
+		return err
+	}
+	if f.rc != nil {
+		f.rc.Close()
+		f.rc = nil
+	}
+	var err error
+	if isRanger && f.offset > 0 {
+		f.rc, err = ranger.OpenRange(uint64(f.offset), uint64(f.info.size-1))
+		f.pos = f.offset
+		return err
+	}
+	if f.rc, err = f.item.Open(); err != nil {
+		return err
+	}
+	f.pos = 0
+	return f.position()
+}
+
+func (f *itemFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errNegativeOffset
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *itemFile) Close() error {
+	if f.rc == nil {
+		return nil
+	}
+	err := f.rc.Close()
+	f.rc = nil
+	return err
+}
+
+// dirFile lists a directory, reading it on the first call to ReadDir.
+type dirFile struct {
+	fs      *containerFS
+	name    string
+	info    *fileInfo
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *dirFile) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errIsDir}
+}
+
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fs.readDir(d.name)
+		if err != nil && err != fs.ErrNotExist {
+			return nil, &fs.PathError{Op: "readdir", Path: d.name, Err: err}
+		}
+		d.entries = entries
+		d.read = true
+	}
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+func (d *dirFile) Close() error {
+	return nil
+}
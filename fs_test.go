@@ -0,0 +1,163 @@
+package stow_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+var fsFiles = map[string]string{
+	"index.html":       "<h1>home</h1>",
+	"css/main.css":     "body { margin: 0 }",
+	"js/app/app.js":    "console.log('app')",
+	"js/app/vendor.js": "",
+}
+
+func newFSItems(ranged bool) []stow.Item {
+	var items []stow.Item
+	for name, content := range fsFiles {
+		item := dataItem{testItem: testItem{name: name}, data: []byte(content)}
+		if ranged {
+			items = append(items, &rangedItem{dataItem: item, failStart: -1})
+		} else {
+			items = append(items, &item)
+		}
+	}
+	return items
+}
+
+// fsContainer is a usageContainer that gets Items by name.
+type fsContainer struct {
+	*usageContainer
+}
+
+func (c fsContainer) Item(id string) (stow.Item, error) {
+	return findItem(c.items, id)
+}
+
+// delimitedFSContainer is an fsContainer that lists a level at a time.
+type delimitedFSContainer struct {
+	delimitedContainer
+}
+
+func (c delimitedFSContainer) Item(id string) (stow.Item, error) {
+	return findItem(c.items, id)
+}
+
+func findItem(items []stow.Item, name string) (stow.Item, error) {
+	for _, item := range items {
+		if item.Name() == name {
+			return item, nil
+		}
+	}
+	return nil, stow.ErrNotFound
+}
+
+func TestFS(t *testing.T) {
+	names := []string{"index.html", "css/main.css", "js/app/app.js", "js/app/vendor.js"}
+	for name, container := range map[string]stow.Container{
+		"plain":     fsContainer{&usageContainer{items: newFSItems(false)}},
+		"ranged":    fsContainer{&usageContainer{items: newFSItems(true)}},
+		"delimited": delimitedFSContainer{delimitedContainer{&usageContainer{items: newFSItems(true)}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := fstest.TestFS(stow.FS(container), names...); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestFSErrors(t *testing.T) {
+	is := is.New(t)
+	fsys := stow.FS(fsContainer{&usageContainer{items: newFSItems(false)}})
+
+	_, err := fs.Stat(fsys, "missing.html")
+	is.True(errors.Is(err, fs.ErrNotExist))
+	_, err = fs.ReadDir(fsys, "css/missing")
+	is.True(errors.Is(err, fs.ErrNotExist))
+	_, err = fsys.Open("/index.html")
+	is.True(errors.Is(err, fs.ErrInvalid))
+	_, err = fs.ReadFile(fsys, "js")
+	is.Err(err)
+
+	info, err := fs.Stat(fsys, "js/app")
+	is.NoErr(err)
+	is.True(info.IsDir())
+	b, err := fs.ReadFile(fsys, "css/main.css")
+	is.NoErr(err)
+	is.Equal(string(b), fsFiles["css/main.css"])
+}
+
+func TestFSSeek(t *testing.T) {
+	is := is.New(t)
+	items := newFSItems(true)
+	fsys := stow.FS(fsContainer{&usageContainer{items: items}})
+	item, err := findItem(items, "css/main.css")
+	is.NoErr(err)
+	ranged := item.(*rangedItem)
+
+	f, err := fsys.Open("css/main.css")
+	is.NoErr(err)
+	defer f.Close()
+	seeker := f.(io.ReadSeeker)
+	_, err = seeker.Seek(7, io.SeekStart)
+	is.NoErr(err)
+	b, err := ioutil.ReadAll(seeker)
+	is.NoErr(err)
+	is.Equal(string(b), "margin: 0 }")
+	is.Equal(ranged.takeOpened(), []int{7})
+
+	// a short seek forward skips what is already open
+	_, err = seeker.Seek(0, io.SeekStart)
+	is.NoErr(err)
+	b = make([]byte, 4)
+	_, err = io.ReadFull(seeker, b)
+	is.NoErr(err)
+	is.Equal(string(b), "body")
+	_, err = seeker.Seek(3, io.SeekCurrent)
+	is.NoErr(err)
+	_, err = io.ReadFull(seeker, b)
+	is.NoErr(err)
+	is.Equal(string(b), "marg")
+	is.Equal(len(ranged.takeOpened()), 0)
+}
+
+func TestHTTPFileSystem(t *testing.T) {
+	is := is.New(t)
+	container := fsContainer{&usageContainer{items: newFSItems(true)}}
+	srv := httptest.NewServer(http.FileServer(stow.HTTPFileSystem(container)))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/")
+	is.NoErr(err)
+	b, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	is.NoErr(err)
+	is.Equal(res.StatusCode, http.StatusOK)
+	is.Equal(string(b), fsFiles["index.html"])
+
+	req, err := http.NewRequest("GET", srv.URL+"/css/main.css", nil)
+	is.NoErr(err)
+	req.Header.Set("Range", "bytes=5-8")
+	res, err = http.DefaultClient.Do(req)
+	is.NoErr(err)
+	b, err = ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	is.NoErr(err)
+	is.Equal(res.StatusCode, http.StatusPartialContent)
+	is.Equal(string(b), "{ ma")
+
+	res, err = http.Get(srv.URL + "/missing.css")
+	is.NoErr(err)
+	res.Body.Close()
+	is.Equal(res.StatusCode, http.StatusNotFound)
+}
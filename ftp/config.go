@@ -0,0 +1,179 @@
+package ftp
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+// Kind represents the name of the location/storage type.
+const Kind = "ftp"
+
+const (
+	// ConfigHost is the hostname or IP address to connect to.
+	ConfigHost = "host"
+
+	// ConfigPort is the numeric port number the FTP server is listening on.
+	// If not set, the standard port 21 is used.
+	ConfigPort = "port"
+
+	// ConfigUsername is the username of the user to connect as. If not set,
+	// the anonymous user is used.
+	ConfigUsername = "username"
+
+	// ConfigPassword is the password use to authenticate with.
+	ConfigPassword = "password"
+
+	// ConfigBasePath is the path to the root folder on the remote server. It can be
+	// relative to the directory the user starts in, or an absolute path. If not set,
+	// or set to an empty string, the directory the user starts in will be used.
+	ConfigBasePath = "base_path"
+
+	// ConfigTLS enables explicit FTPS when set to "true". The connection is
+	// upgraded with AUTH TLS before logging in, and data connections are
+	// protected too.
+	ConfigTLS = "tls"
+
+	// ConfigTLSInsecureSkipVerify disables the verification of the
+	// certificate of the server when set to "true". Only use it with
+	// servers with self-signed certificates on trusted networks.
+	ConfigTLSInsecureSkipVerify = "tls_insecure_skip_verify"
+
+	// ConfigTLSServerName is the name the certificate of the server is
+	// verified against. If not set, the host is used.
+	ConfigTLSServerName = "tls_server_name"
+
+	// ConfigMaxConnections is the number of control connections the
+	// location keeps open to the server at most. Operations wait for a
+	// connection when they are all in use. If not set, 4 are used.
+	ConfigMaxConnections = "max_connections"
+)
+
+const (
+	defaultPort           = 21
+	defaultMaxConnections = 4
+	dialTimeout           = 30 * time.Second
+)
+
+type conf struct {
+	host           string
+	port           int
+	username       string
+	password       string
+	basePath       string
+	tls            *tls.Config
+	maxConnections int
+}
+
+func (c conf) Host() string {
+	return fmt.Sprintf("%s:%d", c.host, c.port)
+}
+
+func parseConfig(config stow.Config) (*conf, error) {
+	var c conf
+	var ok bool
+
+	c.host, ok = config.Config(ConfigHost)
+	if !ok || c.host == "" {
+		return nil, errors.New("invalid hostname")
+	}
+
+	c.port = defaultPort
+	if port, ok := config.Config(ConfigPort); ok && port != "" {
+		var err error
+		c.port, err = strconv.Atoi(port)
+		if err != nil || c.port < 1 {
+			return nil, errors.New("invalid port configuration")
+		}
+	}
+
+	c.username, ok = config.Config(ConfigUsername)
+	if !ok || c.username == "" {
+		c.username = "anonymous"
+	}
+	c.password, _ = config.Config(ConfigPassword)
+
+	c.basePath, ok = config.Config(ConfigBasePath)
+	if !ok || c.basePath == "" {
+		c.basePath = "."
+	}
+
+	if enabled, err := configBool(config, ConfigTLS); err != nil {
+		return nil, err
+	} else if enabled {
+		c.tls = &tls.Config{
+			ServerName: c.host,
+			// data connections resume the session of the control
+			// connection, which many servers require
+			ClientSessionCache: tls.NewLRUClientSessionCache(0),
+		}
+		if name, ok := config.Config(ConfigTLSServerName); ok && name != "" {
+			c.tls.ServerName = name
+		}
+		if c.tls.InsecureSkipVerify, err = configBool(config, ConfigTLSInsecureSkipVerify); err != nil {
+			return nil, err
+		}
+	}
+
+	c.maxConnections = defaultMaxConnections
+	if max, ok := config.Config(ConfigMaxConnections); ok && max != "" {
+		var err error
+		c.maxConnections, err = strconv.Atoi(max)
+		if err != nil || c.maxConnections < 1 {
+			return nil, errors.New("invalid max_connections configuration")
+		}
+	}
+
+	return &c, nil
+}
+
+func configBool(config stow.Config, key string) (bool, error) {
+	value, ok := config.Config(key)
+	if !ok || value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.Errorf("invalid %s configuration", key)
+	}
+	return b, nil
+}
+
+func init() {
+	validatefn := func(config stow.Config) error {
+		_, err := parseConfig(config)
+		return err
+	}
+	makefn := func(config stow.Config) (stow.Location, error) {
+		c, err := parseConfig(config)
+		if err != nil {
+			return nil, err
+		}
+
+		loc := &location{
+			config: c,
+			pool:   newPool(c),
+		}
+
+		// Connect to the remote server and log in, so that bad configuration
+		// fails when dialing. The connection is kept for later use.
+		cn, err := loc.pool.get()
+		if err != nil {
+			return nil, errors.Wrap(err, "ftp connection")
+		}
+		loc.pool.put(cn)
+
+		return loc, nil
+	}
+
+	kindfn := func(u *url.URL) bool {
+		return u.Scheme == Kind
+	}
+
+	stow.Register(Kind, makefn, kindfn, validatefn)
+}
@@ -0,0 +1,462 @@
+package ftp
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+// conn is a control connection to an FTP server, logged in and set to
+// binary transfers.
+type conn struct {
+	config  *conf
+	netConn net.Conn
+	text    *textproto.Conn
+
+	// mlst is whether the server lists with MLST and MLSD, which give
+	// facts in a standard form, rather than LIST.
+	mlst bool
+	// noEPSV is whether the server refused EPSV, so PASV is used.
+	noEPSV bool
+	// broken is whether the connection cannot be used any more.
+	broken   bool
+	lastUsed time.Time
+}
+
+// entry is a file or directory on the server.
+type entry struct {
+	name    string
+	dir     bool
+	size    int64
+	modTime time.Time
+}
+
+func dial(c *conf) (*conn, error) {
+	nc, err := net.DialTimeout("tcp", c.Host(), dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{
+		config:  c,
+		netConn: nc,
+		text:    textproto.NewConn(nc),
+	}
+	if _, _, err := cn.text.ReadResponse(2); err != nil {
+		cn.broken = true
+		cn.close()
+		return nil, errors.Wrap(err, "greeting")
+	}
+	if err := cn.login(); err != nil {
+		cn.broken = true
+		cn.close()
+		return nil, err
+	}
+	cn.lastUsed = time.Now()
+	return cn, nil
+}
+
+func (cn *conn) login() error {
+	if cn.config.tls != nil {
+		if _, _, err := cn.cmd(234, "AUTH TLS"); err != nil {
+			return errors.Wrap(err, "AUTH TLS")
+		}
+		tc := tls.Client(cn.netConn, cn.config.tls)
+		if err := tc.Handshake(); err != nil {
+			return errors.Wrap(err, "TLS handshake")
+		}
+		cn.netConn = tc
+		cn.text = textproto.NewConn(tc)
+	}
+
+	code, _, err := cn.cmd(0, "USER %s", cn.config.username)
+	if err == nil && code == 331 {
+		code, _, err = cn.cmd(0, "PASS %s", cn.config.password)
+	}
+	if err == nil && code != 230 && code != 202 {
+		err = errors.Errorf("login failed with reply %d", code)
+	}
+	if err != nil {
+		return errors.Wrap(err, "login")
+	}
+
+	if cn.config.tls != nil {
+		if _, _, err := cn.cmd(200, "PBSZ 0"); err != nil {
+			return errors.Wrap(err, "PBSZ")
+		}
+		if _, _, err := cn.cmd(200, "PROT P"); err != nil {
+			return errors.Wrap(err, "PROT")
+		}
+	}
+	if _, _, err := cn.cmd(200, "TYPE I"); err != nil {
+		return errors.Wrap(err, "TYPE")
+	}
+
+	// servers that do not know FEAT only get plain listings
+	code, msg, err := cn.cmd(0, "FEAT")
+	if err != nil {
+		return errors.Wrap(err, "FEAT")
+	}
+	if code == 211 {
+		for _, line := range strings.Split(msg, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) > 0 && strings.EqualFold(fields[0], "MLST") {
+				cn.mlst = true
+			}
+		}
+	}
+	return nil
+}
+
+// cmd sends a command and reads the reply, which is an error unless its
+// code is the expected one. A code of 0 expects any reply, and a code
+// below 10 any reply in its class. Replies of 550 give
+// stow.ErrNotFound.
+func (cn *conn) cmd(expect int, format string, args ...interface{}) (int, string, error) {
+	line := fmt.Sprintf(format, args...)
+	if strings.ContainsAny(line, "\r\n") {
+		return 0, "", errors.New("line breaks are not allowed in names")
+	}
+	if _, err := cn.text.Cmd("%s", line); err != nil {
+		cn.broken = true
+		return 0, "", err
+	}
+	return cn.reply(expect)
+}
+
+func (cn *conn) reply(expect int) (int, string, error) {
+	code, msg, err := cn.text.ReadResponse(expect)
+	cn.lastUsed = time.Now()
+	if err != nil {
+		if e, ok := err.(*textproto.Error); ok {
+			switch {
+			case e.Code == 550:
+				return code, msg, stow.ErrNotFound
+			case e.Code == 421:
+				// the server is closing the connection
+				cn.broken = true
+			}
+		} else {
+			cn.broken = true
+		}
+	}
+	return code, msg, err
+}
+
+// dataConn opens a passive data connection. The address in replies to
+// PASV is ignored for the host of the control connection, as servers
+// behind NAT often give private addresses.
+func (cn *conn) dataConn() (net.Conn, error) {
+	host, _, err := net.SplitHostPort(cn.netConn.RemoteAddr().String())
+	if err != nil {
+		return nil, err
+	}
+	port := -1
+	if !cn.noEPSV {
+		_, msg, err := cn.cmd(229, "EPSV")
+		if err == nil {
+			port = parseEPSV(msg)
+		} else if _, ok := err.(*textproto.Error); ok {
+			cn.noEPSV = true
+		} else {
+			return nil, err
+		}
+	}
+	if port < 0 {
+		_, msg, err := cn.cmd(227, "PASV")
+		if err != nil {
+			return nil, err
+		}
+		port = parsePASV(msg)
+	}
+	if port < 0 {
+		cn.broken = true
+		return nil, errors.New("bad passive mode reply")
+	}
+	dc, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if cn.config.tls != nil {
+		dc = tls.Client(dc, cn.config.tls)
+	}
+	return dc, nil
+}
+
+// parseEPSV gets the port of a reply like
+// 229 Entering Extended Passive Mode (|||6446|)
+func parseEPSV(msg string) int {
+	start := strings.Index(msg, "(")
+	end := strings.LastIndex(msg, ")")
+	if start < 0 || end < start+5 {
+		return -1
+	}
+	fields := strings.Split(msg[start+1:end], msg[start+1:start+2])
+	if len(fields) != 5 {
+		return -1
+	}
+	port, err := strconv.Atoi(fields[3])
+	if err != nil || port < 1 || port > 65535 {
+		return -1
+	}
+	return port
+}
+
+// parsePASV gets the port of a reply like
+// 227 Entering Passive Mode (192,168,1,2,25,46)
+func parsePASV(msg string) int {
+	start := strings.Index(msg, "(")
+	end := strings.LastIndex(msg, ")")
+	if start < 0 || end < start {
+		return -1
+	}
+	fields := strings.Split(msg[start+1:end], ",")
+	if len(fields) != 6 {
+		return -1
+	}
+	hi, err1 := strconv.Atoi(fields[4])
+	lo, err2 := strconv.Atoi(fields[5])
+	if err1 != nil || err2 != nil || hi < 0 || hi > 255 || lo < 0 || lo > 255 {
+		return -1
+	}
+	return hi<<8 | lo
+}
+
+// transfer opens a data connection and sends the command that uses it.
+// The transfer must be finished once the data connection is closed.
+func (cn *conn) transfer(format string, args ...interface{}) (net.Conn, error) {
+	dc, err := cn.dataConn()
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := cn.cmd(1, format, args...); err != nil {
+		dc.Close()
+		return nil, err
+	}
+	// the server takes the handshake once it has accepted the command,
+	// and empty transfers would otherwise skip it
+	if tc, ok := dc.(*tls.Conn); ok {
+		if err := tc.Handshake(); err != nil {
+			dc.Close()
+			cn.broken = true
+			return nil, err
+		}
+	}
+	return dc, nil
+}
+
+// finish reads the reply that ends a transfer.
+func (cn *conn) finish() error {
+	_, _, err := cn.reply(2)
+	return err
+}
+
+// list lists the directory with MLSD, or LIST for servers without it.
+func (cn *conn) list(dir string) ([]entry, error) {
+	command := "LIST %s"
+	if cn.mlst {
+		command = "MLSD %s"
+	}
+	dc, err := cn.transfer(command, dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []entry
+	s := bufio.NewScanner(dc)
+	for s.Scan() {
+		var e entry
+		var ok bool
+		if cn.mlst {
+			e, ok = parseMLST(s.Text())
+		} else {
+			e, ok = parseLIST(s.Text(), time.Now())
+		}
+		if ok {
+			entries = append(entries, e)
+		}
+	}
+	if err := s.Err(); err != nil {
+		dc.Close()
+		cn.broken = true
+		return nil, err
+	}
+	dc.Close()
+	if err := cn.finish(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// stat describes the file or directory at the path with MLST, or by
+// listing its directory for servers without it.
+func (cn *conn) stat(p string) (entry, error) {
+	if cn.mlst {
+		_, msg, err := cn.cmd(250, "MLST %s", p)
+		if err != nil {
+			return entry{}, err
+		}
+		// the facts are on the line that starts with a space
+		for _, line := range strings.Split(msg, "\n") {
+			if strings.HasPrefix(line, " ") {
+				if e, ok := parseMLST(strings.TrimPrefix(line, " ")); ok {
+					e.name = path.Base(p)
+					return e, nil
+				}
+			}
+		}
+		return entry{}, errors.Errorf("bad MLST reply for %q", p)
+	}
+	entries, err := cn.list(path.Dir(p))
+	if err != nil {
+		return entry{}, err
+	}
+	for _, e := range entries {
+		if e.name == path.Base(p) {
+			return e, nil
+		}
+	}
+	return entry{}, stow.ErrNotFound
+}
+
+// parseMLST parses the facts and name of a line of MLSD, such as
+// type=file;size=1024;modify=20200101120000; name
+// Entries for the directory itself and its parent are left out.
+func parseMLST(line string) (entry, bool) {
+	i := strings.Index(line, " ")
+	if i < 0 {
+		return entry{}, false
+	}
+	e := entry{name: path.Base(line[i+1:])}
+	for _, fact := range strings.Split(line[:i], ";") {
+		kv := strings.SplitN(fact, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch strings.ToLower(kv[0]) {
+		case "type":
+			switch strings.ToLower(kv[1]) {
+			case "cdir", "pdir":
+				return entry{}, false
+			case "dir":
+				e.dir = true
+			}
+		case "size":
+			e.size, _ = strconv.ParseInt(kv[1], 10, 64)
+		case "modify":
+			value := kv[1]
+			if j := strings.Index(value, "."); j >= 0 {
+				value = value[:j]
+			}
+			e.modTime, _ = time.Parse("20060102150405", value)
+		}
+	}
+	return e, e.name != "." && e.name != ".."
+}
+
+// parseLIST parses a line of LIST in the form of ls -l, such as
+// -rw-r--r--   1 owner group   1024 Jan  2 15:04 name
+// Times without a year are taken to be within the last year, and links
+// are listed as files.
+func parseLIST(line string, now time.Time) (entry, bool) {
+	// the name is what is after the eighth field, and can have spaces
+	rest := line
+	var fields []string
+	for len(fields) < 8 {
+		rest = strings.TrimLeft(rest, " ")
+		i := strings.Index(rest, " ")
+		if i < 0 {
+			return entry{}, false
+		}
+		fields = append(fields, rest[:i])
+		rest = rest[i:]
+	}
+	name := strings.TrimLeft(rest, " ")
+	if name == "" || len(fields[0]) < 10 {
+		return entry{}, false
+	}
+	e := entry{dir: fields[0][0] == 'd'}
+	if fields[0][0] == 'l' {
+		if i := strings.Index(name, " -> "); i >= 0 {
+			name = name[:i]
+		}
+	}
+	e.name = name
+	e.size, _ = strconv.ParseInt(fields[4], 10, 64)
+	stamp := fields[5] + " " + fields[6] + " " + fields[7]
+	if strings.Contains(fields[7], ":") {
+		t, err := time.Parse("Jan 2 15:04", stamp)
+		if err == nil {
+			e.modTime = t.AddDate(now.Year(), 0, 0)
+			if e.modTime.After(now.Add(24 * time.Hour)) {
+				e.modTime = e.modTime.AddDate(-1, 0, 0)
+			}
+		}
+	} else {
+		e.modTime, _ = time.Parse("Jan 2 2006", stamp)
+	}
+	return e, e.name != "." && e.name != ".."
+}
+
+// retrieve opens the file at the path from the offset.
+func (cn *conn) retrieve(p string, offset int64) (net.Conn, error) {
+	if offset > 0 {
+		if _, _, err := cn.cmd(350, "REST %d", offset); err != nil {
+			return nil, err
+		}
+	}
+	return cn.transfer("RETR %s", p)
+}
+
+// store writes the file at the path, giving the number of bytes written.
+func (cn *conn) store(p string, r io.Reader) (int64, error) {
+	dc, err := cn.transfer("STOR %s", p)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(dc, r)
+	if cerr := dc.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		cn.broken = true
+		return n, err
+	}
+	return n, cn.finish()
+}
+
+// mkdirAll makes the directory at the path, along with the directories
+// above it that are missing.
+func (cn *conn) mkdirAll(p string) error {
+	if p == "." || p == "/" {
+		return nil
+	}
+	if e, err := cn.stat(p); err == nil {
+		if !e.dir {
+			return errors.Errorf("%q is not a directory", p)
+		}
+		return nil
+	} else if err != stow.ErrNotFound {
+		return err
+	}
+	if err := cn.mkdirAll(path.Dir(p)); err != nil {
+		return err
+	}
+	_, _, err := cn.cmd(257, "MKD %s", p)
+	return err
+}
+
+func (cn *conn) close() error {
+	if !cn.broken {
+		cn.cmd(0, "QUIT")
+	}
+	return cn.text.Close()
+}
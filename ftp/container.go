@@ -0,0 +1,191 @@
+package ftp
+
+import (
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+type container struct {
+	name     string
+	location *location
+}
+
+// ID returns a string value which represents the name of the container.
+func (c *container) ID() string {
+	return c.name
+}
+
+// Name returns a string value which represents the name of the container.
+func (c *container) Name() string {
+	return c.name
+}
+
+// filePath gets the path on the server of the file with the name.
+func (c *container) filePath(name string) string {
+	return path.Join(c.location.dirPath(c.name), name)
+}
+
+// Item returns a stow.Item instance of a container based on the name of the
+// container and the file.
+func (c *container) Item(id string) (stow.Item, error) {
+	var e entry
+	err := c.location.pool.do(func(cn *conn) error {
+		var err error
+		e, err = cn.stat(c.filePath(id))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if e.dir {
+		return nil, stow.ErrNotFound
+	}
+	return c.newItem(id, e), nil
+}
+
+func (c *container) newItem(name string, e entry) *item {
+	return &item{
+		container: c,
+		path:      name,
+		size:      e.size,
+		modTime:   e.modTime,
+	}
+}
+
+// Items sends a request to retrieve a list of items that are prepended with
+// the prefix argument. The 'cursor' variable facilitates pagination.
+//
+// The directories of the container are listed one at a time, with MLSD
+// where the server has it, and only those that can hold names after the
+// cursor with the prefix are looked into.
+func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	w := walker{
+		container: c,
+		prefix:    prefix,
+		cursor:    cursor,
+		// one more item than asked for tells whether there are more
+		limit: count + 1,
+	}
+	err := c.location.pool.do(func(cn *conn) error {
+		return w.walk(cn, "")
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if len(w.items) > count {
+		return w.items[:count], w.items[count-1].Name(), nil
+	}
+	return w.items, "", nil
+}
+
+// walker collects the items of a container in the order of their names.
+type walker struct {
+	container *container
+	prefix    string
+	cursor    string
+	limit     int
+	items     []stow.Item
+}
+
+// walk collects the items in the directory, whose name is empty or ends
+// with a slash, until it has enough.
+func (w *walker) walk(cn *conn, dir string) error {
+	entries, err := cn.list(w.container.filePath(dir))
+	if err != nil {
+		return err
+	}
+
+	// a directory sorts as its name with a slash, so that walking them in
+	// order keeps the items in the order of their full names
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = dir + e.name
+		if e.dir {
+			names[i] += "/"
+		}
+	}
+	sort.Sort(byName{names, entries})
+
+	for i, e := range entries {
+		name := names[i]
+		if e.dir {
+			if !w.within(name) {
+				continue
+			}
+			if err := w.walk(cn, name); err != nil {
+				return err
+			}
+		} else if name > w.cursor && strings.HasPrefix(name, w.prefix) {
+			w.items = append(w.items, w.container.newItem(name, e))
+		}
+		if len(w.items) == w.limit {
+			return nil
+		}
+	}
+	return nil
+}
+
+// within gets whether the directory can hold names after the cursor with
+// the prefix.
+func (w *walker) within(dir string) bool {
+	if !strings.HasPrefix(dir, w.prefix) && !strings.HasPrefix(w.prefix, dir) {
+		return false
+	}
+	// a cursor past the directory, and not in it, is past all it holds
+	return w.cursor < dir || strings.HasPrefix(w.cursor, dir)
+}
+
+// byName sorts entries by their names.
+type byName struct {
+	names   []string
+	entries []entry
+}
+
+func (s byName) Len() int           { return len(s.names) }
+func (s byName) Less(i, j int) bool { return s.names[i] < s.names[j] }
+func (s byName) Swap(i, j int) {
+	s.names[i], s.names[j] = s.names[j], s.names[i]
+	s.entries[i], s.entries[j] = s.entries[j], s.entries[i]
+}
+
+// RemoveItem removes a file from the remote server.
+func (c *container) RemoveItem(id string) error {
+	return c.location.pool.do(func(cn *conn) error {
+		_, _, err := cn.cmd(250, "DELE %s", c.filePath(id))
+		return err
+	})
+}
+
+// Put sends a request to upload content to the container, making the
+// directories the file is in first.
+func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	if len(metadata) > 0 {
+		return nil, stow.NotSupported("metadata")
+	}
+
+	p := c.filePath(name)
+	var e entry
+	err := c.location.pool.do(func(cn *conn) error {
+		if err := cn.mkdirAll(path.Dir(p)); err != nil {
+			return err
+		}
+		n, err := cn.store(p, r)
+		if err != nil {
+			return err
+		}
+		if n != size {
+			return errors.New("bad size")
+		}
+		e, err = cn.stat(p)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.newItem(name, e), nil
+}
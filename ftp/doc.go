@@ -0,0 +1,8 @@
+/*
+Package ftp provides an abstraction of an FTP server, including explicit FTPS. A Stow Container is a directory under the base path, and a Stow Item is a file within it, at any depth.
+
+stow.Dial requires the Kind ("ftp") and a stow.Config instance with a key of ftp.ConfigHost, along with ftp.ConfigUsername and ftp.ConfigPassword unless the server takes anonymous logins. Setting ftp.ConfigTLS to "true" upgrades the connection with AUTH TLS before logging in and protects the data connections too.
+
+The Location keeps a pool of logged in control connections, at most ftp.ConfigMaxConnections of them, as FTP runs one command at a time on each. Directories are listed with MLSD where the server has it, and with LIST otherwise. Items are put with STOR after making the directories they are in, and opened with RETR, with REST for ranges. Putting Items with metadata gives an error satisfying stow.IsNotSupported.
+*/
+package ftp
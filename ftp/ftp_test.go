@@ -0,0 +1,219 @@
+package ftp
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+	"github.com/graymeta/stow/test"
+)
+
+// serve serves a new directory, with a base path in it, over FTP.
+func serve(t *testing.T, useTLS, mlst, epsv bool) (*testServer, stow.ConfigMap, func()) {
+	dir, err := ioutil.TempDir("", "stow-ftp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(dir+"/base", 0755); err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer(t, dir, useTLS, mlst, epsv)
+	config := stow.ConfigMap{
+		ConfigHost:     "127.0.0.1",
+		ConfigPort:     server.port(),
+		ConfigUsername: "user",
+		ConfigPassword: "secret",
+		ConfigBasePath: "/base",
+	}
+	if useTLS {
+		config[ConfigTLS] = "true"
+		config[ConfigTLSInsecureSkipVerify] = "true"
+	}
+	return server, config, func() {
+		server.close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestStow(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		tls, mlst, epsv bool
+	}{
+		{"mlsd", false, true, true},
+		{"list_pasv", false, false, false},
+		{"ftps", true, true, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, config, done := serve(t, tt.tls, tt.mlst, tt.epsv)
+			defer done()
+			test.All(t, Kind, config)
+		})
+	}
+}
+
+func TestItems(t *testing.T) {
+	is := is.New(t)
+	_, config, done := serve(t, false, true, true)
+	defer done()
+	location, err := stow.Dial(Kind, config)
+	is.NoErr(err)
+	defer location.Close()
+
+	c, err := location.CreateContainer("walk")
+	is.NoErr(err)
+	want := []string{"a-b", "a/b/c", "a/b/d", "a/e", "a0", "b", "z/y/x"}
+	for _, name := range want {
+		_, err := c.Put(name, strings.NewReader(name), int64(len(name)), nil)
+		is.NoErr(err)
+	}
+
+	// paging with any count gets every item once, in order
+	for count := 1; count <= len(want)+1; count++ {
+		var got []string
+		cursor := stow.CursorStart
+		for {
+			items, next, err := c.Items(stow.NoPrefix, cursor, count)
+			is.NoErr(err)
+			is.True(len(items) <= count)
+			for _, item := range items {
+				got = append(got, item.Name())
+			}
+			if stow.IsCursorEnd(next) {
+				break
+			}
+			cursor = next
+		}
+		is.Equal(got, want)
+	}
+
+	items, _, err := c.Items("a/b", stow.CursorStart, 10)
+	is.NoErr(err)
+	is.Equal(len(items), 2)
+	is.Equal(items[0].Name(), "a/b/c")
+
+	_, err = c.Item("a/b")
+	is.Equal(err, stow.ErrNotFound)
+
+	_, err = c.Put("meta", strings.NewReader("x"), 1, map[string]interface{}{"a": "b"})
+	is.True(stow.IsNotSupported(err))
+}
+
+func TestOpenRange(t *testing.T) {
+	is := is.New(t)
+	server, config, done := serve(t, false, true, true)
+	defer done()
+	location, err := stow.Dial(Kind, config)
+	is.NoErr(err)
+	defer location.Close()
+
+	c, err := location.CreateContainer("ranges")
+	is.NoErr(err)
+	item, err := c.Put("item", strings.NewReader("0123456789"), 10, nil)
+	is.NoErr(err)
+
+	rc, err := item.(stow.ItemRanger).OpenRange(4, 6)
+	is.NoErr(err)
+	b, err := ioutil.ReadAll(rc)
+	is.NoErr(err)
+	is.NoErr(rc.Close())
+	is.Equal(string(b), "456")
+
+	// only the range is asked for
+	var rest bool
+	for _, command := range server.sent() {
+		if command == "REST 4" {
+			rest = true
+		}
+	}
+	is.True(rest)
+
+	// the location still works after a transfer that was cut short
+	rc, err = item.Open()
+	is.NoErr(err)
+	b, err = ioutil.ReadAll(rc)
+	is.NoErr(err)
+	is.NoErr(rc.Close())
+	is.Equal(string(b), "0123456789")
+}
+
+func TestPool(t *testing.T) {
+	is := is.New(t)
+	_, config, done := serve(t, false, true, true)
+	defer done()
+	config[ConfigMaxConnections] = "1"
+	location, err := stow.Dial(Kind, config)
+	is.NoErr(err)
+	defer location.Close()
+
+	c, err := location.CreateContainer("pool")
+	is.NoErr(err)
+	item, err := c.Put("item", strings.NewReader("item"), 4, nil)
+	is.NoErr(err)
+
+	// the only connection is held by the open item until it is closed
+	rc, err := item.Open()
+	is.NoErr(err)
+	listed := make(chan error)
+	go func() {
+		_, _, err := c.Items(stow.NoPrefix, stow.CursorStart, 10)
+		listed <- err
+	}()
+	select {
+	case <-listed:
+		is.Fail("listed while the connection was in use")
+	case <-time.After(50 * time.Millisecond):
+	}
+	_, err = ioutil.ReadAll(rc)
+	is.NoErr(err)
+	is.NoErr(rc.Close())
+	is.NoErr(<-listed)
+}
+
+func TestLogin(t *testing.T) {
+	is := is.New(t)
+	_, config, done := serve(t, false, true, true)
+	defer done()
+
+	config[ConfigPassword] = "wrong"
+	_, err := stow.Dial(Kind, config)
+	is.Err(err)
+
+	is.Err(stow.Validate(Kind, stow.ConfigMap{}))
+	is.Err(stow.Validate(Kind, stow.ConfigMap{ConfigHost: "example.com", ConfigPort: "x"}))
+	is.Err(stow.Validate(Kind, stow.ConfigMap{ConfigHost: "example.com", ConfigTLS: "maybe"}))
+	is.NoErr(stow.Validate(Kind, stow.ConfigMap{ConfigHost: "example.com"}))
+}
+
+func TestParseLIST(t *testing.T) {
+	is := is.New(t)
+	now := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	e, ok := parseLIST("-rw-r--r--   1 owner group   1024 Feb  2 15:04 the item", now)
+	is.True(ok)
+	is.Equal(e.name, "the item")
+	is.Equal(e.size, 1024)
+	is.False(e.dir)
+	is.Equal(e.modTime, time.Date(2020, 2, 2, 15, 4, 0, 0, time.UTC))
+
+	// times without a year are within the last year
+	e, ok = parseLIST("-rw-r--r--   1 owner group   1 Dec 24 10:00 x", now)
+	is.True(ok)
+	is.Equal(e.modTime.Year(), 2019)
+
+	e, ok = parseLIST("drwxr-xr-x   2 owner group   4096 Jan  2  2006 dir", now)
+	is.True(ok)
+	is.True(e.dir)
+	is.Equal(e.modTime, time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	e, ok = parseLIST("lrwxrwxrwx   1 owner group   4 Jan  2  2006 link -> target", now)
+	is.True(ok)
+	is.Equal(e.name, "link")
+
+	_, ok = parseLIST("total 8", now)
+	is.False(ok)
+}
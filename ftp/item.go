@@ -0,0 +1,145 @@
+package ftp
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/graymeta/stow"
+)
+
+var _ stow.ItemRanger = (*item)(nil)
+
+type item struct {
+	container *container
+	path      string
+	size      int64
+	modTime   time.Time
+}
+
+// ID returns a string value that represents the name of a file.
+func (i *item) ID() string {
+	return i.path
+}
+
+// Name returns a string value that represents the name of the file.
+func (i *item) Name() string {
+	return i.path
+}
+
+// Size returns the size of an item in bytes.
+func (i *item) Size() (int64, error) {
+	return i.size, nil
+}
+
+// URL returns a formatted string identifying this asset.
+// Format is: ftp://<username>@<host>:<port>/<container>/<path to file>
+func (i *item) URL() *url.URL {
+	genericURL := fmt.Sprintf("/%s/%s", i.container.Name(), i.Name())
+	return &url.URL{
+		Scheme: Kind,
+		User:   url.User(i.container.location.config.username),
+		Path:   genericURL,
+		Host:   i.container.location.config.Host(),
+	}
+}
+
+// Open retrieves the contents of the file. The connection it is read over
+// is kept from other operations until it is closed.
+func (i *item) Open() (io.ReadCloser, error) {
+	return i.open(0, -1)
+}
+
+// OpenRange opens the file for reading starting at byte start and ending
+// at byte end, or at the end of the file when it is shorter. The start is
+// sent with REST, so only the bytes of the range are transferred.
+func (i *item) OpenRange(start, end uint64) (io.ReadCloser, error) {
+	if end < start {
+		return nil, fmt.Errorf("bad range %d-%d", start, end)
+	}
+	if start >= uint64(i.size) {
+		return nil, fmt.Errorf("range %d-%d starts past the end of the file", start, end)
+	}
+	return i.open(int64(start), int64(end-start+1))
+}
+
+// open opens the file from the offset, limited to the length unless it is
+// negative.
+func (i *item) open(offset, length int64) (io.ReadCloser, error) {
+	pool := i.container.location.pool
+	cn, err := pool.get()
+	if err != nil {
+		return nil, err
+	}
+	dc, err := cn.retrieve(i.container.filePath(i.path), offset)
+	if err != nil {
+		pool.put(cn)
+		return nil, err
+	}
+	r := &fileReader{pool: pool, cn: cn, dc: dc}
+	if length < 0 {
+		return r, nil
+	}
+	return &rangeReader{Reader: io.LimitReader(r, length), Closer: r}, nil
+}
+
+// fileReader reads a file over a data connection, giving back the control
+// connection when closed.
+type fileReader struct {
+	pool *pool
+	cn   *conn
+	dc   net.Conn
+	eof  bool
+}
+
+func (r *fileReader) Read(p []byte) (int, error) {
+	n, err := r.dc.Read(p)
+	if err == io.EOF {
+		r.eof = true
+	}
+	return n, err
+}
+
+// Close closes the data connection. A transfer that was not read to the
+// end leaves the control connection waiting for the server to give up on
+// it, so that connection is closed rather than used again.
+func (r *fileReader) Close() error {
+	if r.cn == nil {
+		return nil
+	}
+	defer func() {
+		r.pool.put(r.cn)
+		r.cn = nil
+	}()
+	r.dc.Close()
+	if !r.eof {
+		r.cn.broken = true
+		return nil
+	}
+	return r.cn.finish()
+}
+
+// rangeReader reads a range of a file, closing the file.
+type rangeReader struct {
+	io.Reader
+	io.Closer
+}
+
+// LastMod returns the last modified date of the item.
+func (i *item) LastMod() (time.Time, error) {
+	return i.modTime, nil
+}
+
+// ETag returns the last modified time and size of the file, as FTP has no
+// ETags.
+func (i *item) ETag() (string, error) {
+	return fmt.Sprintf("%d-%d", i.modTime.Unix(), i.size), nil
+}
+
+// Metadata returns an empty map, as FTP files have no metadata of their
+// own.
+func (i *item) Metadata() (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
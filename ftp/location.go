@@ -0,0 +1,153 @@
+package ftp
+
+import (
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+type location struct {
+	// we keep config here so that we can access the server information (username/host)
+	// when constructing the item urls.
+	config *conf
+	pool   *pool
+}
+
+// dirPath gets the path on the server of the directory of the container
+// with the name.
+func (l *location) dirPath(name string) string {
+	return path.Join(l.config.basePath, name)
+}
+
+// CreateContainer creates a new container, in this case a directory on the remote server.
+func (l *location) CreateContainer(containerName string) (stow.Container, error) {
+	err := l.pool.do(func(cn *conn) error {
+		_, _, err := cn.cmd(257, "MKD %s", l.dirPath(containerName))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &container{
+		location: l,
+		name:     containerName,
+	}, nil
+}
+
+// Containers returns a slice of the Container interface, a cursor, and an error.
+func (l *location) Containers(prefix, cursor string, count int) ([]stow.Container, string, error) {
+	var entries []entry
+	err := l.pool.do(func(cn *conn) error {
+		var err error
+		entries, err = cn.list(l.config.basePath)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.dir && strings.HasPrefix(e.name, prefix) && e.name > cursor {
+			names = append(names, e.name)
+		}
+	}
+	sort.Strings(names)
+
+	var cont []stow.Container
+	for _, name := range names {
+		if len(cont) == count {
+			return cont, cont[len(cont)-1].Name(), nil
+		}
+		cont = append(cont, &container{
+			location: l,
+			name:     name,
+		})
+	}
+
+	return cont, "", nil
+}
+
+// Close closes the connections to the server.
+func (l *location) Close() error {
+	return l.pool.close()
+}
+
+// Container retrieves a stow.Container based on its name which must be exact.
+func (l *location) Container(id string) (stow.Container, error) {
+	var e entry
+	err := l.pool.do(func(cn *conn) error {
+		var err error
+		e, err = cn.stat(l.dirPath(id))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !e.dir {
+		return nil, stow.ErrNotFound
+	}
+	return &container{
+		location: l,
+		name:     id,
+	}, nil
+}
+
+// RemoveContainer removes a container by name. Like the sftp Location, it
+// removes the empty directories in it, but not files.
+func (l *location) RemoveContainer(id string) error {
+	return l.pool.do(func(cn *conn) error {
+		return recurseRemove(cn, l.dirPath(id))
+	})
+}
+
+// recurseRemove recursively purges content from a path.
+func recurseRemove(cn *conn, p string) error {
+	entries, err := cn.list(p)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if !e.dir {
+			return errors.Errorf("directory not empty - %q", e.name)
+		}
+		if err := recurseRemove(cn, path.Join(p, e.name)); err != nil {
+			return err
+		}
+	}
+
+	_, _, err = cn.cmd(250, "RMD %s", p)
+	return err
+}
+
+// ItemByURL retrieves a stow.Item by parsing the URL.
+func (l *location) ItemByURL(u *url.URL) (stow.Item, error) {
+	// expect ftp://<username>@<host>:<port>/<container>/<path to file>
+	// example: ftp://someuser@example.com:21/foo/blah/baz.txt
+
+	urlParts := strings.Split(u.Path, "/")
+	if len(urlParts) < 3 {
+		return nil, errors.New("parsing ItemByURL unexpected length")
+	}
+
+	containerName := urlParts[1]
+	itemName := strings.Join(urlParts[2:], "/")
+
+	c, err := l.Container(containerName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ItemByURL, getting container %q", containerName)
+	}
+
+	i, err := c.Item(itemName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ItemByURL, getting item %q", itemName)
+	}
+
+	return i, nil
+}
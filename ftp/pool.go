@@ -0,0 +1,103 @@
+package ftp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// idleCheck is how long a connection may sit in the pool before it is
+// checked with NOOP, as servers close connections that are idle.
+const idleCheck = 30 * time.Second
+
+// pool keeps the control connections of a location, as FTP runs one
+// command at a time on each.
+type pool struct {
+	config *conf
+	// slots holds a value for each connection in use, limiting them to
+	// the configured number.
+	slots chan struct{}
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+func newPool(c *conf) *pool {
+	return &pool{
+		config: c,
+		slots:  make(chan struct{}, c.maxConnections),
+	}
+}
+
+// get gets an idle connection, or dials a new one, waiting while all of
+// them are in use. Connections must be given back with put.
+func (p *pool) get() (*conn, error) {
+	p.slots <- struct{}{}
+	for {
+		p.mu.Lock()
+		if len(p.idle) == 0 {
+			p.mu.Unlock()
+			break
+		}
+		cn := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		p.mu.Unlock()
+		if time.Since(cn.lastUsed) < idleCheck {
+			return cn, nil
+		}
+		if _, _, err := cn.cmd(2, "NOOP"); err == nil {
+			return cn, nil
+		}
+		cn.broken = true
+		cn.close()
+	}
+	cn, err := dial(p.config)
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return cn, nil
+}
+
+// put gives back a connection, closing it when it is broken.
+func (p *pool) put(cn *conn) {
+	p.mu.Lock()
+	if cn.broken || p.closed {
+		p.mu.Unlock()
+		cn.close()
+	} else {
+		p.idle = append(p.idle, cn)
+		p.mu.Unlock()
+	}
+	<-p.slots
+}
+
+// do runs the function with a connection from the pool.
+func (p *pool) do(fn func(cn *conn) error) error {
+	cn, err := p.get()
+	if err != nil {
+		return err
+	}
+	defer p.put(cn)
+	return fn(cn)
+}
+
+// close closes the idle connections, and those in use once they are put
+// back.
+func (p *pool) close() error {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+
+	var errs error
+	for _, cn := range idle {
+		if err := cn.close(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,367 @@
+package ftp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testServer is an FTP server for the files of a directory, with just
+// enough of the protocol for the Location.
+type testServer struct {
+	root     string
+	listener net.Listener
+	// tls enables AUTH TLS when set.
+	tls *tls.Config
+	// mlst enables MLST and MLSD, and epsv enables EPSV.
+	mlst bool
+	epsv bool
+
+	mu       sync.Mutex
+	commands []string
+	wg       sync.WaitGroup
+}
+
+func newTestServer(t *testing.T, root string, useTLS, mlst, epsv bool) *testServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{root: root, listener: l, mlst: mlst, epsv: epsv}
+	if useTLS {
+		s.tls = testTLSConfig(t)
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// testTLSConfig makes a self-signed certificate for 127.0.0.1.
+func testTLSConfig(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+}
+
+func (s *testServer) port() string {
+	return strconv.Itoa(s.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func (s *testServer) close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+// sent gets the commands the server was sent.
+func (s *testServer) sent() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func (s *testServer) serve() {
+	defer s.wg.Done()
+	for {
+		nc, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			(&session{server: s, conn: nc, text: textproto.NewConn(nc)}).run()
+		}()
+	}
+}
+
+// session is the state of a control connection.
+type session struct {
+	server   *testServer
+	conn     net.Conn
+	text     *textproto.Conn
+	loggedIn bool
+	prot     bool
+	rest     int64
+	passive  net.Listener
+}
+
+func (c *session) reply(code int, format string, args ...interface{}) {
+	c.text.PrintfLine("%d %s", code, fmt.Sprintf(format, args...))
+}
+
+// path gets the path of the file the argument names, which cannot leave
+// the root.
+func (c *session) path(arg string) string {
+	return filepath.Join(c.server.root, filepath.FromSlash(path.Clean("/"+arg)))
+}
+
+func (c *session) run() {
+	defer func() {
+		c.conn.Close()
+		if c.passive != nil {
+			c.passive.Close()
+		}
+	}()
+	c.reply(220, "ready")
+	for {
+		line, err := c.text.ReadLine()
+		if err != nil {
+			return
+		}
+		parts := strings.SplitN(line, " ", 2)
+		command, arg := strings.ToUpper(parts[0]), ""
+		if len(parts) == 2 {
+			arg = parts[1]
+		}
+		c.server.mu.Lock()
+		c.server.commands = append(c.server.commands, line)
+		c.server.mu.Unlock()
+
+		switch command {
+		case "QUIT":
+			c.reply(221, "bye")
+			return
+		case "AUTH":
+			if c.server.tls == nil {
+				c.reply(502, "no TLS")
+				continue
+			}
+			c.reply(234, "go ahead")
+			c.conn = tls.Server(c.conn, c.server.tls)
+			c.text = textproto.NewConn(c.conn)
+			continue
+		case "USER":
+			c.reply(331, "password please")
+			continue
+		case "PASS":
+			if arg != "secret" {
+				c.reply(530, "wrong password")
+				continue
+			}
+			c.loggedIn = true
+			c.reply(230, "logged in")
+			continue
+		case "FEAT":
+			c.text.PrintfLine("211-Features:")
+			if c.server.mlst {
+				c.text.PrintfLine(" MLST type*;size*;modify*;")
+			}
+			c.text.PrintfLine(" REST STREAM")
+			c.text.PrintfLine("211 End")
+			continue
+		}
+		if !c.loggedIn {
+			c.reply(530, "not logged in")
+			continue
+		}
+		c.command(command, arg)
+	}
+}
+
+func (c *session) command(command, arg string) {
+	switch command {
+	case "PBSZ", "TYPE", "NOOP":
+		c.reply(200, "ok")
+	case "PROT":
+		c.prot = arg == "P"
+		c.reply(200, "ok")
+	case "EPSV", "PASV":
+		if command == "EPSV" && !c.server.epsv {
+			c.reply(502, "no EPSV")
+			return
+		}
+		if c.passive != nil {
+			c.passive.Close()
+		}
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			c.reply(425, "%v", err)
+			return
+		}
+		c.passive = l
+		port := l.Addr().(*net.TCPAddr).Port
+		if command == "EPSV" {
+			c.reply(229, "Entering Extended Passive Mode (|||%d|)", port)
+		} else {
+			// a private address, which the client should not use
+			c.reply(227, "Entering Passive Mode (10,0,0,1,%d,%d)", port>>8, port&0xff)
+		}
+	case "REST":
+		n, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			c.reply(501, "bad offset")
+			return
+		}
+		c.rest = n
+		c.reply(350, "restarting")
+	case "MLST":
+		if !c.server.mlst {
+			c.reply(502, "no MLST")
+			return
+		}
+		info, err := os.Stat(c.path(arg))
+		if err != nil {
+			c.reply(550, "not found")
+			return
+		}
+		c.text.PrintfLine("250-Listing %s", arg)
+		c.text.PrintfLine(" %s", mlstLine(info, arg))
+		c.text.PrintfLine("250 End")
+	case "MLSD", "LIST":
+		if command == "MLSD" && !c.server.mlst {
+			c.reply(502, "no MLSD")
+			return
+		}
+		f, err := os.Open(c.path(arg))
+		if err != nil {
+			c.reply(550, "not found")
+			return
+		}
+		infos, err := f.Readdir(-1)
+		f.Close()
+		if err != nil {
+			c.reply(550, "not a directory")
+			return
+		}
+		c.transfer(func(dc net.Conn) error {
+			for _, info := range infos {
+				line := mlstLine(info, info.Name())
+				if command == "LIST" {
+					line = fmt.Sprintf("%s 1 owner group %d %s %s", info.Mode(), info.Size(), info.ModTime().Format("Jan _2 15:04"), info.Name())
+				}
+				if _, err := fmt.Fprintf(dc, "%s\r\n", line); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	case "RETR":
+		f, err := os.Open(c.path(arg))
+		if err != nil {
+			c.reply(550, "not found")
+			return
+		}
+		defer f.Close()
+		if _, err := f.Seek(c.rest, io.SeekStart); err != nil {
+			c.reply(550, "bad offset")
+			return
+		}
+		c.rest = 0
+		c.transfer(func(dc net.Conn) error {
+			_, err := io.Copy(dc, f)
+			return err
+		})
+	case "STOR":
+		f, err := os.Create(c.path(arg))
+		if err != nil {
+			c.reply(550, "cannot create")
+			return
+		}
+		defer f.Close()
+		c.transfer(func(dc net.Conn) error {
+			_, err := io.Copy(f, dc)
+			return err
+		})
+	case "DELE":
+		p := c.path(arg)
+		if info, err := os.Stat(p); err != nil || info.IsDir() {
+			c.reply(550, "not a file")
+			return
+		}
+		if err := os.Remove(p); err != nil {
+			c.reply(550, "%v", err)
+			return
+		}
+		c.reply(250, "deleted")
+	case "MKD":
+		if err := os.Mkdir(c.path(arg), 0755); err != nil {
+			c.reply(550, "%v", err)
+			return
+		}
+		c.reply(257, "%q created", arg)
+	case "RMD":
+		if err := os.Remove(c.path(arg)); err != nil {
+			c.reply(550, "%v", err)
+			return
+		}
+		c.reply(250, "removed")
+	default:
+		c.reply(502, "not implemented")
+	}
+}
+
+// transfer runs the function over the data connection of the last EPSV
+// or PASV.
+func (c *session) transfer(fn func(dc net.Conn) error) {
+	if c.passive == nil {
+		c.reply(425, "use EPSV or PASV first")
+		return
+	}
+	l := c.passive
+	c.passive = nil
+	defer l.Close()
+	c.reply(150, "opening data connection")
+	l.(*net.TCPListener).SetDeadline(time.Now().Add(10 * time.Second))
+	dc, err := l.Accept()
+	if err != nil {
+		c.reply(425, "%v", err)
+		return
+	}
+	if c.prot {
+		tc := tls.Server(dc, c.server.tls)
+		if err := tc.Handshake(); err != nil {
+			dc.Close()
+			c.reply(425, "%v", err)
+			return
+		}
+		dc = tc
+	}
+	err = fn(dc)
+	dc.Close()
+	if err != nil {
+		c.reply(426, "%v", err)
+		return
+	}
+	c.reply(226, "done")
+}
+
+func mlstLine(info os.FileInfo, name string) string {
+	kind := "file"
+	if info.IsDir() {
+		kind = "dir"
+	}
+	return fmt.Sprintf("type=%s;size=%d;modify=%s; %s", kind, info.Size(), info.ModTime().UTC().Format("20060102150405"), name)
+}
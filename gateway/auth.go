@@ -0,0 +1,264 @@
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values of the x-amz-content-sha256 header that are not a hash.
+const (
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	streamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+)
+
+// signingAlgorithm is the only algorithm of Signature Version 4 there is
+// for S3.
+const signingAlgorithm = "AWS4-HMAC-SHA256"
+
+// maxClockSkew is how far the time a request was signed at may be from
+// the time of the server.
+const maxClockSkew = 15 * time.Minute
+
+// authorize checks the Signature Version 4 signature of the request
+// against the keys. The body of requests with a signed payload hash is
+// replaced with one that fails at the end when the hash does not match.
+func (s *Server) authorize(r *http.Request) *apiError {
+	if s.options.Anonymous && r.Header.Get("Authorization") == "" {
+		return nil
+	}
+	auth, err := parseAuthorization(r.Header.Get("Authorization"))
+	if err != nil {
+		return err
+	}
+	if err := checkSignedHeaders(r, auth.signedHeaders); err != nil {
+		return err
+	}
+	secret, ok := s.options.Keys[auth.accessKey]
+	if !ok {
+		return errInvalidAccessKeyID
+	}
+	date, perr := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if perr != nil {
+		return errAccessDenied.withMessage("missing or bad X-Amz-Date header")
+	}
+	if skew := s.now().Sub(date); skew > maxClockSkew || skew < -maxClockSkew {
+		return errRequestTimeTooSkewed
+	}
+	if !strings.HasPrefix(auth.scope, date.Format("20060102")+"/") {
+		return errAccessDenied.withMessage("credential scope does not match the date")
+	}
+	payload := r.Header.Get("X-Amz-Content-Sha256")
+	switch payload {
+	case "":
+		return errAccessDenied.withMessage("missing X-Amz-Content-Sha256 header")
+	case unsignedPayload:
+	case streamingPayload:
+		return errNotImplemented.withMessage("streaming payload signatures are not supported")
+	default:
+		want, err := hex.DecodeString(payload)
+		if err != nil || len(want) != sha256.Size {
+			return errInvalidDigest
+		}
+		if r.Body != nil {
+			r.Body = &hashedBody{rc: r.Body, hash: sha256.New(), want: want}
+		}
+	}
+
+	scope := strings.Split(auth.scope, "/")
+	if len(scope) != 4 || scope[2] != "s3" || scope[3] != "aws4_request" {
+		return errAccessDenied.withMessage("bad credential scope")
+	}
+	canonical := canonicalRequest(r, auth.signedHeaders, payload)
+	sum := sha256.Sum256([]byte(canonical))
+	stringToSign := signingAlgorithm + "\n" +
+		r.Header.Get("X-Amz-Date") + "\n" +
+		auth.scope + "\n" +
+		hex.EncodeToString(sum[:])
+	key := []byte("AWS4" + secret)
+	for _, part := range scope {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	if !hmac.Equal([]byte(signature), []byte(auth.signature)) {
+		return errSignatureDoesNotMatch
+	}
+	return nil
+}
+
+// authorization is the parsed Authorization header.
+type authorization struct {
+	accessKey     string
+	scope         string
+	signedHeaders []string
+	signature     string
+}
+
+// parseAuthorization parses a header of the form
+// AWS4-HMAC-SHA256 Credential=<key>/<scope>, SignedHeaders=<headers>, Signature=<signature>
+func parseAuthorization(header string) (authorization, *apiError) {
+	var auth authorization
+	if header == "" {
+		return auth, errAccessDenied.withMessage("missing Authorization header")
+	}
+	if !strings.HasPrefix(header, signingAlgorithm+" ") {
+		return auth, errAccessDenied.withMessage("only " + signingAlgorithm + " signatures are supported")
+	}
+	for _, field := range strings.Split(strings.TrimPrefix(header, signingAlgorithm+" "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "Credential":
+			parts := strings.SplitN(kv[1], "/", 2)
+			if len(parts) == 2 {
+				auth.accessKey, auth.scope = parts[0], parts[1]
+			}
+		case "SignedHeaders":
+			auth.signedHeaders = strings.Split(kv[1], ";")
+		case "Signature":
+			auth.signature = kv[1]
+		}
+	}
+	if auth.accessKey == "" || len(auth.signedHeaders) == 0 || auth.signature == "" {
+		return auth, errAccessDenied.withMessage("malformed Authorization header")
+	}
+	return auth, nil
+}
+
+// requiredSignedHeaders are the headers every request must sign, as the
+// signature does not cover the host, time or payload otherwise.
+var requiredSignedHeaders = []string{"host", "x-amz-content-sha256", "x-amz-date"}
+
+// checkSignedHeaders checks that the request signs the required headers
+// and every x-amz-* header it was sent with, so that none of them can be
+// changed or added without breaking the signature.
+func checkSignedHeaders(r *http.Request, signedHeaders []string) *apiError {
+	signed := make(map[string]bool, len(signedHeaders))
+	for _, name := range signedHeaders {
+		signed[name] = true
+	}
+	for _, name := range requiredSignedHeaders {
+		if !signed[name] {
+			return errAccessDenied.withMessage("the " + name + " header must be signed")
+		}
+	}
+	for name := range r.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") && !signed[name] {
+			return errAccessDenied.withMessage("the " + name + " header must be signed")
+		}
+	}
+	return nil
+}
+
+// canonicalRequest makes the canonical form of the request that is
+// signed.
+func canonicalRequest(r *http.Request, signedHeaders []string, payload string) string {
+	var b strings.Builder
+	b.WriteString(r.Method + "\n")
+	// the path is signed as it was sent, which S3 clients do not escape
+	// a second time
+	path := r.RequestURI
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	if path == "" {
+		path = "/"
+	}
+	b.WriteString(path + "\n")
+	b.WriteString(canonicalQuery(r.URL.Query()) + "\n")
+	for _, name := range signedHeaders {
+		b.WriteString(name + ":" + canonicalHeader(r, name) + "\n")
+	}
+	b.WriteString("\n" + strings.Join(signedHeaders, ";") + "\n")
+	b.WriteString(payload)
+	return b.String()
+}
+
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, awsEscape(key)+"="+awsEscape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// canonicalHeader gets the values of the header, trimmed and joined with
+// commas. Go keeps some headers out of Request.Header.
+func canonicalHeader(r *http.Request, name string) string {
+	switch name {
+	case "host":
+		return r.Host
+	case "content-length":
+		return strconv.FormatInt(r.ContentLength, 10)
+	case "transfer-encoding":
+		return strings.Join(r.TransferEncoding, ",")
+	}
+	values := r.Header[http.CanonicalHeaderKey(name)]
+	trimmed := make([]string, len(values))
+	for i, value := range values {
+		trimmed[i] = strings.Join(strings.Fields(value), " ")
+	}
+	return strings.Join(trimmed, ",")
+}
+
+// awsEscape escapes everything but the unreserved characters of RFC 3986.
+func awsEscape(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&0xf])
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// hashedBody checks the SHA-256 hash of the body against the one it was
+// signed with, failing at the end of the body when they differ. Bodies
+// are read to the end before anything is put from them, as a Location
+// may have replaced an Item by the time the end is reached.
+type hashedBody struct {
+	rc       io.ReadCloser
+	hash     hash.Hash
+	want     []byte
+	mismatch bool
+}
+
+func (b *hashedBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	b.hash.Write(p[:n])
+	if err == io.EOF && !hmac.Equal(b.hash.Sum(nil), b.want) {
+		b.mismatch = true
+		err = errContentSHA256Mismatch
+	}
+	return n, err
+}
+
+func (b *hashedBody) Close() error {
+	return b.rc.Close()
+}
@@ -0,0 +1,80 @@
+package gateway
+
+import (
+	"encoding/xml"
+	"net/http"
+
+	"github.com/graymeta/stow"
+)
+
+// apiError is an error of the S3 API.
+type apiError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.code + ": " + e.message
+}
+
+// withMessage gets a copy of the error with another message.
+func (e *apiError) withMessage(message string) *apiError {
+	c := *e
+	c.message = message
+	return &c
+}
+
+// Errors of the S3 API the gateway gives.
+var (
+	errAccessDenied            = &apiError{http.StatusForbidden, "AccessDenied", "Access Denied"}
+	errInvalidAccessKeyID      = &apiError{http.StatusForbidden, "InvalidAccessKeyId", "The access key ID you provided does not exist in our records."}
+	errSignatureDoesNotMatch   = &apiError{http.StatusForbidden, "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided."}
+	errRequestTimeTooSkewed    = &apiError{http.StatusForbidden, "RequestTimeTooSkewed", "The difference between the request time and the server's time is too large."}
+	errInvalidDigest           = &apiError{http.StatusBadRequest, "InvalidDigest", "The X-Amz-Content-Sha256 header is not valid."}
+	errContentSHA256Mismatch   = &apiError{http.StatusBadRequest, "XAmzContentSHA256Mismatch", "The provided X-Amz-Content-Sha256 header does not match what was computed."}
+	errNoSuchBucket            = &apiError{http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist."}
+	errNoSuchKey               = &apiError{http.StatusNotFound, "NoSuchKey", "The specified key does not exist."}
+	errNoSuchUpload            = &apiError{http.StatusNotFound, "NoSuchUpload", "The specified multipart upload does not exist."}
+	errBucketAlreadyOwnedByYou = &apiError{http.StatusConflict, "BucketAlreadyOwnedByYou", "The bucket you tried to create already exists, and you own it."}
+	errBucketNotEmpty          = &apiError{http.StatusConflict, "BucketNotEmpty", "The bucket you tried to delete is not empty."}
+	errInvalidArgument         = &apiError{http.StatusBadRequest, "InvalidArgument", "Invalid Argument"}
+	errInvalidPart             = &apiError{http.StatusBadRequest, "InvalidPart", "One or more of the specified parts could not be found."}
+	errInvalidPartOrder        = &apiError{http.StatusBadRequest, "InvalidPartOrder", "The list of parts was not in ascending order."}
+	errMalformedXML            = &apiError{http.StatusBadRequest, "MalformedXML", "The XML you provided was not well-formed."}
+	errMissingContentLength    = &apiError{http.StatusLengthRequired, "MissingContentLength", "You must provide the Content-Length HTTP header."}
+	errInvalidRange            = &apiError{http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The requested range is not satisfiable."}
+	errMethodNotAllowed        = &apiError{http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource."}
+	errNotImplemented          = &apiError{http.StatusNotImplemented, "NotImplemented", "A header you provided implies functionality that is not implemented."}
+	errInternalError           = &apiError{http.StatusInternalServerError, "InternalError", "We encountered an internal error. Please try again."}
+	errIncompleteBody          = &apiError{http.StatusBadRequest, "IncompleteBody", "You did not provide the number of bytes specified by the Content-Length HTTP header."}
+)
+
+type errorXML struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string   `xml:"Code"`
+	Message  string   `xml:"Message"`
+	Resource string   `xml:"Resource"`
+}
+
+// writeError writes the error as an S3 error response. Errors of the
+// Location are mapped to the closest S3 error, and logged when they are
+// not the client's fault.
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	e, ok := err.(*apiError)
+	switch {
+	case ok:
+	case err == stow.ErrNotFound:
+		e = errNoSuchKey
+	case stow.IsNotSupported(err):
+		e = errNotImplemented.withMessage(err.Error())
+	default:
+		s.options.Logger.Printf("gateway: %s %s: %v", r.Method, r.URL.Path, err)
+		e = errInternalError
+	}
+	if r.Method == http.MethodHead {
+		w.WriteHeader(e.status)
+		return
+	}
+	writeXML(w, e.status, errorXML{Code: e.code, Message: e.message, Resource: r.URL.Path})
+}
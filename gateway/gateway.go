@@ -0,0 +1,309 @@
+// Package gateway serves any stow.Location through the S3 REST API, so
+// that tools that only speak S3 can reach data kept elsewhere.
+//
+// The Containers of the Location are the buckets, found by name, and the
+// Items are the objects. Buckets are addressed in the path of requests,
+// as clients with path style addressing send them. Requests are
+// authenticated with Signature Version 4 against the configured keys.
+//
+// The gateway covers listing buckets, creating and removing them,
+// ListObjectsV2 with a delimiter, and getting (with ranges), heading,
+// putting and deleting objects, along with multipart uploads, whose
+// parts are kept in a local directory until the upload completes.
+package gateway
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graymeta/stow"
+)
+
+// defaultRegion is the region clients sign requests for by default.
+const defaultRegion = "us-east-1"
+
+// Options configure a Server.
+type Options struct {
+	// Keys are the secret keys by access key ID that requests may be
+	// signed with.
+	Keys map[string]string
+	// Anonymous lets requests with no Authorization header through.
+	// Signed requests are still checked against the Keys.
+	Anonymous bool
+	// PartDir is the directory the parts of multipart uploads are kept in
+	// until the upload completes. Defaults to a new temporary directory,
+	// removed by Close.
+	PartDir string
+	// Logger logs the requests that fail on the server side. Defaults to
+	// the standard logger.
+	Logger *log.Logger
+}
+
+// Server is an http.Handler serving the S3 API in front of a Location.
+type Server struct {
+	location stow.Location
+	options  Options
+	partDir  string
+	tempDir  bool
+	now      func() time.Time
+
+	mu      sync.Mutex
+	uploads map[string]*upload
+}
+
+// New makes a Server for the Location. It needs keys, unless anonymous
+// requests are allowed.
+func New(location stow.Location, options *Options) (*Server, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	if len(options.Keys) == 0 && !options.Anonymous {
+		return nil, errors.New("gateway needs keys or anonymous access")
+	}
+	s := &Server{
+		location: location,
+		options:  *options,
+		partDir:  options.PartDir,
+		now:      time.Now,
+		uploads:  make(map[string]*upload),
+	}
+	if s.options.Logger == nil {
+		s.options.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	if s.partDir == "" {
+		dir, err := ioutil.TempDir("", "stow-gateway")
+		if err != nil {
+			return nil, err
+		}
+		s.partDir = dir
+		s.tempDir = true
+	}
+	return s, nil
+}
+
+// Close aborts the multipart uploads in progress, and removes the
+// temporary directory of their parts. It does not close the Location.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, u := range s.uploads {
+		u.remove()
+		delete(s.uploads, id)
+	}
+	if s.tempDir {
+		return os.RemoveAll(s.partDir)
+	}
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := s.authorize(r); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	bucket, key := splitPath(r.URL.Path)
+	query := r.URL.Query()
+	var err error
+	switch {
+	case !validName(bucket) || key != "" && !validName(key):
+		err = errInvalidArgument.withMessage("bucket names and keys must be clean relative paths")
+	case bucket == "":
+		if r.Method != http.MethodGet {
+			err = errMethodNotAllowed
+			break
+		}
+		err = s.listBuckets(w, r)
+	case key == "":
+		err = s.serveBucket(w, r, bucket)
+	case query.Get("uploadId") != "" || hasQuery(query, "uploads"):
+		err = s.serveMultipart(w, r, bucket, key)
+	default:
+		err = s.serveObject(w, r, bucket, key)
+	}
+	if err != nil {
+		s.writeError(w, r, err)
+	}
+}
+
+func (s *Server) serveBucket(w http.ResponseWriter, r *http.Request, bucket string) error {
+	switch r.Method {
+	case http.MethodGet:
+		if r.URL.Query().Get("list-type") != "2" {
+			return errNotImplemented.withMessage("only ListObjectsV2 is supported")
+		}
+		return s.listObjects(w, r, bucket)
+	case http.MethodHead:
+		_, err := s.container(bucket)
+		return err
+	case http.MethodPut:
+		return s.createBucket(w, bucket)
+	case http.MethodDelete:
+		return s.deleteBucket(w, bucket)
+	}
+	return errMethodNotAllowed
+}
+
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	container, err := s.container(bucket)
+	if err != nil {
+		return err
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return s.getObject(w, r, container, key)
+	case http.MethodPut:
+		if r.Header.Get("X-Amz-Copy-Source") != "" {
+			return errNotImplemented.withMessage("copying objects is not supported")
+		}
+		return s.putObject(w, r, container, key)
+	case http.MethodDelete:
+		return s.deleteObject(w, container, key)
+	}
+	return errMethodNotAllowed
+}
+
+// splitPath splits the path of a request into the bucket and the key.
+func splitPath(path string) (string, string) {
+	path = strings.TrimPrefix(path, "/")
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// validName gets whether the bucket or key is a clean relative path, so
+// that it cannot name anything outside of its Container in Locations that
+// map names to paths, such as local ones. Names with a .stow segment are
+// kept for the retention records of local Locations.
+func validName(name string) bool {
+	if name == "" {
+		return true
+	}
+	if strings.HasPrefix(name, "/") || path.Clean(name) != name {
+		return false
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "." || segment == ".." || segment == ".stow" {
+			return false
+		}
+	}
+	return true
+}
+
+// hasQuery gets whether the query has the parameter, with or without a
+// value.
+func hasQuery(query map[string][]string, name string) bool {
+	_, ok := query[name]
+	return ok
+}
+
+// container finds the Container named after the bucket.
+func (s *Server) container(bucket string) (stow.Container, error) {
+	cursor := stow.CursorStart
+	for {
+		containers, next, err := s.location.Containers(bucket, cursor, 100)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range containers {
+			if c.Name() == bucket {
+				return c, nil
+			}
+		}
+		if stow.IsCursorEnd(next) {
+			return nil, errNoSuchBucket
+		}
+		cursor = next
+	}
+}
+
+type bucketXML struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+type listAllMyBucketsResult struct {
+	XMLName xml.Name    `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListAllMyBucketsResult"`
+	Owner   ownerXML    `xml:"Owner"`
+	Buckets []bucketXML `xml:"Buckets>Bucket"`
+}
+
+type ownerXML struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter, r *http.Request) error {
+	result := listAllMyBucketsResult{Owner: ownerXML{ID: "stow", DisplayName: "stow"}}
+	cursor := stow.CursorStart
+	for {
+		containers, next, err := s.location.Containers(stow.NoPrefix, cursor, 100)
+		if err != nil {
+			return err
+		}
+		for _, c := range containers {
+			b := bucketXML{Name: c.Name(), CreationDate: time.Unix(0, 0).UTC().Format(time.RFC3339)}
+			if info, err := stow.StatContainer(c); err == nil && !info.Created.IsZero() {
+				b.CreationDate = info.Created.UTC().Format(time.RFC3339)
+			}
+			result.Buckets = append(result.Buckets, b)
+		}
+		if stow.IsCursorEnd(next) {
+			break
+		}
+		cursor = next
+	}
+	return writeXML(w, http.StatusOK, result)
+}
+
+func (s *Server) createBucket(w http.ResponseWriter, bucket string) error {
+	if _, err := s.container(bucket); err == nil {
+		return errBucketAlreadyOwnedByYou
+	}
+	if _, err := s.location.CreateContainer(bucket); err != nil {
+		return err
+	}
+	w.Header().Set("Location", "/"+bucket)
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (s *Server) deleteBucket(w http.ResponseWriter, bucket string) error {
+	container, err := s.container(bucket)
+	if err != nil {
+		return err
+	}
+	items, _, err := container.Items(stow.NoPrefix, stow.CursorStart, 1)
+	if err != nil {
+		return err
+	}
+	if len(items) > 0 {
+		return errBucketNotEmpty
+	}
+	if err := s.location.RemoveContainer(container.ID()); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func writeXML(w http.ResponseWriter, status int, v interface{}) error {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	w.Write(b)
+	return nil
+}
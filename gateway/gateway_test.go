@@ -0,0 +1,363 @@
+package gateway_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+	"github.com/graymeta/stow/gateway"
+	"github.com/graymeta/stow/local"
+)
+
+const (
+	accessKey = "AKIDEXAMPLE"
+	secretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+)
+
+// serve serves a local Location with a bucket through the gateway, and
+// gets an S3 client for it signing with the secret.
+func serve(t *testing.T, secret string) (*s3.S3, func()) {
+	is := is.New(t)
+	dir, err := ioutil.TempDir("", "stow-gateway-test")
+	is.NoErr(err)
+	is.NoErr(os.Mkdir(filepath.Join(dir, "bucket"), 0755))
+	location, err := stow.Dial(local.Kind, stow.ConfigMap{local.ConfigKeyPath: dir})
+	is.NoErr(err)
+	server, err := gateway.New(location, &gateway.Options{
+		Keys: map[string]string{accessKey: secretKey},
+	})
+	is.NoErr(err)
+	ts := httptest.NewServer(server)
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:         aws.String(ts.URL),
+		Region:           aws.String("us-east-1"),
+		Credentials:      credentials.NewStaticCredentials(accessKey, secret, ""),
+		S3ForcePathStyle: aws.Bool(true),
+		DisableSSL:       aws.Bool(true),
+	})
+	is.NoErr(err)
+	return s3.New(sess), func() {
+		ts.Close()
+		server.Close()
+		location.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func put(is is.I, client *s3.S3, key, body string) {
+	_, err := client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader([]byte(body)),
+	})
+	is.NoErr(err)
+}
+
+func errorCode(err error) string {
+	if e, ok := err.(awserr.Error); ok {
+		return e.Code()
+	}
+	return ""
+}
+
+func TestBuckets(t *testing.T) {
+	is := is.New(t)
+	client, done := serve(t, secretKey)
+	defer done()
+
+	_, err := client.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String("other")})
+	is.NoErr(err)
+	out, err := client.ListBuckets(&s3.ListBucketsInput{})
+	is.NoErr(err)
+	var names []string
+	for _, b := range out.Buckets {
+		names = append(names, aws.StringValue(b.Name))
+	}
+	sort.Strings(names)
+	// the local Location lists an All container too
+	is.Equal(names, []string{"All", "bucket", "other"})
+
+	_, err = client.HeadBucket(&s3.HeadBucketInput{Bucket: aws.String("missing")})
+	is.Err(err)
+	_, err = client.ListObjectsV2(&s3.ListObjectsV2Input{Bucket: aws.String("missing")})
+	is.Equal(errorCode(err), "NoSuchBucket")
+}
+
+func TestObjects(t *testing.T) {
+	is := is.New(t)
+	client, done := serve(t, secretKey)
+	defer done()
+
+	put(is, client, "dir/hello.txt", "hello gateway")
+
+	get, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("dir/hello.txt"),
+	})
+	is.NoErr(err)
+	b, err := ioutil.ReadAll(get.Body)
+	get.Body.Close()
+	is.NoErr(err)
+	is.Equal(string(b), "hello gateway")
+	is.Equal(aws.Int64Value(get.ContentLength), 13)
+	is.OK(aws.StringValue(get.ETag))
+
+	ranged, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("dir/hello.txt"),
+		Range:  aws.String("bytes=6-"),
+	})
+	is.NoErr(err)
+	b, err = ioutil.ReadAll(ranged.Body)
+	ranged.Body.Close()
+	is.NoErr(err)
+	is.Equal(string(b), "gateway")
+	is.Equal(aws.StringValue(ranged.ContentRange), "bytes 6-12/13")
+
+	head, err := client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("dir/hello.txt"),
+	})
+	is.NoErr(err)
+	is.Equal(aws.Int64Value(head.ContentLength), 13)
+	is.Equal(aws.StringValue(head.ETag), aws.StringValue(get.ETag))
+
+	_, err = client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("dir/hello.txt"),
+	})
+	is.NoErr(err)
+	_, err = client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("dir/hello.txt"),
+	})
+	is.Equal(errorCode(err), "NoSuchKey")
+}
+
+func TestListObjects(t *testing.T) {
+	is := is.New(t)
+	client, done := serve(t, secretKey)
+	defer done()
+
+	for _, key := range []string{"a.txt", "b/1.txt", "b/2.txt", "c/3.txt", "d.txt"} {
+		put(is, client, key, key)
+	}
+
+	out, err := client.ListObjectsV2(&s3.ListObjectsV2Input{
+		Bucket:    aws.String("bucket"),
+		Delimiter: aws.String("/"),
+	})
+	is.NoErr(err)
+	var keys, prefixes []string
+	for _, o := range out.Contents {
+		keys = append(keys, aws.StringValue(o.Key))
+	}
+	for _, p := range out.CommonPrefixes {
+		prefixes = append(prefixes, aws.StringValue(p.Prefix))
+	}
+	is.Equal(keys, []string{"a.txt", "d.txt"})
+	is.Equal(prefixes, []string{"b/", "c/"})
+
+	out, err = client.ListObjectsV2(&s3.ListObjectsV2Input{
+		Bucket: aws.String("bucket"),
+		Prefix: aws.String("b/"),
+	})
+	is.NoErr(err)
+	is.Equal(len(out.Contents), 2)
+	is.Equal(aws.StringValue(out.Contents[0].Key), "b/1.txt")
+	is.Equal(aws.Int64Value(out.Contents[0].Size), 7)
+
+	// paging with the client gets every key once
+	keys = nil
+	err = client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket:  aws.String("bucket"),
+		MaxKeys: aws.Int64(2),
+	}, func(page *s3.ListObjectsV2Output, last bool) bool {
+		is.True(len(page.Contents) <= 2)
+		for _, o := range page.Contents {
+			keys = append(keys, aws.StringValue(o.Key))
+		}
+		return true
+	})
+	is.NoErr(err)
+	is.Equal(keys, []string{"a.txt", "b/1.txt", "b/2.txt", "c/3.txt", "d.txt"})
+}
+
+func TestMultipartUpload(t *testing.T) {
+	is := is.New(t)
+	client, done := serve(t, secretKey)
+	defer done()
+
+	body := make([]byte, 11<<20)
+	for i := range body {
+		body[i] = byte(i % 251)
+	}
+	uploader := s3manager.NewUploaderWithClient(client, func(u *s3manager.Uploader) {
+		u.PartSize = 5 << 20
+	})
+	_, err := uploader.Upload(&s3manager.UploadInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("big"),
+		Body:   bytes.NewReader(body),
+	})
+	is.NoErr(err)
+
+	get, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("big"),
+	})
+	is.NoErr(err)
+	b, err := ioutil.ReadAll(get.Body)
+	get.Body.Close()
+	is.NoErr(err)
+	is.True(bytes.Equal(b, body))
+
+	// an aborted upload is gone
+	created, err := client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("aborted"),
+	})
+	is.NoErr(err)
+	_, err = client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String("bucket"),
+		Key:      aws.String("aborted"),
+		UploadId: created.UploadId,
+	})
+	is.NoErr(err)
+	_, err = client.UploadPart(&s3.UploadPartInput{
+		Bucket:     aws.String("bucket"),
+		Key:        aws.String("aborted"),
+		UploadId:   created.UploadId,
+		PartNumber: aws.Int64(1),
+		Body:       bytes.NewReader([]byte("part")),
+	})
+	is.Equal(errorCode(err), "NoSuchUpload")
+}
+
+func TestAuth(t *testing.T) {
+	is := is.New(t)
+	client, done := serve(t, "wrong")
+	defer done()
+
+	_, err := client.ListBuckets(&s3.ListBucketsInput{})
+	is.Equal(errorCode(err), "SignatureDoesNotMatch")
+
+	client.Config.Credentials = credentials.NewStaticCredentials("unknown", secretKey, "")
+	_, err = client.ListBuckets(&s3.ListBucketsInput{})
+	is.Equal(errorCode(err), "InvalidAccessKeyId")
+
+	client.Config.Credentials = credentials.AnonymousCredentials
+	_, err = client.ListBuckets(&s3.ListBucketsInput{})
+	is.Equal(errorCode(err), "AccessDenied")
+}
+
+func TestSignedHeaders(t *testing.T) {
+	is := is.New(t)
+	client, done := serve(t, secretKey)
+	defer done()
+
+	// headers added after signing are not covered by the signature
+	req, _ := client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("key"),
+		Body:   bytes.NewReader([]byte("body")),
+	})
+	req.Handlers.Send.PushFront(func(r *request.Request) {
+		r.HTTPRequest.Header.Set("X-Amz-Meta-Unsigned", "value")
+	})
+	is.Equal(errorCode(req.Send()), "AccessDenied")
+
+	req, _ = client.ListBucketsRequest(&s3.ListBucketsInput{})
+	req.Handlers.Send.PushFront(func(r *request.Request) {
+		auth := r.HTTPRequest.Header.Get("Authorization")
+		r.HTTPRequest.Header.Set("Authorization", strings.Replace(auth, "host;", "", 1))
+	})
+	is.Equal(errorCode(req.Send()), "AccessDenied")
+}
+
+func TestContentSHA256Mismatch(t *testing.T) {
+	is := is.New(t)
+	client, done := serve(t, secretKey)
+	defer done()
+	put(is, client, "key", "original")
+
+	// the body is swapped for one of the same length after signing
+	req, _ := client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("key"),
+		Body:   bytes.NewReader([]byte("replaced")),
+	})
+	req.Handlers.Send.PushFront(func(r *request.Request) {
+		r.HTTPRequest.Body = ioutil.NopCloser(strings.NewReader("tampered"))
+	})
+	is.Equal(errorCode(req.Send()), "XAmzContentSHA256Mismatch")
+
+	got, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("key"),
+	})
+	is.NoErr(err)
+	defer got.Body.Close()
+	body, err := ioutil.ReadAll(got.Body)
+	is.NoErr(err)
+	is.Equal(string(body), "original")
+}
+
+func TestTraversal(t *testing.T) {
+	is := is.New(t)
+	dir, err := ioutil.TempDir("", "stow-gateway-test")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, "root")
+	is.NoErr(os.MkdirAll(filepath.Join(root, "bucket"), 0755))
+	is.NoErr(ioutil.WriteFile(filepath.Join(dir, "secret"), []byte("secret"), 0644))
+	location, err := stow.Dial(local.Kind, stow.ConfigMap{local.ConfigKeyPath: root})
+	is.NoErr(err)
+	defer location.Close()
+	server, err := gateway.New(location, &gateway.Options{Anonymous: true})
+	is.NoErr(err)
+	defer server.Close()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	for _, tt := range []struct {
+		method, path string
+	}{
+		{http.MethodPut, "/bucket/../../written"},
+		{http.MethodPut, "/bucket/a/../../../written"},
+		{http.MethodGet, "/bucket/../../secret"},
+		{http.MethodGet, "/bucket//" + filepath.ToSlash(dir) + "/secret"},
+		{http.MethodGet, "/bucket/./a"},
+		{http.MethodDelete, "/bucket/../../secret"},
+		{http.MethodPut, "/../written/"},
+		{http.MethodPut, "/bucket/.stow/written.json"},
+		{http.MethodGet, "/bucket/a/.stow/b.json"},
+		{http.MethodDelete, "/.stow/a"},
+	} {
+		req, err := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader("written"))
+		is.NoErr(err)
+		resp, err := http.DefaultClient.Do(req)
+		is.NoErr(err)
+		resp.Body.Close()
+		is.Equal(resp.StatusCode, http.StatusBadRequest)
+	}
+	_, err = os.Stat(filepath.Join(dir, "written"))
+	is.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "secret"))
+	is.NoErr(err)
+}
@@ -0,0 +1,228 @@
+package gateway
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/graymeta/stow"
+)
+
+// maxPartNumber is the highest part number of a multipart upload.
+const maxPartNumber = 10000
+
+// upload is a multipart upload in progress, whose parts are files in its
+// directory.
+type upload struct {
+	bucket   string
+	key      string
+	dir      string
+	metadata map[string]interface{}
+	props    stow.ContentProperties
+
+	mu    sync.Mutex
+	parts map[int]part
+}
+
+type part struct {
+	etag string
+	size int64
+}
+
+func (u *upload) partPath(number int) string {
+	return filepath.Join(u.dir, strconv.Itoa(number))
+}
+
+func (u *upload) remove() {
+	os.RemoveAll(u.dir)
+}
+
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ InitiateMultipartUploadResult"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+type completeMultipartUpload struct {
+	Parts []struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	} `xml:"Part"`
+}
+
+type completeMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CompleteMultipartUploadResult"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
+
+func (s *Server) serveMultipart(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	container, err := s.container(bucket)
+	if err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	id := query.Get("uploadId")
+	if id == "" {
+		if r.Method != http.MethodPost {
+			return errMethodNotAllowed
+		}
+		return s.createUpload(w, r, bucket, key)
+	}
+	u, err := s.upload(id, bucket, key)
+	if err != nil {
+		return err
+	}
+	switch r.Method {
+	case http.MethodPut:
+		return s.uploadPart(w, r, u, query.Get("partNumber"))
+	case http.MethodPost:
+		return s.completeUpload(w, r, container, id, u)
+	case http.MethodDelete:
+		s.removeUpload(id)
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	return errMethodNotAllowed
+}
+
+func (s *Server) createUpload(w http.ResponseWriter, r *http.Request, bucket, key string) error {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	id := hex.EncodeToString(b)
+	u := &upload{
+		bucket: bucket,
+		key:    key,
+		dir:    filepath.Join(s.partDir, id),
+		parts:  make(map[int]part),
+	}
+	u.metadata, u.props = requestMetadata(r.Header)
+	if err := os.MkdirAll(u.dir, 0700); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.uploads[id] = u
+	s.mu.Unlock()
+	return writeXML(w, http.StatusOK, initiateMultipartUploadResult{Bucket: bucket, Key: key, UploadID: id})
+}
+
+// upload gets the upload with the ID, which must be of the key.
+func (s *Server) upload(id, bucket, key string) (*upload, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.uploads[id]
+	if !ok || u.bucket != bucket || u.key != key {
+		return nil, errNoSuchUpload
+	}
+	return u, nil
+}
+
+func (s *Server) removeUpload(id string) {
+	s.mu.Lock()
+	u, ok := s.uploads[id]
+	delete(s.uploads, id)
+	s.mu.Unlock()
+	if ok {
+		u.remove()
+	}
+}
+
+func (s *Server) uploadPart(w http.ResponseWriter, r *http.Request, u *upload, partNumber string) error {
+	number, err := strconv.Atoi(partNumber)
+	if err != nil || number < 1 || number > maxPartNumber {
+		return errInvalidArgument.withMessage("part number must be an integer between 1 and 10000")
+	}
+	if r.ContentLength < 0 {
+		return errMissingContentLength
+	}
+	// the part is written aside, so that a failed upload of a part leaves
+	// the previous upload of the part in place
+	f, err := ioutil.TempFile(u.dir, "part")
+	if err != nil {
+		return err
+	}
+	h := md5.New()
+	n, err := io.Copy(io.MultiWriter(f, h), r.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && n != r.ContentLength {
+		err = errIncompleteBody
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), u.partPath(number))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return bodyError(r, err)
+	}
+	etag := hex.EncodeToString(h.Sum(nil))
+	u.mu.Lock()
+	u.parts[number] = part{etag: etag, size: n}
+	u.mu.Unlock()
+	w.Header().Set("ETag", quoteETag(etag))
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (s *Server) completeUpload(w http.ResponseWriter, r *http.Request, container stow.Container, id string, u *upload) error {
+	var complete completeMultipartUpload
+	if err := xml.NewDecoder(r.Body).Decode(&complete); err != nil {
+		return errMalformedXML
+	}
+	if len(complete.Parts) == 0 {
+		return errMalformedXML.withMessage("the upload must have at least one part")
+	}
+	var readers []io.Reader
+	var size int64
+	u.mu.Lock()
+	for i, p := range complete.Parts {
+		if i > 0 && p.PartNumber <= complete.Parts[i-1].PartNumber {
+			u.mu.Unlock()
+			return errInvalidPartOrder
+		}
+		uploaded, ok := u.parts[p.PartNumber]
+		if !ok || quoteETag(p.ETag) != quoteETag(uploaded.etag) {
+			u.mu.Unlock()
+			return errInvalidPart
+		}
+		size += uploaded.size
+	}
+	u.mu.Unlock()
+	for _, p := range complete.Parts {
+		f, err := os.Open(u.partPath(p.PartNumber))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+	item, err := put(container, u.key, io.MultiReader(readers...), size, u.metadata, u.props)
+	if err != nil {
+		return err
+	}
+	s.removeUpload(id)
+	etag, err := item.ETag()
+	if err != nil {
+		return err
+	}
+	return writeXML(w, http.StatusOK, completeMultipartUploadResult{
+		Location: "/" + u.bucket + "/" + u.key,
+		Bucket:   u.bucket,
+		Key:      u.key,
+		ETag:     quoteETag(etag),
+	})
+}
@@ -0,0 +1,489 @@
+package gateway
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graymeta/stow"
+)
+
+// maxKeys is the most keys a listing gives.
+const maxKeys = 1000
+
+// timeFormat is the format of times in S3 listings.
+const timeFormat = "2006-01-02T15:04:05.000Z"
+
+type listBucketResult struct {
+	XMLName               xml.Name       `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name                  string         `xml:"Name"`
+	Prefix                string         `xml:"Prefix"`
+	Delimiter             string         `xml:"Delimiter,omitempty"`
+	MaxKeys               int            `xml:"MaxKeys"`
+	KeyCount              int            `xml:"KeyCount"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string         `xml:"StartAfter,omitempty"`
+	EncodingType          string         `xml:"EncodingType,omitempty"`
+	Contents              []objectXML    `xml:"Contents"`
+	CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+}
+
+type objectXML struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+func (s *Server) listObjects(w http.ResponseWriter, r *http.Request, bucket string) error {
+	container, err := s.container(bucket)
+	if err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	result := listBucketResult{
+		Name:              bucket,
+		Prefix:            query.Get("prefix"),
+		Delimiter:         query.Get("delimiter"),
+		MaxKeys:           maxKeys,
+		ContinuationToken: query.Get("continuation-token"),
+		StartAfter:        query.Get("start-after"),
+		EncodingType:      query.Get("encoding-type"),
+	}
+	if v := query.Get("max-keys"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return errInvalidArgument.withMessage("max-keys must be a number that is not negative")
+		}
+		if n < maxKeys {
+			result.MaxKeys = n
+		}
+	}
+	cursor, err := base64.RawURLEncoding.DecodeString(result.ContinuationToken)
+	if err != nil {
+		return errInvalidArgument.withMessage("the continuation token provided is incorrect")
+	}
+	var page listPage
+	if result.MaxKeys > 0 {
+		page, err = list(container, result.Prefix, result.Delimiter, string(cursor), result.StartAfter, result.MaxKeys)
+		if err != nil {
+			return err
+		}
+	}
+	encode := func(s string) string { return s }
+	if result.EncodingType == "url" {
+		encode = func(s string) string { return strings.Replace(url.QueryEscape(s), "+", "%20", -1) }
+		result.Prefix = encode(result.Prefix)
+		result.Delimiter = encode(result.Delimiter)
+		result.StartAfter = encode(result.StartAfter)
+	}
+	for _, item := range page.items {
+		o, err := describeObject(item)
+		if err != nil {
+			return err
+		}
+		o.Key = encode(o.Key)
+		result.Contents = append(result.Contents, o)
+	}
+	for _, p := range page.prefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: encode(p)})
+	}
+	result.KeyCount = len(page.items) + len(page.prefixes)
+	if !stow.IsCursorEnd(page.next) {
+		result.IsTruncated = true
+		result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(page.next))
+	}
+	return writeXML(w, http.StatusOK, result)
+}
+
+func describeObject(item stow.Item) (objectXML, error) {
+	o := objectXML{Key: item.Name(), StorageClass: "STANDARD"}
+	var err error
+	if o.Size, err = item.Size(); err != nil {
+		return o, err
+	}
+	lastMod, err := item.LastMod()
+	if err != nil {
+		return o, err
+	}
+	o.LastModified = lastMod.UTC().Format(timeFormat)
+	etag, err := item.ETag()
+	if err != nil {
+		return o, err
+	}
+	o.ETag = quoteETag(etag)
+	return o, nil
+}
+
+// listPage is a page of a listing, and the cursor of the next page.
+type listPage struct {
+	items    []stow.Item
+	prefixes []string
+	next     string
+}
+
+// list gets up to count Items and prefixes after the cursor. The cursor
+// is the one of the Container, or the last key listed when the Container
+// cannot list by delimiter, as then a level is listed in full.
+func list(container stow.Container, prefix, delimiter, cursor, startAfter string, count int) (listPage, error) {
+	var page listPage
+	d, delimited := container.(stow.DelimiterLister)
+	if delimiter != "" && !delimited {
+		return listLevel(container, prefix, delimiter, cursor, startAfter, count)
+	}
+	// start-after only applies to the first page
+	if cursor != stow.CursorStart {
+		startAfter = ""
+	}
+	after := func(name string) bool {
+		return name > startAfter
+	}
+	for {
+		var items []stow.Item
+		var prefixes []string
+		var err error
+		n := count - len(page.items) - len(page.prefixes)
+		if delimiter == "" {
+			items, cursor, err = container.Items(prefix, cursor, n)
+		} else {
+			items, prefixes, cursor, err = d.ItemsDelimited(prefix, delimiter, cursor, n)
+		}
+		if err != nil {
+			return page, err
+		}
+		for _, item := range items {
+			if after(item.Name()) {
+				page.items = append(page.items, item)
+			}
+		}
+		for _, p := range prefixes {
+			if after(p) {
+				page.prefixes = append(page.prefixes, p)
+			}
+		}
+		if stow.IsCursorEnd(cursor) || len(page.items)+len(page.prefixes) >= count {
+			page.next = cursor
+			return page, nil
+		}
+	}
+}
+
+// listLevel lists a level of a Container in full, paging by key.
+func listLevel(container stow.Container, prefix, delimiter, cursor, startAfter string, count int) (listPage, error) {
+	var page listPage
+	items, prefixes, err := stow.ListDelimited(container, prefix, delimiter)
+	if err != nil {
+		return page, err
+	}
+	type entry struct {
+		key  string
+		item stow.Item
+	}
+	var entries []entry
+	for _, item := range items {
+		entries = append(entries, entry{key: item.Name(), item: item})
+	}
+	for _, p := range prefixes {
+		entries = append(entries, entry{key: p})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	if startAfter > cursor {
+		cursor = startAfter
+	}
+	for _, e := range entries {
+		if e.key <= cursor {
+			continue
+		}
+		if len(page.items)+len(page.prefixes) == count {
+			page.next = cursor
+			return page, nil
+		}
+		if e.item != nil {
+			page.items = append(page.items, e.item)
+		} else {
+			page.prefixes = append(page.prefixes, e.key)
+		}
+		cursor = e.key
+	}
+	return page, nil
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, container stow.Container, key string) error {
+	item, err := container.Item(key)
+	if err != nil {
+		return err
+	}
+	o, err := describeObject(item)
+	if err != nil {
+		return err
+	}
+	lastMod, err := item.LastMod()
+	if err != nil {
+		return err
+	}
+	h := w.Header()
+	h.Set("ETag", o.ETag)
+	h.Set("Last-Modified", lastMod.UTC().Format(http.TimeFormat))
+	h.Set("Accept-Ranges", "bytes")
+	h.Set("Content-Type", "binary/octet-stream")
+	if d, ok := item.(stow.ContentDescriber); ok {
+		props, err := d.ContentProperties()
+		if err != nil && !stow.IsNotSupported(err) {
+			return err
+		}
+		setContentHeaders(h, props)
+	}
+	metadata, err := item.Metadata()
+	if err != nil {
+		return err
+	}
+	for key, value := range metadata {
+		// values that are not strings cannot be headers
+		if v, ok := value.(string); ok && validHeaderValue(v) {
+			h.Set("X-Amz-Meta-"+key, v)
+		}
+	}
+
+	start, end, ranged, err := parseRange(r.Header.Get("Range"), o.Size)
+	if err != nil {
+		return err
+	}
+	status := http.StatusOK
+	if ranged {
+		status = http.StatusPartialContent
+		h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, o.Size))
+	}
+	length := end - start + 1
+	if o.Size == 0 {
+		length = 0
+	}
+	h.Set("Content-Length", strconv.FormatInt(length, 10))
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return nil
+	}
+	rc, err := openRange(item, start, end, ranged)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	w.WriteHeader(status)
+	if _, err := io.CopyN(w, rc, length); err != nil {
+		// the status is written, so the client sees a short body
+		s.options.Logger.Printf("gateway: GET %s: %v", r.URL.Path, err)
+	}
+	return nil
+}
+
+// openRange opens the range of the Item, skipping to the start of the
+// range when it is not an ItemRanger.
+func openRange(item stow.Item, start, end int64, ranged bool) (io.ReadCloser, error) {
+	if ranger, ok := item.(stow.ItemRanger); ok && ranged {
+		return ranger.OpenRange(uint64(start), uint64(end))
+	}
+	rc, err := item.Open()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(ioutil.Discard, rc, start); err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return rc, nil
+}
+
+// parseRange parses a Range header of a single byte range. Headers it
+// cannot parse are ignored, giving the whole object.
+func parseRange(header string, size int64) (int64, int64, bool, error) {
+	whole := func() (int64, int64, bool, error) { return 0, size - 1, false, nil }
+	if !strings.HasPrefix(header, "bytes=") || strings.Contains(header, ",") {
+		return whole()
+	}
+	parts := strings.SplitN(strings.TrimPrefix(header, "bytes="), "-", 2)
+	if len(parts) != 2 {
+		return whole()
+	}
+	first, last := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if first == "" {
+		// the last bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return whole()
+		}
+		if size == 0 {
+			return 0, 0, false, errInvalidRange
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, true, nil
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return whole()
+	}
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return whole()
+		}
+		if end > size-1 {
+			end = size - 1
+		}
+	}
+	if start >= size {
+		return 0, 0, false, errInvalidRange
+	}
+	return start, end, true, nil
+}
+
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, container stow.Container, key string) error {
+	if r.ContentLength < 0 {
+		return errMissingContentLength
+	}
+	body := io.Reader(r.Body)
+	if _, ok := r.Body.(*hashedBody); ok {
+		f, err := s.spool(r)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			f.Close()
+			os.Remove(f.Name())
+		}()
+		body = f
+	}
+	metadata, props := requestMetadata(r.Header)
+	item, err := put(container, key, body, r.ContentLength, metadata, props)
+	if err != nil {
+		return bodyError(r, err)
+	}
+	etag, err := item.ETag()
+	if err != nil {
+		return err
+	}
+	w.Header().Set("ETag", quoteETag(etag))
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// spool reads the body of the request into a file in the part directory,
+// so that its hash is checked before the Item is replaced with it.
+func (s *Server) spool(r *http.Request) (*os.File, error) {
+	f, err := ioutil.TempFile(s.partDir, "object")
+	if err != nil {
+		return nil, err
+	}
+	n, err := io.Copy(f, r.Body)
+	if err == nil && n != r.ContentLength {
+		err = errIncompleteBody
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, bodyError(r, err)
+	}
+	return f, nil
+}
+
+// put puts the Item with the content properties if the Container supports
+// them, and without them otherwise, as they only tell how to serve it.
+func put(container stow.Container, key string, r io.Reader, size int64, metadata map[string]interface{}, props stow.ContentProperties) (stow.Item, error) {
+	if p, ok := container.(stow.OptionsPutter); ok && !props.IsZero() {
+		return p.PutWithOptions(key, r, size, metadata, &stow.PutOptions{ContentProperties: props})
+	}
+	return container.Put(key, r, size, metadata)
+}
+
+// bodyError gets the error to give when putting the body of the request
+// failed, which is a client error when the body did not match its hash.
+func bodyError(r *http.Request, err error) error {
+	if b, ok := r.Body.(*hashedBody); ok && b.mismatch {
+		return errContentSHA256Mismatch
+	}
+	return err
+}
+
+func (s *Server) deleteObject(w http.ResponseWriter, container stow.Container, key string) error {
+	item, err := container.Item(key)
+	if err != nil && err != stow.ErrNotFound {
+		return err
+	}
+	// deleting a key that does not exist succeeds
+	if err == nil {
+		if err := container.RemoveItem(item.ID()); err != nil {
+			return err
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// requestMetadata gets the user metadata and the content properties from
+// the headers of a request.
+func requestMetadata(h http.Header) (map[string]interface{}, stow.ContentProperties) {
+	var metadata map[string]interface{}
+	for name, values := range h {
+		if !strings.HasPrefix(name, "X-Amz-Meta-") || len(values) == 0 {
+			continue
+		}
+		if metadata == nil {
+			metadata = make(map[string]interface{})
+		}
+		metadata[strings.ToLower(strings.TrimPrefix(name, "X-Amz-Meta-"))] = values[0]
+	}
+	props := stow.ContentProperties{
+		ContentType:        h.Get("Content-Type"),
+		ContentEncoding:    h.Get("Content-Encoding"),
+		ContentLanguage:    h.Get("Content-Language"),
+		CacheControl:       h.Get("Cache-Control"),
+		ContentDisposition: h.Get("Content-Disposition"),
+	}
+	return metadata, props
+}
+
+func setContentHeaders(h http.Header, props stow.ContentProperties) {
+	for name, value := range map[string]string{
+		"Content-Type":        props.ContentType,
+		"Content-Encoding":    props.ContentEncoding,
+		"Content-Language":    props.ContentLanguage,
+		"Cache-Control":       props.CacheControl,
+		"Content-Disposition": props.ContentDisposition,
+	} {
+		if value != "" {
+			h.Set(name, value)
+		}
+	}
+}
+
+func validHeaderValue(v string) bool {
+	for i := 0; i < len(v); i++ {
+		if v[i] < ' ' || v[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// quoteETag quotes the ETag as S3 does.
+func quoteETag(etag string) string {
+	return `"` + strings.Trim(etag, `"`) + `"`
+}
@@ -0,0 +1,382 @@
+package stow
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrBadPattern is returned for malformed glob patterns.
+var ErrBadPattern = errors.New("syntax error in glob pattern")
+
+// GlobOptions filter the Items visited by WalkGlob. The zero value
+// filters by the pattern only.
+type GlobOptions struct {
+	// Include are patterns besides the one given to WalkGlob. Items whose
+	// names match any of them are visited.
+	Include []string
+	// Exclude are patterns of names to skip, even when they are included.
+	Exclude []string
+	// Regexp, when set, must match the names of the Items visited.
+	Regexp *regexp.Regexp
+	// MinSize is the smallest size in bytes of the Items visited.
+	MinSize int64
+	// MaxSize is the largest size in bytes of the Items visited. Zero means
+	// there is no limit.
+	MaxSize int64
+	// ModifiedAfter, when set, skips Items last modified before it.
+	ModifiedAfter time.Time
+	// ModifiedBefore, when set, skips Items last modified at or after it.
+	ModifiedBefore time.Time
+	// Metadata are the metadata values the Items visited must have, which
+	// are compared as strings. Checking metadata can take a request per
+	// Item with some implementations.
+	Metadata map[string]string
+	// PageSize is the number of Items to get per request. Defaults to 100.
+	PageSize int
+}
+
+// WalkGlob walks the Items in the Container whose names match the glob
+// pattern, or any of the include patterns of the options, and pass their
+// other filters. An empty pattern matches every name.
+//
+// Patterns are those of path.Match, plus {a,b} for alternatives and ** for
+// any sequence of characters, slashes included. A /**/ also matches a
+// single slash, so logs/**/*.gz matches logs/a.gz as well as
+// logs/2024/01/a.gz. Only the Items under the literal prefix of each
+// pattern, up to its first special character, are listed.
+func WalkGlob(container Container, pattern string, options *GlobOptions, fn WalkFunc) error {
+	if options == nil {
+		options = &GlobOptions{}
+	}
+	includes := options.Include
+	if pattern != "" || len(includes) == 0 {
+		includes = append([]string{pattern}, includes...)
+	}
+	var patterns []string
+	for _, p := range includes {
+		if p == "" {
+			p = "**"
+		}
+		expanded, err := expandGlob(p)
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, expanded...)
+	}
+	var excludes []string
+	for _, p := range options.Exclude {
+		expanded, err := expandGlob(p)
+		if err != nil {
+			return err
+		}
+		excludes = append(excludes, expanded...)
+	}
+	pageSize := options.PageSize
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	for _, prefix := range globPrefixes(patterns) {
+		err := Walk(container, prefix, pageSize, func(item Item, err error) error {
+			if err != nil {
+				return fn(nil, err)
+			}
+			ok, err := globFilter(item, patterns, excludes, options)
+			if err != nil {
+				return fn(nil, err)
+			}
+			if !ok {
+				return nil
+			}
+			return fn(item, nil)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// globFilter gets whether the Item passes the patterns and filters.
+func globFilter(item Item, patterns, excludes []string, options *GlobOptions) (bool, error) {
+	name := item.Name()
+	if !matchAny(patterns, name) || matchAny(excludes, name) {
+		return false, nil
+	}
+	if options.Regexp != nil && !options.Regexp.MatchString(name) {
+		return false, nil
+	}
+	if options.MinSize > 0 || options.MaxSize > 0 {
+		size, err := item.Size()
+		if err != nil {
+			return false, err
+		}
+		if size < options.MinSize || (options.MaxSize > 0 && size > options.MaxSize) {
+			return false, nil
+		}
+	}
+	if !options.ModifiedAfter.IsZero() || !options.ModifiedBefore.IsZero() {
+		lastMod, err := item.LastMod()
+		if err != nil {
+			return false, err
+		}
+		if lastMod.Before(options.ModifiedAfter) {
+			return false, nil
+		}
+		if !options.ModifiedBefore.IsZero() && !lastMod.Before(options.ModifiedBefore) {
+			return false, nil
+		}
+	}
+	if len(options.Metadata) > 0 {
+		metadata, err := item.Metadata()
+		if err != nil {
+			return false, err
+		}
+		for key, want := range options.Metadata {
+			value, ok := metadata[key]
+			if !ok || fmt.Sprint(value) != want {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if globMatch(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// globPrefixes gets the literal prefixes of the patterns, leaving out those
+// that another one covers.
+func globPrefixes(patterns []string) []string {
+	var prefixes []string
+	for _, p := range patterns {
+		prefixes = append(prefixes, globPrefix(p))
+	}
+	sort.Strings(prefixes)
+	var narrow []string
+	for _, p := range prefixes {
+		if len(narrow) > 0 && strings.HasPrefix(p, narrow[len(narrow)-1]) {
+			continue
+		}
+		narrow = append(narrow, p)
+	}
+	return narrow
+}
+
+// globPrefix gets the part of the pattern before its first special
+// character.
+func globPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// MatchGlob gets whether the name matches the glob pattern, as described
+// for WalkGlob.
+func MatchGlob(pattern, name string) (bool, error) {
+	patterns, err := expandGlob(pattern)
+	if err != nil {
+		return false, err
+	}
+	return matchAny(patterns, name), nil
+}
+
+// expandGlob checks the pattern, and expands its alternatives into
+// patterns without any.
+func expandGlob(pattern string) ([]string, error) {
+	start, depth := -1, 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			if i+1 == len(pattern) {
+				return nil, ErrBadPattern
+			}
+			i++
+		case '[':
+			end := classEnd(pattern, i)
+			if end < 0 {
+				return nil, ErrBadPattern
+			}
+			i = end
+		case '{':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				return nil, ErrBadPattern
+			}
+			depth--
+			if depth > 0 {
+				continue
+			}
+			var expanded []string
+			for _, alt := range splitAlternatives(pattern[start+1 : i]) {
+				more, err := expandGlob(pattern[:start] + alt + pattern[i+1:])
+				if err != nil {
+					return nil, err
+				}
+				expanded = append(expanded, more...)
+			}
+			return expanded, nil
+		}
+	}
+	if depth > 0 {
+		return nil, ErrBadPattern
+	}
+	return expandDirs(pattern, 0), nil
+}
+
+// expandDirs expands each **/ starting a segment of the pattern, from the
+// index on, into itself and nothing, as it also matches no directories.
+func expandDirs(pattern string, from int) []string {
+	for i := from; i+3 <= len(pattern); i++ {
+		if pattern[i:i+3] == "**/" && (i == 0 || pattern[i-1] == '/') {
+			return append(expandDirs(pattern, i+3), expandDirs(pattern[:i]+pattern[i+3:], i)...)
+		}
+	}
+	return []string{pattern}
+}
+
+// splitAlternatives splits the inside of braces at the commas outside
+// nested braces.
+func splitAlternatives(s string) []string {
+	var alts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				alts = append(alts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(alts, s[start:])
+}
+
+// classEnd gets the index of the bracket closing the character class that
+// starts at i, or -1.
+func classEnd(pattern string, i int) int {
+	i++
+	if i < len(pattern) && (pattern[i] == '^' || pattern[i] == '!') {
+		i++
+	}
+	// a bracket first in the class is part of it
+	if i < len(pattern) && pattern[i] == ']' {
+		i++
+	}
+	for ; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// globMatch matches the name against a checked pattern without
+// alternatives.
+func globMatch(pattern, name string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			if strings.HasPrefix(pattern, "**") {
+				rest := strings.TrimLeft(pattern, "*")
+				for i := 0; i <= len(name); i++ {
+					if globMatch(rest, name[i:]) {
+						return true
+					}
+				}
+				return false
+			}
+			for i := 0; i <= len(name); i++ {
+				if globMatch(pattern[1:], name[i:]) {
+					return true
+				}
+				if i < len(name) && name[i] == '/' {
+					break
+				}
+			}
+			return false
+		case '?':
+			r, n := utf8.DecodeRuneInString(name)
+			if n == 0 || r == '/' {
+				return false
+			}
+			pattern, name = pattern[1:], name[n:]
+		case '[':
+			r, n := utf8.DecodeRuneInString(name)
+			if n == 0 || r == '/' {
+				return false
+			}
+			end := classEnd(pattern, 0)
+			if !matchClass(pattern[1:end], r) {
+				return false
+			}
+			pattern, name = pattern[end+1:], name[n:]
+		case '\\':
+			pattern = pattern[1:]
+			fallthrough
+		default:
+			if len(name) == 0 || name[0] != pattern[0] {
+				return false
+			}
+			pattern, name = pattern[1:], name[1:]
+		}
+	}
+	return len(name) == 0
+}
+
+// matchClass matches the rune against the inside of a character class.
+func matchClass(class string, r rune) bool {
+	negated := false
+	if len(class) > 0 && (class[0] == '^' || class[0] == '!') {
+		negated = true
+		class = class[1:]
+	}
+	matched := false
+	for len(class) > 0 {
+		lo, hi := nextClassRune(&class)
+		if len(class) > 1 && class[0] == '-' {
+			class = class[1:]
+			hi, _ = nextClassRune(&class)
+		}
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+	return matched != negated
+}
+
+// nextClassRune takes the next, possibly escaped, rune of a character
+// class, returning it twice as a range of one.
+func nextClassRune(class *string) (rune, rune) {
+	s := *class
+	if s[0] == '\\' && len(s) > 1 {
+		s = s[1:]
+	}
+	r, n := utf8.DecodeRuneInString(s)
+	*class = s[n:]
+	return r, r
+}
@@ -0,0 +1,103 @@
+package stow_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestMatchGlob(t *testing.T) {
+	is := is.New(t)
+	for _, test := range []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"*.gz", "a.gz", true},
+		{"*.gz", "logs/a.gz", false},
+		{"logs/*/2024-*/**.gz", "logs/web/2024-01/02/a.gz", true},
+		{"logs/*/2024-*/**.gz", "logs/web/2023-01/02/a.gz", false},
+		{"logs/**/*.gz", "logs/a.gz", true},
+		{"logs/**/*.gz", "logs/x/y/a.gz", true},
+		{"logs/**/*.gz", "logsa.gz", false},
+		{"**/a", "a", true},
+		{"**/a", "x/y/a", true},
+		{"**", "anything/at/all", true},
+		{"a?c", "abc", true},
+		{"a?c", "a/c", false},
+		{"[a-c]x", "bx", true},
+		{"[!a-c]x", "bx", false},
+		{"[^a-c]x", "dx", true},
+		{"[]]x", "]x", true},
+		{"{jpg,png}/*", "png/a", true},
+		{"*.{jpg,png}", "a.gif", false},
+		{"a{b,c{d,e}}", "ace", true},
+		{`\*`, "*", true},
+		{`\*`, "a", false},
+		{"café?", "caféé", true},
+	} {
+		match, err := stow.MatchGlob(test.pattern, test.name)
+		is.NoErr(err)
+		is.Equal(match, test.match)
+	}
+	for _, pattern := range []string{"[a", "{a,b", "a}", `a\`} {
+		_, err := stow.MatchGlob(pattern, "a")
+		is.Equal(err, stow.ErrBadPattern)
+	}
+}
+
+// timedItem is an Item with a last modified time and metadata.
+type timedItem struct {
+	dataItem
+	lastMod  time.Time
+	metadata map[string]interface{}
+}
+
+func (i *timedItem) LastMod() (time.Time, error)               { return i.lastMod, nil }
+func (i *timedItem) Metadata() (map[string]interface{}, error) { return i.metadata, nil }
+
+func TestWalkGlob(t *testing.T) {
+	is := is.New(t)
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &usageContainer{}
+	for i, name := range []string{"logs/web/2024-01/a.gz", "logs/web/2024-01/b.txt", "logs/db/2024-02/c.gz", "logs/db/2023-12/d.gz", "images/e.png"} {
+		c.items = append(c.items, &timedItem{
+			dataItem: dataItem{testItem: testItem{name: name}, data: make([]byte, i*10)},
+			lastMod:  day.AddDate(0, 0, i),
+			metadata: map[string]interface{}{"index": i},
+		})
+	}
+	walk := func(pattern string, options *stow.GlobOptions) []string {
+		var names []string
+		err := stow.WalkGlob(c, pattern, options, func(item stow.Item, err error) error {
+			is.NoErr(err)
+			names = append(names, item.Name())
+			return nil
+		})
+		is.NoErr(err)
+		return names
+	}
+
+	is.Equal(walk("logs/*/2024-*/**.gz", nil), []string{"logs/web/2024-01/a.gz", "logs/db/2024-02/c.gz"})
+	is.Equal(c.takeListed(), []string{"logs/"})
+
+	// the listing is as narrow as the literal prefixes allow
+	is.Equal(walk("logs/web/*", &stow.GlobOptions{Include: []string{"images/*", "logs/web/2024-01/*"}}),
+		[]string{"images/e.png", "logs/web/2024-01/a.gz", "logs/web/2024-01/b.txt"})
+	is.Equal(c.takeListed(), []string{"images/", "logs/web/"})
+	is.Equal(walk("{images,logs/db}/**", nil), []string{"images/e.png", "logs/db/2024-02/c.gz", "logs/db/2023-12/d.gz"})
+	is.Equal(c.takeListed(), []string{"images/", "logs/db/"})
+
+	is.Equal(walk("", &stow.GlobOptions{Exclude: []string{"**.gz"}}), []string{"logs/web/2024-01/b.txt", "images/e.png"})
+	is.Equal(c.takeListed(), []string{""})
+	is.Equal(walk("", &stow.GlobOptions{Regexp: regexp.MustCompile(`/[ab]\.`)}), []string{"logs/web/2024-01/a.gz", "logs/web/2024-01/b.txt"})
+	is.Equal(walk("", &stow.GlobOptions{MinSize: 10, MaxSize: 30}), []string{"logs/web/2024-01/b.txt", "logs/db/2024-02/c.gz", "logs/db/2023-12/d.gz"})
+	is.Equal(walk("", &stow.GlobOptions{ModifiedAfter: day.AddDate(0, 0, 1), ModifiedBefore: day.AddDate(0, 0, 3)}), []string{"logs/web/2024-01/b.txt", "logs/db/2024-02/c.gz"})
+	is.Equal(walk("**.gz", &stow.GlobOptions{Metadata: map[string]string{"index": "2"}}), []string{"logs/db/2024-02/c.gz"})
+
+	err := stow.WalkGlob(c, "[", nil, func(stow.Item, error) error { return nil })
+	is.Equal(err, stow.ErrBadPattern)
+}
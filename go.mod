@@ -1,6 +1,6 @@
 module github.com/graymeta/stow
 
-go 1.14
+go 1.16
 
 require (
 	cloud.google.com/go v0.38.0
@@ -20,7 +20,7 @@ require (
 	github.com/satori/go.uuid v1.2.0 // indirect
 	github.com/stretchr/testify v1.4.0
 	golang.org/x/crypto v0.0.0-20190701094942-4def268fd1a4
-	golang.org/x/net v0.0.0-20190813141303-74dc4d7220e7 // indirect
+	golang.org/x/net v0.0.0-20190813141303-74dc4d7220e7
 	golang.org/x/oauth2 v0.0.0-20190604053449-0f29369cfe45
 	google.golang.org/api v0.8.0
 	gopkg.in/kothar/go-backblaze.v0 v0.0.0-20190520213052-702d4e7eb465
@@ -3,6 +3,7 @@ package google
 import (
 	"context"
 	"io"
+	"net/http"
 
 	"cloud.google.com/go/storage"
 	"github.com/pkg/errors"
@@ -11,6 +12,8 @@ import (
 	"github.com/graymeta/stow"
 )
 
+var _ stow.DelimiterLister = (*Container)(nil)
+
 type Container struct {
 	// Name is needed to retrieve items.
 	name string
@@ -18,6 +21,9 @@ type Container struct {
 	// Client is responsible for performing the requests.
 	client *storage.Client
 
+	// httpClient is authorized for the requests the client cannot make.
+	httpClient *http.Client
+
 	// ctx is used on google storage API calls
 	ctx context.Context
 }
@@ -37,6 +43,54 @@ func (c *Container) Bucket() *storage.BucketHandle{
 	return c.client.Bucket(c.name)
 }
 
+// Stat describes the bucket. The bucket is public if its ACL or IAM policy
+// grants all users access, and its labels are returned as metadata.
+func (c *Container) Stat() (stow.ContainerInfo, error) {
+	var info stow.ContainerInfo
+	attrs, err := c.Bucket().Attrs(c.ctx)
+	if err != nil {
+		if err == storage.ErrBucketNotExist {
+			return info, stow.ErrNotFound
+		}
+		return info, errors.Wrap(err, "getting bucket attributes")
+	}
+	info.Created = attrs.Created
+	info.Region = attrs.Location
+	info.StorageClass = stow.StorageClassFromNative(attrs.StorageClass, storageClasses)
+	info.Versioning = stow.VersioningDisabled
+	if attrs.VersioningEnabled {
+		info.Versioning = stow.VersioningEnabled
+	}
+	info.Metadata = make(map[string]interface{}, len(attrs.Labels))
+	for k, v := range attrs.Labels {
+		info.Metadata[k] = v
+	}
+	for _, rule := range attrs.ACL {
+		if isPublic(string(rule.Entity)) {
+			info.PublicAccess = true
+		}
+	}
+	if !info.PublicAccess {
+		policy, err := c.Bucket().IAM().Policy(c.ctx)
+		if err != nil {
+			return info, errors.Wrap(err, "getting bucket IAM policy")
+		}
+		for _, role := range policy.Roles() {
+			for _, member := range policy.Members(role) {
+				if isPublic(member) {
+					info.PublicAccess = true
+				}
+			}
+		}
+	}
+	return info, nil
+}
+
+// isPublic gets whether an ACL entity or IAM member is anyone.
+func isPublic(entity string) bool {
+	return entity == string(storage.AllUsers) || entity == string(storage.AllAuthenticatedUsers)
+}
+
 // Item returns a stow.Item instance of a container based on the
 // name of the container
 func (c *Container) Item(id string) (stow.Item, error) {
@@ -77,15 +131,56 @@ func (c *Container) Items(prefix string, cursor string, count int) ([]stow.Item,
 	return items, nextPageToken, nil
 }
 
+// ItemsDelimited lists the objects with the prefix that have no delimiter
+// after it, and the prefixes of the others, which Google Cloud Storage
+// gives as objects with only a prefix.
+func (c *Container) ItemsDelimited(prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	query := &storage.Query{Prefix: prefix, Delimiter: delimiter}
+	call := c.Bucket().Objects(c.ctx, query)
+
+	p := iterator.NewPager(call, count, cursor)
+	var results []*storage.ObjectAttrs
+	nextPageToken, err := p.NextPage(&results)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	var items []stow.Item
+	var prefixes []string
+	for _, attr := range results {
+		if attr.Prefix != "" {
+			prefixes = append(prefixes, attr.Prefix)
+			continue
+		}
+		i, err := c.convertToStowItem(attr)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		items = append(items, i)
+	}
+
+	return items, prefixes, nextPageToken, nil
+}
+
 // RemoveItem will delete a google storage Object
 func (c *Container) RemoveItem(id string) error {
-	return c.Bucket().Object(id).Delete(c.ctx)
+	err := c.Bucket().Object(id).Delete(c.ctx)
+	if isRetained(err) {
+		return stow.ErrLocked
+	}
+	return err
 }
 
 // Put sends a request to upload content to the container. The arguments
 // received are the name of the item, a reader representing the
 // content, and the size of the file.
 func (c *Container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutWithOptions(name, r, size, metadata, nil)
+}
+
+// PutWithOptions uploads content to the container like Put, also setting
+// the content headers given in the options.
+func (c *Container) PutWithOptions(name string, r io.Reader, size int64, metadata map[string]interface{}, options *stow.PutOptions) (stow.Item, error) {
 	obj := c.Bucket().Object(name)
 
 	mdPrepped, err := prepMetadata(metadata)
@@ -93,7 +188,44 @@ func (c *Container) Put(name string, r io.Reader, size int64, metadata map[strin
 		return nil, err
 	}
 
+	content, r, err := stow.PrepareContentProperties(name, r, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var customerKey []byte
+	if options != nil {
+		if len(options.Tags) > 0 {
+			values, err := stow.TagValues(options.Tags)
+			if err != nil {
+				return nil, err
+			}
+			if mdPrepped[tagsMetadataKey], err = encodeTags(values); err != nil {
+				return nil, err
+			}
+		}
+		if options.Encryption != nil && options.Encryption.Mode == stow.EncryptionCustomerKey {
+			customerKey = options.Encryption.CustomerKey
+			obj = obj.Key(customerKey)
+		}
+	}
+
 	w := obj.NewWriter(c.ctx)
+	if options != nil {
+		if err := setWriterOptions(w, options); err != nil {
+			return nil, err
+		}
+		// the chunks of an upload are sent one after another, so there
+		// is no concurrency to tune
+		if options.Transfer.PartSize > 0 {
+			w.ChunkSize = int(options.Transfer.PartSize)
+		}
+	}
+	w.ContentType = content.ContentType
+	w.ContentEncoding = content.ContentEncoding
+	w.ContentLanguage = content.ContentLanguage
+	w.CacheControl = content.CacheControl
+	w.ContentDisposition = content.ContentDisposition
 	if _, err := io.Copy(w, r); err != nil {
 		return nil, err
 	}
@@ -104,7 +236,55 @@ func (c *Container) Put(name string, r io.Reader, size int64, metadata map[strin
 		return nil, err
 	}
 
-	return c.convertToStowItem(attr)
+	item, err := c.convertToStowItem(attr)
+	if err != nil {
+		return nil, err
+	}
+	item.(*Item).customerKey = customerKey
+	return item, nil
+}
+
+// storageClasses are the names Google Cloud Storage uses for the
+// provider-neutral storage classes.
+var storageClasses = map[stow.StorageClass]string{
+	stow.StorageClassHot:     "STANDARD",
+	stow.StorageClassCool:    "NEARLINE",
+	stow.StorageClassCold:    "COLDLINE",
+	stow.StorageClassArchive: "ARCHIVE",
+}
+
+// predefinedACLs are the names Google Cloud Storage uses for the
+// provider-neutral ACLs.
+var predefinedACLs = map[stow.ACL]string{
+	stow.ACLPrivate:    "private",
+	stow.ACLPublicRead: "publicRead",
+}
+
+// setWriterOptions applies the storage class, ACL and KMS key of the options
+// to the writer. Customer provided keys are set on the object handle.
+func setWriterOptions(w *storage.Writer, options *stow.PutOptions) error {
+	var err error
+	w.StorageClass, err = stow.NativeStorageClass(options.StorageClass, storageClasses)
+	if err != nil {
+		return err
+	}
+	w.PredefinedACL = string(options.ACL)
+	if acl, ok := predefinedACLs[options.ACL]; ok {
+		w.PredefinedACL = acl
+	}
+	if e := options.Encryption; e != nil {
+		switch e.Mode {
+		case stow.EncryptionProvider, stow.EncryptionCustomerKey:
+		case stow.EncryptionKMS:
+			if e.KMSKeyID == "" {
+				return errors.New("KMS key ID is required")
+			}
+			w.KMSKeyName = e.KMSKeyID
+		default:
+			return stow.NotSupported("encryption mode " + string(e.Mode))
+		}
+	}
+	return nil
 }
 
 func (c *Container) convertToStowItem(attr *storage.ObjectAttrs) (stow.Item, error) {
@@ -130,12 +310,22 @@ func (c *Container) convertToStowItem(attr *storage.ObjectAttrs) (stow.Item, err
 		metadata:     mdParsed,
 		object:       attr,
 		ctx:          c.ctx,
+		content: stow.ContentProperties{
+			ContentType:        attr.ContentType,
+			ContentEncoding:    attr.ContentEncoding,
+			ContentLanguage:    attr.ContentLanguage,
+			CacheControl:       attr.CacheControl,
+			ContentDisposition: attr.ContentDisposition,
+		},
 	}, nil
 }
 
 func parseMetadata(metadataParsed map[string]string) (map[string]interface{}, error) {
 	metadataParsedMap := make(map[string]interface{}, len(metadataParsed))
 	for key, value := range metadataParsed {
+		if key == tagsMetadataKey {
+			continue
+		}
 		metadataParsedMap[key] = value
 	}
 	return metadataParsedMap, nil
@@ -144,6 +334,9 @@ func parseMetadata(metadataParsed map[string]string) (map[string]interface{}, er
 func prepMetadata(metadataParsed map[string]interface{}) (map[string]string, error) {
 	returnMap := make(map[string]string, len(metadataParsed))
 	for key, value := range metadataParsed {
+		if key == tagsMetadataKey {
+			return nil, errors.Errorf(`metadata key '%s' is reserved for tags`, key)
+		}
 		str, ok := value.(string)
 		if !ok {
 			return nil, errors.Errorf(`value of key '%s' in metadata must be of type string`, key)
@@ -7,8 +7,12 @@ import (
 	"time"
 
 	"cloud.google.com/go/storage"
+
+	"github.com/graymeta/stow"
 )
 
+var _ stow.StorageClassDescriber = (*Item)(nil)
+
 type Item struct {
 	container    *Container       // Container information is required by a few methods.
 	client       *storage.Client  // A client is needed to make requests.
@@ -21,6 +25,8 @@ type Item struct {
 	metadata     map[string]interface{}
 	object       *storage.ObjectAttrs
 	ctx          context.Context
+	content      stow.ContentProperties
+	customerKey  []byte // the key the object was encrypted with, if any
 }
 
 // ID returns a string value that represents the name of a file.
@@ -45,13 +51,12 @@ func (i *Item) URL() *url.URL {
 
 // Open returns an io.ReadCloser to the object. Useful for downloading/streaming the object.
 func (i *Item) Open() (io.ReadCloser, error) {
-	obj := i.container.Bucket().Object(i.name)
-	return obj.NewReader(i.ctx)
+	return i.handle().NewReader(i.ctx)
 }
 
 // OpenRange returns an io.Reader to the object for a specific byte range
 func (i *Item) OpenRange(start, end uint64) (io.ReadCloser, error) {
-	obj := i.container.Bucket().Object(i.name)
+	obj := i.handle()
 	return obj.NewRangeReader(i.ctx, int64(start), int64(end - start) + 1)
 }
 
@@ -70,11 +75,26 @@ func (i *Item) ETag() (string, error) {
 	return i.etag, nil
 }
 
+// ContentProperties returns the content headers of the object.
+func (i *Item) ContentProperties() (stow.ContentProperties, error) {
+	return i.content, nil
+}
+
 // Object returns the Google Storage Object
 func (i *Item) StorageObject() *storage.ObjectAttrs {
 	return i.object
 }
 
+// handle returns a handle to the object, which carries the key the object
+// was encrypted with when it was put with a customer provided key.
+func (i *Item) handle() *storage.ObjectHandle {
+	obj := i.container.Bucket().Object(i.name)
+	if i.customerKey != nil {
+		obj = obj.Key(i.customerKey)
+	}
+	return obj
+}
+
 // prepUrl takes a MediaLink string and returns a url
 func prepUrl(str string) (*url.URL, error) {
 	u, err := url.Parse(str)
@@ -87,3 +107,8 @@ func prepUrl(str string) (*url.URL, error) {
 	u.RawQuery = ""
 	return u, nil
 }
+
+// StorageClass gets the storage class the object was listed with.
+func (i *Item) StorageClass() (stow.StorageClass, error) {
+	return stow.StorageClassFromNative(i.object.StorageClass, storageClasses), nil
+}
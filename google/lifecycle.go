@@ -0,0 +1,94 @@
+package google
+
+import (
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+
+	"github.com/graymeta/stow"
+)
+
+var _ stow.LifecycleManager = (*Container)(nil)
+
+// LifecycleRules gets the lifecycle rules of the bucket. Google Cloud
+// Storage rules have no IDs or prefixes, and rules with conditions other
+// than the age of the object are left out.
+func (c *Container) LifecycleRules() ([]stow.LifecycleRule, error) {
+	attrs, err := c.Bucket().Attrs(c.ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting bucket attributes")
+	}
+	return fromLifecycle(attrs.Lifecycle), nil
+}
+
+// SetLifecycleRules replaces the lifecycle rules of the bucket. Only delete
+// and transition rules without a prefix are supported, and the rules cannot
+// all be removed.
+func (c *Container) SetLifecycleRules(rules []stow.LifecycleRule) error {
+	lifecycle, err := toLifecycle(rules)
+	if err != nil {
+		return err
+	}
+	_, err = c.Bucket().Update(c.ctx, storage.BucketAttrsToUpdate{Lifecycle: &lifecycle})
+	if err != nil {
+		return errors.Wrap(err, "updating bucket lifecycle")
+	}
+	return nil
+}
+
+func toLifecycle(rules []stow.LifecycleRule) (storage.Lifecycle, error) {
+	var lifecycle storage.Lifecycle
+	if len(rules) == 0 {
+		return lifecycle, stow.NotSupported("removing all lifecycle rules")
+	}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return lifecycle, err
+		}
+		if rule.Prefix != "" {
+			return lifecycle, stow.NotSupported("lifecycle rule prefix")
+		}
+		r := storage.LifecycleRule{
+			Condition: storage.LifecycleCondition{AgeInDays: int64(rule.AgeDays())},
+		}
+		switch rule.Action {
+		case stow.LifecycleDelete:
+			r.Action.Type = storage.DeleteAction
+		case stow.LifecycleTransition:
+			class, err := stow.NativeStorageClass(rule.StorageClass, storageClasses)
+			if err != nil {
+				return lifecycle, err
+			}
+			r.Action.Type = storage.SetStorageClassAction
+			r.Action.StorageClass = class
+		default:
+			return lifecycle, stow.NotSupported("lifecycle action " + string(rule.Action))
+		}
+		lifecycle.Rules = append(lifecycle.Rules, r)
+	}
+	return lifecycle, nil
+}
+
+func fromLifecycle(lifecycle storage.Lifecycle) []stow.LifecycleRule {
+	var rules []stow.LifecycleRule
+	for _, r := range lifecycle.Rules {
+		cond := r.Condition
+		if cond.Liveness == storage.Archived || !cond.CreatedBefore.IsZero() ||
+			cond.NumNewerVersions != 0 || len(cond.MatchesStorageClasses) > 0 {
+			continue
+		}
+		rule := stow.LifecycleRule{Age: time.Duration(cond.AgeInDays) * 24 * time.Hour}
+		switch r.Action.Type {
+		case storage.DeleteAction:
+			rule.Action = stow.LifecycleDelete
+		case storage.SetStorageClassAction:
+			rule.Action = stow.LifecycleTransition
+			rule.StorageClass = stow.StorageClassFromNative(r.Action.StorageClass, storageClasses)
+		default:
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
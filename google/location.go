@@ -3,6 +3,7 @@ package google
 import (
 	"context"
 	"errors"
+	"net/http"
 	"net/url"
 	"strings"
 
@@ -15,9 +16,10 @@ import (
 
 // A Location contains a client + the configurations used to create the client.
 type Location struct {
-	config stow.Config
-	client *storage.Client
-	ctx    context.Context
+	config     stow.Config
+	client     *storage.Client
+	httpClient *http.Client
+	ctx        context.Context
 }
 
 func (l *Location) Service() *storage.Client {
@@ -37,17 +39,19 @@ func (l *Location) CreateContainer(containerName string) (stow.Container, error)
 	if err := bucket.Create(l.ctx, projId, nil); err != nil {
 		if e, ok := err.(*googleapi.Error); ok && e.Code == 409 {
 			return &Container{
-				name:   containerName,
-				client: l.client,
+				name:       containerName,
+				client:     l.client,
+				httpClient: l.httpClient,
 			}, nil
 		}
 		return nil, err
 	}
 
 	return &Container{
-		name:   containerName,
-		client: l.client,
-		ctx:    l.ctx,
+		name:       containerName,
+		client:     l.client,
+		httpClient: l.httpClient,
+		ctx:        l.ctx,
 	}, nil
 }
 
@@ -69,9 +73,10 @@ func (l *Location) Containers(prefix string, cursor string, count int) ([]stow.C
 	var containers []stow.Container
 	for _, container := range results {
 		containers = append(containers, &Container{
-			name:   container.Name,
-			client: l.client,
-			ctx:    l.ctx,
+			name:       container.Name,
+			client:     l.client,
+			httpClient: l.httpClient,
+			ctx:        l.ctx,
 		})
 	}
 
@@ -90,9 +95,10 @@ func (l *Location) Container(id string) (stow.Container, error) {
 	}
 
 	c := &Container{
-		name:   attrs.Name,
-		client: l.client,
-		ctx:    l.ctx,
+		name:       attrs.Name,
+		client:     l.client,
+		httpClient: l.httpClient,
+		ctx:        l.ctx,
 	}
 
 	return c, nil
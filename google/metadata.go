@@ -0,0 +1,85 @@
+package google
+
+import (
+	"cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+
+	"github.com/graymeta/stow"
+)
+
+var _ stow.MetadataSetter = (*Item)(nil)
+
+// SetMetadata replaces the metadata of the object, keeping its tags.
+func (i *Item) SetMetadata(metadata map[string]interface{}) error {
+	mdPrepped, err := prepMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	attrs, err := i.handle().Attrs(i.ctx)
+	if err != nil {
+		return i.updateError(err)
+	}
+	return i.updateMetadata(attrs, mdPrepped)
+}
+
+// MergeMetadata changes the given keys in the metadata of the object.
+func (i *Item) MergeMetadata(changes map[string]interface{}) error {
+	attrs, err := i.handle().Attrs(i.ctx)
+	if err != nil {
+		return i.updateError(err)
+	}
+	current, err := parseMetadata(attrs.Metadata)
+	if err != nil {
+		return err
+	}
+	mdPrepped, err := prepMetadata(stow.MergedMetadata(current, changes))
+	if err != nil {
+		return err
+	}
+	return i.updateMetadata(attrs, mdPrepped)
+}
+
+// updateMetadata sets the metadata of the object described by attrs.
+// Updates can add keys but not remove them, so when keys are removed all
+// metadata is cleared first. Both updates are conditional, so that changes
+// made in between are not lost silently.
+func (i *Item) updateMetadata(attrs *storage.ObjectAttrs, metadata map[string]string) error {
+	if tags, ok := attrs.Metadata[tagsMetadataKey]; ok {
+		metadata[tagsMetadataKey] = tags
+	}
+	remove := false
+	for key := range attrs.Metadata {
+		if _, ok := metadata[key]; !ok {
+			remove = true
+			break
+		}
+	}
+	if remove {
+		obj := i.handle().If(storage.Conditions{MetagenerationMatch: attrs.Metageneration})
+		var err error
+		attrs, err = obj.Update(i.ctx, storage.ObjectAttrsToUpdate{Metadata: map[string]string{}})
+		if err != nil {
+			return i.updateError(err)
+		}
+	}
+	if len(metadata) > 0 {
+		obj := i.handle().If(storage.Conditions{MetagenerationMatch: attrs.Metageneration})
+		var err error
+		attrs, err = obj.Update(i.ctx, storage.ObjectAttrsToUpdate{Metadata: metadata})
+		if err != nil {
+			return i.updateError(err)
+		}
+	}
+	i.object = attrs
+	i.etag = attrs.Etag
+	var err error
+	i.metadata, err = parseMetadata(attrs.Metadata)
+	return err
+}
+
+func (i *Item) updateError(err error) error {
+	if err == storage.ErrObjectNotExist {
+		return stow.ErrNotFound
+	}
+	return errors.Wrap(err, "updating object metadata")
+}
@@ -0,0 +1,250 @@
+package google
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/graymeta/stow"
+)
+
+// uploadURL is the endpoint of the JSON API for uploads. The storage
+// client hides the sessions of its resumable uploads, so they are made
+// with requests of their own.
+const uploadURL = "https://storage.googleapis.com/upload/storage/v1/b/"
+
+const (
+	// chunkAlignment is what all chunks of a session but the last must be
+	// a multiple of.
+	chunkAlignment = 256 * 1024
+	// defaultChunkSize is the size of chunks when none is given.
+	defaultChunkSize = 16 * 1024 * 1024
+)
+
+var _ stow.ResumableUploader = (*Container)(nil)
+
+// PutResumable puts the object in a resumable upload session, which can be
+// resumed from its checkpoint when it fails. Sessions expire a week after
+// they are started. The part size is rounded up to a multiple of 256 KiB.
+func (c *Container) PutResumable(name string, r io.ReaderAt, size int64, metadata map[string]interface{}, options *stow.ResumableOptions) (stow.Item, error) {
+	if options == nil {
+		options = &stow.ResumableOptions{}
+	}
+	mdPrepped, err := prepMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+	content, _, err := stow.PrepareContentProperties(name, io.NewSectionReader(r, 0, size), &stow.PutOptions{
+		ContentProperties: options.ContentProperties,
+	})
+	if err != nil {
+		return nil, err
+	}
+	partSize := int64(defaultChunkSize)
+	if options.PartSize > 0 {
+		partSize = (options.PartSize + chunkAlignment - 1) / chunkAlignment * chunkAlignment
+	}
+	u := &sessionUploader{
+		container: c,
+		name:      name,
+		size:      size,
+		metadata:  mdPrepped,
+		content:   content,
+		committed: -1,
+	}
+	return stow.ResumeUpload(u, options.Store, stow.CheckpointKey(c, name), r, size, partSize)
+}
+
+// sessionUploader uploads an object in a resumable upload session, whose
+// URI is the ID of the upload. Sessions keep bytes rather than parts, so
+// a part is held once all of its bytes are, and its ETag is its range.
+type sessionUploader struct {
+	container *Container
+	name      string
+	size      int64
+	metadata  map[string]string
+	content   stow.ContentProperties
+	// committed is the number of bytes the session holds, or -1 when
+	// it is not known yet.
+	committed int64
+	// finished is whether the session has created the object.
+	finished bool
+}
+
+var _ stow.MultipartUploader = (*sessionUploader)(nil)
+
+// sessionObject is the resource of the object a session creates.
+type sessionObject struct {
+	Name               string            `json:"name"`
+	ContentType        string            `json:"contentType,omitempty"`
+	ContentEncoding    string            `json:"contentEncoding,omitempty"`
+	ContentLanguage    string            `json:"contentLanguage,omitempty"`
+	CacheControl       string            `json:"cacheControl,omitempty"`
+	ContentDisposition string            `json:"contentDisposition,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+}
+
+func (u *sessionUploader) Start() (string, error) {
+	body, err := json.Marshal(sessionObject{
+		Name:               u.name,
+		ContentType:        u.content.ContentType,
+		ContentEncoding:    u.content.ContentEncoding,
+		ContentLanguage:    u.content.ContentLanguage,
+		CacheControl:       u.content.CacheControl,
+		ContentDisposition: u.content.ContentDisposition,
+		Metadata:           u.metadata,
+	})
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{"uploadType": {"resumable"}, "name": {u.name}}
+	req, err := http.NewRequest(http.MethodPost, uploadURL+url.PathEscape(u.container.name)+"/o?"+query.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(u.size, 10))
+	if u.content.ContentType != "" {
+		req.Header.Set("X-Upload-Content-Type", u.content.ContentType)
+	}
+	resp, err := u.container.httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "starting resumable upload")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", sessionError("starting resumable upload", resp)
+	}
+	session := resp.Header.Get("Location")
+	if session == "" {
+		return "", errors.New("starting resumable upload: no session URI")
+	}
+	u.committed, u.finished = 0, false
+	return session, nil
+}
+
+// send puts the data at offset in the session, and updates the number of
+// bytes it holds. Empty data asks for the number without sending any.
+func (u *sessionUploader) send(uploadID string, offset int64, data []byte) error {
+	req, err := http.NewRequest(http.MethodPut, uploadID, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(data))
+	if len(data) == 0 {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", u.size))
+	} else {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(len(data))-1, u.size))
+	}
+	resp, err := u.container.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "resumable upload")
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		u.committed, u.finished = u.size, true
+	case http.StatusPermanentRedirect:
+		// the Range header holds the bytes received, if any
+		u.committed = 0
+		if r := resp.Header.Get("Range"); r != "" {
+			last, err := strconv.ParseInt(r[strings.LastIndex(r, "-")+1:], 10, 64)
+			if err != nil {
+				return errors.Errorf("resumable upload: bad range %q", r)
+			}
+			u.committed = last + 1
+		}
+	case http.StatusNotFound, http.StatusGone:
+		return stow.ErrNotFound
+	default:
+		return sessionError("resumable upload", resp)
+	}
+	return nil
+}
+
+// holds gets whether the session holds all bytes up to end. The object is
+// only there once the session is finished.
+func (u *sessionUploader) holds(end int64) bool {
+	return end <= u.committed && (end < u.size || u.finished)
+}
+
+func (u *sessionUploader) Parts(uploadID string, partSize int64) (map[int]string, error) {
+	if err := u.send(uploadID, 0, nil); err != nil {
+		return nil, err
+	}
+	parts := map[int]string{}
+	for n, offset := 1, int64(0); offset < u.size || n == 1; n, offset = n+1, offset+partSize {
+		end := offset + partSize
+		if end > u.size {
+			end = u.size
+		}
+		if !u.holds(end) {
+			break
+		}
+		parts[n] = partRange(offset, end)
+	}
+	return parts, nil
+}
+
+// partRange gets the ETag of the part from offset to end.
+func partRange(offset, end int64) string {
+	return fmt.Sprintf("bytes=%d-%d", offset, end)
+}
+
+func (u *sessionUploader) UploadPart(uploadID string, number int, offset int64, data []byte) (string, error) {
+	if u.committed < 0 {
+		if err := u.send(uploadID, 0, nil); err != nil {
+			return "", err
+		}
+	}
+	end := offset + int64(len(data))
+	for !u.holds(end) {
+		if u.committed < offset {
+			return "", errors.Errorf("resumable upload: part %d starts after the %d bytes received", number, u.committed)
+		}
+		committed := u.committed
+		if err := u.send(uploadID, committed, data[committed-offset:]); err != nil {
+			return "", errors.Wrapf(err, "uploading part %d", number)
+		}
+		if u.committed <= committed && !u.finished {
+			return "", errors.Errorf("resumable upload: part %d was not received", number)
+		}
+	}
+	return partRange(offset, end), nil
+}
+
+func (u *sessionUploader) Complete(uploadID string, parts []stow.CompletedPart) (stow.Item, error) {
+	// the session creates the object with its last bytes
+	return u.container.Item(u.name)
+}
+
+func (u *sessionUploader) Abort(uploadID string) error {
+	req, err := http.NewRequest(http.MethodDelete, uploadID, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := u.container.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "cancelling resumable upload")
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case 499, http.StatusNoContent, http.StatusNotFound, http.StatusGone:
+		return nil
+	}
+	return sessionError("cancelling resumable upload", resp)
+}
+
+// sessionError gets the error of an unexpected response.
+func sessionError(action string, resp *http.Response) error {
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	return errors.Errorf("%s: %s: %s", action, resp.Status, strings.TrimSpace(string(b)))
+}
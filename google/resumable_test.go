@@ -0,0 +1,139 @@
+package google
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/cheekybits/is"
+	"google.golang.org/api/option"
+
+	"github.com/graymeta/stow"
+)
+
+// rewriteTransport sends all requests to a test server.
+type rewriteTransport struct {
+	server *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.server.Scheme
+	req.URL.Host = t.server.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// sessionServer serves a resumable upload session from memory.
+type sessionServer struct {
+	object   sessionObject
+	received []byte
+	size     int
+	finished bool
+	ranges   []string
+	// failAfter makes the session keep only this many bytes of the chunk
+	// starting at failStart, and fail.
+	failStart, failAfter int
+}
+
+func (s *sessionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/upload/storage/v1/b/bucket/o"):
+		json.NewDecoder(r.Body).Decode(&s.object)
+		fmt.Sscan(r.Header.Get("X-Upload-Content-Length"), &s.size)
+		w.Header().Set("Location", "https://storage.googleapis.com/upload/storage/v1/b/bucket/o?upload_id=session")
+	case r.Method == http.MethodPut && r.URL.Query().Get("upload_id") == "session":
+		contentRange := r.Header.Get("Content-Range")
+		b, _ := ioutil.ReadAll(r.Body)
+		if len(b) > 0 {
+			s.ranges = append(s.ranges, contentRange)
+			var start int
+			fmt.Sscanf(contentRange, "bytes %d-", &start)
+			if start != len(s.received) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if s.failAfter > 0 && start == s.failStart {
+				s.received = append(s.received, b[:s.failAfter]...)
+				s.failAfter = 0
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			s.received = append(s.received, b...)
+		}
+		if len(s.received) == s.size {
+			s.finished = true
+			s.writeObject(w)
+			return
+		}
+		if len(s.received) > 0 {
+			w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(s.received)-1))
+		}
+		w.WriteHeader(http.StatusPermanentRedirect)
+	case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/bucket/o/"+s.object.Name && s.finished:
+		s.writeObject(w)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *sessionServer) writeObject(w http.ResponseWriter) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"bucket":      "bucket",
+		"name":        s.object.Name,
+		"contentType": s.object.ContentType,
+		"metadata":    s.object.Metadata,
+		"size":        fmt.Sprint(len(s.received)),
+		"etag":        "etag",
+		"updated":     "2020-01-02T03:04:05Z",
+	})
+}
+
+func TestPutResumable(t *testing.T) {
+	is := is.New(t)
+
+	session := &sessionServer{}
+	server := httptest.NewServer(session)
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	is.NoErr(err)
+	httpClient := &http.Client{Transport: rewriteTransport{server: u}}
+	client, err := storage.NewClient(context.Background(), option.WithHTTPClient(httpClient))
+	is.NoErr(err)
+	c := &Container{name: "bucket", client: client, httpClient: httpClient, ctx: context.Background()}
+
+	data := bytes.Repeat([]byte("0123456789"), 2*chunkAlignment/10+10)
+	store := stow.NewMemoryCheckpointStore()
+	options := &stow.ResumableOptions{Store: store, PartSize: 1000}
+	metadata := map[string]interface{}{"key": "value"}
+
+	session.failStart, session.failAfter = chunkAlignment, chunkAlignment/2
+	_, err = c.PutResumable("dir/item.txt", bytes.NewReader(data), int64(len(data)), metadata, options)
+	is.Err(err)
+	checkpoint, err := store.Load("bucket/dir/item.txt")
+	is.NoErr(err)
+	is.Equal(checkpoint.PartSize, chunkAlignment)
+	is.Equal(len(checkpoint.Parts), 1)
+
+	session.ranges = nil
+	item, err := c.PutResumable("dir/item.txt", bytes.NewReader(data), int64(len(data)), metadata, options)
+	is.NoErr(err)
+	is.Equal(item.Name(), "dir/item.txt")
+	// the bytes the session kept of part 2 are not sent again
+	is.Equal(session.ranges, []string{
+		fmt.Sprintf("bytes %d-%d/%d", chunkAlignment*3/2, 2*chunkAlignment-1, len(data)),
+		fmt.Sprintf("bytes %d-%d/%d", 2*chunkAlignment, len(data)-1, len(data)),
+	})
+	is.True(bytes.Equal(session.received, data))
+	is.Equal(session.object.ContentType, "text/plain; charset=utf-8")
+	is.Equal(session.object.Metadata, map[string]string{"key": "value"})
+	_, err = store.Load("bucket/dir/item.txt")
+	is.Equal(err, stow.ErrNotFound)
+}
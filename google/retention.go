@@ -0,0 +1,128 @@
+package google
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+	"google.golang.org/api/googleapi"
+
+	"github.com/graymeta/stow"
+)
+
+var (
+	_ stow.ItemRetainer      = (*Item)(nil)
+	_ stow.ContainerRetainer = (*Container)(nil)
+)
+
+// isRetained gets whether err is returned because a retention policy or a
+// hold keeps the object, which Cloud Storage denies with a 403.
+func isRetained(err error) bool {
+	e, ok := err.(*googleapi.Error)
+	if !ok || e.Code != http.StatusForbidden {
+		return false
+	}
+	for _, item := range e.Errors {
+		if item.Reason == "retentionPolicyNotMet" {
+			return true
+		}
+	}
+	message := strings.ToLower(e.Message)
+	return strings.Contains(message, "retention") || strings.Contains(message, "hold")
+}
+
+// Retention gets the retention of the object, which comes from the
+// retention policy of the bucket. It is in compliance mode once the policy
+// is locked. Temporary and event-based holds are reported as legal hold.
+func (i *Item) Retention() (stow.Retention, error) {
+	var retention stow.Retention
+	attrs, err := i.handle().Attrs(i.ctx)
+	if err != nil {
+		if err == storage.ErrObjectNotExist {
+			return retention, stow.ErrNotFound
+		}
+		return retention, errors.Wrap(err, "getting object attributes")
+	}
+	retention.LegalHold = attrs.TemporaryHold || attrs.EventBasedHold
+	if attrs.RetentionExpirationTime.IsZero() {
+		return retention, nil
+	}
+	retention.RetainUntil = attrs.RetentionExpirationTime
+	retention.Mode = stow.RetentionGovernance
+	bucket, err := i.container.Bucket().Attrs(i.ctx)
+	if err != nil {
+		return retention, errors.Wrap(err, "getting bucket attributes")
+	}
+	if bucket.RetentionPolicy != nil && bucket.RetentionPolicy.IsLocked {
+		retention.Mode = stow.RetentionCompliance
+	}
+	return retention, nil
+}
+
+// SetRetention is not supported, objects are retained by the retention
+// policy of the bucket. See SetDefaultRetention.
+func (i *Item) SetRetention(mode stow.RetentionMode, retainUntil time.Time) error {
+	return stow.NotSupported("object retention")
+}
+
+// SetLegalHold places or releases a temporary hold on the object.
+// Releasing the hold also releases any event-based hold.
+func (i *Item) SetLegalHold(hold bool) error {
+	update := storage.ObjectAttrsToUpdate{TemporaryHold: hold}
+	if !hold {
+		update.EventBasedHold = false
+	}
+	_, err := i.handle().Update(i.ctx, update)
+	if err != nil {
+		if err == storage.ErrObjectNotExist {
+			return stow.ErrNotFound
+		}
+		return errors.Wrap(err, "updating object holds")
+	}
+	return nil
+}
+
+// DefaultRetention gets the retention policy of the bucket. A locked
+// policy is in compliance mode.
+func (c *Container) DefaultRetention() (stow.DefaultRetention, error) {
+	var retention stow.DefaultRetention
+	attrs, err := c.Bucket().Attrs(c.ctx)
+	if err != nil {
+		return retention, errors.Wrap(err, "getting bucket attributes")
+	}
+	if attrs.RetentionPolicy == nil {
+		return retention, nil
+	}
+	retention.Mode = stow.RetentionGovernance
+	if attrs.RetentionPolicy.IsLocked {
+		retention.Mode = stow.RetentionCompliance
+	}
+	retention.Period = attrs.RetentionPolicy.RetentionPeriod
+	return retention, nil
+}
+
+// SetDefaultRetention sets the retention policy of the bucket. In
+// compliance mode the policy is locked, after which it can no longer be
+// removed or shortened.
+func (c *Container) SetDefaultRetention(retention stow.DefaultRetention) error {
+	if retention.Period > 0 && retention.Mode != stow.RetentionGovernance && retention.Mode != stow.RetentionCompliance {
+		return errors.Errorf("unknown retention mode %q", retention.Mode)
+	}
+	policy := &storage.RetentionPolicy{}
+	if retention.Period > 0 {
+		policy.RetentionPeriod = retention.Period
+	}
+	attrs, err := c.Bucket().Update(c.ctx, storage.BucketAttrsToUpdate{RetentionPolicy: policy})
+	if err != nil {
+		return errors.Wrap(err, "updating bucket retention policy")
+	}
+	if retention.Period > 0 && retention.Mode == stow.RetentionCompliance {
+		bucket := c.Bucket().If(storage.BucketConditions{MetagenerationMatch: attrs.MetaGeneration})
+		if err := bucket.LockRetentionPolicy(c.ctx); err != nil {
+			return errors.Wrap(err, "locking bucket retention policy")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package google
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/cheekybits/is"
+	"google.golang.org/api/option"
+
+	"github.com/graymeta/stow"
+)
+
+func TestRemoveRetainedItem(t *testing.T) {
+	is := is.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Method, http.MethodDelete)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		switch r.URL.Path {
+		case "/storage/v1/b/bucket/o/retained":
+			w.Write([]byte(`{"error":{"code":403,"message":"Object 'bucket/retained' is subject to bucket's retention policy or object retention and cannot be deleted or overwritten until 2030-01-02T03:04:05Z","errors":[{"reason":"retentionPolicyNotMet"}]}}`))
+		case "/storage/v1/b/bucket/o/held":
+			w.Write([]byte(`{"error":{"code":403,"message":"Object 'bucket/held' is under active Temporary hold and cannot be deleted, overwritten or archived until hold is removed.","errors":[{"reason":"forbidden"}]}}`))
+		default:
+			w.Write([]byte(`{"error":{"code":403,"message":"caller does not have storage.objects.delete access","errors":[{"reason":"forbidden"}]}}`))
+		}
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	is.NoErr(err)
+	httpClient := &http.Client{Transport: rewriteTransport{server: u}}
+	client, err := storage.NewClient(context.Background(), option.WithHTTPClient(httpClient))
+	is.NoErr(err)
+	c := &Container{name: "bucket", client: client, httpClient: httpClient, ctx: context.Background()}
+
+	is.Equal(c.RemoveItem("retained"), stow.ErrLocked)
+	is.Equal(c.RemoveItem("held"), stow.ErrLocked)
+	err = c.RemoveItem("denied")
+	is.Err(err)
+	is.NotEqual(err, stow.ErrLocked)
+}
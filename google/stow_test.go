@@ -5,7 +5,9 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
+	"cloud.google.com/go/storage"
 	"github.com/cheekybits/is"
 
 	"github.com/graymeta/stow"
@@ -66,3 +68,84 @@ func TestPrepMetadataFailureWithNonStringValues(t *testing.T) {
 	_, err := prepMetadata(m)
 	is.Err(err)
 }
+
+func TestSetWriterOptions(t *testing.T) {
+	is := is.New(t)
+
+	w := &storage.Writer{}
+	err := setWriterOptions(w, &stow.PutOptions{
+		StorageClass: stow.StorageClassCold,
+		ACL:          stow.ACLPublicRead,
+		Encryption:   &stow.Encryption{Mode: stow.EncryptionKMS, KMSKeyID: "projects/p/locations/l/keyRings/r/cryptoKeys/k"},
+	})
+	is.NoErr(err)
+	is.Equal(w.StorageClass, "COLDLINE")
+	is.Equal(w.PredefinedACL, "publicRead")
+	is.Equal(w.KMSKeyName, "projects/p/locations/l/keyRings/r/cryptoKeys/k")
+
+	// native names are passed through
+	w = &storage.Writer{}
+	is.NoErr(setWriterOptions(w, &stow.PutOptions{StorageClass: "MULTI_REGIONAL", ACL: "projectPrivate"}))
+	is.Equal(w.StorageClass, "MULTI_REGIONAL")
+	is.Equal(w.PredefinedACL, "projectPrivate")
+
+	err = setWriterOptions(&storage.Writer{}, &stow.PutOptions{StorageClass: stow.StorageClassDeepArchive})
+	is.True(stow.IsNotSupported(err))
+}
+
+func TestLifecycle(t *testing.T) {
+	is := is.New(t)
+
+	day := 24 * time.Hour
+	rules := []stow.LifecycleRule{
+		{Action: stow.LifecycleTransition, Age: 30 * day, StorageClass: stow.StorageClassCool},
+		{Action: stow.LifecycleDelete, Age: 365 * day},
+	}
+	lifecycle, err := toLifecycle(rules)
+	is.NoErr(err)
+	is.Equal(len(lifecycle.Rules), 2)
+	is.Equal(lifecycle.Rules[0].Action, storage.LifecycleAction{Type: storage.SetStorageClassAction, StorageClass: "NEARLINE"})
+	is.Equal(lifecycle.Rules[0].Condition.AgeInDays, 30)
+	is.Equal(lifecycle.Rules[1].Action.Type, storage.DeleteAction)
+
+	// rules on noncurrent versions cannot be represented
+	lifecycle.Rules = append(lifecycle.Rules, storage.LifecycleRule{
+		Action:    storage.LifecycleAction{Type: storage.DeleteAction},
+		Condition: storage.LifecycleCondition{Liveness: storage.Archived, AgeInDays: 7},
+	})
+	is.Equal(fromLifecycle(lifecycle), rules)
+
+	_, err = toLifecycle([]stow.LifecycleRule{{Prefix: "logs/", Action: stow.LifecycleDelete}})
+	is.True(stow.IsNotSupported(err))
+	_, err = toLifecycle([]stow.LifecycleRule{{Action: stow.LifecycleAbortIncompleteUploads}})
+	is.True(stow.IsNotSupported(err))
+	_, err = toLifecycle(nil)
+	is.True(stow.IsNotSupported(err))
+}
+
+func TestTagsInMetadata(t *testing.T) {
+	is := is.New(t)
+
+	encoded, err := encodeTags(map[string]string{"team": "media"})
+	is.NoErr(err)
+	attrs := &storage.ObjectAttrs{Metadata: map[string]string{
+		"one":           "two",
+		tagsMetadataKey: encoded,
+	}}
+	tags, err := parseTags(attrs)
+	is.NoErr(err)
+	is.Equal(tags, map[string]interface{}{"team": "media"})
+
+	md, err := parseMetadata(attrs.Metadata)
+	is.NoErr(err)
+	is.Equal(md, map[string]interface{}{"one": "two"})
+
+	// deleted tags leave an empty value
+	attrs.Metadata[tagsMetadataKey] = ""
+	tags, err = parseTags(attrs)
+	is.NoErr(err)
+	is.Equal(len(tags), 0)
+
+	_, err = prepMetadata(map[string]interface{}{tagsMetadataKey: "{}"})
+	is.Err(err)
+}
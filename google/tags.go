@@ -0,0 +1,86 @@
+package google
+
+import (
+	"encoding/json"
+
+	"cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+
+	"github.com/graymeta/stow"
+)
+
+var (
+	_ stow.Taggable  = (*Item)(nil)
+	_ stow.TagSetter = (*Item)(nil)
+)
+
+// tagsMetadataKey is the metadata key the tags of an object are kept under,
+// encoded as JSON, since Google Cloud Storage has no object tags. It is
+// left out of the metadata of Items, and cannot be put as metadata.
+const tagsMetadataKey = "stow-tags"
+
+// Tags gets the tags of the object.
+func (i *Item) Tags() (map[string]interface{}, error) {
+	return parseTags(i.object)
+}
+
+// SetTags replaces the tags of the object, leaving the rest of its metadata
+// unchanged.
+func (i *Item) SetTags(tags map[string]interface{}) error {
+	values, err := stow.TagValues(tags)
+	if err != nil {
+		return err
+	}
+	encoded, err := encodeTags(values)
+	if err != nil {
+		return err
+	}
+	return i.updateTags(encoded)
+}
+
+// DeleteTags removes the tags of the object. Updates can only add metadata
+// keys, so the key is left with an empty value.
+func (i *Item) DeleteTags() error {
+	return i.updateTags("")
+}
+
+func (i *Item) updateTags(encoded string) error {
+	attrs, err := i.handle().Update(i.ctx, storage.ObjectAttrsToUpdate{
+		Metadata: map[string]string{tagsMetadataKey: encoded},
+	})
+	if err != nil {
+		if err == storage.ErrObjectNotExist {
+			return stow.ErrNotFound
+		}
+		return errors.Wrap(err, "updating object tags")
+	}
+	i.object = attrs
+	return nil
+}
+
+func encodeTags(values map[string]string) (string, error) {
+	if len(values) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", errors.Wrap(err, "encoding tags")
+	}
+	return string(b), nil
+}
+
+// parseTags gets the tags kept in the metadata of the object.
+func parseTags(attrs *storage.ObjectAttrs) (map[string]interface{}, error) {
+	tags := map[string]interface{}{}
+	if attrs == nil || attrs.Metadata[tagsMetadataKey] == "" {
+		return tags, nil
+	}
+	var values map[string]string
+	if err := json.Unmarshal([]byte(attrs.Metadata[tagsMetadataKey]), &values); err != nil {
+		return nil, errors.Wrap(err, "decoding tags")
+	}
+	for key, value := range values {
+		tags[key] = value
+	}
+	return tags, nil
+}
@@ -0,0 +1,108 @@
+package inventory
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/graymeta/stow"
+)
+
+// ChangeType is the kind of difference between two manifests.
+type ChangeType string
+
+const (
+	// Added is an entry only the newer manifest has.
+	Added ChangeType = "added"
+	// Removed is an entry only the older manifest has.
+	Removed ChangeType = "removed"
+	// Changed is an entry both manifests have, with different fields.
+	Changed ChangeType = "changed"
+)
+
+// Change is a difference between two manifests.
+type Change struct {
+	Type ChangeType
+	// Name is the name of the entry.
+	Name string
+	// Old is the entry in the older manifest, nil when it was added.
+	Old *Entry
+	// New is the entry in the newer manifest, nil when it was removed.
+	New *Entry
+	// Fields are the names of the fields that changed, such as "size" or
+	// "etag", as they appear in manifests.
+	Fields []string
+}
+
+// String describes the change on a line, with a leading +, - or ~ as in a
+// diff.
+func (c Change) String() string {
+	switch c.Type {
+	case Added:
+		return "+ " + c.Name
+	case Removed:
+		return "- " + c.Name
+	}
+	return fmt.Sprintf("~ %s (%s)", c.Name, strings.Join(c.Fields, ", "))
+}
+
+// Diff compares two manifests ordered by name, such as Load and Entries
+// give, returning the changes ordered by name.
+func Diff(old, new []Entry) []Change {
+	var changes []Change
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case j == len(new) || (i < len(old) && old[i].Name < new[j].Name):
+			changes = append(changes, Change{Type: Removed, Name: old[i].Name, Old: &old[i]})
+			i++
+		case i == len(old) || new[j].Name < old[i].Name:
+			changes = append(changes, Change{Type: Added, Name: new[j].Name, New: &new[j]})
+			j++
+		default:
+			if fields := changedFields(old[i], new[j]); len(fields) > 0 {
+				changes = append(changes, Change{Type: Changed, Name: old[i].Name, Old: &old[i], New: &new[j], Fields: fields})
+			}
+			i++
+			j++
+		}
+	}
+	return changes
+}
+
+// DiffContainer compares a manifest with what the Container holds now.
+func DiffContainer(old []Entry, container stow.Container, options *Options) ([]Change, error) {
+	entries, err := Entries(container, options)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(old, entries), nil
+}
+
+func changedFields(old, new Entry) []string {
+	var fields []string
+	if old.Size != new.Size {
+		fields = append(fields, "size")
+	}
+	if old.ETag != new.ETag {
+		fields = append(fields, "etag")
+	}
+	if !old.LastMod.Equal(new.LastMod) {
+		fields = append(fields, "last_modified")
+	}
+	if !equalMaps(old.Metadata, new.Metadata) {
+		fields = append(fields, "metadata")
+	}
+	if !equalMaps(old.Tags, new.Tags) {
+		fields = append(fields, "tags")
+	}
+	return fields
+}
+
+// equalMaps compares maps, taking nil to be the same as empty.
+func equalMaps(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,349 @@
+// Package inventory records what a stow.Container holds in manifests, and
+// compares manifests with each other or with a Container.
+//
+// A manifest has an Entry per Item, with its name, size, ETag, last
+// modified time, metadata and tags, written as JSON lines or CSV.
+package inventory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/graymeta/stow"
+)
+
+// Entry describes an Item in a manifest.
+type Entry struct {
+	Name     string            `json:"name"`
+	Size     int64             `json:"size"`
+	ETag     string            `json:"etag"`
+	LastMod  time.Time         `json:"last_modified"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+// Format is the way a manifest is written.
+type Format string
+
+const (
+	// FormatJSONL writes an Entry per line as JSON.
+	FormatJSONL Format = "jsonl"
+	// FormatCSV writes an Entry per row after a header row, with the
+	// metadata and tags as JSON objects.
+	FormatCSV Format = "csv"
+)
+
+// csvHeader is the header row of CSV manifests.
+var csvHeader = []string{"name", "size", "etag", "last_modified", "metadata", "tags"}
+
+// defaultConcurrency is the number of Items described at once.
+const defaultConcurrency = 8
+
+// Options configure taking an inventory.
+type Options struct {
+	// Prefix limits the inventory to the Items with the prefix.
+	Prefix string
+	// Format is the format of the manifest Export writes. Defaults to
+	// FormatJSONL.
+	Format Format
+	// Concurrency is the number of Items described at once, as getting
+	// metadata and tags can take requests of their own. Defaults to 8.
+	Concurrency int
+}
+
+// Export writes a manifest of the Items in the Container, and returns the
+// number of entries written. The entries are written as the Items are
+// described, in no particular order.
+func Export(container stow.Container, w io.Writer, options *Options) (int, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	var write func(Entry) error
+	var flush func() error
+	switch options.Format {
+	case "", FormatJSONL:
+		enc := json.NewEncoder(w)
+		write = func(e Entry) error { return enc.Encode(e) }
+		flush = func() error { return nil }
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return 0, err
+		}
+		write = func(e Entry) error {
+			row, err := e.csvRow()
+			if err != nil {
+				return err
+			}
+			return cw.Write(row)
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		return 0, fmt.Errorf("unknown manifest format %q", options.Format)
+	}
+	n := 0
+	err := walkEntries(container, options, func(e Entry) error {
+		if err := write(e); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, flush()
+}
+
+// Entries gets the entries of the Items in the Container, ordered by name.
+func Entries(container stow.Container, options *Options) ([]Entry, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	var entries []Entry
+	err := walkEntries(container, options, func(e Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+// Load reads a manifest, returning its entries ordered by name.
+func Load(r io.Reader, format Format) ([]Entry, error) {
+	var entries []Entry
+	switch format {
+	case "", FormatJSONL:
+		dec := json.NewDecoder(r)
+		for {
+			var e Entry
+			err := dec.Decode(&e)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = len(csvHeader)
+		rows, err := cr.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return nil, errors.New("missing CSV header")
+		}
+		for _, row := range rows[1:] {
+			e, err := parseCSVRow(row)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+	default:
+		return nil, fmt.Errorf("unknown manifest format %q", format)
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+func (e Entry) csvRow() ([]string, error) {
+	metadata, err := csvMap(e.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := csvMap(e.Tags)
+	if err != nil {
+		return nil, err
+	}
+	return []string{
+		e.Name,
+		strconv.FormatInt(e.Size, 10),
+		e.ETag,
+		e.LastMod.Format(time.RFC3339Nano),
+		metadata,
+		tags,
+	}, nil
+}
+
+// csvMap encodes a map as a JSON object, or nothing when it is empty.
+func csvMap(m map[string]string) (string, error) {
+	if len(m) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(m)
+	return string(b), err
+}
+
+func parseCSVRow(row []string) (Entry, error) {
+	e := Entry{Name: row[0], ETag: row[2]}
+	var err error
+	if e.Size, err = strconv.ParseInt(row[1], 10, 64); err != nil {
+		return e, fmt.Errorf("size of %s: %v", e.Name, err)
+	}
+	if e.LastMod, err = time.Parse(time.RFC3339Nano, row[3]); err != nil {
+		return e, fmt.Errorf("last modified time of %s: %v", e.Name, err)
+	}
+	for i, m := range []*map[string]string{&e.Metadata, &e.Tags} {
+		if row[4+i] == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(row[4+i]), m); err != nil {
+			return e, fmt.Errorf("%s of %s: %v", csvHeader[4+i], e.Name, err)
+		}
+	}
+	return e, nil
+}
+
+// walkEntries describes the Items in the Container with the prefix of the
+// options, calling fn with each entry. The Items are listed a prefix at a
+// time in parallel when the Container can list by delimiter, and described
+// by several goroutines at once, but fn is only called by one at a time.
+func walkEntries(container stow.Container, options *Options, fn func(Entry) error) error {
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+	items := make(chan stow.Item)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				if failed() {
+					continue
+				}
+				e, err := describe(item)
+				if err != nil {
+					fail(err)
+					continue
+				}
+				mu.Lock()
+				if firstErr == nil {
+					if err := fn(e); err != nil {
+						firstErr = err
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	visit := func(item stow.Item, err error) error {
+		if err != nil {
+			return err
+		}
+		if failed() {
+			return errStopped
+		}
+		items <- item
+		return nil
+	}
+	err := listItems(container, options.Prefix, concurrency, visit)
+	close(items)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return err
+}
+
+// errStopped stops listing once describing an Item failed.
+var errStopped = errors.New("inventory stopped")
+
+// listItems visits the Items with the prefix, walking the prefixes one
+// level down in parallel when the Container can list by delimiter.
+func listItems(container stow.Container, prefix string, concurrency int, fn stow.WalkFunc) error {
+	if _, ok := container.(stow.DelimiterLister); !ok {
+		return stow.Walk(container, prefix, 1000, fn)
+	}
+	top, prefixes, err := stow.ListDelimited(container, prefix, "/")
+	if err != nil {
+		return err
+	}
+	for _, item := range top {
+		if err := fn(item, nil); err != nil {
+			return err
+		}
+	}
+	return stow.WalkParallel(container, prefixes, 1000, concurrency, fn)
+}
+
+// describe makes the entry of the Item.
+func describe(item stow.Item) (Entry, error) {
+	e := Entry{Name: item.Name()}
+	var err error
+	if e.Size, err = item.Size(); err != nil {
+		return e, err
+	}
+	if e.ETag, err = item.ETag(); err != nil {
+		return e, err
+	}
+	if e.LastMod, err = item.LastMod(); err != nil {
+		return e, err
+	}
+	metadata, err := item.Metadata()
+	if err != nil {
+		return e, err
+	}
+	e.Metadata = stringMap(metadata)
+	if t, ok := item.(stow.Taggable); ok {
+		tags, err := t.Tags()
+		if err != nil && !stow.IsNotSupported(err) {
+			return e, err
+		}
+		e.Tags = stringMap(tags)
+	}
+	return e, nil
+}
+
+// stringMap formats the values of the map as strings, giving nil for an
+// empty map.
+func stringMap(m map[string]interface{}) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	s := make(map[string]string, len(m))
+	for key, value := range m {
+		s[key] = fmt.Sprint(value)
+	}
+	return s
+}
@@ -0,0 +1,97 @@
+package inventory_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+	"github.com/graymeta/stow/inventory"
+	"github.com/graymeta/stow/local"
+)
+
+func setup(is is.I) (stow.Container, func()) {
+	dir, err := ioutil.TempDir("", "stow-inventory")
+	is.NoErr(err)
+	location, err := stow.Dial(local.Kind, stow.ConfigMap{local.ConfigKeyPath: dir})
+	is.NoErr(err)
+	container, err := location.CreateContainer("bucket")
+	is.NoErr(err)
+	for _, name := range []string{"a", "dir/b", "dir/sub/c", "d"} {
+		_, err := container.Put(name, strings.NewReader(name), int64(len(name)), nil)
+		is.NoErr(err)
+	}
+	return container, func() { os.RemoveAll(dir) }
+}
+
+func TestExportLoad(t *testing.T) {
+	is := is.New(t)
+	container, teardown := setup(is)
+	defer teardown()
+	item, err := container.Item("a")
+	is.NoErr(err)
+	is.NoErr(item.(stow.TagSetter).SetTags(map[string]interface{}{"team": "finance"}))
+
+	entries, err := inventory.Entries(container, nil)
+	is.NoErr(err)
+	is.Equal(len(entries), 4)
+	is.Equal(entries[0].Name, "a")
+	is.Equal(entries[0].Size, 1)
+	is.Equal(entries[0].Tags, map[string]string{"team": "finance"})
+	is.Equal(entries[2].Name, "dir/b")
+	is.Equal(entries[3].Name, "dir/sub/c")
+
+	for _, format := range []inventory.Format{inventory.FormatJSONL, inventory.FormatCSV} {
+		var buf bytes.Buffer
+		n, err := inventory.Export(container, &buf, &inventory.Options{Format: format, Concurrency: 2})
+		is.NoErr(err)
+		is.Equal(n, 4)
+		loaded, err := inventory.Load(&buf, format)
+		is.NoErr(err)
+		is.Equal(len(inventory.Diff(entries, loaded)), 0)
+		is.Equal(loaded[0].Tags, entries[0].Tags)
+		is.True(loaded[0].LastMod.Equal(entries[0].LastMod))
+	}
+
+	entries, err = inventory.Entries(container, &inventory.Options{Prefix: "dir/"})
+	is.NoErr(err)
+	is.Equal(len(entries), 2)
+
+	_, err = inventory.Export(container, ioutil.Discard, &inventory.Options{Format: "xml"})
+	is.Err(err)
+}
+
+func TestDiffContainer(t *testing.T) {
+	is := is.New(t)
+	container, teardown := setup(is)
+	defer teardown()
+
+	var buf bytes.Buffer
+	_, err := inventory.Export(container, &buf, nil)
+	is.NoErr(err)
+	manifest, err := inventory.Load(&buf, inventory.FormatJSONL)
+	is.NoErr(err)
+
+	time.Sleep(10 * time.Millisecond)
+	item, err := container.Item(manifest[0].Name)
+	is.NoErr(err)
+	is.NoErr(container.RemoveItem(item.ID()))
+	_, err = container.Put("dir/b", strings.NewReader("changed"), 7, nil)
+	is.NoErr(err)
+	_, err = container.Put("e", strings.NewReader("e"), 1, nil)
+	is.NoErr(err)
+
+	changes, err := inventory.DiffContainer(manifest, container, nil)
+	is.NoErr(err)
+	is.Equal(len(changes), 3)
+	is.Equal(changes[0].String(), "- a")
+	is.Equal(changes[1].Type, inventory.Changed)
+	is.Equal(changes[1].Name, "dir/b")
+	is.Equal(changes[1].Fields[:3], []string{"size", "etag", "last_modified"})
+	is.Equal(changes[2].String(), "+ e")
+	is.Equal(changes[2].New.Size, 1)
+}
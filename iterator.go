@@ -0,0 +1,273 @@
+package stow
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// errCursorStuck is returned by the iterators when an implementation
+// keeps handing back the same cursor, which would otherwise loop forever.
+var errCursorStuck = errors.New("stow: cursor did not advance")
+
+// iteratorToken is the decoded form of the resumable token returned by
+// ItemIterator.Token and ContainerIterator.Token.
+type iteratorToken struct {
+	// Cursor is the cursor the current page was requested with.
+	Cursor string `json:"c"`
+	// Last is the ID of the last entry handed to the caller.
+	Last string `json:"l"`
+}
+
+func encodeToken(t iteratorToken) string {
+	if t.Cursor == CursorStart && t.Last == "" {
+		return ""
+	}
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeToken(token string) (iteratorToken, error) {
+	var t iteratorToken
+	if token == "" {
+		return t, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return t, ErrBadCursor
+	}
+	if err := json.Unmarshal(b, &t); err != nil {
+		return t, ErrBadCursor
+	}
+	return t, nil
+}
+
+// pager is the paging state shared by ItemIterator and ContainerIterator.
+// fetch gets the IDs of a page along with the cursor to the next one; the
+// entries themselves are kept by the caller.
+type pager struct {
+	fetch func(cursor string) (ids []string, next string, err error)
+
+	cursor string // cursor the current page was fetched with
+	next   string // cursor of the page after the current one
+	ids    []string
+	pos    int    // index of the next entry to hand out
+	last   string // ID of the last entry handed out
+	done   bool
+	err    error
+
+	// when skipping, entries are passed over until anchor is seen. If
+	// anchor has gone away, the listing is scanned again and entries
+	// sorting lexically after anchor are handed out instead.
+	skipping bool
+	lexical  bool
+	anchor   string
+}
+
+func newPager(token string, fetch func(string) ([]string, string, error)) *pager {
+	p := &pager{fetch: fetch, pos: -1} // the page is fetched on the first advance
+	t, err := decodeToken(token)
+	if err != nil {
+		p.err = err
+		return p
+	}
+	p.next = t.Cursor
+	p.last = t.Last
+	if t.Last != "" {
+		p.skipping, p.anchor = true, t.Last
+	}
+	return p
+}
+
+// advance moves to the next entry and returns its index in the current page.
+func (p *pager) advance() (int, bool) {
+	for {
+		if p.err != nil || p.done {
+			return 0, false
+		}
+		if p.pos >= 0 && p.pos < len(p.ids) {
+			i := p.pos
+			p.pos++
+			id := p.ids[i]
+			if p.skipping {
+				if p.lexical {
+					if id <= p.anchor {
+						continue
+					}
+				} else {
+					if id == p.anchor {
+						p.skipping = false
+					}
+					continue
+				}
+				p.skipping = false
+			}
+			p.last = id
+			return i, true
+		}
+		if p.pos >= 0 && IsCursorEnd(p.next) {
+			if p.skipping && !p.lexical {
+				p.lexical = true
+				p.load(CursorStart)
+				continue
+			}
+			p.done = true
+			return 0, false
+		}
+		p.load(p.next)
+	}
+}
+
+// load fetches the page at cursor. Implementations that cannot find the
+// entry a cursor points to (because it was removed between calls) return
+// ErrBadCursor; in that case paging starts over from the beginning and
+// skips everything up to and including the last entry handed out.
+func (p *pager) load(cursor string) {
+	ids, next, err := p.fetch(cursor)
+	if err == ErrBadCursor && cursor != CursorStart {
+		p.skipping, p.lexical, p.anchor = true, false, p.last
+		p.load(CursorStart)
+		return
+	}
+	if err != nil {
+		p.err = err
+		return
+	}
+	if !IsCursorEnd(next) && next == cursor {
+		p.err = errCursorStuck
+		return
+	}
+	p.cursor, p.next, p.ids, p.pos = cursor, next, ids, 0
+}
+
+func (p *pager) token() string {
+	return encodeToken(iteratorToken{Cursor: p.cursor, Last: p.last})
+}
+
+// ItemIterator iterates over the Items in a Container, taking care of
+// paging and of the differences in how implementations handle cursors.
+//
+//	it := stow.NewItemIterator(container, stow.NoPrefix, stow.CursorStart, 100)
+//	for it.Next() {
+//		log.Println(it.Item().Name())
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type ItemIterator struct {
+	pager *pager
+	page  []Item
+	item  Item
+}
+
+// NewItemIterator creates an ItemIterator over the Items in the container
+// with the specified prefix.
+// The token is the value of Token from a previous iterator, which resumes
+// right after the last Item that iterator returned, or CursorStart to start
+// from the first Item.
+// pageSize is the number of Items requested from the container at a time.
+func NewItemIterator(container Container, prefix, token string, pageSize int) *ItemIterator {
+	it := &ItemIterator{}
+	it.pager = newPager(token, func(cursor string) ([]string, string, error) {
+		items, next, err := container.Items(prefix, cursor, pageSize)
+		if err != nil {
+			return nil, "", err
+		}
+		ids := make([]string, len(items))
+		for i := range items {
+			ids[i] = items[i].ID()
+		}
+		it.page = items
+		return ids, next, nil
+	})
+	return it
+}
+
+// Next advances the iterator to the next Item, which is then available
+// through the Item method. It returns false when there are no more Items,
+// or when an error occurred.
+func (it *ItemIterator) Next() bool {
+	i, ok := it.pager.advance()
+	if !ok {
+		it.item = nil
+		return false
+	}
+	it.item = it.page[i]
+	return true
+}
+
+// Item gets the current Item.
+func (it *ItemIterator) Item() Item {
+	return it.item
+}
+
+// Err gets the error that stopped the iteration, if any.
+func (it *ItemIterator) Err() error {
+	return it.pager.err
+}
+
+// Token gets a string that can be passed to NewItemIterator to resume
+// iterating right after the current Item.
+func (it *ItemIterator) Token() string {
+	return it.pager.token()
+}
+
+// ContainerIterator iterates over the Containers in a Location, taking
+// care of paging and of the differences in how implementations handle
+// cursors.
+type ContainerIterator struct {
+	pager     *pager
+	page      []Container
+	container Container
+}
+
+// NewContainerIterator creates a ContainerIterator over the Containers in
+// the location with the specified prefix.
+// The token is the value of Token from a previous iterator, or CursorStart
+// to start from the first Container.
+// pageSize is the number of Containers requested from the location at a time.
+func NewContainerIterator(location Location, prefix, token string, pageSize int) *ContainerIterator {
+	it := &ContainerIterator{}
+	it.pager = newPager(token, func(cursor string) ([]string, string, error) {
+		containers, next, err := location.Containers(prefix, cursor, pageSize)
+		if err != nil {
+			return nil, "", err
+		}
+		ids := make([]string, len(containers))
+		for i := range containers {
+			ids[i] = containers[i].ID()
+		}
+		it.page = containers
+		return ids, next, nil
+	})
+	return it
+}
+
+// Next advances the iterator to the next Container, which is then
+// available through the Container method. It returns false when there are
+// no more Containers, or when an error occurred.
+func (it *ContainerIterator) Next() bool {
+	i, ok := it.pager.advance()
+	if !ok {
+		it.container = nil
+		return false
+	}
+	it.container = it.page[i]
+	return true
+}
+
+// Container gets the current Container.
+func (it *ContainerIterator) Container() Container {
+	return it.container
+}
+
+// Err gets the error that stopped the iteration, if any.
+func (it *ContainerIterator) Err() error {
+	return it.pager.err
+}
+
+// Token gets a string that can be passed to NewContainerIterator to resume
+// iterating right after the current Container.
+func (it *ContainerIterator) Token() string {
+	return it.pager.token()
+}
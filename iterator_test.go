@@ -0,0 +1,349 @@
+package stow_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+// cursorStyle mimics the way one of the implementations builds cursors.
+type cursorStyle int
+
+const (
+	// s3 uses StartAfter with the last key of the page.
+	styleStartAfter cursorStyle = iota
+	// b2 appends a space to the last name of the page.
+	styleB2
+	// swift returns a marker only when exactly count objects came back.
+	styleMarker
+	// local points the cursor at the first item of the next page, and
+	// returns ErrBadCursor when that item no longer exists.
+	styleLocal
+)
+
+type pagedContainer struct {
+	style cursorStyle
+	names []string
+}
+
+func newPagedContainer(style cursorStyle, n int) *pagedContainer {
+	c := &pagedContainer{style: style}
+	for i := 0; i < n; i++ {
+		c.names = append(c.names, fmt.Sprintf("item-%02d", i))
+	}
+	return c
+}
+
+func (c *pagedContainer) remove(name string) {
+	for i := range c.names {
+		if c.names[i] == name {
+			c.names = append(c.names[:i], c.names[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *pagedContainer) ID() string   { return "paged" }
+func (c *pagedContainer) Name() string { return "paged" }
+func (c *pagedContainer) Item(id string) (stow.Item, error) {
+	return nil, stow.NotSupported("Item")
+}
+func (c *pagedContainer) RemoveItem(id string) error {
+	c.remove(id)
+	return nil
+}
+func (c *pagedContainer) Put(name string, r io.Reader, size int64, md map[string]interface{}) (stow.Item, error) {
+	c.names = append(c.names, name)
+	sort.Strings(c.names)
+	return &testItem{name: name}, nil
+}
+
+func (c *pagedContainer) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	var names []string
+	for _, n := range c.names {
+		if strings.HasPrefix(n, prefix) {
+			names = append(names, n)
+		}
+	}
+	start := 0
+	if cursor != stow.CursorStart {
+		switch c.style {
+		case styleStartAfter, styleMarker:
+			start = sort.SearchStrings(names, cursor+"\x00")
+		case styleB2:
+			start = sort.SearchStrings(names, cursor)
+		case styleLocal:
+			start = -1
+			for i := range names {
+				if names[i] == cursor {
+					start = i
+				}
+			}
+			if start < 0 {
+				return nil, "", stow.ErrBadCursor
+			}
+		}
+	}
+	names = names[start:]
+	var next string
+	switch c.style {
+	case styleStartAfter, styleB2:
+		if len(names) > count {
+			names = names[:count]
+			next = names[count-1]
+			if c.style == styleB2 {
+				next += " "
+			}
+		}
+	case styleMarker:
+		if len(names) > count {
+			names = names[:count]
+		}
+		if len(names) == count {
+			next = names[count-1]
+		}
+	case styleLocal:
+		if len(names) > count {
+			next = names[count]
+			names = names[:count]
+		}
+	}
+	items := make([]stow.Item, len(names))
+	for i := range names {
+		items[i] = &testItem{name: names[i]}
+	}
+	return items, next, nil
+}
+
+type testItem struct {
+	name string
+}
+
+func (i *testItem) ID() string                                { return i.name }
+func (i *testItem) Name() string                              { return i.name }
+func (i *testItem) URL() *url.URL                             { return &url.URL{Scheme: testKind, Path: i.name} }
+func (i *testItem) Size() (int64, error)                      { return 0, nil }
+func (i *testItem) Open() (io.ReadCloser, error)              { return ioutil.NopCloser(strings.NewReader("")), nil }
+func (i *testItem) ETag() (string, error)                     { return i.name, nil }
+func (i *testItem) LastMod() (time.Time, error)               { return time.Time{}, nil }
+func (i *testItem) Metadata() (map[string]interface{}, error) { return nil, nil }
+
+var cursorStyles = map[string]cursorStyle{
+	"startafter": styleStartAfter,
+	"b2":         styleB2,
+	"marker":     styleMarker,
+	"local":      styleLocal,
+}
+
+func collect(it *stow.ItemIterator) []string {
+	var names []string
+	for it.Next() {
+		names = append(names, it.Item().Name())
+	}
+	return names
+}
+
+func TestItemIterator(t *testing.T) {
+	for name, style := range cursorStyles {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			for _, pageSize := range []int{1, 3, 5, 10, 100} {
+				c := newPagedContainer(style, 10)
+				it := stow.NewItemIterator(c, stow.NoPrefix, stow.CursorStart, pageSize)
+				names := collect(it)
+				is.NoErr(it.Err())
+				is.Equal(names, c.names)
+			}
+		})
+	}
+}
+
+func TestItemIteratorPrefix(t *testing.T) {
+	for name, style := range cursorStyles {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			c := newPagedContainer(style, 25)
+			it := stow.NewItemIterator(c, "item-1", stow.CursorStart, 3)
+			names := collect(it)
+			is.NoErr(it.Err())
+			is.Equal(len(names), 10)
+			is.Equal(names[0], "item-10")
+			is.Equal(names[9], "item-19")
+		})
+	}
+}
+
+func TestItemIteratorEmpty(t *testing.T) {
+	for name, style := range cursorStyles {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			c := newPagedContainer(style, 0)
+			it := stow.NewItemIterator(c, stow.NoPrefix, stow.CursorStart, 5)
+			is.False(it.Next())
+			is.NoErr(it.Err())
+			is.Nil(it.Item())
+		})
+	}
+}
+
+func TestItemIteratorResume(t *testing.T) {
+	for name, style := range cursorStyles {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			c := newPagedContainer(style, 10)
+			for stop := 1; stop < 10; stop++ {
+				it := stow.NewItemIterator(c, stow.NoPrefix, stow.CursorStart, 4)
+				var names []string
+				for len(names) < stop && it.Next() {
+					names = append(names, it.Item().Name())
+				}
+				is.NoErr(it.Err())
+				resumed := stow.NewItemIterator(c, stow.NoPrefix, it.Token(), 4)
+				names = append(names, collect(resumed)...)
+				is.NoErr(resumed.Err())
+				is.Equal(names, c.names)
+			}
+		})
+	}
+}
+
+func TestItemIteratorRemovedBetweenPages(t *testing.T) {
+	for name, style := range cursorStyles {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			c := newPagedContainer(style, 10)
+			it := stow.NewItemIterator(c, stow.NoPrefix, stow.CursorStart, 3)
+			var names []string
+			for it.Next() {
+				names = append(names, it.Item().Name())
+				if it.Item().Name() == "item-02" {
+					// remove the first item of the next page, which is what
+					// the local cursor points at
+					c.remove("item-03")
+				}
+			}
+			is.NoErr(it.Err())
+			is.Equal(names, []string{"item-00", "item-01", "item-02", "item-04",
+				"item-05", "item-06", "item-07", "item-08", "item-09"})
+		})
+	}
+}
+
+func TestItemIteratorResumeAfterRemovedItem(t *testing.T) {
+	for name, style := range cursorStyles {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			c := newPagedContainer(style, 10)
+			it := stow.NewItemIterator(c, stow.NoPrefix, stow.CursorStart, 3)
+			for i := 0; i < 5; i++ {
+				is.True(it.Next())
+			}
+			is.Equal(it.Item().Name(), "item-04")
+			token := it.Token()
+			c.remove("item-03")
+			c.remove("item-04")
+			resumed := stow.NewItemIterator(c, stow.NoPrefix, token, 3)
+			is.Equal(collect(resumed), []string{"item-05", "item-06", "item-07", "item-08", "item-09"})
+			is.NoErr(resumed.Err())
+		})
+	}
+}
+
+func TestItemIteratorBadToken(t *testing.T) {
+	is := is.New(t)
+	c := newPagedContainer(styleStartAfter, 3)
+	it := stow.NewItemIterator(c, stow.NoPrefix, "not a token!", 3)
+	is.False(it.Next())
+	is.Equal(it.Err(), stow.ErrBadCursor)
+}
+
+type stuckContainer struct {
+	pagedContainer
+}
+
+func (c *stuckContainer) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	return []stow.Item{&testItem{name: "same"}}, "same", nil
+}
+
+func TestItemIteratorStuckCursor(t *testing.T) {
+	is := is.New(t)
+	it := stow.NewItemIterator(&stuckContainer{}, stow.NoPrefix, stow.CursorStart, 1)
+	is.True(it.Next())
+	is.False(it.Next())
+	is.Err(it.Err())
+}
+
+type failingContainer struct {
+	pagedContainer
+	err error
+}
+
+func (c *failingContainer) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	return nil, "", c.err
+}
+
+func TestItemIteratorError(t *testing.T) {
+	is := is.New(t)
+	testErr := errors.New("test error")
+	it := stow.NewItemIterator(&failingContainer{err: testErr}, stow.NoPrefix, stow.CursorStart, 1)
+	is.False(it.Next())
+	is.Equal(it.Err(), testErr)
+}
+
+type pagedLocation struct {
+	testLocation
+	names []string
+}
+
+func (l *pagedLocation) Containers(prefix string, cursor string, count int) ([]stow.Container, string, error) {
+	// behaves like the swift implementation
+	start := 0
+	if cursor != stow.CursorStart {
+		start = sort.SearchStrings(l.names, cursor+"\x00")
+	}
+	names := l.names[start:]
+	if len(names) > count {
+		names = names[:count]
+	}
+	next := ""
+	if len(names) == count {
+		next = names[count-1]
+	}
+	cs := make([]stow.Container, len(names))
+	for i := range names {
+		cs[i] = &pagedContainer{names: []string{names[i]}}
+	}
+	return cs, next, nil
+}
+
+func TestContainerIterator(t *testing.T) {
+	is := is.New(t)
+	l := &pagedLocation{names: []string{"a", "b", "c", "d"}}
+	it := stow.NewContainerIterator(l, stow.NoPrefix, stow.CursorStart, 2)
+	var n int
+	for it.Next() {
+		is.OK(it.Container())
+		n++
+		if n == 1 {
+			// resume a second iterator from here
+			resumed := stow.NewContainerIterator(l, stow.NoPrefix, it.Token(), 2)
+			var m int
+			for resumed.Next() {
+				m++
+			}
+			is.NoErr(resumed.Err())
+			is.Equal(m, 3)
+		}
+	}
+	is.NoErr(it.Err())
+	is.Equal(n, 4)
+}
@@ -0,0 +1,79 @@
+package stow
+
+import (
+	"errors"
+	"time"
+)
+
+// LifecycleAction is what a LifecycleRule does to the Items it applies to.
+type LifecycleAction string
+
+const (
+	// LifecycleDelete deletes Items once they are older than the age of
+	// the rule. Where the Container keeps versions, the current version
+	// becomes noncurrent instead.
+	LifecycleDelete LifecycleAction = "delete"
+	// LifecycleTransition moves Items to the storage class of the rule
+	// once they are older than its age.
+	LifecycleTransition LifecycleAction = "transition"
+	// LifecycleDeleteNoncurrent deletes noncurrent versions of Items once
+	// they have been noncurrent for the age of the rule.
+	LifecycleDeleteNoncurrent LifecycleAction = "delete_noncurrent"
+	// LifecycleAbortIncompleteUploads aborts multipart uploads that have
+	// not completed within the age of the rule.
+	LifecycleAbortIncompleteUploads LifecycleAction = "abort_incomplete_uploads"
+)
+
+// LifecycleRule is a rule a Container applies to its Items as they age.
+type LifecycleRule struct {
+	// ID identifies the rule. Implementations generate one when empty.
+	ID string
+	// Prefix limits the rule to the Items with names starting with it.
+	Prefix string
+	// Action is what the rule does.
+	Action LifecycleAction
+	// Age is how long after its creation the rule applies to an Item, or
+	// for LifecycleDeleteNoncurrent after it became noncurrent.
+	// Implementations round it up to whole days.
+	Age time.Duration
+	// StorageClass is the class Items are moved to by a
+	// LifecycleTransition rule.
+	StorageClass StorageClass
+}
+
+// AgeDays gets the age of the rule in whole days, rounded up.
+func (r LifecycleRule) AgeDays() int {
+	const day = 24 * time.Hour
+	return int((r.Age + day - 1) / day)
+}
+
+// Validate checks the rule is complete.
+func (r LifecycleRule) Validate() error {
+	switch r.Action {
+	case LifecycleDelete, LifecycleDeleteNoncurrent, LifecycleAbortIncompleteUploads:
+		if r.StorageClass != "" {
+			return errors.New("storage class only applies to transition rules")
+		}
+	case LifecycleTransition:
+		if r.StorageClass == "" {
+			return errors.New("missing storage class for transition rule")
+		}
+	default:
+		return errors.New("unknown lifecycle action " + string(r.Action))
+	}
+	if r.Age < 0 {
+		return errors.New("negative lifecycle rule age")
+	}
+	return nil
+}
+
+// LifecycleManager represents a Container with lifecycle rules.
+type LifecycleManager interface {
+	// LifecycleRules gets the lifecycle rules of the Container. Rules
+	// that cannot be represented by LifecycleRule are left out.
+	LifecycleRules() ([]LifecycleRule, error)
+	// SetLifecycleRules replaces the lifecycle rules of the Container.
+	// No rules removes them all. Rules an implementation cannot apply
+	// give an error satisfying IsNotSupported.
+	SetLifecycleRules(rules []LifecycleRule) error
+}
@@ -0,0 +1,27 @@
+package stow_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestLifecycleRuleAgeDays(t *testing.T) {
+	is := is.New(t)
+	is.Equal(stow.LifecycleRule{}.AgeDays(), 0)
+	is.Equal(stow.LifecycleRule{Age: time.Hour}.AgeDays(), 1)
+	is.Equal(stow.LifecycleRule{Age: 30 * 24 * time.Hour}.AgeDays(), 30)
+	is.Equal(stow.LifecycleRule{Age: 30*24*time.Hour + time.Second}.AgeDays(), 31)
+}
+
+func TestLifecycleRuleValidate(t *testing.T) {
+	is := is.New(t)
+	is.NoErr(stow.LifecycleRule{Action: stow.LifecycleDelete, Age: time.Hour}.Validate())
+	is.NoErr(stow.LifecycleRule{Action: stow.LifecycleTransition, StorageClass: stow.StorageClassCool}.Validate())
+	is.Err(stow.LifecycleRule{Action: stow.LifecycleTransition}.Validate())
+	is.Err(stow.LifecycleRule{Action: stow.LifecycleDelete, StorageClass: stow.StorageClassCool}.Validate())
+	is.Err(stow.LifecycleRule{Action: "archive"}.Validate())
+	is.Err(stow.LifecycleRule{Action: stow.LifecycleDelete, Age: -time.Hour}.Validate())
+}
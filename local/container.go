@@ -3,14 +3,19 @@ package local
 import (
 	"errors"
 	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
 
 	"github.com/graymeta/stow"
 )
 
+var _ stow.DelimiterLister = (*container)(nil)
+
 type container struct {
 	name string
 	path string
@@ -31,27 +36,87 @@ func (c *container) URL() *url.URL {
 	}
 }
 
+// nameOf gets the name within the container of the file at path.
+func (c *container) nameOf(path string) string {
+	if rel, err := filepath.Rel(c.path, path); err == nil {
+		return rel
+	}
+	return path
+}
+
 func (c *container) CreateItem(name string) (stow.Item, io.WriteCloser, error) {
+	if inSidecar(name) {
+		return nil, nil, errSidecarName
+	}
 	path := filepath.Join(c.path, filepath.FromSlash(name))
 	item := &item{
 		path:          path,
 		contPrefixLen: len(c.path) + 1,
 	}
+	if err := checkUnlocked(path); err != nil {
+		return nil, nil, err
+	}
 	f, err := os.Create(path)
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := applyDefaultRetention(c.path, path); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if err := resetRecord(path, nil, nil); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
 	return item, f, nil
 }
 
 func (c *container) RemoveItem(id string) error {
-	return os.Remove(id)
+	if inSidecar(c.nameOf(id)) {
+		return stow.ErrNotFound
+	}
+	if err := checkUnlocked(id); err != nil {
+		return err
+	}
+	if err := os.Remove(id); err != nil {
+		return err
+	}
+	return writeRecord(recordPath(id), record{})
 }
 
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutWithOptions(name, r, size, metadata, nil)
+}
+
+// PutWithOptions writes the file like Put, recording the content
+// properties and tags given in the options in its sidecar.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, metadata map[string]interface{}, options *stow.PutOptions) (stow.Item, error) {
+	if inSidecar(name) {
+		return nil, errSidecarName
+	}
 	if len(metadata) > 0 {
 		return nil, stow.NotSupported("metadata")
 	}
+	var content *stow.ContentProperties
+	var tags map[string]string
+	if options != nil {
+		switch {
+		case options.StorageClass != "":
+			return nil, stow.NotSupported("storage class")
+		case options.ACL != "":
+			return nil, stow.NotSupported("ACL")
+		case options.Encryption != nil:
+			return nil, stow.NotSupported("encryption")
+		}
+		var err error
+		if tags, err = stow.TagValues(options.Tags); err != nil {
+			return nil, err
+		}
+		if !options.ContentProperties.IsZero() {
+			props := options.ContentProperties
+			content = &props
+		}
+	}
 
 	path := filepath.Join(c.path, filepath.FromSlash(name))
 	item := &item{
@@ -62,6 +127,9 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 	if err != nil {
 		return nil, err
 	}
+	if err := checkUnlocked(path); err != nil {
+		return nil, err
+	}
 	f, err := os.Create(path)
 	if err != nil {
 		return nil, err
@@ -74,6 +142,12 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 	if n != size {
 		return nil, errors.New("bad size")
 	}
+	if err := applyDefaultRetention(c.path, path); err != nil {
+		return nil, err
+	}
+	if err := resetRecord(path, content, tags); err != nil {
+		return nil, err
+	}
 	return item, nil
 }
 
@@ -124,15 +198,77 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 	return items, cursor, nil
 }
 
+// ItemsDelimited lists the files with the prefix in the directory the
+// prefix names, and its subdirectories as prefixes. Only the slash
+// delimiter is supported, as it is the one that separates directories.
+func (c *container) ItemsDelimited(prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	if delimiter != "/" {
+		return nil, nil, "", stow.NotSupported("delimiter " + delimiter)
+	}
+	dir := prefix[:strings.LastIndex(prefix, "/")+1]
+	infos, err := ioutil.ReadDir(filepath.Join(c.path, filepath.FromSlash(dir)))
+	if os.IsNotExist(err) || errors.Is(err, syscall.ENOTDIR) {
+		return nil, nil, "", nil
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+	var names []string
+	for _, info := range infos {
+		name := dir + info.Name()
+		if info.IsDir() {
+			if info.Name() == sidecarDir {
+				continue
+			}
+			name += "/"
+		}
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if cursor != stow.CursorStart {
+		i := sort.SearchStrings(names, cursor)
+		if i == len(names) || names[i] != cursor {
+			return nil, nil, "", stow.ErrBadCursor
+		}
+		names = names[i:]
+	}
+	cursor = ""
+	if len(names) > count {
+		cursor = names[count]
+		names = names[:count]
+	}
+	var items []stow.Item
+	var prefixes []string
+	for _, name := range names {
+		if strings.HasSuffix(name, "/") {
+			prefixes = append(prefixes, name)
+			continue
+		}
+		items = append(items, &item{
+			path:          filepath.Join(c.path, filepath.FromSlash(name)),
+			contPrefixLen: len(c.path) + 1,
+		})
+	}
+	return items, prefixes, cursor, nil
+}
+
 func (c *container) Item(id string) (stow.Item, error) {
 	path := id
 	if !filepath.IsAbs(id) {
 		path = filepath.Join(c.path, filepath.FromSlash(id))
 	}
+	if inSidecar(c.nameOf(path)) {
+		return nil, stow.ErrNotFound
+	}
 	info, err := os.Stat(path)
-	if os.IsNotExist(err) {
+	if os.IsNotExist(err) || errors.Is(err, syscall.ENOTDIR) {
 		return nil, stow.ErrNotFound
 	}
+	if err != nil {
+		return nil, err
+	}
 	if info.IsDir() {
 		return nil, errors.New("unexpected directory")
 	}
@@ -148,7 +284,8 @@ func (c *container) Item(id string) (stow.Item, error) {
 }
 
 // flatdirs walks the entire tree returning a list of
-// os.FileInfo for all items encountered.
+// os.FileInfo for all items encountered. Sidecar directories
+// are skipped.
 func flatdirs(path string) ([]os.FileInfo, error) {
 	var list []os.FileInfo
 	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
@@ -156,6 +293,9 @@ func flatdirs(path string) ([]os.FileInfo, error) {
 			return err
 		}
 		if info.IsDir() {
+			if info.Name() == sidecarDir {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 		flatname, err := filepath.Rel(path, p)
@@ -182,3 +322,21 @@ type fileinfo struct {
 func (f fileinfo) Name() string {
 	return f.name
 }
+
+// Stat describes the directory of the container. Created is the time the
+// directory was last modified, as creation times are not portable, and
+// Metadata holds the same file metadata as items have.
+func (c *container) Stat() (stow.ContainerInfo, error) {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return stow.ContainerInfo{}, stow.ErrNotFound
+		}
+		return stow.ContainerInfo{}, err
+	}
+	return stow.ContainerInfo{
+		Created:    info.ModTime(),
+		Versioning: stow.VersioningDisabled,
+		Metadata:   getFileMetadata(c.path, info),
+	}, nil
+}
@@ -1,9 +1,15 @@
 package local_test
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/cheekybits/is"
 	"github.com/graymeta/stow"
@@ -60,3 +66,114 @@ func TestItemsPaging(t *testing.T) {
 	is.Equal(err, stow.ErrBadCursor)
 
 }
+
+func TestItemIteratorRemovedCursor(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+	l, err := stow.Dial(local.Kind, stow.ConfigMap{"path": testDir})
+	is.NoErr(err)
+	container, err := l.Container(filepath.Join(testDir, "one"))
+	is.NoErr(err)
+	for i := 0; i < 5; i++ {
+		_, err := container.Put(fmt.Sprintf("item-%d", i), strings.NewReader(`item`), 4, nil)
+		is.NoErr(err)
+	}
+
+	it := stow.NewItemIterator(container, stow.NoPrefix, stow.CursorStart, 2)
+	var names []string
+	for len(names) < 2 && it.Next() {
+		names = append(names, it.Item().Name())
+	}
+	// the cursor of the next page names the Item that is removed, so the
+	// listing is read again from the start
+	is.NoErr(container.RemoveItem(filepath.Join(container.ID(), "item-2")))
+	for it.Next() {
+		names = append(names, it.Item().Name())
+	}
+	is.NoErr(it.Err())
+	is.Equal(names, []string{"item-0", "item-1", "item-3", "item-4"})
+}
+
+func TestContainerStat(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+
+	l, err := stow.Dial(local.Kind, stow.ConfigMap{"path": testDir})
+	is.NoErr(err)
+	c, err := l.Container("three")
+	is.NoErr(err)
+
+	info, err := stow.StatContainer(c)
+	is.NoErr(err)
+	is.False(info.Created.IsZero())
+	is.Equal(info.Versioning, stow.VersioningDisabled)
+	is.Equal(info.Metadata[local.MetadataIsDir], true)
+	is.Equal(info.Metadata[local.MetadataName], "three")
+}
+
+func TestItemsDelimited(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+	l, err := stow.Dial(local.Kind, stow.ConfigMap{"path": testDir})
+	is.NoErr(err)
+	c, err := l.Container(filepath.Join(testDir, "three"))
+	is.NoErr(err)
+
+	is.NoErr(os.MkdirAll(filepath.Join(c.ID(), "sub", "deep"), 0777))
+	is.NoErr(ioutil.WriteFile(filepath.Join(c.ID(), "sub", "a"), []byte("12345"), 0777))
+	is.NoErr(ioutil.WriteFile(filepath.Join(c.ID(), "sub", "deep", "b"), []byte("1234567"), 0777))
+
+	d := c.(stow.DelimiterLister)
+	items, prefixes, cursor, err := d.ItemsDelimited("", "/", stow.CursorStart, 2)
+	is.NoErr(err)
+	is.Equal(len(items), 2)
+	is.Equal(items[0].Name(), "item1")
+	is.Equal(len(prefixes), 0)
+	items, prefixes, cursor, err = d.ItemsDelimited("", "/", cursor, 2)
+	is.NoErr(err)
+	is.Equal(len(items), 1)
+	is.Equal(items[0].Name(), "item3")
+	is.Equal(prefixes, []string{"sub/"})
+	is.Equal(cursor, "")
+
+	items, prefixes, _, err = d.ItemsDelimited("sub/", "/", stow.CursorStart, 10)
+	is.NoErr(err)
+	is.Equal(len(items), 1)
+	is.Equal(items[0].Name(), "sub/a")
+	is.Equal(prefixes, []string{"sub/deep/"})
+
+	usage, err := stow.Usage(c, stow.NoPrefix, 2)
+	is.NoErr(err)
+	is.Equal(len(usage), 3)
+	is.Equal(usage[0].UsageCount, stow.UsageCount{Objects: 5, Bytes: 21})
+	is.Equal(usage[1].Prefix, "sub/")
+	is.Equal(usage[1].UsageCount, stow.UsageCount{Objects: 2, Bytes: 12})
+	is.Equal(usage[2].Prefix, "sub/deep/")
+	is.Equal(usage[2].UsageCount, stow.UsageCount{Objects: 1, Bytes: 7})
+}
+
+func TestFS(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+	l, err := stow.Dial(local.Kind, stow.ConfigMap{"path": testDir})
+	is.NoErr(err)
+	c, err := l.Container(filepath.Join(testDir, "three"))
+	is.NoErr(err)
+	_, err = c.Put("sub/deep/b", strings.NewReader("1234567"), 7, nil)
+	is.NoErr(err)
+
+	fsys := stow.FS(c)
+	if err := fstest.TestFS(fsys, "item1", "sub/deep/b"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.Stat(fsys, "item1/b")
+	is.True(errors.Is(err, fs.ErrNotExist))
+}
@@ -1,12 +1,21 @@
 package local
 
 import (
+	"fmt"
 	"io"
 	"net/url"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/graymeta/stow"
+)
+
+var (
+	_ stow.ContentDescriber = (*item)(nil)
+	_ stow.ItemSeeker       = (*item)(nil)
+	_ stow.ItemRanger       = (*item)(nil)
 )
 
 // Metadata constants describe the metadata available
@@ -71,6 +80,47 @@ func (i *item) Open() (io.ReadCloser, error) {
 	return os.Open(i.path)
 }
 
+// OpenRange opens the file for reading starting at byte start and ending
+// at byte end, or at the end of the file when it is shorter.
+func (i *item) OpenRange(start, end uint64) (io.ReadCloser, error) {
+	if end < start {
+		return nil, fmt.Errorf("bad range %d-%d", start, end)
+	}
+	f, err := os.Open(i.path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if start >= uint64(info.Size()) {
+		f.Close()
+		return nil, fmt.Errorf("range %d-%d starts past the end of the file", start, end)
+	}
+	if _, err := f.Seek(int64(start), io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &rangeReader{Reader: io.LimitReader(f, int64(end-start+1)), Closer: f}, nil
+}
+
+// rangeReader reads a range of a file, closing the file.
+type rangeReader struct {
+	io.Reader
+	io.Closer
+}
+
+// OpenSeeker opens the file for random access.
+func (i *item) OpenSeeker() (stow.ReadSeekCloser, error) {
+	f, err := os.Open(i.path)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
 func (i *item) LastMod() (time.Time, error) {
 	err := i.ensureInfo()
 	if err != nil {
@@ -97,11 +147,52 @@ func (i *item) setMetadata(info os.FileInfo) {
 	i.metadata = fileMetadata
 }
 
-// Metadata gets stat information for the file.
+// Metadata gets stat information for the file, along with the metadata
+// set with SetMetadata or MergeMetadata.
 func (i *item) Metadata() (map[string]interface{}, error) {
 	err := i.ensureInfo()
 	if err != nil {
 		return nil, err
 	}
-	return i.metadata, nil
+	rec, err := readRecord(recordPath(i.path))
+	if err != nil {
+		return nil, err
+	}
+	if len(rec.Metadata) == 0 {
+		return i.metadata, nil
+	}
+	md := make(map[string]interface{}, len(i.metadata)+len(rec.Metadata))
+	for key, value := range rec.Metadata {
+		md[key] = value
+	}
+	for key, value := range i.metadata {
+		md[key] = value
+	}
+	return md, nil
+}
+
+// ContentProperties gets the content properties the file was put with. A
+// missing content type is detected from the name and contents of the file.
+func (i *item) ContentProperties() (stow.ContentProperties, error) {
+	var props stow.ContentProperties
+	rec, err := readRecord(recordPath(i.path))
+	if err != nil {
+		return props, err
+	}
+	if rec.Content != nil {
+		props = *rec.Content
+	}
+	if props.ContentType != "" {
+		return props, nil
+	}
+	f, err := os.Open(i.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return props, stow.ErrNotFound
+		}
+		return props, err
+	}
+	defer f.Close()
+	props.ContentType, _, err = stow.DetectContentType(i.path, f)
+	return props, err
 }
@@ -1,6 +1,7 @@
 package local_test
 
 import (
+	"io"
 	"io/ioutil"
 	"net/url"
 	"os"
@@ -45,6 +46,35 @@ func TestItemReader(t *testing.T) {
 
 }
 
+func TestItemSeeker(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+
+	l, err := stow.Dial(local.Kind, stow.ConfigMap{"path": testDir})
+	is.NoErr(err)
+	c, err := l.Container(filepath.Join(testDir, "three"))
+	is.NoErr(err)
+	item, err := c.Put("seekable", strings.NewReader("0123456789"), 10, nil)
+	is.NoErr(err)
+
+	rs, err := stow.OpenSeeker(item)
+	is.NoErr(err)
+	defer rs.Close()
+	_, ok := rs.(*os.File)
+	is.True(ok)
+	b := make([]byte, 3)
+	_, err = rs.ReadAt(b, 6)
+	is.NoErr(err)
+	is.Equal(string(b), "678")
+	_, err = rs.Seek(-2, io.SeekEnd)
+	is.NoErr(err)
+	b, err = ioutil.ReadAll(rs)
+	is.NoErr(err)
+	is.Equal(string(b), "89")
+}
+
 func TestHardlink(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.SkipNow()
@@ -19,6 +19,9 @@ func (l *location) Close() error {
 }
 
 func (l *location) ItemByURL(u *url.URL) (stow.Item, error) {
+	if inSidecar(u.Path) {
+		return nil, stow.ErrNotFound
+	}
 	dir, _ := filepath.Split(u.Path)
 	return &item{
 		path:          u.Path,
@@ -26,7 +29,24 @@ func (l *location) ItemByURL(u *url.URL) (stow.Item, error) {
 	}, nil
 }
 
+// RemoveContainer removes the directory of the container, unless a file in
+// it is retained or under legal hold.
 func (l *location) RemoveContainer(id string) error {
+	err := filepath.Walk(id, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == sidecarDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return checkUnlocked(path)
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
 	return os.RemoveAll(id)
 }
 
@@ -135,7 +155,7 @@ func (l *location) filesToContainers(root string, files ...string) ([]stow.Conta
 		if err != nil {
 			return nil, err
 		}
-		if !info.IsDir() {
+		if !info.IsDir() || info.Name() == sidecarDir {
 			continue
 		}
 		absroot, err := filepath.Abs(root)
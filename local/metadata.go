@@ -0,0 +1,59 @@
+package local
+
+import (
+	"os"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var _ stow.MetadataSetter = (*item)(nil)
+
+// SetMetadata records the metadata of the file in its sidecar. Keys used
+// for the stat information of the file cannot be set.
+func (i *item) SetMetadata(metadata map[string]interface{}) error {
+	return i.updateMetadata(func(map[string]interface{}) map[string]interface{} {
+		return metadata
+	})
+}
+
+// MergeMetadata changes the given keys in the recorded metadata of the
+// file.
+func (i *item) MergeMetadata(changes map[string]interface{}) error {
+	return i.updateMetadata(func(current map[string]interface{}) map[string]interface{} {
+		return stow.MergedMetadata(current, changes)
+	})
+}
+
+func (i *item) updateMetadata(update func(current map[string]interface{}) map[string]interface{}) error {
+	info, err := os.Lstat(i.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return stow.ErrNotFound
+		}
+		return err
+	}
+	path := recordPath(i.path)
+	rec, err := readRecord(path)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]interface{}, len(rec.Metadata))
+	for key, value := range rec.Metadata {
+		current[key] = value
+	}
+	fileMetadata := getFileMetadata(i.path, info)
+	values := make(map[string]string)
+	for key, value := range update(current) {
+		if _, ok := fileMetadata[key]; ok {
+			return errors.Errorf("metadata key '%s' is used for file information", key)
+		}
+		str, ok := value.(string)
+		if !ok {
+			return errors.Errorf(`value of key '%s' in metadata must be of type string`, key)
+		}
+		values[key] = str
+	}
+	rec.Metadata = values
+	return writeRecord(path, rec)
+}
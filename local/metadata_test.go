@@ -0,0 +1,48 @@
+package local_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+	"github.com/graymeta/stow/local"
+)
+
+func TestMetadataSetter(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+
+	l, err := stow.Dial(local.Kind, stow.ConfigMap{"path": testDir})
+	is.NoErr(err)
+	c, err := l.Container("one")
+	is.NoErr(err)
+
+	item, err := c.Put("dir/described.txt", strings.NewReader("described"), 9, nil)
+	is.NoErr(err)
+	setter := item.(stow.MetadataSetter)
+
+	is.NoErr(setter.SetMetadata(map[string]interface{}{"one": "1", "two": "2"}))
+	is.NoErr(setter.MergeMetadata(map[string]interface{}{"one": nil, "three": "3"}))
+	md, err := item.Metadata()
+	is.NoErr(err)
+	is.Equal(md["two"], "2")
+	is.Equal(md["three"], "3")
+	_, ok := md["one"]
+	is.False(ok)
+	// the stat information is still there
+	is.Equal(md[local.MetadataName], "described.txt")
+
+	is.Err(setter.SetMetadata(map[string]interface{}{local.MetadataSize: "big"}))
+	is.Err(setter.SetMetadata(map[string]interface{}{"count": 1}))
+
+	// metadata belongs to the file, so is gone when it is replaced
+	item, err = c.Put("dir/described.txt", strings.NewReader("new"), 3, nil)
+	is.NoErr(err)
+	md, err = item.Metadata()
+	is.NoErr(err)
+	_, ok = md["two"]
+	is.False(ok)
+}
@@ -0,0 +1,150 @@
+package local
+
+import (
+	"os"
+	"time"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ stow.ItemRetainer      = (*item)(nil)
+	_ stow.ContainerRetainer = (*container)(nil)
+)
+
+func (rec record) retention() stow.Retention {
+	r := stow.Retention{
+		Mode:      rec.RetentionMode,
+		LegalHold: rec.LegalHold,
+	}
+	if rec.RetainUntil != nil {
+		r.RetainUntil = *rec.RetainUntil
+	}
+	return r
+}
+
+// checkUnlocked returns stow.ErrLocked if the file at path is retained.
+func checkUnlocked(path string) error {
+	rec, err := readRecord(recordPath(path))
+	if err != nil {
+		return err
+	}
+	if rec.retention().IsLocked(time.Now()) {
+		return stow.ErrLocked
+	}
+	return nil
+}
+
+// applyDefaultRetention retains the newly written file at path as set up
+// for the container at containerPath.
+func applyDefaultRetention(containerPath, path string) error {
+	crec, err := readRecord(containerRecordPath(containerPath))
+	if err != nil {
+		return err
+	}
+	if crec.RetentionPeriod <= 0 {
+		return nil
+	}
+	rec, err := readRecord(recordPath(path))
+	if err != nil {
+		return err
+	}
+	until := time.Now().Add(crec.RetentionPeriod).UTC()
+	rec.RetentionMode = crec.RetentionMode
+	rec.RetainUntil = &until
+	return writeRecord(recordPath(path), rec)
+}
+
+// Retention gets the retention of the file from its sidecar record.
+func (i *item) Retention() (stow.Retention, error) {
+	rec, err := readRecord(recordPath(i.path))
+	if err != nil {
+		return stow.Retention{}, err
+	}
+	return rec.retention(), nil
+}
+
+// SetRetention records the retention of the file. As with object storage
+// services, a compliance mode retention can only be extended.
+func (i *item) SetRetention(mode stow.RetentionMode, retainUntil time.Time) error {
+	if _, err := os.Stat(i.path); err != nil {
+		if os.IsNotExist(err) {
+			return stow.ErrNotFound
+		}
+		return err
+	}
+	if !retainUntil.IsZero() && mode != stow.RetentionGovernance && mode != stow.RetentionCompliance {
+		return errors.Errorf("unknown retention mode %q", mode)
+	}
+	path := recordPath(i.path)
+	rec, err := readRecord(path)
+	if err != nil {
+		return err
+	}
+	current := rec.retention()
+	if current.Mode == stow.RetentionCompliance && time.Now().Before(current.RetainUntil) {
+		if mode != stow.RetentionCompliance || retainUntil.Before(current.RetainUntil) {
+			return stow.ErrLocked
+		}
+	}
+	if retainUntil.IsZero() {
+		rec.RetentionMode = ""
+		rec.RetainUntil = nil
+	} else {
+		until := retainUntil.UTC()
+		rec.RetentionMode = mode
+		rec.RetainUntil = &until
+	}
+	return writeRecord(path, rec)
+}
+
+// SetLegalHold records whether the file is under legal hold.
+func (i *item) SetLegalHold(hold bool) error {
+	if _, err := os.Stat(i.path); err != nil {
+		if os.IsNotExist(err) {
+			return stow.ErrNotFound
+		}
+		return err
+	}
+	path := recordPath(i.path)
+	rec, err := readRecord(path)
+	if err != nil {
+		return err
+	}
+	rec.LegalHold = hold
+	return writeRecord(path, rec)
+}
+
+// DefaultRetention gets the retention applied to files put in the
+// container.
+func (c *container) DefaultRetention() (stow.DefaultRetention, error) {
+	rec, err := readRecord(containerRecordPath(c.path))
+	if err != nil {
+		return stow.DefaultRetention{}, err
+	}
+	return stow.DefaultRetention{
+		Mode:   rec.RetentionMode,
+		Period: rec.RetentionPeriod,
+	}, nil
+}
+
+// SetDefaultRetention sets the retention applied to files put in the
+// container.
+func (c *container) SetDefaultRetention(retention stow.DefaultRetention) error {
+	if retention.Period > 0 && retention.Mode != stow.RetentionGovernance && retention.Mode != stow.RetentionCompliance {
+		return errors.Errorf("unknown retention mode %q", retention.Mode)
+	}
+	path := containerRecordPath(c.path)
+	rec, err := readRecord(path)
+	if err != nil {
+		return err
+	}
+	rec.RetentionMode = ""
+	rec.RetentionPeriod = 0
+	if retention.Period > 0 {
+		rec.RetentionMode = retention.Mode
+		rec.RetentionPeriod = retention.Period
+	}
+	return writeRecord(path, rec)
+}
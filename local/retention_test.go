@@ -0,0 +1,199 @@
+package local_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+	"github.com/graymeta/stow/local"
+)
+
+func TestRetention(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+
+	l, err := stow.Dial(local.Kind, stow.ConfigMap{"path": testDir})
+	is.NoErr(err)
+	c, err := l.Container("one")
+	is.NoErr(err)
+
+	item, err := c.Put("dir/locked", strings.NewReader("locked"), 6, nil)
+	is.NoErr(err)
+	retainer, ok := item.(stow.ItemRetainer)
+	is.True(ok)
+
+	retention, err := retainer.Retention()
+	is.NoErr(err)
+	is.Equal(retention, stow.Retention{})
+
+	until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	is.NoErr(retainer.SetRetention(stow.RetentionCompliance, until))
+	retention, err = retainer.Retention()
+	is.NoErr(err)
+	is.Equal(retention.Mode, stow.RetentionCompliance)
+	is.True(retention.RetainUntil.Equal(until))
+
+	// the item can be neither removed nor overwritten
+	is.Equal(c.RemoveItem(item.ID()), stow.ErrLocked)
+	_, err = c.Put("dir/locked", strings.NewReader("changed"), 7, nil)
+	is.Equal(err, stow.ErrLocked)
+	b, err := ioutil.ReadFile(item.ID())
+	is.NoErr(err)
+	is.Equal(string(b), "locked")
+
+	// compliance retention can be extended, not shortened
+	is.Equal(retainer.SetRetention(stow.RetentionCompliance, until.Add(-time.Minute)), stow.ErrLocked)
+	is.Equal(retainer.SetRetention(stow.RetentionGovernance, until.Add(time.Hour)), stow.ErrLocked)
+	is.NoErr(retainer.SetRetention(stow.RetentionCompliance, until.Add(time.Hour)))
+
+	// sidecar records are not listed as items
+	items, _, err := c.Items(stow.NoPrefix, stow.CursorStart, 10)
+	is.NoErr(err)
+	is.Equal(len(items), 1)
+	is.Equal(items[0].Name(), "dir/locked")
+}
+
+func TestRetentionExpired(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+
+	l, err := stow.Dial(local.Kind, stow.ConfigMap{"path": testDir})
+	is.NoErr(err)
+	c, err := l.Container("one")
+	is.NoErr(err)
+
+	item, err := c.Put("expired", strings.NewReader("expired"), 7, nil)
+	is.NoErr(err)
+	retainer := item.(stow.ItemRetainer)
+	is.NoErr(retainer.SetRetention(stow.RetentionCompliance, time.Now().Add(-time.Second)))
+
+	is.NoErr(c.RemoveItem(item.ID()))
+	_, err = os.Stat(filepath.Join(testDir, "one", ".stow", "expired.json"))
+	is.True(os.IsNotExist(err))
+}
+
+func TestLegalHold(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+
+	l, err := stow.Dial(local.Kind, stow.ConfigMap{"path": testDir})
+	is.NoErr(err)
+	c, err := l.Container("one")
+	is.NoErr(err)
+
+	item, err := c.Put("held", strings.NewReader("held"), 4, nil)
+	is.NoErr(err)
+	retainer := item.(stow.ItemRetainer)
+
+	// governance retention can be removed, the legal hold still applies
+	is.NoErr(retainer.SetRetention(stow.RetentionGovernance, time.Now().Add(time.Hour)))
+	is.NoErr(retainer.SetLegalHold(true))
+	is.NoErr(retainer.SetRetention(stow.RetentionGovernance, time.Time{}))
+	retention, err := retainer.Retention()
+	is.NoErr(err)
+	is.Equal(retention, stow.Retention{LegalHold: true})
+	is.Equal(c.RemoveItem(item.ID()), stow.ErrLocked)
+
+	// nor can the container holding it be removed
+	is.Equal(l.RemoveContainer(c.ID()), stow.ErrLocked)
+	_, err = os.Stat(item.ID())
+	is.NoErr(err)
+
+	is.NoErr(retainer.SetLegalHold(false))
+	is.NoErr(c.RemoveItem(item.ID()))
+	is.NoErr(l.RemoveContainer(c.ID()))
+}
+
+func TestSidecarNames(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+
+	l, err := stow.Dial(local.Kind, stow.ConfigMap{"path": testDir})
+	is.NoErr(err)
+	c, err := l.Container("one")
+	is.NoErr(err)
+
+	item, err := c.Put("f.txt", strings.NewReader("locked"), 6, nil)
+	is.NoErr(err)
+	is.NoErr(item.(stow.ItemRetainer).SetRetention(stow.RetentionCompliance, time.Now().Add(time.Hour)))
+	is.Equal(c.RemoveItem(item.ID()), stow.ErrLocked)
+
+	// the records cannot be changed as items to get around the retention
+	_, err = c.Put(".stow/f.txt.json", strings.NewReader("{}"), 2, nil)
+	is.Err(err)
+	_, err = c.Put("dir/.stow/../.stow/f.txt.json", strings.NewReader("{}"), 2, nil)
+	is.Err(err)
+	creator := c.(interface {
+		CreateItem(name string) (stow.Item, io.WriteCloser, error)
+	})
+	_, _, err = creator.CreateItem(".stow/f.txt.json")
+	is.Err(err)
+	record := filepath.Join(c.ID(), ".stow", "f.txt.json")
+	_, err = c.Item(".stow/f.txt.json")
+	is.Equal(err, stow.ErrNotFound)
+	_, err = c.Item(record)
+	is.Equal(err, stow.ErrNotFound)
+	is.Equal(c.RemoveItem(record), stow.ErrNotFound)
+	_, err = l.ItemByURL(&url.URL{Scheme: "file", Path: record})
+	is.Equal(err, stow.ErrNotFound)
+
+	is.Equal(c.RemoveItem(item.ID()), stow.ErrLocked)
+	b, err := ioutil.ReadFile(item.ID())
+	is.NoErr(err)
+	is.Equal(string(b), "locked")
+}
+
+func TestDefaultRetention(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+
+	l, err := stow.Dial(local.Kind, stow.ConfigMap{"path": testDir})
+	is.NoErr(err)
+	c, err := l.Container("one")
+	is.NoErr(err)
+	retainer, ok := c.(stow.ContainerRetainer)
+	is.True(ok)
+
+	def := stow.DefaultRetention{Mode: stow.RetentionGovernance, Period: time.Hour}
+	is.NoErr(retainer.SetDefaultRetention(def))
+	got, err := retainer.DefaultRetention()
+	is.NoErr(err)
+	is.Equal(got, def)
+
+	item, err := c.Put("new", strings.NewReader("new"), 3, nil)
+	is.NoErr(err)
+	retention, err := item.(stow.ItemRetainer).Retention()
+	is.NoErr(err)
+	is.Equal(retention.Mode, stow.RetentionGovernance)
+	is.True(retention.RetainUntil.After(time.Now().Add(59 * time.Minute)))
+	is.Equal(c.RemoveItem(item.ID()), stow.ErrLocked)
+
+	// the sidecar directory is not a container
+	containers, _, err := l.Containers(stow.NoPrefix, stow.CursorStart, 10)
+	is.NoErr(err)
+	for _, c := range containers {
+		is.NotEqual(filepath.Base(c.ID()), ".stow")
+	}
+
+	is.NoErr(retainer.SetDefaultRetention(stow.DefaultRetention{}))
+	got, err = retainer.DefaultRetention()
+	is.NoErr(err)
+	is.Equal(got, stow.DefaultRetention{})
+}
@@ -0,0 +1,120 @@
+package local
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+// sidecarDir is the name of the directories holding the records kept for
+// the files next to them. They are left out of listings.
+const sidecarDir = ".stow"
+
+// errSidecarName is returned for Items named with a segment of sidecarDir,
+// which would write over the records.
+var errSidecarName = errors.New("names with a " + sidecarDir + " segment are reserved for sidecar records")
+
+// inSidecar gets whether the name of a file within a container has a
+// segment of sidecarDir.
+func inSidecar(name string) bool {
+	for _, segment := range strings.Split(filepath.ToSlash(name), "/") {
+		if segment == sidecarDir {
+			return true
+		}
+	}
+	return false
+}
+
+// containerRecordName is the name of the record of a container in its
+// sidecar directory. Records of files always have a .json extension, so it
+// cannot clash with them.
+const containerRecordName = ".container"
+
+// record is the information kept in the sidecar of a file or container.
+type record struct {
+	RetentionMode   stow.RetentionMode `json:"retention_mode,omitempty"`
+	RetainUntil     *time.Time         `json:"retain_until,omitempty"`
+	LegalHold       bool               `json:"legal_hold,omitempty"`
+	RetentionPeriod time.Duration      `json:"retention_period,omitempty"`
+	Tags            map[string]string  `json:"tags,omitempty"`
+	// Content is set when the file was put with content properties.
+	Content *stow.ContentProperties `json:"content,omitempty"`
+	// Metadata is the metadata set with MetadataSetter.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func (rec record) isZero() bool {
+	return rec.RetentionMode == "" && rec.RetainUntil == nil && !rec.LegalHold &&
+		rec.RetentionPeriod == 0 && len(rec.Tags) == 0 && rec.Content == nil &&
+		len(rec.Metadata) == 0
+}
+
+// recordPath gets the path of the record kept for the file at path.
+func recordPath(path string) string {
+	dir, name := filepath.Split(path)
+	return filepath.Join(dir, sidecarDir, name+".json")
+}
+
+// containerRecordPath gets the path of the record kept for the container
+// at path.
+func containerRecordPath(path string) string {
+	return filepath.Join(path, sidecarDir, containerRecordName)
+}
+
+// readRecord reads the record at path. A missing record gives a zero value.
+func readRecord(path string) (record, error) {
+	var rec record
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return rec, nil
+	}
+	if err != nil {
+		return rec, errors.Wrap(err, "reading record")
+	}
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return rec, errors.Wrap(err, "decoding record")
+	}
+	return rec, nil
+}
+
+// writeRecord writes the record at path, removing it when it holds nothing.
+func writeRecord(path string, rec record) error {
+	if rec.isZero() {
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "removing record")
+		}
+		return nil
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "encoding record")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return errors.Wrap(err, "creating sidecar directory")
+	}
+	if err := ioutil.WriteFile(path, b, 0666); err != nil {
+		return errors.Wrap(err, "writing record")
+	}
+	return nil
+}
+
+// resetRecord records the content properties and tags of the file just
+// written at path, replacing those of the file it replaced, whose metadata
+// is dropped too.
+func resetRecord(path string, content *stow.ContentProperties, tags map[string]string) error {
+	rec, err := readRecord(recordPath(path))
+	if err != nil {
+		return err
+	}
+	rec.Content = content
+	rec.Tags = tags
+	rec.Metadata = nil
+	return writeRecord(recordPath(path), rec)
+}
@@ -0,0 +1,117 @@
+package local
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/graymeta/stow"
+)
+
+var (
+	_ stow.Taggable  = (*item)(nil)
+	_ stow.TagSetter = (*item)(nil)
+	_ stow.TagLister = (*container)(nil)
+)
+
+func (rec record) tags() map[string]interface{} {
+	tags := make(map[string]interface{}, len(rec.Tags))
+	for key, value := range rec.Tags {
+		tags[key] = value
+	}
+	return tags
+}
+
+// setTags records the tags of the file at path, replacing any it had.
+func setTags(path string, tags map[string]string) error {
+	rec, err := readRecord(recordPath(path))
+	if err != nil {
+		return err
+	}
+	rec.Tags = tags
+	return writeRecord(recordPath(path), rec)
+}
+
+// Tags gets the tags of the file from its sidecar record.
+func (i *item) Tags() (map[string]interface{}, error) {
+	if _, err := os.Stat(i.path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, stow.ErrNotFound
+		}
+		return nil, err
+	}
+	rec, err := readRecord(recordPath(i.path))
+	if err != nil {
+		return nil, err
+	}
+	return rec.tags(), nil
+}
+
+// SetTags records the tags of the file, replacing any it had.
+func (i *item) SetTags(tags map[string]interface{}) error {
+	values, err := stow.TagValues(tags)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(i.path); err != nil {
+		if os.IsNotExist(err) {
+			return stow.ErrNotFound
+		}
+		return err
+	}
+	return setTags(i.path, values)
+}
+
+// DeleteTags removes the tags of the file.
+func (i *item) DeleteTags() error {
+	if _, err := os.Stat(i.path); err != nil {
+		if os.IsNotExist(err) {
+			return stow.ErrNotFound
+		}
+		return err
+	}
+	return setTags(i.path, nil)
+}
+
+// ItemsByTags finds the files having all of the tags by reading the
+// sidecar record of every file in the container.
+func (c *container) ItemsByTags(tags map[string]string, cursor string, count int) ([]stow.Item, string, error) {
+	files, err := flatdirs(c.path)
+	if err != nil {
+		return nil, "", err
+	}
+	if cursor != stow.CursorStart {
+		ok := false
+		for i, file := range files {
+			if file.Name() == cursor {
+				files = files[i:]
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return nil, "", stow.ErrBadCursor
+		}
+	}
+	var items []stow.Item
+	for _, f := range files {
+		if len(items) == count {
+			return items, f.Name(), nil
+		}
+		path, err := filepath.Abs(filepath.Join(c.path, f.Name()))
+		if err != nil {
+			return nil, "", err
+		}
+		rec, err := readRecord(recordPath(path))
+		if err != nil {
+			return nil, "", err
+		}
+		if len(rec.Tags) == 0 || !stow.MatchTags(rec.tags(), tags) {
+			continue
+		}
+		items = append(items, &item{
+			path:          path,
+			contPrefixLen: len(c.path) + 1,
+		})
+	}
+	return items, "", nil
+}
@@ -0,0 +1,80 @@
+package local_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+	"github.com/graymeta/stow/local"
+)
+
+func TestTags(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+
+	l, err := stow.Dial(local.Kind, stow.ConfigMap{"path": testDir})
+	is.NoErr(err)
+	c, err := l.Container("one")
+	is.NoErr(err)
+
+	item, err := stow.PutWithOptions(c, "dir/tagged.txt", strings.NewReader("tagged"), 6, nil, &stow.PutOptions{
+		Tags: map[string]interface{}{"team": "media"},
+	})
+	is.NoErr(err)
+	tags, err := item.(stow.Taggable).Tags()
+	is.NoErr(err)
+	is.Equal(tags, map[string]interface{}{"team": "media"})
+
+	_, err = stow.PutWithOptions(c, "other.txt", strings.NewReader("other"), 5, nil, &stow.PutOptions{
+		Tags: map[string]interface{}{"team": "sound"},
+	})
+	is.NoErr(err)
+
+	items, cursor, err := c.(stow.TagLister).ItemsByTags(map[string]string{"team": "media"}, stow.CursorStart, 10)
+	is.NoErr(err)
+	is.Equal(cursor, "")
+	is.Equal(len(items), 1)
+	is.Equal(items[0].Name(), "dir/tagged.txt")
+
+	setter := item.(stow.TagSetter)
+	is.NoErr(setter.SetTags(map[string]interface{}{"team": "sound", "stage": "raw"}))
+	is.Err(setter.SetTags(map[string]interface{}{"count": 1}))
+	items, _, err = c.(stow.TagLister).ItemsByTags(map[string]string{"team": "sound"}, stow.CursorStart, 10)
+	is.NoErr(err)
+	is.Equal(len(items), 2)
+	items, _, err = c.(stow.TagLister).ItemsByTags(map[string]string{"team": "sound", "stage": "raw"}, stow.CursorStart, 10)
+	is.NoErr(err)
+	is.Equal(len(items), 1)
+
+	// the sidecar is not listed as an item
+	all, _, err := c.Items(stow.NoPrefix, stow.CursorStart, 100)
+	is.NoErr(err)
+	for _, i := range all {
+		is.False(strings.Contains(i.Name(), ".stow"))
+	}
+
+	// tags belong to the file, so are gone when it is replaced
+	item, err = c.Put("dir/tagged.txt", strings.NewReader("new"), 3, nil)
+	is.NoErr(err)
+	tags, err = item.(stow.Taggable).Tags()
+	is.NoErr(err)
+	is.Equal(len(tags), 0)
+
+	other, err := c.Item("other.txt")
+	is.NoErr(err)
+	is.NoErr(other.(stow.TagSetter).DeleteTags())
+	tags, err = other.(stow.Taggable).Tags()
+	is.NoErr(err)
+	is.Equal(len(tags), 0)
+
+	is.NoErr(c.RemoveItem(other.ID()))
+	_, err = other.(stow.Taggable).Tags()
+	is.Equal(err, stow.ErrNotFound)
+	_, err = os.Stat(filepath.Join(testDir, "one", ".stow", "other.txt.json"))
+	is.True(os.IsNotExist(err))
+}
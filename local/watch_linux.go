@@ -0,0 +1,284 @@
+package local
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/graymeta/stow"
+)
+
+var _ stow.Watcher = (*container)(nil)
+
+// watchMask is the inotify events watched for on each directory.
+const watchMask = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_ATTRIB |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// Watch watches the files in the container with inotify, adding a watch
+// for each directory in it.
+func (c *container) Watch(prefix string, options *stow.WatchOptions) (stow.Watch, error) {
+	if options == nil {
+		options = &stow.WatchOptions{}
+	}
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	w := &watcher{
+		container: c,
+		prefix:    prefix,
+		fd:        fd,
+		file:      os.NewFile(uintptr(fd), "inotify"),
+		dirs:      make(map[int32]string),
+	}
+	// the watches are added before listing, so no change is missed
+	if err := w.addDirs(c.path); err != nil {
+		w.file.Close()
+		return nil, err
+	}
+	checkpoint, items, err := stow.TakeSnapshot(c, prefix)
+	if err != nil {
+		w.file.Close()
+		return nil, err
+	}
+	if options.Checkpoint == nil {
+		items = nil
+	} else {
+		checkpoint = options.Checkpoint
+	}
+	w.stream = stow.NewEventStream(checkpoint)
+	go w.run(items)
+	return w.stream, nil
+}
+
+// watcher reads the inotify events of a watch, and turns them into Events.
+type watcher struct {
+	container *container
+	prefix    string
+	fd        int
+	file      *os.File
+	// dirs are the paths of the watched directories by watch descriptor.
+	dirs   map[int32]string
+	stream *stow.EventStream
+}
+
+// addDirs watches the directory and those below it, except sidecars.
+func (w *watcher) addDirs(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if info.Name() == sidecarDir {
+			return filepath.SkipDir
+		}
+		wd, err := syscall.InotifyAddWatch(w.fd, path, watchMask)
+		if err != nil {
+			return os.NewSyscallError("inotify_add_watch", err)
+		}
+		w.dirs[int32(wd)] = path
+		return nil
+	})
+}
+
+func (w *watcher) run(items map[string]stow.Item) {
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-w.stream.Done():
+		case <-finished:
+		}
+		w.file.Close()
+	}()
+	// send what changed since the checkpoint first
+	if items != nil {
+		events, err := w.stream.Checkpoint().Diff(items)
+		if err != nil {
+			w.stream.Stop(err)
+			return
+		}
+		for _, event := range events {
+			if !w.stream.Send(event) {
+				return
+			}
+		}
+	}
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			select {
+			case <-w.stream.Done():
+				err = nil
+			default:
+			}
+			w.stream.Stop(err)
+			return
+		}
+		if err := w.handle(buf[:n]); err != nil {
+			if err == errClosed {
+				return
+			}
+			w.stream.Stop(err)
+			return
+		}
+	}
+}
+
+// errClosed stops handling events once the watch was closed.
+var errClosed = errors.New("watch closed")
+
+// handle sends the Events for the inotify events in buf.
+func (w *watcher) handle(buf []byte) error {
+	for off := 0; off+syscall.SizeofInotifyEvent <= len(buf); {
+		ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+		start := off + syscall.SizeofInotifyEvent
+		off = start + int(ev.Len)
+		name := strings.TrimRight(string(buf[start:off]), "\x00")
+		if ev.Mask&syscall.IN_Q_OVERFLOW != 0 {
+			// events were dropped, so compare everything
+			if err := w.rescan(); err != nil {
+				return err
+			}
+			continue
+		}
+		dir, ok := w.dirs[ev.Wd]
+		if !ok {
+			continue
+		}
+		if ev.Mask&syscall.IN_IGNORED != 0 {
+			delete(w.dirs, ev.Wd)
+			continue
+		}
+		path := filepath.Join(dir, name)
+		var err error
+		switch {
+		case ev.Mask&syscall.IN_ISDIR == 0 && ev.Mask&syscall.IN_CREATE != 0:
+			// new files are checked once they are closed after writing,
+			// so that they are not sent before they have their contents
+		case ev.Mask&syscall.IN_ISDIR == 0:
+			err = w.check(path)
+		case name == sidecarDir:
+		case ev.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+			err = w.addTree(path)
+		case ev.Mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0:
+			err = w.removeTree(path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check sends the Event for the file at the path, if it changed.
+func (w *watcher) check(path string) error {
+	name, err := filepath.Rel(w.container.path, path)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(filepath.ToSlash(name), w.prefix) {
+		return nil
+	}
+	var it stow.Item
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	case info.Mode().IsRegular():
+		it = &item{
+			path:          path,
+			contPrefixLen: len(w.container.path) + 1,
+		}
+	}
+	// only the version of the path is needed, not all of the checkpoint
+	current := make(stow.Snapshot, 1)
+	if version, ok := w.stream.Version(path); ok {
+		current[path] = version
+	}
+	event, changed, err := current.Change(path, it)
+	if err != nil {
+		return err
+	}
+	if changed && !w.stream.Send(event) {
+		return errClosed
+	}
+	return nil
+}
+
+// addTree watches a directory that was created or moved into the
+// container, and sends Events for the files already in it.
+func (w *watcher) addTree(root string) error {
+	if err := w.addDirs(root); err != nil {
+		return err
+	}
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == sidecarDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return w.check(path)
+	})
+}
+
+// removeTree sends delete Events for the files that were in a directory
+// which is gone, and drops the watches below it.
+func (w *watcher) removeTree(root string) error {
+	below := root + string(filepath.Separator)
+	for wd, dir := range w.dirs {
+		if dir == root || strings.HasPrefix(dir, below) {
+			syscall.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.dirs, wd)
+		}
+	}
+	var paths []string
+	for id := range w.stream.Checkpoint() {
+		if strings.HasPrefix(id, below) {
+			paths = append(paths, id)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := w.check(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rescan compares the whole container with the checkpoint.
+func (w *watcher) rescan() error {
+	_, items, err := stow.TakeSnapshot(w.container, w.prefix)
+	if err != nil {
+		return err
+	}
+	events, err := w.stream.Checkpoint().Diff(items)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if !w.stream.Send(event) {
+			return errClosed
+		}
+	}
+	return nil
+}
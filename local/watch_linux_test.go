@@ -0,0 +1,83 @@
+package local_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+	"github.com/graymeta/stow/local"
+)
+
+func nextEvent(is is.I, w stow.Watch) stow.Event {
+	select {
+	case event, ok := <-w.Events():
+		is.True(ok)
+		return event
+	case <-time.After(5 * time.Second):
+		is.Fail("no event")
+	}
+	return stow.Event{}
+}
+
+func TestWatch(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+
+	l, err := stow.Dial(local.Kind, stow.ConfigMap{"path": testDir})
+	is.NoErr(err)
+	c, err := l.Container(filepath.Join(testDir, "three"))
+	is.NoErr(err)
+	is.True(c.(stow.Watcher) != nil)
+
+	w, err := stow.WatchContainer(c, stow.NoPrefix, nil)
+	is.NoErr(err)
+
+	// writing through the container does not report its sidecar
+	_, err = c.Put("new", strings.NewReader("new"), 3, nil)
+	is.NoErr(err)
+	event := nextEvent(is, w)
+	is.Equal(event.Type, stow.EventCreated)
+	is.Equal(event.Item.Name(), "new")
+
+	time.Sleep(10 * time.Millisecond)
+	is.NoErr(ioutil.WriteFile(filepath.Join(c.ID(), "item1"), []byte("changed"), 0777))
+	event = nextEvent(is, w)
+	is.Equal(event.Type, stow.EventUpdated)
+	is.Equal(event.Item.Name(), "item1")
+
+	// files in new directories are watched
+	sub := filepath.Join(c.ID(), "sub")
+	is.NoErr(os.Mkdir(sub, 0777))
+	is.NoErr(ioutil.WriteFile(filepath.Join(sub, "file"), []byte("file"), 0777))
+	event = nextEvent(is, w)
+	is.Equal(event.Type, stow.EventCreated)
+	is.Equal(event.Item.Name(), "sub/file")
+
+	is.NoErr(os.RemoveAll(sub))
+	event = nextEvent(is, w)
+	is.Equal(event.Type, stow.EventDeleted)
+	is.Equal(event.ID, filepath.Join(sub, "file"))
+
+	is.NoErr(w.Close())
+	for range w.Events() {
+	}
+	is.NoErr(w.Err())
+	checkpoint := w.Checkpoint()
+	is.Equal(len(checkpoint), 4)
+
+	// resuming sends what changed while not watching
+	is.NoErr(c.RemoveItem(filepath.Join(c.ID(), "item2")))
+	w, err = stow.WatchContainer(c, stow.NoPrefix, &stow.WatchOptions{Checkpoint: checkpoint})
+	is.NoErr(err)
+	defer w.Close()
+	event = nextEvent(is, w)
+	is.Equal(event.Type, stow.EventDeleted)
+	is.Equal(event.ID, filepath.Join(c.ID(), "item2"))
+}
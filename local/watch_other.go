@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package local
+
+import "github.com/graymeta/stow"
+
+var _ stow.Watcher = (*container)(nil)
+
+// Watch polls the files in the container, as there are no inotify
+// watches on this platform.
+func (c *container) Watch(prefix string, options *stow.WatchOptions) (stow.Watch, error) {
+	return stow.PollWatch(c, prefix, options)
+}
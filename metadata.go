@@ -0,0 +1,28 @@
+package stow
+
+// MetadataSetter represents an Item whose metadata can be changed without
+// putting it again.
+type MetadataSetter interface {
+	// SetMetadata replaces the metadata of the Item.
+	SetMetadata(metadata map[string]interface{}) error
+	// MergeMetadata sets the given keys in the metadata of the Item,
+	// leaving the other keys unchanged. Keys with nil values are removed.
+	MergeMetadata(changes map[string]interface{}) error
+}
+
+// MergedMetadata gets the metadata with the changes applied, the way
+// MetadataSetter.MergeMetadata applies them. Neither map is modified.
+func MergedMetadata(metadata, changes map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(metadata)+len(changes))
+	for key, value := range metadata {
+		merged[key] = value
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
@@ -0,0 +1,21 @@
+package stow_test
+
+import (
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestMergedMetadata(t *testing.T) {
+	is := is.New(t)
+	metadata := map[string]interface{}{"one": "1", "two": "2"}
+	merged := stow.MergedMetadata(metadata, map[string]interface{}{
+		"two":   "zwei",
+		"one":   nil,
+		"three": "3",
+	})
+	is.Equal(merged, map[string]interface{}{"two": "zwei", "three": "3"})
+	is.Equal(metadata, map[string]interface{}{"one": "1", "two": "2"})
+	is.Equal(len(stow.MergedMetadata(nil, nil)), 0)
+}
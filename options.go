@@ -0,0 +1,233 @@
+package stow
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+)
+
+// sniffLen is the number of bytes considered by http.DetectContentType.
+const sniffLen = 512
+
+// ContentProperties describes how the contents of an Item are presented
+// when they are served over HTTP.
+type ContentProperties struct {
+	// ContentType is the MIME type of the contents, for example "text/plain".
+	ContentType string
+	// ContentEncoding is the encoding applied to the contents, for example
+	// "gzip".
+	ContentEncoding string
+	// ContentLanguage is the language of the contents, for example "en-US".
+	ContentLanguage string
+	// CacheControl holds caching directives, for example "max-age=3600".
+	CacheControl string
+	// ContentDisposition tells browsers how to present the contents, for
+	// example `attachment; filename="report.pdf"`.
+	ContentDisposition string
+}
+
+// IsZero gets whether none of the properties are set.
+func (p ContentProperties) IsZero() bool {
+	return p == ContentProperties{}
+}
+
+// StorageClass is the storage tier an Item is kept in.
+// Besides the provider-neutral classes below, the native name of a class
+// may be used, for example "STANDARD_IA" for S3 or "NEARLINE" for Google
+// Cloud Storage. Native names are passed to the implementation unchanged.
+type StorageClass string
+
+// The provider-neutral storage classes, from the fastest and most
+// expensive to store to the slowest and cheapest.
+const (
+	// StorageClassHot is for frequently accessed data.
+	StorageClassHot StorageClass = "hot"
+	// StorageClassCool is for infrequently accessed data, kept for a
+	// month or more.
+	StorageClassCool StorageClass = "cool"
+	// StorageClassCold is for rarely accessed data, kept for a quarter or
+	// more, that still needs to be read without delay.
+	StorageClassCold StorageClass = "cold"
+	// StorageClassArchive is for data that is rarely if ever read, and
+	// may need to be restored before reading.
+	StorageClassArchive StorageClass = "archive"
+	// StorageClassDeepArchive is for long term retention, where restoring
+	// can take hours.
+	StorageClassDeepArchive StorageClass = "deep_archive"
+)
+
+// isNeutral gets whether c is one of the provider-neutral classes.
+func (c StorageClass) isNeutral() bool {
+	switch c {
+	case StorageClassHot, StorageClassCool, StorageClassCold, StorageClassArchive, StorageClassDeepArchive:
+		return true
+	}
+	return false
+}
+
+// NativeStorageClass gets the name an implementation uses for the class,
+// given its names for the provider-neutral classes.
+// Native names are returned unchanged. If class is a provider-neutral class
+// without a native name, an error satisfying IsNotSupported is returned.
+// It is intended for use by implementations of OptionsPutter.
+func NativeStorageClass(class StorageClass, names map[StorageClass]string) (string, error) {
+	if !class.isNeutral() {
+		return string(class), nil
+	}
+	name, ok := names[class]
+	if !ok {
+		return "", NotSupported("storage class " + string(class))
+	}
+	return name, nil
+}
+
+// StorageClassFromNative gets the provider-neutral class for the name an
+// implementation uses, given its names for the provider-neutral classes.
+// Names without a provider-neutral class are returned unchanged.
+func StorageClassFromNative(name string, names map[StorageClass]string) StorageClass {
+	for class, native := range names {
+		if native == name {
+			return class
+		}
+	}
+	return StorageClass(name)
+}
+
+// ACL is a canned access control list applied to an Item.
+// Besides the provider-neutral ACLs below, the native name of a canned ACL
+// may be used, for example "authenticated-read" for S3.
+type ACL string
+
+const (
+	// ACLPrivate gives access to the owner only.
+	ACLPrivate ACL = "private"
+	// ACLPublicRead gives anyone read access.
+	ACLPublicRead ACL = "public-read"
+)
+
+// EncryptionMode is the way an Item is encrypted at rest.
+type EncryptionMode string
+
+const (
+	// EncryptionProvider encrypts with keys managed by the provider.
+	EncryptionProvider EncryptionMode = "provider"
+	// EncryptionKMS encrypts with the key Encryption.KMSKeyID held in the
+	// provider's key management service.
+	EncryptionKMS EncryptionMode = "kms"
+	// EncryptionCustomerKey encrypts with Encryption.CustomerKey, which
+	// the provider does not keep. The same key is needed to read the Item.
+	EncryptionCustomerKey EncryptionMode = "customer-key"
+)
+
+// Encryption describes how an Item is encrypted at rest.
+type Encryption struct {
+	// Mode is the way the Item is encrypted.
+	Mode EncryptionMode
+	// KMSKeyID identifies the key when Mode is EncryptionKMS, for example
+	// the ARN of an AWS KMS key, or the resource name of a Google Cloud KMS
+	// key.
+	KMSKeyID string
+	// CustomerKey is the 256 bit AES key when Mode is EncryptionCustomerKey.
+	CustomerKey []byte
+}
+
+// PutOptions holds optional settings for putting an Item.
+// The zero value puts the Item the same way Container.Put does.
+// Implementations return an error satisfying IsNotSupported for any option
+// they cannot honor.
+type PutOptions struct {
+	ContentProperties
+	// StorageClass is the storage tier to put the Item in. When empty the
+	// default of the container is used.
+	StorageClass StorageClass
+	// ACL is the canned access control list to apply to the Item.
+	ACL ACL
+	// Encryption describes how to encrypt the Item. When nil the default
+	// of the container is used.
+	Encryption *Encryption
+	// Tags are the tags to put the Item with. Values must be strings.
+	Tags map[string]interface{}
+	// Transfer tunes the upload of large Items.
+	Transfer TransferOptions
+}
+
+// IsZero gets whether no options are set. Transfer options are hints that
+// any implementation can ignore, so they are not considered.
+func (o *PutOptions) IsZero() bool {
+	return o == nil || (o.ContentProperties.IsZero() && o.StorageClass == "" && o.ACL == "" && o.Encryption == nil && len(o.Tags) == 0)
+}
+
+// OptionsPutter represents a Container that can put Items with PutOptions.
+type OptionsPutter interface {
+	// PutWithOptions creates a new Item with the specified name, and contents
+	// read from the reader, applying the options.
+	// If options.ContentType is empty, it is detected from the name and the
+	// contents.
+	PutWithOptions(name string, r io.Reader, size int64, metadata map[string]interface{}, options *PutOptions) (Item, error)
+}
+
+// ContentDescriber represents an Item that knows its ContentProperties.
+type ContentDescriber interface {
+	// ContentProperties gets the content properties the Item was put with.
+	ContentProperties() (ContentProperties, error)
+}
+
+// PutWithOptions creates a new Item in the container with the specified
+// options.
+// If the container does not support PutOptions, the Item is put with
+// Container.Put as long as no options are set, otherwise an error
+// satisfying IsNotSupported is returned.
+func PutWithOptions(container Container, name string, r io.Reader, size int64, metadata map[string]interface{}, options *PutOptions) (Item, error) {
+	if p, ok := container.(OptionsPutter); ok {
+		return p.PutWithOptions(name, r, size, metadata, options)
+	}
+	if !options.IsZero() {
+		return nil, NotSupported("put options")
+	}
+	return container.Put(name, r, size, metadata)
+}
+
+// DetectContentType gets the MIME type of an Item with the specified name
+// and contents.
+// The extension of the name is consulted first; failing that, the first
+// bytes of the contents are sniffed with http.DetectContentType.
+// The returned reader must be used in place of r, since sniffing may have
+// consumed some of it.
+func DetectContentType(name string, r io.Reader) (string, io.Reader, error) {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t, r, nil
+	}
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, err
+	}
+	buf = buf[:n]
+	contentType := http.DetectContentType(buf)
+	if s, ok := r.(io.Seeker); ok {
+		// keep the reader seekable, some implementations rely on it
+		if _, err := s.Seek(int64(-n), io.SeekCurrent); err == nil {
+			return contentType, r, nil
+		}
+	}
+	return contentType, io.MultiReader(bytes.NewReader(buf), r), nil
+}
+
+// PrepareContentProperties gets the properties to put an Item with, filling
+// in the ContentType with DetectContentType if it is not set.
+// The returned reader must be used in place of r.
+// It is intended for use by implementations of OptionsPutter.
+func PrepareContentProperties(name string, r io.Reader, options *PutOptions) (ContentProperties, io.Reader, error) {
+	var props ContentProperties
+	if options != nil {
+		props = options.ContentProperties
+	}
+	if props.ContentType != "" {
+		return props, r, nil
+	}
+	var err error
+	props.ContentType, r, err = DetectContentType(name, r)
+	return props, r, err
+}
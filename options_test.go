@@ -0,0 +1,125 @@
+package stow_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestDetectContentType(t *testing.T) {
+	is := is.New(t)
+
+	for name, tc := range map[string]struct {
+		name        string
+		content     string
+		contentType string
+	}{
+		"extension":      {"style.css", "body {}", "text/css; charset=utf-8"},
+		"sniffed html":   {"index", "<!DOCTYPE html><html></html>", "text/html; charset=utf-8"},
+		"sniffed binary": {"blob", "\x00\x01\x02\x03", "application/octet-stream"},
+		"empty":          {"empty", "", "text/plain; charset=utf-8"},
+		"long":           {"long", strings.Repeat("hello ", 200), "text/plain; charset=utf-8"},
+	} {
+		// hide the Seeker so the contents have to be read back from the
+		// returned reader
+		r := struct{ io.Reader }{strings.NewReader(tc.content)}
+		contentType, rr, err := stow.DetectContentType(tc.name, r)
+		is.NoErr(err)
+		is.Equal(contentType, tc.contentType)
+		b, err := ioutil.ReadAll(rr)
+		is.NoErr(err)
+		if string(b) != tc.content {
+			t.Errorf("%s: contents were not preserved", name)
+		}
+	}
+}
+
+func TestDetectContentTypeSeeker(t *testing.T) {
+	is := is.New(t)
+	r := bytes.NewReader([]byte("<html><body>hello</body></html>"))
+	contentType, rr, err := stow.DetectContentType("page", r)
+	is.NoErr(err)
+	is.Equal(contentType, "text/html; charset=utf-8")
+	// the original reader is rewound rather than wrapped
+	is.Equal(rr, r)
+	is.Equal(r.Len(), 31)
+}
+
+func TestPrepareContentProperties(t *testing.T) {
+	is := is.New(t)
+	props, _, err := stow.PrepareContentProperties("a.json", strings.NewReader("{}"), nil)
+	is.NoErr(err)
+	is.Equal(props.ContentType, "application/json")
+
+	options := &stow.PutOptions{
+		ContentProperties: stow.ContentProperties{
+			ContentType:  "text/x-custom",
+			CacheControl: "no-cache",
+		},
+	}
+	props, _, err = stow.PrepareContentProperties("a.json", strings.NewReader("{}"), options)
+	is.NoErr(err)
+	is.Equal(props, options.ContentProperties)
+}
+
+func TestPutWithOptionsNotSupported(t *testing.T) {
+	is := is.New(t)
+	c := newPagedContainer(styleStartAfter, 0)
+
+	item, err := stow.PutWithOptions(c, "plain", strings.NewReader(""), 0, nil, nil)
+	is.NoErr(err)
+	is.Equal(item.Name(), "plain")
+
+	_, err = stow.PutWithOptions(c, "plain", strings.NewReader(""), 0, nil, &stow.PutOptions{})
+	is.NoErr(err)
+
+	options := &stow.PutOptions{
+		ContentProperties: stow.ContentProperties{CacheControl: "no-cache"},
+	}
+	_, err = stow.PutWithOptions(c, "cached", strings.NewReader(""), 0, nil, options)
+	is.True(stow.IsNotSupported(err))
+}
+
+func TestNativeStorageClass(t *testing.T) {
+	is := is.New(t)
+	names := map[stow.StorageClass]string{
+		stow.StorageClassHot:  "STANDARD",
+		stow.StorageClassCool: "INFREQUENT",
+	}
+	name, err := stow.NativeStorageClass(stow.StorageClassCool, names)
+	is.NoErr(err)
+	is.Equal(name, "INFREQUENT")
+	name, err = stow.NativeStorageClass("", names)
+	is.NoErr(err)
+	is.Equal(name, "")
+	name, err = stow.NativeStorageClass("REDUCED_REDUNDANCY", names)
+	is.NoErr(err)
+	is.Equal(name, "REDUCED_REDUNDANCY")
+	_, err = stow.NativeStorageClass(stow.StorageClassArchive, names)
+	is.True(stow.IsNotSupported(err))
+}
+
+func TestPutOptionsIsZero(t *testing.T) {
+	is := is.New(t)
+	var options *stow.PutOptions
+	is.True(options.IsZero())
+	is.True((&stow.PutOptions{}).IsZero())
+	is.False((&stow.PutOptions{ACL: stow.ACLPrivate}).IsZero())
+	is.False((&stow.PutOptions{StorageClass: stow.StorageClassCool}).IsZero())
+	is.False((&stow.PutOptions{Encryption: &stow.Encryption{Mode: stow.EncryptionProvider}}).IsZero())
+}
+
+func TestStorageClassFromNative(t *testing.T) {
+	is := is.New(t)
+	names := map[stow.StorageClass]string{
+		stow.StorageClassHot:  "STANDARD",
+		stow.StorageClassCool: "INFREQUENT",
+	}
+	is.Equal(stow.StorageClassFromNative("INFREQUENT", names), stow.StorageClassCool)
+	is.Equal(stow.StorageClassFromNative("REDUCED_REDUNDANCY", names), stow.StorageClass("REDUCED_REDUNDANCY"))
+}
@@ -66,19 +66,42 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 
 // Put creates or updates a CloudStorage object within the given container.
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutWithOptions(name, r, size, metadata, nil)
+}
+
+// PutWithOptions creates or updates a CloudStorage object like Put, also
+// setting the content headers given in the options.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, metadata map[string]interface{}, options *stow.PutOptions) (stow.Item, error) {
 	mdPrepped, err := prepMetadata(metadata)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create or update Item, preparing metadata")
 	}
 
-	_, err = c.client.ObjectPut(c.id, name, r, false, "", "", nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to create or update Item")
+	if options != nil {
+		// storage policies and access are set on the container,
+		// encryption is up to the cluster, and objects have no tags.
+		switch {
+		case options.StorageClass != "":
+			return nil, stow.NotSupported("storage class")
+		case options.ACL != "":
+			return nil, stow.NotSupported("ACL")
+		case options.Encryption != nil:
+			return nil, stow.NotSupported("encryption")
+		case len(options.Tags) > 0:
+			return nil, stow.NotSupported("tags")
+		}
 	}
 
-	err = c.client.ObjectUpdate(c.id, name, mdPrepped)
+	content, r, err := stow.PrepareContentProperties(name, r, options)
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to update Item metadata")
+		return nil, errors.Wrap(err, "unable to create or update Item, detecting content type")
+	}
+	_, err = c.client.ObjectPut(c.id, name, r, false, "", content.ContentType, contentHeaders(content))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create or update Item")
+	}
+	if err := c.updateMetadata(name, content, mdPrepped); err != nil {
+		return nil, err
 	}
 
 	item := &item{
@@ -89,10 +112,27 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 		// not setting metadata here, the refined version isn't available
 		// unless an explicit getItem() is done. Possible to write a func to facilitate
 		// this.
+		content: &content,
 	}
 	return item, nil
 }
 
+// updateMetadata sets the metadata of a CloudStorage object. Updating the
+// metadata replaces the content headers too, so they are sent again along
+// with it.
+func (c *container) updateMetadata(name string, content stow.ContentProperties, metadata map[string]string) error {
+	for key, value := range contentHeaders(content) {
+		metadata[key] = value
+	}
+	if content.ContentType != "" {
+		metadata["Content-Type"] = content.ContentType
+	}
+	if err := c.client.ObjectUpdate(c.id, name, metadata); err != nil {
+		return errors.Wrap(err, "unable to update Item metadata")
+	}
+	return nil
+}
+
 // RemoveItem removes a CloudStorage object located within the given
 // container.
 func (c *container) RemoveItem(id string) error {
@@ -121,11 +161,40 @@ func (c *container) getItem(id string) (*item, error) {
 		size:         info.Bytes,
 		lastModified: info.LastModified,
 		metadata:     md,
+		content:      parseContentProperties(info, headers),
 	}
 
 	return item, nil
 }
 
+// contentHeaders gets the headers for the content properties other than
+// the type, which is passed to ObjectPut separately.
+func contentHeaders(content stow.ContentProperties) swift.Headers {
+	headers := swift.Headers{}
+	for key, value := range map[string]string{
+		"Content-Encoding":    content.ContentEncoding,
+		"Content-Language":    content.ContentLanguage,
+		"Cache-Control":       content.CacheControl,
+		"Content-Disposition": content.ContentDisposition,
+	} {
+		if value != "" {
+			headers[key] = value
+		}
+	}
+	return headers
+}
+
+// parseContentProperties gets the content properties of an object.
+func parseContentProperties(info swift.Object, headers swift.Headers) *stow.ContentProperties {
+	return &stow.ContentProperties{
+		ContentType:        info.ContentType,
+		ContentEncoding:    headers["Content-Encoding"],
+		ContentLanguage:    headers["Content-Language"],
+		CacheControl:       headers["Cache-Control"],
+		ContentDisposition: headers["Content-Disposition"],
+	}
+}
+
 // Keys are returned as all lowercase
 func parseMetadata(md swift.Headers) (map[string]interface{}, error) {
 	m := make(map[string]interface{}, len(md))
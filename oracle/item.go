@@ -1,6 +1,7 @@
 package oracle
 
 import (
+	"fmt"
 	"io"
 	"net/url"
 	"path"
@@ -21,11 +22,16 @@ type item struct {
 	url          url.URL
 	lastModified time.Time
 	metadata     map[string]interface{}
+	content      *stow.ContentProperties
 	infoOnce     sync.Once
 	infoErr      error
 }
 
-var _ stow.Item = (*item)(nil)
+var (
+	_ stow.Item             = (*item)(nil)
+	_ stow.ContentDescriber = (*item)(nil)
+	_ stow.ItemRanger       = (*item)(nil)
+)
 
 // ID returns a string value representing the Item, in this case it's the
 // name of the object.
@@ -64,6 +70,18 @@ func (i *item) Open() (io.ReadCloser, error) {
 	return res, err
 }
 
+// OpenRange opens the item for reading starting at byte start and ending
+// at byte end.
+func (i *item) OpenRange(start, end uint64) (io.ReadCloser, error) {
+	r, _, err := i.client.ObjectOpen(i.container.id, i.id, false, swift.Headers{
+		"Range": fmt.Sprintf("bytes=%d-%d", start, end),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
 type readSeekCloser interface {
 	io.ReadSeeker
 	io.Closer
@@ -115,13 +133,22 @@ func (i *item) Metadata() (map[string]interface{}, error) {
 	return i.metadata, nil
 }
 
+// ContentProperties returns the content headers of the object.
+func (i *item) ContentProperties() (stow.ContentProperties, error) {
+	err := i.ensureInfo()
+	if err != nil {
+		return stow.ContentProperties{}, err
+	}
+	return *i.content, nil
+}
+
 // ensureInfo checks the fields that may be empty when an item is PUT.
 // Verify if the fields are empty, get information on the item, fill in
 // the missing fields.
 func (i *item) ensureInfo() error {
 	// If lastModified is empty, so is hash. get info on the Item and
 	// update the necessary fields at the same time.
-	if i.lastModified.IsZero() || i.hash == "" || i.metadata == nil {
+	if i.lastModified.IsZero() || i.hash == "" || i.metadata == nil || i.content == nil {
 		i.infoOnce.Do(func() {
 			itemInfo, infoErr := i.getInfo()
 			if infoErr != nil {
@@ -140,12 +167,13 @@ func (i *item) ensureInfo() error {
 				i.infoErr = infoErr
 				return
 			}
+			i.content = itemInfo.content
 		})
 	}
 	return i.infoErr
 }
 
-func (i *item) getInfo() (stow.Item, error) {
+func (i *item) getInfo() (*item, error) {
 	itemInfo, err := i.container.getItem(i.ID())
 	if err != nil {
 		return nil, err
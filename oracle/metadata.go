@@ -0,0 +1,48 @@
+package oracle
+
+import (
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var _ stow.MetadataSetter = (*item)(nil)
+
+// SetMetadata replaces the metadata of the CloudStorage object, keeping
+// its content headers as Put does.
+func (i *item) SetMetadata(metadata map[string]interface{}) error {
+	mdPrepped, err := prepMetadata(metadata)
+	if err != nil {
+		return errors.Wrap(err, "unable to update Item metadata, preparing metadata")
+	}
+	current, err := i.container.getItem(i.id)
+	if err != nil {
+		return err
+	}
+	return i.setMetadata(*current.content, mdPrepped)
+}
+
+// MergeMetadata changes the given keys in the metadata of the CloudStorage
+// object, reading the current metadata first.
+func (i *item) MergeMetadata(changes map[string]interface{}) error {
+	current, err := i.container.getItem(i.id)
+	if err != nil {
+		return err
+	}
+	mdPrepped, err := prepMetadata(stow.MergedMetadata(current.metadata, changes))
+	if err != nil {
+		return errors.Wrap(err, "unable to update Item metadata, preparing metadata")
+	}
+	return i.setMetadata(*current.content, mdPrepped)
+}
+
+func (i *item) setMetadata(content stow.ContentProperties, metadata map[string]string) error {
+	if err := i.container.updateMetadata(i.id, content, metadata); err != nil {
+		return err
+	}
+	md, err := parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	i.metadata = md
+	return nil
+}
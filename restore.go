@@ -0,0 +1,87 @@
+package stow
+
+import (
+	"fmt"
+	"time"
+)
+
+// RestoreTier is how quickly an archived Item is restored. Faster tiers
+// cost more.
+type RestoreTier string
+
+const (
+	// RestoreExpedited restores within minutes, where the provider can.
+	RestoreExpedited RestoreTier = "expedited"
+	// RestoreStandard restores within hours.
+	RestoreStandard RestoreTier = "standard"
+	// RestoreBulk is the cheapest tier, taking up to a couple of days.
+	RestoreBulk RestoreTier = "bulk"
+)
+
+// RestoreStatus describes whether an Item is archived, and the progress of
+// its restore.
+type RestoreStatus struct {
+	// StorageClass is the storage class the Item is kept in.
+	StorageClass StorageClass
+	// Archived is whether the Item is in a storage class it cannot be read
+	// from until it is restored.
+	Archived bool
+	// Restoring is whether a restore is in progress.
+	Restoring bool
+	// Restored is whether a restored copy of an archived Item can be read.
+	Restored bool
+	// Expires is when the restored copy is removed, zero when there is
+	// none or it is kept for good.
+	Expires time.Time
+}
+
+// Readable gets whether the Item can be read.
+func (s RestoreStatus) Readable() bool {
+	return !s.Archived || s.Restored
+}
+
+// ItemRestorer represents an Item that can be archived, and has to be
+// restored before it can be read.
+type ItemRestorer interface {
+	// RestoreStatus gets whether the Item is archived and how its restore
+	// is going.
+	RestoreStatus() (RestoreStatus, error)
+	// Restore starts restoring the Item, keeping the restored copy for the
+	// number of days. Implementations that restore by moving the Item out
+	// of its archive tier keep it there for good, ignoring days. Restoring
+	// an Item that is already being restored is not an error.
+	Restore(tier RestoreTier, days int) error
+}
+
+// Restore starts restoring the Item if it is an ItemRestorer, and gives a
+// NotSupported error otherwise.
+func Restore(item Item, tier RestoreTier, days int) error {
+	r, ok := item.(ItemRestorer)
+	if !ok {
+		return NotSupported("restore")
+	}
+	return r.Restore(tier, days)
+}
+
+// ArchivedError is returned when opening an archived Item that has not
+// been restored.
+type ArchivedError struct {
+	// Name is the name of the Item.
+	Name string
+	// Status is the restore status of the Item.
+	Status RestoreStatus
+}
+
+func (e *ArchivedError) Error() string {
+	msg := fmt.Sprintf("%s is archived in storage class %s", e.Name, e.Status.StorageClass)
+	if e.Status.Restoring {
+		return msg + ", restore in progress"
+	}
+	return msg + ", it must be restored to be read"
+}
+
+// IsArchived gets whether the error is due to an Item being archived.
+func IsArchived(err error) bool {
+	_, ok := err.(*ArchivedError)
+	return ok
+}
@@ -0,0 +1,25 @@
+package stow_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestRestore(t *testing.T) {
+	is := is.New(t)
+
+	err := stow.Restore(&testItem{name: "item"}, stow.RestoreStandard, 1)
+	is.True(stow.IsNotSupported(err))
+
+	err = &stow.ArchivedError{Name: "item", Status: stow.RestoreStatus{StorageClass: stow.StorageClassArchive, Archived: true, Restoring: true}}
+	is.True(stow.IsArchived(err))
+	is.Equal(err.Error(), "item is archived in storage class archive, restore in progress")
+	is.False(stow.IsArchived(errors.New("archived")))
+
+	is.True(stow.RestoreStatus{StorageClass: stow.StorageClassHot}.Readable())
+	is.False(stow.RestoreStatus{Archived: true}.Readable())
+	is.True(stow.RestoreStatus{Archived: true, Restored: true}.Readable())
+}
@@ -0,0 +1,380 @@
+package stow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Checkpoint is the progress of a resumable upload, which is saved after
+// each part so that the upload can carry on after a failure. Downloads
+// keep their progress the same way.
+type Checkpoint struct {
+	// UploadID identifies the upload with the service. For downloads, it
+	// is the ETag of the Item.
+	UploadID string `json:"upload_id"`
+	// Size is the size of the whole upload.
+	Size int64 `json:"size"`
+	// PartSize is the size of all parts but the last.
+	PartSize int64 `json:"part_size"`
+	// Parts are the parts uploaded so far, in order.
+	Parts []CompletedPart `json:"parts"`
+}
+
+// CompletedPart is a part of a resumable upload the service has received.
+type CompletedPart struct {
+	// Number is the number of the part, starting with 1.
+	Number int `json:"number"`
+	// ETag is how the service identifies the part, such as the ETag of
+	// an S3 part or the ID of an Azure block.
+	ETag string `json:"etag"`
+	// Checksum is the hex encoded SHA-256 of the part, used to find out
+	// whether the source has changed since.
+	Checksum string `json:"checksum"`
+}
+
+// CheckpointStore keeps the checkpoints of resumable uploads.
+type CheckpointStore interface {
+	// Load gets the checkpoint saved with the key, or ErrNotFound.
+	Load(key string) (*Checkpoint, error)
+	// Save saves the checkpoint with the key, replacing any other.
+	Save(key string, checkpoint *Checkpoint) error
+	// Delete removes the checkpoint saved with the key, if any.
+	Delete(key string) error
+}
+
+// MultipartUploader is implemented by backends to upload an Item in
+// parts. ResumeUpload uses it to upload the parts that are missing.
+type MultipartUploader interface {
+	// Start starts a new upload and returns its ID.
+	Start() (string, error)
+	// Parts gets the ETags of the parts the service holds for the upload,
+	// by part number. The part size is the one the upload was started
+	// with, for services that keep bytes rather than parts. It returns
+	// ErrNotFound when the upload is gone.
+	Parts(uploadID string, partSize int64) (map[int]string, error)
+	// UploadPart uploads the part starting at offset and returns its ETag.
+	UploadPart(uploadID string, number int, offset int64, data []byte) (string, error)
+	// Complete assembles the parts into the Item.
+	Complete(uploadID string, parts []CompletedPart) (Item, error)
+	// Abort discards the upload and its parts.
+	Abort(uploadID string) error
+}
+
+// ResumableOptions are the options for resumable uploads.
+type ResumableOptions struct {
+	// Store keeps the checkpoints of the upload. It is required.
+	Store CheckpointStore
+	// PartSize is the size of the parts. Zero lets the implementation
+	// choose, which is also the case when it would break a limit of the
+	// service. Resumed uploads keep the size they were started with.
+	PartSize int64
+	// ContentProperties are the content headers of the Item.
+	ContentProperties ContentProperties
+}
+
+// ResumableUploader represents a Container that can put Items in parts,
+// resuming uploads that failed from the last saved checkpoint.
+type ResumableUploader interface {
+	// PutResumable puts the Item like Container.Put, reading it from r.
+	// Putting the same name with the same store resumes a failed upload,
+	// as long as the source has not changed.
+	PutResumable(name string, r io.ReaderAt, size int64, metadata map[string]interface{}, options *ResumableOptions) (Item, error)
+}
+
+// PutResumable puts the Item in parts if the Container is a
+// ResumableUploader, and gives a NotSupported error otherwise.
+func PutResumable(container Container, name string, r io.ReaderAt, size int64, metadata map[string]interface{}, options *ResumableOptions) (Item, error) {
+	u, ok := container.(ResumableUploader)
+	if !ok {
+		return nil, NotSupported("resumable upload")
+	}
+	return u.PutResumable(name, r, size, metadata, options)
+}
+
+// CheckpointKey gets the key the checkpoints of an upload are saved with.
+func CheckpointKey(container Container, name string) string {
+	return container.ID() + "/" + name
+}
+
+// errNoCheckpointStore is returned when resumable uploads have no store.
+var errNoCheckpointStore = errors.New("resumable upload needs a checkpoint store")
+
+// ResumeUpload uploads r in parts with u, resuming the upload saved in
+// the store with the key. Parts the service no longer holds are uploaded
+// again, and the upload starts over when its size differs or the
+// checksums show the source has changed. The checkpoint is saved after
+// each part and deleted once the upload is complete; a failed upload is
+// not aborted, so it can be resumed.
+func ResumeUpload(u MultipartUploader, store CheckpointStore, key string, r io.ReaderAt, size, partSize int64) (Item, error) {
+	if store == nil {
+		return nil, errNoCheckpointStore
+	}
+	if partSize <= 0 {
+		return nil, errors.New("part size must be positive")
+	}
+	checkpoint, err := store.Load(key)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	if checkpoint != nil {
+		ok, err := verifyCheckpoint(u, checkpoint, r, size)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			// the parts are of no use, failing to discard them is harmless
+			u.Abort(checkpoint.UploadID)
+			checkpoint = nil
+		}
+	}
+	if checkpoint == nil {
+		id, err := u.Start()
+		if err != nil {
+			return nil, err
+		}
+		checkpoint = &Checkpoint{UploadID: id, Size: size, PartSize: partSize}
+		if err := store.Save(key, checkpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	done := make(map[int]bool, len(checkpoint.Parts))
+	for _, part := range checkpoint.Parts {
+		done[part.Number] = true
+	}
+	var buf []byte
+	for n := 1; n <= partCount(checkpoint.Size, checkpoint.PartSize); n++ {
+		if done[n] {
+			continue
+		}
+		var checksum string
+		buf, checksum, err = readPart(r, checkpoint, n, buf)
+		if err != nil {
+			return nil, err
+		}
+		etag, err := u.UploadPart(checkpoint.UploadID, n, int64(n-1)*checkpoint.PartSize, buf)
+		if err != nil {
+			return nil, err
+		}
+		checkpoint.Parts = append(checkpoint.Parts, CompletedPart{Number: n, ETag: etag, Checksum: checksum})
+		sort.Slice(checkpoint.Parts, func(i, j int) bool {
+			return checkpoint.Parts[i].Number < checkpoint.Parts[j].Number
+		})
+		if err := store.Save(key, checkpoint); err != nil {
+			return nil, err
+		}
+	}
+	item, err := u.Complete(checkpoint.UploadID, checkpoint.Parts)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Delete(key); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// verifyCheckpoint keeps the parts of the checkpoint the service still
+// holds, and gets whether the upload can be resumed at all.
+func verifyCheckpoint(u MultipartUploader, checkpoint *Checkpoint, r io.ReaderAt, size int64) (bool, error) {
+	if checkpoint.Size != size || checkpoint.PartSize <= 0 {
+		return false, nil
+	}
+	held, err := u.Parts(checkpoint.UploadID, checkpoint.PartSize)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var parts []CompletedPart
+	var buf []byte
+	for _, part := range checkpoint.Parts {
+		var checksum string
+		buf, checksum, err = readPart(r, checkpoint, part.Number, buf)
+		if err != nil {
+			return false, err
+		}
+		if checksum != part.Checksum {
+			return false, nil
+		}
+		if held[part.Number] != part.ETag {
+			continue
+		}
+		parts = append(parts, part)
+	}
+	checkpoint.Parts = parts
+	return true, nil
+}
+
+// partCount gets the number of parts of an upload, which is at least one.
+func partCount(size, partSize int64) int {
+	n := int((size + partSize - 1) / partSize)
+	if n == 0 {
+		return 1
+	}
+	return n
+}
+
+// readPart reads part n of the upload into buf, growing it as needed,
+// and gets its checksum.
+func readPart(r io.ReaderAt, checkpoint *Checkpoint, n int, buf []byte) ([]byte, string, error) {
+	off := int64(n-1) * checkpoint.PartSize
+	length := checkpoint.Size - off
+	if length > checkpoint.PartSize {
+		length = checkpoint.PartSize
+	}
+	if int64(cap(buf)) < length {
+		buf = make([]byte, length)
+	}
+	buf = buf[:length]
+	if _, err := io.ReadFull(io.NewSectionReader(r, off, length), buf); err != nil {
+		return nil, "", fmt.Errorf("reading part %d: %v", n, err)
+	}
+	sum := sha256.Sum256(buf)
+	return buf, hex.EncodeToString(sum[:]), nil
+}
+
+// SeekerReaderAt gets an io.ReaderAt reading from rs, for uploading from
+// sources that can seek but cannot read at an offset. Reads are
+// serialized, and rs must not be used otherwise while it is in use.
+func SeekerReaderAt(rs io.ReadSeeker) io.ReaderAt {
+	return &seekerReaderAt{rs: rs}
+}
+
+type seekerReaderAt struct {
+	mu sync.Mutex
+	rs io.ReadSeeker
+}
+
+func (s *seekerReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.rs.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(s.rs, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// NewMemoryCheckpointStore gets a CheckpointStore keeping checkpoints in
+// memory, which resumes uploads retried by the same process.
+func NewMemoryCheckpointStore() CheckpointStore {
+	return &memoryCheckpointStore{checkpoints: map[string]Checkpoint{}}
+}
+
+type memoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string]Checkpoint
+}
+
+func (s *memoryCheckpointStore) Load(key string) (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	checkpoint, ok := s.checkpoints[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	checkpoint.Parts = append([]CompletedPart(nil), checkpoint.Parts...)
+	return &checkpoint, nil
+}
+
+func (s *memoryCheckpointStore) Save(key string, checkpoint *Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saved := *checkpoint
+	saved.Parts = append([]CompletedPart(nil), checkpoint.Parts...)
+	s.checkpoints[key] = saved
+	return nil
+}
+
+func (s *memoryCheckpointStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.checkpoints, key)
+	return nil
+}
+
+// NewFileCheckpointStore gets a CheckpointStore keeping checkpoints as
+// JSON files in dir, which is created when needed. Files are replaced
+// atomically, so a checkpoint survives the process dying while saving.
+func NewFileCheckpointStore(dir string) CheckpointStore {
+	return fileCheckpointStore{dir: dir}
+}
+
+type fileCheckpointStore struct {
+	dir string
+	// file is the only file of a store keeping a single checkpoint,
+	// whatever its key.
+	file string
+}
+
+// path gets the file of the key. Keys are hashed as they hold slashes
+// and may be longer than file names can be.
+func (s fileCheckpointStore) path(key string) string {
+	if s.file != "" {
+		return s.file
+	}
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (s fileCheckpointStore) Load(key string) (*Checkpoint, error) {
+	b, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(b, &checkpoint); err != nil {
+		return nil, fmt.Errorf("bad checkpoint %s: %v", s.path(key), err)
+	}
+	return &checkpoint, nil
+}
+
+func (s fileCheckpointStore) Save(key string, checkpoint *Checkpoint) error {
+	b, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.path(key))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, ".checkpoint-")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), s.path(key))
+}
+
+func (s fileCheckpointStore) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,195 @@
+package stow_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+// fakeUploader holds the parts of its uploads in memory.
+type fakeUploader struct {
+	uploads  map[string]map[int][]byte
+	started  int
+	aborted  []string
+	uploaded []int
+	// failPart makes UploadPart fail for the part with this number.
+	failPart int
+	result   []byte
+}
+
+func newFakeUploader() *fakeUploader {
+	return &fakeUploader{uploads: map[string]map[int][]byte{}}
+}
+
+func (u *fakeUploader) Start() (string, error) {
+	u.started++
+	id := fmt.Sprintf("upload-%d", u.started)
+	u.uploads[id] = map[int][]byte{}
+	return id, nil
+}
+
+func (u *fakeUploader) Parts(uploadID string, partSize int64) (map[int]string, error) {
+	parts, ok := u.uploads[uploadID]
+	if !ok {
+		return nil, stow.ErrNotFound
+	}
+	etags := map[int]string{}
+	for n, data := range parts {
+		etags[n] = etag(data)
+	}
+	return etags, nil
+}
+
+func (u *fakeUploader) UploadPart(uploadID string, number int, offset int64, data []byte) (string, error) {
+	if number == u.failPart {
+		return "", errors.New("connection reset")
+	}
+	u.uploaded = append(u.uploaded, number)
+	u.uploads[uploadID][number] = append([]byte(nil), data...)
+	return etag(data), nil
+}
+
+func (u *fakeUploader) Complete(uploadID string, parts []stow.CompletedPart) (stow.Item, error) {
+	var buf bytes.Buffer
+	for _, part := range parts {
+		data := u.uploads[uploadID][part.Number]
+		if etag(data) != part.ETag {
+			return nil, errors.New("bad part")
+		}
+		buf.Write(data)
+	}
+	u.result = buf.Bytes()
+	delete(u.uploads, uploadID)
+	return nil, nil
+}
+
+func (u *fakeUploader) Abort(uploadID string) error {
+	u.aborted = append(u.aborted, uploadID)
+	delete(u.uploads, uploadID)
+	return nil
+}
+
+func etag(data []byte) string {
+	return fmt.Sprintf("%d-%s", len(data), data)
+}
+
+func TestResumeUpload(t *testing.T) {
+	is := is.New(t)
+	source := strings.NewReader("abcdefghij")
+	store := stow.NewMemoryCheckpointStore()
+	u := newFakeUploader()
+
+	u.failPart = 3
+	_, err := stow.ResumeUpload(u, store, "key", source, 10, 3)
+	is.Err(err)
+	is.Equal(u.uploaded, []int{1, 2})
+	checkpoint, err := store.Load("key")
+	is.NoErr(err)
+	is.Equal(checkpoint.UploadID, "upload-1")
+	is.Equal(len(checkpoint.Parts), 2)
+
+	// the service lost part 2, which is uploaded again
+	delete(u.uploads["upload-1"], 2)
+	u.failPart = 0
+	u.uploaded = nil
+	// the part size of the checkpoint is kept
+	_, err = stow.ResumeUpload(u, store, "key", source, 10, 5)
+	is.NoErr(err)
+	is.Equal(u.uploaded, []int{2, 3, 4})
+	is.Equal(u.started, 1)
+	is.Equal(string(u.result), "abcdefghij")
+	_, err = store.Load("key")
+	is.Equal(err, stow.ErrNotFound)
+}
+
+func TestResumeUploadStartsOver(t *testing.T) {
+	is := is.New(t)
+	store := stow.NewMemoryCheckpointStore()
+	u := newFakeUploader()
+
+	u.failPart = 2
+	_, err := stow.ResumeUpload(u, store, "key", strings.NewReader("abcdefghij"), 10, 4)
+	is.Err(err)
+
+	// the source has changed since part 1 was uploaded
+	u.failPart = 0
+	u.uploaded = nil
+	_, err = stow.ResumeUpload(u, store, "key", strings.NewReader("ABCDefghij"), 10, 4)
+	is.NoErr(err)
+	is.Equal(u.aborted, []string{"upload-1"})
+	is.Equal(u.started, 2)
+	is.Equal(u.uploaded, []int{1, 2, 3})
+	is.Equal(string(u.result), "ABCDefghij")
+
+	// an upload the service no longer has starts over
+	u.failPart = 3
+	_, err = stow.ResumeUpload(u, store, "key", strings.NewReader("abcdefghij"), 10, 4)
+	is.Err(err)
+	delete(u.uploads, "upload-3")
+	u.failPart = 0
+	u.uploaded = nil
+	_, err = stow.ResumeUpload(u, store, "key", strings.NewReader("abcdefghij"), 10, 4)
+	is.NoErr(err)
+	is.Equal(u.uploaded, []int{1, 2, 3})
+	is.Equal(string(u.result), "abcdefghij")
+}
+
+func TestResumeUploadEmpty(t *testing.T) {
+	is := is.New(t)
+	u := newFakeUploader()
+	_, err := stow.ResumeUpload(u, stow.NewMemoryCheckpointStore(), "key", strings.NewReader(""), 0, 4)
+	is.NoErr(err)
+	is.Equal(u.uploaded, []int{1})
+
+	_, err = stow.ResumeUpload(u, nil, "key", strings.NewReader(""), 0, 4)
+	is.Err(err)
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	is := is.New(t)
+	dir, err := ioutil.TempDir("", "stow")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+
+	store := stow.NewFileCheckpointStore(dir + "/checkpoints")
+	_, err = store.Load("container/dir/item")
+	is.Equal(err, stow.ErrNotFound)
+	checkpoint := &stow.Checkpoint{
+		UploadID: "upload",
+		Size:     10,
+		PartSize: 4,
+		Parts:    []stow.CompletedPart{{Number: 1, ETag: "etag", Checksum: "sum"}},
+	}
+	is.NoErr(store.Save("container/dir/item", checkpoint))
+	loaded, err := store.Load("container/dir/item")
+	is.NoErr(err)
+	is.Equal(loaded, checkpoint)
+
+	is.NoErr(store.Delete("container/dir/item"))
+	is.NoErr(store.Delete("container/dir/item"))
+	_, err = store.Load("container/dir/item")
+	is.Equal(err, stow.ErrNotFound)
+	files, err := ioutil.ReadDir(dir + "/checkpoints")
+	is.NoErr(err)
+	is.Equal(len(files), 0)
+}
+
+func TestSeekerReaderAt(t *testing.T) {
+	is := is.New(t)
+	r := stow.SeekerReaderAt(strings.NewReader("abcdefghij"))
+	buf := make([]byte, 3)
+	n, err := r.ReadAt(buf, 4)
+	is.NoErr(err)
+	is.Equal(string(buf[:n]), "efg")
+	n, err = r.ReadAt(buf, 8)
+	is.Equal(err, io.EOF)
+	is.Equal(string(buf[:n]), "ij")
+}
@@ -0,0 +1,71 @@
+package stow
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrLocked is returned when an Item cannot be changed or removed because
+// it is under retention or legal hold.
+var ErrLocked = errors.New("locked by retention or legal hold")
+
+// RetentionMode describes how strictly an Item is retained.
+type RetentionMode string
+
+const (
+	// RetentionGovernance protects the Item, while still allowing users
+	// with special permissions to shorten or remove the retention.
+	RetentionGovernance RetentionMode = "governance"
+	// RetentionCompliance protects the Item from everyone until the
+	// retention expires. The retention can be extended but not shortened.
+	RetentionCompliance RetentionMode = "compliance"
+)
+
+// Retention describes the protection of an Item against being changed or
+// removed.
+type Retention struct {
+	// Mode is the retention mode, empty when the Item is not retained.
+	Mode RetentionMode
+	// RetainUntil is the time the retention expires, zero when the Item
+	// is not retained.
+	RetainUntil time.Time
+	// LegalHold is whether the Item is under legal hold, which protects it
+	// regardless of the retention until the hold is released.
+	LegalHold bool
+}
+
+// IsLocked gets whether the retention protects the Item at time t.
+func (r Retention) IsLocked(t time.Time) bool {
+	return r.LegalHold || t.Before(r.RetainUntil)
+}
+
+// ItemRetainer represents an Item that can be protected from being
+// changed or removed (write once, read many).
+type ItemRetainer interface {
+	// Retention gets the current retention of the Item.
+	Retention() (Retention, error)
+	// SetRetention protects the Item until retainUntil. A zero retainUntil
+	// removes the retention, which is only possible in governance mode.
+	SetRetention(mode RetentionMode, retainUntil time.Time) error
+	// SetLegalHold places or releases a legal hold on the Item.
+	SetLegalHold(hold bool) error
+}
+
+// DefaultRetention is the retention a Container applies to new Items.
+type DefaultRetention struct {
+	// Mode is the retention mode of new Items.
+	Mode RetentionMode
+	// Period is how long new Items are retained. Zero means new Items are
+	// not retained.
+	Period time.Duration
+}
+
+// ContainerRetainer represents a Container that can retain the Items put
+// in it by default.
+type ContainerRetainer interface {
+	// DefaultRetention gets the retention applied to new Items.
+	DefaultRetention() (DefaultRetention, error)
+	// SetDefaultRetention sets the retention applied to new Items. Some
+	// implementations cannot undo a compliance mode default retention.
+	SetDefaultRetention(retention DefaultRetention) error
+}
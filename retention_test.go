@@ -0,0 +1,19 @@
+package stow_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestRetentionIsLocked(t *testing.T) {
+	is := is.New(t)
+	now := time.Now()
+	is.False(stow.Retention{}.IsLocked(now))
+	is.True(stow.Retention{LegalHold: true}.IsLocked(now))
+	retention := stow.Retention{Mode: stow.RetentionGovernance, RetainUntil: now.Add(time.Minute)}
+	is.True(retention.IsLocked(now))
+	is.False(retention.IsLocked(now.Add(time.Minute)))
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/pkg/errors"
 )
 
+var _ stow.DelimiterLister = (*container)(nil)
+
 // Amazon S3 bucket contains a creation date and a name.
 type container struct {
 	// name is needed to retrieve items.
@@ -37,7 +39,7 @@ func (c *container) Name() string {
 // retrieved item only contains metadata about the object. This ensures that only the minimum amount of information is
 // transferred. Calling item.Open() will actually do a get request and open a stream to read from.
 func (c *container) Item(id string) (stow.Item, error) {
-	return c.getItem(id)
+	return c.getItem(id, nil)
 }
 
 // Items sends a request to retrieve a list of items that are prepended with
@@ -60,37 +62,71 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 	var containerItems []stow.Item
 
 	for _, object := range response.Contents {
-		if *object.StorageClass == "GLACIER" {
-			continue
-		}
-		etag := cleanEtag(*object.ETag) // Copy etag value and remove the strings.
-		object.ETag = &etag             // Assign the value to the object field representing the item.
-
-		newItem := &item{
-			container: c,
-			client:    c.client,
-			properties: properties{
-				ETag:         object.ETag,
-				Key:          object.Key,
-				LastModified: object.LastModified,
-				Owner:        object.Owner,
-				Size:         object.Size,
-				StorageClass: object.StorageClass,
-			},
-		}
-		containerItems = append(containerItems, newItem)
+		containerItems = append(containerItems, c.listedItem(object))
 	}
 
 	// Create a marker and determine if the list of items to retrieve is complete.
-	// If not, the last file is the input to the value of after which item to start
+	// If not, the last key listed is the input to the value of after which item
+	// to start.
 	startAfter := ""
-	if *response.IsTruncated {
-		startAfter = containerItems[len(containerItems)-1].Name()
+	if *response.IsTruncated && len(response.Contents) > 0 {
+		startAfter = *response.Contents[len(response.Contents)-1].Key
 	}
 
 	return containerItems, startAfter, nil
 }
 
+// ItemsDelimited lists the objects with the prefix that have no delimiter
+// after it, and the common prefixes of the others. The cursor is the
+// continuation token S3 gives, as pages end in either.
+func (c *container) ItemsDelimited(prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	params := &s3.ListObjectsV2Input{
+		Bucket:    aws.String(c.Name()),
+		Delimiter: aws.String(delimiter),
+		MaxKeys:   aws.Int64(int64(count)),
+		Prefix:    aws.String(prefix),
+	}
+	if cursor != stow.CursorStart {
+		params.ContinuationToken = aws.String(cursor)
+	}
+	response, err := c.client.ListObjectsV2(params)
+	if err != nil {
+		return nil, nil, "", errors.Wrap(err, "ItemsDelimited, listing objects")
+	}
+	var items []stow.Item
+	for _, object := range response.Contents {
+		items = append(items, c.listedItem(object))
+	}
+	var prefixes []string
+	for _, p := range response.CommonPrefixes {
+		prefixes = append(prefixes, aws.StringValue(p.Prefix))
+	}
+	next := ""
+	if aws.BoolValue(response.IsTruncated) {
+		next = aws.StringValue(response.NextContinuationToken)
+	}
+	return items, prefixes, next, nil
+}
+
+// listedItem makes the item for an object in a listing.
+func (c *container) listedItem(object *s3.Object) *item {
+	etag := cleanEtag(*object.ETag) // Copy etag value and remove the strings.
+	object.ETag = &etag             // Assign the value to the object field representing the item.
+
+	return &item{
+		container: c,
+		client:    c.client,
+		properties: properties{
+			ETag:         object.ETag,
+			Key:          object.Key,
+			LastModified: object.LastModified,
+			Owner:        object.Owner,
+			Size:         object.Size,
+			StorageClass: object.StorageClass,
+		},
+	}
+}
+
 func (c *container) RemoveItem(id string) error {
 	params := &s3.DeleteObjectInput{
 		Bucket: aws.String(c.Name()),
@@ -98,6 +134,9 @@ func (c *container) RemoveItem(id string) error {
 	}
 
 	_, err := c.client.DeleteObject(params)
+	if isObjectLocked(err) {
+		return stow.ErrLocked
+	}
 	if err != nil {
 		return errors.Wrapf(err, "RemoveItem, deleting object %+v", params)
 	}
@@ -109,27 +148,78 @@ func (c *container) RemoveItem(id string) error {
 // content, and the size of the file. Many more attributes can be given to the
 // file, including metadata. Keeping it simple for now.
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutWithOptions(name, r, size, metadata, nil)
+}
+
+// PutWithOptions uploads content to the container like Put, also setting
+// the content headers given in the options.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, metadata map[string]interface{}, options *stow.PutOptions) (stow.Item, error) {
 	// Convert map[string]interface{} to map[string]*string
 	mdPrepped, err := prepMetadata(metadata)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create or update item, preparing metadata")
 	}
 
-	uploader := s3manager.NewUploaderWithClient(c.client)
-	_, err = uploader.Upload(&s3manager.UploadInput{
-		Bucket:   aws.String(c.name), // Required
-		Key:      aws.String(name),   // Required
-		Body:     r,
-		Metadata: mdPrepped, // map[string]*string
+	content, r, err := stow.PrepareContentProperties(name, r, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create or update item, detecting content type")
+	}
+
+	input := &s3manager.UploadInput{
+		Bucket:             aws.String(c.name), // Required
+		Key:                aws.String(name),   // Required
+		Body:               r,
+		Metadata:           mdPrepped, // map[string]*string
+		ContentType:        optionalString(content.ContentType),
+		ContentEncoding:    optionalString(content.ContentEncoding),
+		ContentLanguage:    optionalString(content.ContentLanguage),
+		CacheControl:       optionalString(content.CacheControl),
+		ContentDisposition: optionalString(content.ContentDisposition),
+	}
+	var customerKey []byte
+	if options != nil {
+		storageClass, err := stow.NativeStorageClass(options.StorageClass, storageClasses)
+		if err != nil {
+			return nil, err
+		}
+		input.StorageClass = optionalString(storageClass)
+		input.ACL = optionalString(string(options.ACL))
+		if options.Encryption != nil {
+			if err := setEncryption(input, options.Encryption); err != nil {
+				return nil, err
+			}
+			customerKey = options.Encryption.CustomerKey
+		}
+		if input.Tagging, err = encodeTagging(options.Tags); err != nil {
+			return nil, errors.Wrap(err, "unable to create or update item, preparing tags")
+		}
+	}
+
+	uploader := s3manager.NewUploaderWithClient(c.client, func(u *s3manager.Uploader) {
+		if options == nil {
+			return
+		}
+		if options.Transfer.PartSize > 0 {
+			u.PartSize = partSize(size, options.Transfer.PartSize)
+		}
+		if options.Transfer.Concurrency > 0 {
+			u.Concurrency = options.Transfer.Concurrency
+		}
 	})
+	_, err = uploader.Upload(input)
 
 	if err != nil {
 		return nil, errors.Wrap(err, "PutObject, putting object")
 	}
-	i, err := c.client.HeadObject(&s3.HeadObjectInput{
+	head := &s3.HeadObjectInput{
 		Key:    aws.String(name),
 		Bucket: aws.String(c.name),
-	})
+	}
+	if customerKey != nil {
+		head.SSECustomerAlgorithm = aws.String(s3.ServerSideEncryptionAes256)
+		head.SSECustomerKey = aws.String(string(customerKey))
+	}
+	i, err := c.client.HeadObject(head)
 	var etag string
 	if i.ETag != nil && err == nil {
 		etag = cleanEtag(*i.ETag)
@@ -141,12 +231,14 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 	// s3.Object info: https://github.com/aws/aws-sdk-go/blob/master/service/s3/api.go#L7092-L7107
 	// Response: https://github.com/aws/aws-sdk-go/blob/master/service/s3/api.go#L8193-L8227
 	newItem := &item{
-		container: c,
-		client:    c.client,
+		container:   c,
+		client:      c.client,
+		customerKey: customerKey,
 		properties: properties{
-			ETag: &etag,
-			Key:  &name,
-			Size: &size,
+			ETag:    &etag,
+			Key:     &name,
+			Size:    &size,
+			Content: &content,
 			//LastModified *time.Time
 			//Owner        *s3.Owner
 			//StorageClass *string
@@ -161,6 +253,87 @@ func (c *container) Region() string {
 	return c.region
 }
 
+// allUsersURI is the grantee of ACL grants to anyone.
+const allUsersURI = "http://acs.amazonaws.com/groups/global/AllUsers"
+
+// Stat describes the bucket. The bucket is public if its policy or ACL
+// grants anyone access, and its tags are returned as metadata. S3 has no
+// default storage class, objects are put in STANDARD.
+func (c *container) Stat() (stow.ContainerInfo, error) {
+	info := stow.ContainerInfo{
+		Region:       c.region,
+		StorageClass: stow.StorageClassHot,
+		Versioning:   stow.VersioningDisabled,
+	}
+
+	// the creation date is only listed for the buckets of the account
+	buckets, err := c.client.ListBuckets(&s3.ListBucketsInput{})
+	if err != nil {
+		return info, errors.Wrap(err, "listing buckets")
+	}
+	for _, b := range buckets.Buckets {
+		if aws.StringValue(b.Name) == c.name {
+			info.Created = aws.TimeValue(b.CreationDate)
+		}
+	}
+
+	versioning, err := c.client.GetBucketVersioning(&s3.GetBucketVersioningInput{Bucket: aws.String(c.name)})
+	if err != nil {
+		return info, errors.Wrap(err, "getting bucket versioning")
+	}
+	switch aws.StringValue(versioning.Status) {
+	case s3.BucketVersioningStatusEnabled:
+		info.Versioning = stow.VersioningEnabled
+	case s3.BucketVersioningStatusSuspended:
+		info.Versioning = stow.VersioningSuspended
+	}
+
+	status, err := c.client.GetBucketPolicyStatus(&s3.GetBucketPolicyStatusInput{Bucket: aws.String(c.name)})
+	switch {
+	case err == nil:
+		info.PublicAccess = status.PolicyStatus != nil && aws.BoolValue(status.PolicyStatus.IsPublic)
+	case !hasErrorCode(err, "NoSuchBucketPolicy", "NotImplemented"):
+		return info, errors.Wrap(err, "getting bucket policy status")
+	}
+	if !info.PublicAccess {
+		acl, err := c.client.GetBucketAcl(&s3.GetBucketAclInput{Bucket: aws.String(c.name)})
+		if err != nil {
+			return info, errors.Wrap(err, "getting bucket ACL")
+		}
+		for _, g := range acl.Grants {
+			if g.Grantee != nil && aws.StringValue(g.Grantee.URI) == allUsersURI {
+				info.PublicAccess = true
+			}
+		}
+	}
+
+	tagging, err := c.client.GetBucketTagging(&s3.GetBucketTaggingInput{Bucket: aws.String(c.name)})
+	switch {
+	case err == nil:
+		info.Metadata = make(map[string]interface{}, len(tagging.TagSet))
+		for _, t := range tagging.TagSet {
+			info.Metadata[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+		}
+	case !hasErrorCode(err, "NoSuchTagSet", "NotImplemented"):
+		return info, errors.Wrap(err, "getting bucket tagging")
+	}
+	return info, nil
+}
+
+// hasErrorCode gets whether err is an AWS error with one of the codes.
+func hasErrorCode(err error, codes ...string) bool {
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+	for _, code := range codes {
+		if aerr.Code() == code {
+			return true
+		}
+	}
+	return false
+}
+
 // A request to retrieve a single item includes information that is more specific than
 // a PUT. Instead of doing a request within the PUT, make this method available so that the
 // request can be made by the field retrieval methods when necessary. This is the case for
@@ -168,11 +341,17 @@ func (c *container) Region() string {
 // done only once since the requested information is retained.
 // May be simpler to just stick it in PUT and and do a request every time, please vouch
 // for this if so.
-func (c *container) getItem(id string) (*item, error) {
+//
+// customerKey is the key the object was encrypted with, if any.
+func (c *container) getItem(id string, customerKey []byte) (*item, error) {
 	params := &s3.HeadObjectInput{
 		Bucket: aws.String(c.name),
 		Key:    aws.String(id),
 	}
+	if customerKey != nil {
+		params.SSECustomerAlgorithm = aws.String(s3.ServerSideEncryptionAes256)
+		params.SSECustomerKey = aws.String(string(customerKey))
+	}
 
 	res, err := c.client.HeadObject(params)
 	if err != nil {
@@ -190,8 +369,9 @@ func (c *container) getItem(id string) (*item, error) {
 	}
 
 	i := &item{
-		container: c,
-		client:    c.client,
+		container:   c,
+		client:      c.client,
+		customerKey: customerKey,
 		properties: properties{
 			ETag:         &etag,
 			Key:          &id,
@@ -199,7 +379,15 @@ func (c *container) getItem(id string) (*item, error) {
 			Owner:        nil, // not returned in the response.
 			Size:         res.ContentLength,
 			StorageClass: res.StorageClass,
+			Restore:      res.Restore,
 			Metadata:     md,
+			Content: &stow.ContentProperties{
+				ContentType:        aws.StringValue(res.ContentType),
+				ContentEncoding:    aws.StringValue(res.ContentEncoding),
+				ContentLanguage:    aws.StringValue(res.ContentLanguage),
+				CacheControl:       aws.StringValue(res.CacheControl),
+				ContentDisposition: aws.StringValue(res.ContentDisposition),
+			},
 		},
 	}
 
@@ -242,6 +430,46 @@ func cleanEtag(etag string) string {
 	return etag
 }
 
+// storageClasses are the names S3 uses for the provider-neutral storage
+// classes.
+var storageClasses = map[stow.StorageClass]string{
+	stow.StorageClassHot:         s3.StorageClassStandard,
+	stow.StorageClassCool:        s3.StorageClassStandardIa,
+	stow.StorageClassCold:        "GLACIER_IR",
+	stow.StorageClassArchive:     s3.StorageClassGlacier,
+	stow.StorageClassDeepArchive: s3.StorageClassDeepArchive,
+}
+
+// setEncryption sets the server-side encryption fields of the input.
+func setEncryption(input *s3manager.UploadInput, encryption *stow.Encryption) error {
+	switch encryption.Mode {
+	case stow.EncryptionProvider:
+		input.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAes256)
+	case stow.EncryptionKMS:
+		// without a key ID, S3 uses the AWS managed key
+		input.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAwsKms)
+		input.SSEKMSKeyId = optionalString(encryption.KMSKeyID)
+	case stow.EncryptionCustomerKey:
+		if len(encryption.CustomerKey) != 32 {
+			return errors.New("customer key must be 256 bits")
+		}
+		input.SSECustomerAlgorithm = aws.String(s3.ServerSideEncryptionAes256)
+		input.SSECustomerKey = aws.String(string(encryption.CustomerKey))
+	default:
+		return stow.NotSupported("encryption mode " + string(encryption.Mode))
+	}
+	return nil
+}
+
+// optionalString gets a pointer to s, or nil if s is empty so the field is
+// left out of the request.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
+
 // prepMetadata parses a raw map into the native type required by S3 to set metadata (map[string]*string).
 // TODO: validation for key values. This function also assumes that the value of a key value pair is a string.
 func prepMetadata(md map[string]interface{}) (map[string]*string, error) {
@@ -0,0 +1,61 @@
+package s3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestContainerStat(t *testing.T) {
+	is := is.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		_, versioning := query["versioning"]
+		_, policyStatus := query["policyStatus"]
+		_, acl := query["acl"]
+		_, tagging := query["tagging"]
+		switch {
+		case r.URL.Path == "/":
+			w.Write([]byte(`<ListAllMyBucketsResult><Buckets>
+<Bucket><Name>other</Name><CreationDate>2019-01-01T00:00:00Z</CreationDate></Bucket>
+<Bucket><Name>bucket</Name><CreationDate>2020-02-03T04:05:06Z</CreationDate></Bucket>
+</Buckets></ListAllMyBucketsResult>`))
+		case versioning:
+			w.Write([]byte(`<VersioningConfiguration><Status>Suspended</Status></VersioningConfiguration>`))
+		case policyStatus:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchBucketPolicy</Code></Error>`))
+		case acl:
+			w.Write([]byte(`<AccessControlPolicy><AccessControlList>
+<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>http://acs.amazonaws.com/groups/global/AllUsers</URI></Grantee><Permission>READ</Permission></Grant>
+</AccessControlList></AccessControlPolicy>`))
+		case tagging:
+			w.Write([]byte(`<Tagging><TagSet><Tag><Key>team</Key><Value>media</Value></Tag></TagSet></Tagging>`))
+		}
+	}))
+	defer server.Close()
+
+	location, err := stow.Dial(Kind, stow.ConfigMap{
+		ConfigAccessKeyID: "access-key",
+		ConfigSecretKey:   "secret-key",
+		ConfigRegion:      "eu-west-1",
+		ConfigEndpoint:    server.URL,
+	})
+	is.NoErr(err)
+	container, err := location.Container("bucket")
+	is.NoErr(err)
+
+	info, err := stow.StatContainer(container)
+	is.NoErr(err)
+	is.True(info.Created.Equal(time.Date(2020, 2, 3, 4, 5, 6, 0, time.UTC)))
+	is.Equal(info.Region, "eu-west-1")
+	is.Equal(info.StorageClass, stow.StorageClassHot)
+	is.Equal(info.Versioning, stow.VersioningSuspended)
+	is.True(info.PublicAccess)
+	is.Equal(info.Metadata, map[string]interface{}{"team": "media"})
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/pkg/errors"
 )
 
+var _ stow.StorageClassDescriber = (*item)(nil)
+
 // The item struct contains an id (also the name of the file/S3 Object/Item),
 // a container which it belongs to (s3 Bucket), a client, and a URL. The last
 // field, properties, contains information about the item, including the ETag,
@@ -33,6 +35,13 @@ type item struct {
 	tags       map[string]interface{}
 	tagsOnce   sync.Once
 	tagsErr    error
+
+	contentOnce sync.Once
+	contentErr  error
+
+	// customerKey is the key the object was encrypted with when it was
+	// put with a customer provided key. S3 needs it to read the object.
+	customerKey []byte
 }
 
 type properties struct {
@@ -43,6 +52,12 @@ type properties struct {
 	Size         *int64     `type:"integer"`
 	StorageClass *string    `type:"string" enum:"ObjectStorageClass"`
 	Metadata     map[string]interface{}
+	// Restore is the x-amz-restore header, only known once the object
+	// has been fetched.
+	Restore *string
+	// Content is nil until the content properties are known, as they are
+	// not included when listing objects.
+	Content *stow.ContentProperties
 }
 
 // ID returns a string value that represents the name of a file.
@@ -87,10 +102,11 @@ func (i *item) Open() (io.ReadCloser, error) {
 		Bucket: aws.String(i.container.Name()),
 		Key:    aws.String(i.ID()),
 	}
+	i.setCustomerKey(params)
 
 	response, err := i.client.GetObject(params)
 	if err != nil {
-		return nil, errors.Wrap(err, "Open, getting the object")
+		return nil, i.openError(err)
 	}
 	return response.Body, nil
 }
@@ -150,8 +166,27 @@ func (i *item) ensureInfo() error {
 	return i.infoErr
 }
 
+// ContentProperties returns the content headers the object was uploaded
+// with.
+func (i *item) ContentProperties() (stow.ContentProperties, error) {
+	if i.properties.Content == nil {
+		i.contentOnce.Do(func() {
+			itemInfo, err := i.container.getItem(i.ID(), i.customerKey)
+			if err != nil {
+				i.contentErr = err
+				return
+			}
+			i.properties.Content = itemInfo.properties.Content
+		})
+		if i.contentErr != nil {
+			return stow.ContentProperties{}, errors.Wrap(i.contentErr, "retrieving content properties")
+		}
+	}
+	return *i.properties.Content, nil
+}
+
 func (i *item) getInfo() (stow.Item, error) {
-	itemInfo, err := i.container.getItem(i.ID())
+	itemInfo, err := i.container.getItem(i.ID(), i.customerKey)
 	if err != nil {
 		return nil, err
 	}
@@ -193,10 +228,32 @@ func (i *item) OpenRange(start, end uint64) (io.ReadCloser, error) {
 		Key:    aws.String(i.ID()),
 		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
 	}
+	i.setCustomerKey(params)
 
 	response, err := i.client.GetObject(params)
 	if err != nil {
-		return nil, errors.Wrap(err, "Open, getting the object")
+		return nil, i.openError(err)
 	}
 	return response.Body, nil
 }
+
+// setCustomerKey adds the customer provided key to a request to read the
+// object, if it was put with one.
+func (i *item) setCustomerKey(params *s3.GetObjectInput) {
+	if i.customerKey == nil {
+		return
+	}
+	params.SSECustomerAlgorithm = aws.String(s3.ServerSideEncryptionAes256)
+	params.SSECustomerKey = aws.String(string(i.customerKey))
+}
+
+// StorageClass gets the storage class of the object, which listings and
+// HEAD requests give.
+func (i *item) StorageClass() (stow.StorageClass, error) {
+	// S3 leaves out the class of standard objects
+	class := aws.StringValue(i.properties.StorageClass)
+	if class == "" {
+		class = s3.StorageClassStandard
+	}
+	return stow.StorageClassFromNative(class, storageClasses), nil
+}
@@ -0,0 +1,154 @@
+package s3
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var _ stow.LifecycleManager = (*container)(nil)
+
+// LifecycleRules gets the enabled lifecycle rules of the bucket. S3 rules
+// with several actions give a stow.LifecycleRule for each action, sharing
+// the ID. Rules filtering on tags are left out.
+func (c *container) LifecycleRules() ([]stow.LifecycleRule, error) {
+	res, err := c.client.GetBucketLifecycleConfiguration(&s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(c.name),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NoSuchLifecycleConfiguration" {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "getting bucket lifecycle configuration")
+	}
+	var rules []stow.LifecycleRule
+	for _, r := range res.Rules {
+		if aws.StringValue(r.Status) != s3.ExpirationStatusEnabled {
+			continue
+		}
+		prefix, ok := lifecycleRulePrefix(r)
+		if !ok {
+			continue
+		}
+		rule := stow.LifecycleRule{
+			ID:     aws.StringValue(r.ID),
+			Prefix: prefix,
+		}
+		if r.Expiration != nil && r.Expiration.Days != nil {
+			rule.Action = stow.LifecycleDelete
+			rule.Age = days(*r.Expiration.Days)
+			rules = append(rules, rule)
+		}
+		for _, t := range r.Transitions {
+			if t.Days == nil {
+				continue
+			}
+			rule := rule
+			rule.Action = stow.LifecycleTransition
+			rule.Age = days(*t.Days)
+			rule.StorageClass = stow.StorageClassFromNative(aws.StringValue(t.StorageClass), storageClasses)
+			rules = append(rules, rule)
+		}
+		if r.NoncurrentVersionExpiration != nil {
+			rule.Action = stow.LifecycleDeleteNoncurrent
+			rule.Age = days(aws.Int64Value(r.NoncurrentVersionExpiration.NoncurrentDays))
+			rules = append(rules, rule)
+		}
+		if r.AbortIncompleteMultipartUpload != nil {
+			rule.Action = stow.LifecycleAbortIncompleteUploads
+			rule.Age = days(aws.Int64Value(r.AbortIncompleteMultipartUpload.DaysAfterInitiation))
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// SetLifecycleRules replaces the lifecycle configuration of the bucket.
+// Rules sharing an ID are combined into a single S3 rule, so they must
+// share the prefix too.
+func (c *container) SetLifecycleRules(rules []stow.LifecycleRule) error {
+	if len(rules) == 0 {
+		_, err := c.client.DeleteBucketLifecycle(&s3.DeleteBucketLifecycleInput{
+			Bucket: aws.String(c.name),
+		})
+		if err != nil {
+			return errors.Wrap(err, "deleting bucket lifecycle configuration")
+		}
+		return nil
+	}
+	config := &s3.BucketLifecycleConfiguration{}
+	byID := map[string]*s3.LifecycleRule{}
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+		id := rule.ID
+		if id == "" {
+			id = fmt.Sprintf("rule-%d", i+1)
+		}
+		r, ok := byID[id]
+		if !ok {
+			r = &s3.LifecycleRule{
+				ID:     aws.String(id),
+				Filter: &s3.LifecycleRuleFilter{Prefix: aws.String(rule.Prefix)},
+				Status: aws.String(s3.ExpirationStatusEnabled),
+			}
+			byID[id] = r
+			config.Rules = append(config.Rules, r)
+		}
+		if aws.StringValue(r.Filter.Prefix) != rule.Prefix {
+			return errors.Errorf("lifecycle rules with ID %q have different prefixes", id)
+		}
+		age := aws.Int64(int64(rule.AgeDays()))
+		switch rule.Action {
+		case stow.LifecycleDelete:
+			if r.Expiration != nil {
+				return errors.Errorf("lifecycle rule %q has more than one delete action", id)
+			}
+			r.Expiration = &s3.LifecycleExpiration{Days: age}
+		case stow.LifecycleTransition:
+			class, err := stow.NativeStorageClass(rule.StorageClass, storageClasses)
+			if err != nil {
+				return err
+			}
+			r.Transitions = append(r.Transitions, &s3.Transition{Days: age, StorageClass: aws.String(class)})
+		case stow.LifecycleDeleteNoncurrent:
+			if r.NoncurrentVersionExpiration != nil {
+				return errors.Errorf("lifecycle rule %q has more than one noncurrent delete action", id)
+			}
+			r.NoncurrentVersionExpiration = &s3.NoncurrentVersionExpiration{NoncurrentDays: age}
+		case stow.LifecycleAbortIncompleteUploads:
+			if r.AbortIncompleteMultipartUpload != nil {
+				return errors.Errorf("lifecycle rule %q has more than one abort action", id)
+			}
+			r.AbortIncompleteMultipartUpload = &s3.AbortIncompleteMultipartUpload{DaysAfterInitiation: age}
+		}
+	}
+	_, err := c.client.PutBucketLifecycleConfiguration(&s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(c.name),
+		LifecycleConfiguration: config,
+	})
+	if err != nil {
+		return errors.Wrap(err, "setting bucket lifecycle configuration")
+	}
+	return nil
+}
+
+// lifecycleRulePrefix gets the prefix a rule is filtered on, and whether
+// the filter is on the prefix alone.
+func lifecycleRulePrefix(r *s3.LifecycleRule) (string, bool) {
+	f := r.Filter
+	switch {
+	case f == nil:
+		return aws.StringValue(r.Prefix), true
+	case f.Tag != nil:
+		return "", false
+	case f.And != nil:
+		return aws.StringValue(f.And.Prefix), len(f.And.Tags) == 0
+	}
+	return aws.StringValue(f.Prefix), true
+}
@@ -0,0 +1,88 @@
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestLifecycleRules(t *testing.T) {
+	is := is.New(t)
+
+	var method, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		switch r.Method {
+		case http.MethodPut:
+			b, _ := ioutil.ReadAll(r.Body)
+			body = string(b)
+		case http.MethodGet:
+			w.Write([]byte(`<LifecycleConfiguration>
+<Rule><ID>logs</ID><Filter><Prefix>logs/</Prefix></Filter><Status>Enabled</Status>
+<Transition><Days>30</Days><StorageClass>STANDARD_IA</StorageClass></Transition>
+<Transition><Days>90</Days><StorageClass>GLACIER</StorageClass></Transition>
+<Expiration><Days>365</Days></Expiration>
+<NoncurrentVersionExpiration><NoncurrentDays>7</NoncurrentDays></NoncurrentVersionExpiration>
+</Rule>
+<Rule><ID>tagged</ID><Filter><Tag><Key>k</Key><Value>v</Value></Tag></Filter><Status>Enabled</Status><Expiration><Days>1</Days></Expiration></Rule>
+<Rule><ID>disabled</ID><Filter><Prefix></Prefix></Filter><Status>Disabled</Status><Expiration><Days>1</Days></Expiration></Rule>
+<Rule><ID>uploads</ID><Prefix></Prefix><Status>Enabled</Status><AbortIncompleteMultipartUpload><DaysAfterInitiation>2</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule>
+</LifecycleConfiguration>`))
+		}
+	}))
+	defer server.Close()
+
+	location, err := stow.Dial(Kind, stow.ConfigMap{
+		ConfigAccessKeyID: "access-key",
+		ConfigSecretKey:   "secret-key",
+		ConfigRegion:      "do-not-care",
+		ConfigEndpoint:    server.URL,
+	})
+	is.NoErr(err)
+	container, err := location.Container("bucket")
+	is.NoErr(err)
+	manager := container.(stow.LifecycleManager)
+
+	day := 24 * time.Hour
+	expected := []stow.LifecycleRule{
+		{ID: "logs", Prefix: "logs/", Action: stow.LifecycleDelete, Age: 365 * day},
+		{ID: "logs", Prefix: "logs/", Action: stow.LifecycleTransition, Age: 30 * day, StorageClass: stow.StorageClassCool},
+		{ID: "logs", Prefix: "logs/", Action: stow.LifecycleTransition, Age: 90 * day, StorageClass: stow.StorageClassArchive},
+		{ID: "logs", Prefix: "logs/", Action: stow.LifecycleDeleteNoncurrent, Age: 7 * day},
+		{ID: "uploads", Action: stow.LifecycleAbortIncompleteUploads, Age: 2 * day},
+	}
+	rules, err := manager.LifecycleRules()
+	is.NoErr(err)
+	is.Equal(rules, expected)
+
+	// the rules sharing an ID are put back as one rule
+	is.NoErr(manager.SetLifecycleRules(rules))
+	is.Equal(method, http.MethodPut)
+	is.Equal(strings.Count(body, "<Rule>"), 2)
+	is.True(strings.Contains(body, "<ID>logs</ID>"))
+	// the SDK marshals the fields of elements in no particular order
+	is.True(strings.Contains(body, "<Days>90</Days>"))
+	is.True(strings.Contains(body, "<StorageClass>GLACIER</StorageClass>"))
+	is.True(strings.Contains(body, "<NoncurrentVersionExpiration><NoncurrentDays>7</NoncurrentDays></NoncurrentVersionExpiration>"))
+	is.True(strings.Contains(body, "<AbortIncompleteMultipartUpload><DaysAfterInitiation>2</DaysAfterInitiation></AbortIncompleteMultipartUpload>"))
+
+	// IDs are generated when missing
+	is.NoErr(manager.SetLifecycleRules([]stow.LifecycleRule{{Action: stow.LifecycleDelete, Age: time.Hour}}))
+	is.True(strings.Contains(body, "<ID>rule-1</ID>"))
+	is.True(strings.Contains(body, "<Expiration><Days>1</Days></Expiration>"))
+
+	err = manager.SetLifecycleRules([]stow.LifecycleRule{
+		{ID: "a", Prefix: "one/", Action: stow.LifecycleDelete},
+		{ID: "a", Prefix: "two/", Action: stow.LifecycleDeleteNoncurrent},
+	})
+	is.Err(err)
+
+	is.NoErr(manager.SetLifecycleRules(nil))
+	is.Equal(method, http.MethodDelete)
+}
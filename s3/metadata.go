@@ -0,0 +1,225 @@
+package s3
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var _ stow.MetadataSetter = (*item)(nil)
+
+// maxCopyObjectSize is the size of the largest object CopyObject copies.
+// Larger objects are copied in parts.
+const maxCopyObjectSize = 5 << 30
+
+// copyPartSize is the size of the parts of objects copied in parts.
+const copyPartSize = 512 << 20
+
+// SetMetadata replaces the metadata of the object by copying the object
+// onto itself, in parts when it is larger than 5 GiB. The content headers,
+// storage class and encryption are kept, but the ACL of the object is
+// reset to the default of the bucket.
+func (i *item) SetMetadata(metadata map[string]interface{}) error {
+	mdPrepped, err := prepMetadata(metadata)
+	if err != nil {
+		return errors.Wrap(err, "unable to update item metadata, preparing metadata")
+	}
+	head, err := i.head()
+	if err != nil {
+		return err
+	}
+	return i.copyWithMetadata(head, mdPrepped)
+}
+
+// MergeMetadata changes the given keys in the metadata of the object, the
+// same way SetMetadata replaces it.
+func (i *item) MergeMetadata(changes map[string]interface{}) error {
+	head, err := i.head()
+	if err != nil {
+		return err
+	}
+	current, err := parseMetadata(head.Metadata)
+	if err != nil {
+		return err
+	}
+	mdPrepped, err := prepMetadata(stow.MergedMetadata(current, changes))
+	if err != nil {
+		return errors.Wrap(err, "unable to update item metadata, preparing metadata")
+	}
+	return i.copyWithMetadata(head, mdPrepped)
+}
+
+func (i *item) head() (*s3.HeadObjectOutput, error) {
+	params := &s3.HeadObjectInput{
+		Bucket: aws.String(i.container.name),
+		Key:    aws.String(i.ID()),
+	}
+	if i.customerKey != nil {
+		params.SSECustomerAlgorithm = aws.String(s3.ServerSideEncryptionAes256)
+		params.SSECustomerKey = aws.String(string(i.customerKey))
+	}
+	head, err := i.client.HeadObject(params)
+	if err != nil {
+		if hasErrorCode(err, "NotFound", s3.ErrCodeNoSuchKey) {
+			return nil, stow.ErrNotFound
+		}
+		return nil, errors.Wrap(err, "HeadObject")
+	}
+	return head, nil
+}
+
+// copySource gets the source of copies of the object.
+func (i *item) copySource() string {
+	source := url.URL{Path: i.container.name + "/" + i.ID()}
+	return source.EscapedPath()
+}
+
+// copyWithMetadata copies the object described by head onto itself with
+// the metadata. The copy fails if the object changed since head was
+// fetched.
+func (i *item) copyWithMetadata(head *s3.HeadObjectOutput, metadata map[string]*string) error {
+	if aws.Int64Value(head.ContentLength) > maxCopyObjectSize {
+		return i.copyPartsWithMetadata(head, metadata)
+	}
+	params := &s3.CopyObjectInput{
+		Bucket:                  aws.String(i.container.name),
+		Key:                     aws.String(i.ID()),
+		CopySource:              aws.String(i.copySource()),
+		CopySourceIfMatch:       head.ETag,
+		MetadataDirective:       aws.String(s3.MetadataDirectiveReplace),
+		Metadata:                metadata,
+		ContentType:             head.ContentType,
+		ContentEncoding:         head.ContentEncoding,
+		ContentLanguage:         head.ContentLanguage,
+		CacheControl:            head.CacheControl,
+		ContentDisposition:      head.ContentDisposition,
+		WebsiteRedirectLocation: head.WebsiteRedirectLocation,
+		StorageClass:            head.StorageClass,
+	}
+	if head.Expires != nil {
+		if expires, err := http.ParseTime(*head.Expires); err == nil {
+			params.Expires = &expires
+		}
+	}
+	if i.customerKey != nil {
+		params.SSECustomerAlgorithm = aws.String(s3.ServerSideEncryptionAes256)
+		params.SSECustomerKey = aws.String(string(i.customerKey))
+		params.CopySourceSSECustomerAlgorithm = params.SSECustomerAlgorithm
+		params.CopySourceSSECustomerKey = params.SSECustomerKey
+	} else {
+		params.ServerSideEncryption = head.ServerSideEncryption
+		params.SSEKMSKeyId = head.SSEKMSKeyId
+	}
+	res, err := i.client.CopyObject(params)
+	if err != nil {
+		return errors.Wrap(err, "CopyObject, updating metadata")
+	}
+	if i.properties.Metadata, err = parseMetadata(metadata); err != nil {
+		return err
+	}
+	if res.CopyObjectResult != nil {
+		etag := cleanEtag(aws.StringValue(res.CopyObjectResult.ETag))
+		i.properties.ETag = &etag
+		i.properties.LastModified = res.CopyObjectResult.LastModified
+	}
+	return nil
+}
+
+// copyPartsWithMetadata copies the object described by head onto itself
+// with the metadata like copyWithMetadata, with a multipart upload whose
+// parts are copied from ranges of the object. The upload is aborted when a
+// part fails, leaving the object as it was.
+func (i *item) copyPartsWithMetadata(head *s3.HeadObjectOutput, metadata map[string]*string) error {
+	params := &s3.CreateMultipartUploadInput{
+		Bucket:                  aws.String(i.container.name),
+		Key:                     aws.String(i.ID()),
+		Metadata:                metadata,
+		ContentType:             head.ContentType,
+		ContentEncoding:         head.ContentEncoding,
+		ContentLanguage:         head.ContentLanguage,
+		CacheControl:            head.CacheControl,
+		ContentDisposition:      head.ContentDisposition,
+		WebsiteRedirectLocation: head.WebsiteRedirectLocation,
+		StorageClass:            head.StorageClass,
+	}
+	if head.Expires != nil {
+		if expires, err := http.ParseTime(*head.Expires); err == nil {
+			params.Expires = &expires
+		}
+	}
+	if i.customerKey != nil {
+		params.SSECustomerAlgorithm = aws.String(s3.ServerSideEncryptionAes256)
+		params.SSECustomerKey = aws.String(string(i.customerKey))
+	} else {
+		params.ServerSideEncryption = head.ServerSideEncryption
+		params.SSEKMSKeyId = head.SSEKMSKeyId
+	}
+	upload, err := i.client.CreateMultipartUpload(params)
+	if err != nil {
+		return errors.Wrap(err, "CreateMultipartUpload, updating metadata")
+	}
+	abort := func(err error) error {
+		i.client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+			Bucket:   params.Bucket,
+			Key:      params.Key,
+			UploadId: upload.UploadId,
+		})
+		return err
+	}
+
+	size := aws.Int64Value(head.ContentLength)
+	part := partSize(size, copyPartSize)
+	var parts []*s3.CompletedPart
+	for number, start := int64(1), int64(0); start < size; number, start = number+1, start+part {
+		end := start + part - 1
+		if end >= size {
+			end = size - 1
+		}
+		partParams := &s3.UploadPartCopyInput{
+			Bucket:            params.Bucket,
+			Key:               params.Key,
+			UploadId:          upload.UploadId,
+			PartNumber:        aws.Int64(number),
+			CopySource:        aws.String(i.copySource()),
+			CopySourceIfMatch: head.ETag,
+			CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+		}
+		if i.customerKey != nil {
+			partParams.SSECustomerAlgorithm = params.SSECustomerAlgorithm
+			partParams.SSECustomerKey = params.SSECustomerKey
+			partParams.CopySourceSSECustomerAlgorithm = params.SSECustomerAlgorithm
+			partParams.CopySourceSSECustomerKey = params.SSECustomerKey
+		}
+		res, err := i.client.UploadPartCopy(partParams)
+		if err != nil {
+			return abort(errors.Wrapf(err, "UploadPartCopy %d, updating metadata", number))
+		}
+		if res.CopyPartResult == nil {
+			return abort(errors.Errorf("UploadPartCopy %d, updating metadata: no result", number))
+		}
+		parts = append(parts, &s3.CompletedPart{
+			PartNumber: aws.Int64(number),
+			ETag:       res.CopyPartResult.ETag,
+		})
+	}
+	res, err := i.client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          params.Bucket,
+		Key:             params.Key,
+		UploadId:        upload.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return abort(errors.Wrap(err, "CompleteMultipartUpload, updating metadata"))
+	}
+	if i.properties.Metadata, err = parseMetadata(metadata); err != nil {
+		return err
+	}
+	etag := cleanEtag(aws.StringValue(res.ETag))
+	i.properties.ETag = &etag
+	return nil
+}
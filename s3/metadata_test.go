@@ -0,0 +1,131 @@
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestMetadataSetter(t *testing.T) {
+	is := is.New(t)
+
+	var copyRequest *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			w.Header().Set("ETag", `"etag"`)
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("X-Amz-Storage-Class", "STANDARD_IA")
+			w.Header().Set("X-Amz-Meta-One", "1")
+			w.Header().Set("X-Amz-Meta-Two", "2")
+		case http.MethodPut:
+			copyRequest = r
+			w.Write([]byte(`<CopyObjectResult><ETag>"copied"</ETag><LastModified>2020-01-02T03:04:05.000Z</LastModified></CopyObjectResult>`))
+		}
+	}))
+	defer server.Close()
+
+	location, err := stow.Dial(Kind, stow.ConfigMap{
+		ConfigAccessKeyID: "access-key",
+		ConfigSecretKey:   "secret-key",
+		ConfigRegion:      "do-not-care",
+		ConfigEndpoint:    server.URL,
+	})
+	is.NoErr(err)
+	container, err := location.Container("bucket")
+	is.NoErr(err)
+	item, err := container.Item("dir/the item")
+	is.NoErr(err)
+	setter := item.(stow.MetadataSetter)
+
+	is.NoErr(setter.MergeMetadata(map[string]interface{}{"two": "zwei", "one": nil, "three": "3"}))
+	is.Equal(copyRequest.URL.Path, "/bucket/dir/the item")
+	is.Equal(copyRequest.Header.Get("X-Amz-Copy-Source"), "bucket/dir/the%20item")
+	is.Equal(copyRequest.Header.Get("X-Amz-Copy-Source-If-Match"), `"etag"`)
+	is.Equal(copyRequest.Header.Get("X-Amz-Metadata-Directive"), "REPLACE")
+	is.Equal(copyRequest.Header.Get("X-Amz-Meta-One"), "")
+	is.Equal(copyRequest.Header.Get("X-Amz-Meta-Two"), "zwei")
+	is.Equal(copyRequest.Header.Get("X-Amz-Meta-Three"), "3")
+	// the content headers and storage class are kept
+	is.Equal(copyRequest.Header.Get("Content-Type"), "text/plain")
+	is.Equal(copyRequest.Header.Get("Cache-Control"), "max-age=60")
+	is.Equal(copyRequest.Header.Get("X-Amz-Storage-Class"), "STANDARD_IA")
+
+	metadata, err := item.Metadata()
+	is.NoErr(err)
+	is.Equal(metadata, map[string]interface{}{"two": "zwei", "three": "3"})
+	etag, err := item.ETag()
+	is.NoErr(err)
+	is.Equal(etag, "copied")
+
+	is.NoErr(setter.SetMetadata(map[string]interface{}{"four": "4"}))
+	is.Equal(copyRequest.Header.Get("X-Amz-Meta-Two"), "")
+	is.Equal(copyRequest.Header.Get("X-Amz-Meta-Four"), "4")
+	is.Err(setter.SetMetadata(map[string]interface{}{"five": 5}))
+}
+
+func TestMetadataSetterLargeObject(t *testing.T) {
+	is := is.New(t)
+
+	const size = 6 << 30
+	var createRequest *http.Request
+	var ranges []string
+	var completed string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		_, uploads := query["uploads"]
+		switch {
+		case r.Method == http.MethodHead:
+			w.Header().Set("ETag", `"etag"`)
+			w.Header().Set("Content-Length", strconv.Itoa(size))
+			w.Header().Set("Content-Type", "video/mp4")
+		case r.Method == http.MethodPost && uploads:
+			createRequest = r
+			w.Write([]byte(`<InitiateMultipartUploadResult><UploadId>upload</UploadId></InitiateMultipartUploadResult>`))
+		case r.Method == http.MethodPut && query.Get("uploadId") == "upload":
+			is.Equal(r.Header.Get("X-Amz-Copy-Source"), "bucket/big")
+			is.Equal(r.Header.Get("X-Amz-Copy-Source-If-Match"), `"etag"`)
+			ranges = append(ranges, query.Get("partNumber")+"="+r.Header.Get("X-Amz-Copy-Source-Range"))
+			w.Write([]byte(`<CopyPartResult><ETag>"part` + query.Get("partNumber") + `"</ETag></CopyPartResult>`))
+		case r.Method == http.MethodPost && query.Get("uploadId") == "upload":
+			b, _ := ioutil.ReadAll(r.Body)
+			completed = string(b)
+			w.Write([]byte(`<CompleteMultipartUploadResult><ETag>"copied-12"</ETag></CompleteMultipartUploadResult>`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL)
+		}
+	}))
+	defer server.Close()
+
+	location, err := stow.Dial(Kind, stow.ConfigMap{
+		ConfigAccessKeyID: "access-key",
+		ConfigSecretKey:   "secret-key",
+		ConfigRegion:      "do-not-care",
+		ConfigEndpoint:    server.URL,
+	})
+	is.NoErr(err)
+	container, err := location.Container("bucket")
+	is.NoErr(err)
+	item, err := container.Item("big")
+	is.NoErr(err)
+
+	// objects over 5 GiB are copied in parts, as CopyObject cannot
+	is.NoErr(item.(stow.MetadataSetter).SetMetadata(map[string]interface{}{"one": "1"}))
+	is.Equal(createRequest.Header.Get("X-Amz-Meta-One"), "1")
+	is.Equal(createRequest.Header.Get("Content-Type"), "video/mp4")
+	is.Equal(len(ranges), 12)
+	is.Equal(ranges[0], "1=bytes=0-536870911")
+	is.Equal(ranges[11], "12=bytes=5905580032-6442450943")
+	is.True(strings.Contains(completed, `<PartNumber>12</PartNumber>`))
+	is.True(strings.Contains(completed, "part12"))
+	etag, err := item.ETag()
+	is.NoErr(err)
+	is.Equal(etag, "copied-12")
+}
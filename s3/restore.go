@@ -0,0 +1,111 @@
+package s3
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var _ stow.ItemRestorer = (*item)(nil)
+
+// restoreTiers are the names S3 uses for the restore tiers.
+var restoreTiers = map[stow.RestoreTier]string{
+	stow.RestoreExpedited: s3.TierExpedited,
+	stow.RestoreStandard:  s3.TierStandard,
+	stow.RestoreBulk:      s3.TierBulk,
+}
+
+// archived gets whether objects of the storage class must be restored to
+// be read.
+func archived(storageClass string) bool {
+	return storageClass == s3.StorageClassGlacier || storageClass == s3.StorageClassDeepArchive
+}
+
+// RestoreStatus gets the storage class of the object and the progress of
+// its restore, which S3 gives in the x-amz-restore header.
+func (i *item) RestoreStatus() (stow.RestoreStatus, error) {
+	info, err := i.container.getItem(i.ID(), i.customerKey)
+	if err != nil {
+		return stow.RestoreStatus{}, err
+	}
+	return restoreStatus(aws.StringValue(info.properties.StorageClass), aws.StringValue(info.properties.Restore))
+}
+
+// restoreFields matches the fields of the x-amz-restore header.
+var restoreFields = regexp.MustCompile(`([a-z-]+)="([^"]*)"`)
+
+// restoreStatus parses the restore status of an object from its x-amz-restore
+// header, such as
+//
+//	ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"
+func restoreStatus(storageClass, restore string) (stow.RestoreStatus, error) {
+	// S3 leaves out the class of standard objects
+	if storageClass == "" {
+		storageClass = s3.StorageClassStandard
+	}
+	status := stow.RestoreStatus{
+		StorageClass: stow.StorageClassFromNative(storageClass, storageClasses),
+		Archived:     archived(storageClass),
+	}
+	for _, field := range restoreFields.FindAllStringSubmatch(restore, -1) {
+		value := field[2]
+		switch field[1] {
+		case "ongoing-request":
+			status.Restoring = value == "true"
+			status.Restored = value == "false"
+		case "expiry-date":
+			expires, err := time.Parse(http.TimeFormat, value)
+			if err != nil {
+				return status, errors.Wrap(err, "parsing restore expiry date")
+			}
+			status.Expires = expires
+		}
+	}
+	return status, nil
+}
+
+// Restore starts restoring the archived object, keeping a copy that can be
+// read for the number of days.
+func (i *item) Restore(tier stow.RestoreTier, days int) error {
+	if tier == "" {
+		tier = stow.RestoreStandard
+	}
+	name, ok := restoreTiers[tier]
+	if !ok {
+		return stow.NotSupported("restore tier " + string(tier))
+	}
+	_, err := i.client.RestoreObject(&s3.RestoreObjectInput{
+		Bucket: aws.String(i.container.name),
+		Key:    aws.String(i.ID()),
+		RestoreRequest: &s3.RestoreRequest{
+			Days:                 aws.Int64(int64(days)),
+			GlacierJobParameters: &s3.GlacierJobParameters{Tier: aws.String(name)},
+		},
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "RestoreAlreadyInProgress" {
+		return nil
+	}
+	return errors.Wrap(err, "restoring object")
+}
+
+// openError gets the error of opening the object, which is a
+// *stow.ArchivedError when the object has to be restored first.
+func (i *item) openError(err error) error {
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != "InvalidObjectState" {
+		return errors.Wrap(err, "Open, getting the object")
+	}
+	status, serr := i.RestoreStatus()
+	if serr != nil {
+		status = stow.RestoreStatus{
+			StorageClass: stow.StorageClassFromNative(aws.StringValue(i.properties.StorageClass), storageClasses),
+			Archived:     true,
+		}
+	}
+	return &stow.ArchivedError{Name: i.Name(), Status: status}
+}
@@ -0,0 +1,106 @@
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestArchivedItems(t *testing.T) {
+	is := is.New(t)
+
+	var restoreBody string
+	restoring := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, isRestore := r.URL.Query()["restore"]
+		switch {
+		case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+			w.Write([]byte(`<ListBucketResult><IsTruncated>false</IsTruncated>` +
+				`<Contents><Key>archived</Key><ETag>"a"</ETag><Size>5</Size><StorageClass>DEEP_ARCHIVE</StorageClass></Contents>` +
+				`<Contents><Key>glacier</Key><ETag>"g"</ETag><Size>5</Size><StorageClass>GLACIER</StorageClass></Contents>` +
+				`<Contents><Key>standard</Key><ETag>"s"</ETag><Size>5</Size><StorageClass>STANDARD</StorageClass></Contents>` +
+				`</ListBucketResult>`))
+		case r.Method == http.MethodHead:
+			w.Header().Set("ETag", `"a"`)
+			w.Header().Set("x-amz-storage-class", "DEEP_ARCHIVE")
+			if restoring {
+				w.Header().Set("x-amz-restore", `ongoing-request="true"`)
+			}
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`<Error><Code>InvalidObjectState</Code><Message>The operation is not valid for the object's storage class</Message></Error>`))
+		case r.Method == http.MethodPost && isRestore:
+			if restoring {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`<Error><Code>RestoreAlreadyInProgress</Code><Message>Object restore is already in progress</Message></Error>`))
+				return
+			}
+			b, _ := ioutil.ReadAll(r.Body)
+			restoreBody = string(b)
+			restoring = true
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+
+	location, err := stow.Dial(Kind, stow.ConfigMap{
+		ConfigAccessKeyID: "access-key",
+		ConfigSecretKey:   "secret-key",
+		ConfigRegion:      "do-not-care",
+		ConfigEndpoint:    server.URL,
+	})
+	is.NoErr(err)
+	container, err := location.Container("bucket")
+	is.NoErr(err)
+
+	// archived objects are listed
+	items, _, err := container.Items("", stow.CursorStart, 10)
+	is.NoErr(err)
+	is.Equal(len(items), 3)
+	item := items[0]
+
+	status, err := item.(stow.ItemRestorer).RestoreStatus()
+	is.NoErr(err)
+	is.Equal(status.StorageClass, stow.StorageClassDeepArchive)
+	is.True(status.Archived)
+	is.False(status.Readable())
+
+	_, err = item.Open()
+	is.True(stow.IsArchived(err))
+	is.False(err.(*stow.ArchivedError).Status.Restoring)
+
+	is.NoErr(stow.Restore(item, stow.RestoreBulk, 3))
+	is.True(strings.Contains(restoreBody, "<Days>3</Days>"))
+	is.True(strings.Contains(restoreBody, "<Tier>Bulk</Tier>"))
+	// restoring again while the restore is in progress is fine
+	is.NoErr(stow.Restore(item, stow.RestoreBulk, 3))
+
+	_, err = item.Open()
+	is.True(stow.IsArchived(err))
+	is.True(err.(*stow.ArchivedError).Status.Restoring)
+}
+
+func TestRestoreStatus(t *testing.T) {
+	is := is.New(t)
+
+	status, err := restoreStatus("GLACIER", `ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`)
+	is.NoErr(err)
+	is.Equal(status.StorageClass, stow.StorageClassArchive)
+	is.True(status.Archived)
+	is.True(status.Restored)
+	is.False(status.Restoring)
+	is.True(status.Readable())
+	is.Equal(status.Expires, time.Date(2012, 12, 21, 0, 0, 0, 0, time.UTC))
+
+	status, err = restoreStatus("", "")
+	is.NoErr(err)
+	is.Equal(status.StorageClass, stow.StorageClassHot)
+	is.False(status.Archived)
+	is.True(status.Readable())
+}
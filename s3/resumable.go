@@ -0,0 +1,148 @@
+package s3
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var _ stow.ResumableUploader = (*container)(nil)
+
+// PutResumable puts the object with a multipart upload, which can be
+// resumed from its checkpoint when it fails. Unlike Put, a failed upload
+// is not aborted, so its parts are kept by the bucket until it is
+// resumed or a lifecycle rule cleans it up.
+func (c *container) PutResumable(name string, r io.ReaderAt, size int64, metadata map[string]interface{}, options *stow.ResumableOptions) (stow.Item, error) {
+	if options == nil {
+		options = &stow.ResumableOptions{}
+	}
+	mdPrepped, err := prepMetadata(metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create or update item, preparing metadata")
+	}
+	content, _, err := stow.PrepareContentProperties(name, io.NewSectionReader(r, 0, size), &stow.PutOptions{
+		ContentProperties: options.ContentProperties,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create or update item, detecting content type")
+	}
+	u := &multipartUploader{
+		container: c,
+		name:      name,
+		metadata:  mdPrepped,
+		content:   content,
+	}
+	return stow.ResumeUpload(u, options.Store, stow.CheckpointKey(c, name), r, size, partSize(size, options.PartSize))
+}
+
+// partSize gets the size of the parts of an upload, keeping to the
+// limits of S3 on the size and number of parts.
+func partSize(size, requested int64) int64 {
+	if requested < s3manager.MinUploadPartSize {
+		requested = s3manager.MinUploadPartSize
+	}
+	if least := (size + s3manager.MaxUploadParts - 1) / s3manager.MaxUploadParts; requested < least {
+		requested = least
+	}
+	return requested
+}
+
+// multipartUploader uploads an object with the multipart upload API.
+type multipartUploader struct {
+	container *container
+	name      string
+	metadata  map[string]*string
+	content   stow.ContentProperties
+}
+
+var _ stow.MultipartUploader = (*multipartUploader)(nil)
+
+func (u *multipartUploader) Start() (string, error) {
+	out, err := u.container.client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket:             aws.String(u.container.name),
+		Key:                aws.String(u.name),
+		Metadata:           u.metadata,
+		ContentType:        optionalString(u.content.ContentType),
+		ContentEncoding:    optionalString(u.content.ContentEncoding),
+		ContentLanguage:    optionalString(u.content.ContentLanguage),
+		CacheControl:       optionalString(u.content.CacheControl),
+		ContentDisposition: optionalString(u.content.ContentDisposition),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "CreateMultipartUpload")
+	}
+	return aws.StringValue(out.UploadId), nil
+}
+
+func (u *multipartUploader) Parts(uploadID string, partSize int64) (map[int]string, error) {
+	parts := map[int]string{}
+	err := u.container.client.ListPartsPages(&s3.ListPartsInput{
+		Bucket:   aws.String(u.container.name),
+		Key:      aws.String(u.name),
+		UploadId: aws.String(uploadID),
+	}, func(page *s3.ListPartsOutput, last bool) bool {
+		for _, part := range page.Parts {
+			parts[int(aws.Int64Value(part.PartNumber))] = aws.StringValue(part.ETag)
+		}
+		return true
+	})
+	if err != nil {
+		if hasErrorCode(err, s3.ErrCodeNoSuchUpload) {
+			return nil, stow.ErrNotFound
+		}
+		return nil, errors.Wrap(err, "ListParts")
+	}
+	return parts, nil
+}
+
+func (u *multipartUploader) UploadPart(uploadID string, number int, offset int64, data []byte) (string, error) {
+	out, err := u.container.client.UploadPart(&s3.UploadPartInput{
+		Bucket:        aws.String(u.container.name),
+		Key:           aws.String(u.name),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    aws.Int64(int64(number)),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "UploadPart %d", number)
+	}
+	return aws.StringValue(out.ETag), nil
+}
+
+func (u *multipartUploader) Complete(uploadID string, parts []stow.CompletedPart) (stow.Item, error) {
+	completed := make([]*s3.CompletedPart, len(parts))
+	for i, part := range parts {
+		completed[i] = &s3.CompletedPart{
+			PartNumber: aws.Int64(int64(part.Number)),
+			ETag:       aws.String(part.ETag),
+		}
+	}
+	_, err := u.container.client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(u.container.name),
+		Key:             aws.String(u.name),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "CompleteMultipartUpload")
+	}
+	return u.container.Item(u.name)
+}
+
+func (u *multipartUploader) Abort(uploadID string) error {
+	_, err := u.container.client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(u.container.name),
+		Key:      aws.String(u.name),
+		UploadId: aws.String(uploadID),
+	})
+	if err != nil && !hasErrorCode(err, s3.ErrCodeNoSuchUpload) {
+		return errors.Wrap(err, "AbortMultipartUpload")
+	}
+	return nil
+}
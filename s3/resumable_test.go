@@ -0,0 +1,102 @@
+package s3
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestPutResumable(t *testing.T) {
+	is := is.New(t)
+
+	var mu sync.Mutex
+	parts := map[string][]byte{}
+	var uploaded []string
+	var completed string
+	failPart := "2"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		q := r.URL.Query()
+		_, isStart := q["uploads"]
+		switch {
+		case r.Method == http.MethodPost && isStart:
+			w.Write([]byte(`<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`))
+		case r.Method == http.MethodPut && q.Get("partNumber") != "":
+			n := q.Get("partNumber")
+			b, _ := ioutil.ReadAll(r.Body)
+			if n == failPart {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			uploaded = append(uploaded, n)
+			parts[n] = b
+			w.Header().Set("ETag", fmt.Sprintf(`"etag-%s-%d"`, n, len(b)))
+		case r.Method == http.MethodGet && q.Get("uploadId") != "":
+			var names []string
+			for n := range parts {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			var b strings.Builder
+			b.WriteString(`<ListPartsResult>`)
+			for _, n := range names {
+				fmt.Fprintf(&b, `<Part><PartNumber>%s</PartNumber><ETag>"etag-%s-%d"</ETag></Part>`, n, n, len(parts[n]))
+			}
+			b.WriteString(`</ListPartsResult>`)
+			w.Write([]byte(b.String()))
+		case r.Method == http.MethodPost && q.Get("uploadId") != "":
+			b, _ := ioutil.ReadAll(r.Body)
+			completed = string(b)
+			w.Write([]byte(`<CompleteMultipartUploadResult><ETag>"done"</ETag></CompleteMultipartUploadResult>`))
+		case r.Method == http.MethodHead:
+			w.Header().Set("ETag", `"done"`)
+		}
+	}))
+	defer server.Close()
+
+	location, err := stow.Dial(Kind, stow.ConfigMap{
+		ConfigAccessKeyID: "access-key",
+		ConfigSecretKey:   "secret-key",
+		ConfigRegion:      "do-not-care",
+		ConfigEndpoint:    server.URL,
+	})
+	is.NoErr(err)
+	container, err := location.Container("bucket")
+	is.NoErr(err)
+
+	data := bytes.Repeat([]byte("x"), int(s3manager.MinUploadPartSize)+10)
+	store := stow.NewMemoryCheckpointStore()
+	options := &stow.ResumableOptions{Store: store, PartSize: 1024}
+	_, err = stow.PutResumable(container, "big.bin", bytes.NewReader(data), int64(len(data)), nil, options)
+	is.Err(err)
+	checkpoint, err := store.Load("bucket/big.bin")
+	is.NoErr(err)
+	is.Equal(checkpoint.UploadID, "upload-1")
+	is.Equal(checkpoint.PartSize, s3manager.MinUploadPartSize)
+	is.Equal(len(checkpoint.Parts), 1)
+
+	failPart = ""
+	uploaded = nil
+	item, err := stow.PutResumable(container, "big.bin", bytes.NewReader(data), int64(len(data)), nil, options)
+	is.NoErr(err)
+	is.Equal(uploaded, []string{"2"})
+	is.Equal(len(parts["2"]), 10)
+	is.True(strings.Contains(completed, "etag-1-5242880"))
+	is.True(strings.Contains(completed, "etag-2-10"))
+	etag, err := item.ETag()
+	is.NoErr(err)
+	is.Equal(etag, "done")
+	_, err = store.Load("bucket/big.bin")
+	is.Equal(err, stow.ErrNotFound)
+}
@@ -0,0 +1,185 @@
+package s3
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ stow.ItemRetainer      = (*item)(nil)
+	_ stow.ContainerRetainer = (*container)(nil)
+)
+
+// retentionModes maps the stow retention modes to the S3 Object Lock modes.
+var retentionModes = map[stow.RetentionMode]string{
+	stow.RetentionGovernance: s3.ObjectLockRetentionModeGovernance,
+	stow.RetentionCompliance: s3.ObjectLockRetentionModeCompliance,
+}
+
+func nativeRetentionMode(mode stow.RetentionMode) (string, error) {
+	m, ok := retentionModes[mode]
+	if !ok {
+		return "", errors.Errorf("unknown retention mode %q", mode)
+	}
+	return m, nil
+}
+
+func parseRetentionMode(mode *string) stow.RetentionMode {
+	for m, native := range retentionModes {
+		if aws.StringValue(mode) == native {
+			return m
+		}
+	}
+	return ""
+}
+
+// isNoObjectLock gets whether err is returned because no retention or
+// legal hold is set on the object.
+func isNoObjectLock(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == "NoSuchObjectLockConfiguration"
+}
+
+// isObjectLocked gets whether err is returned because Object Lock protects
+// the object. S3 denies access, saying so in the message.
+func isObjectLocked(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == "AccessDenied" && strings.Contains(strings.ToLower(aerr.Message()), "object lock")
+}
+
+// Retention gets the Object Lock retention and legal hold of the object.
+func (i *item) Retention() (stow.Retention, error) {
+	var retention stow.Retention
+	res, err := i.client.GetObjectRetention(&s3.GetObjectRetentionInput{
+		Bucket: aws.String(i.container.name),
+		Key:    aws.String(i.ID()),
+	})
+	if err != nil && !isNoObjectLock(err) {
+		return retention, errors.Wrap(err, "getting object retention")
+	}
+	if err == nil && res.Retention != nil {
+		retention.Mode = parseRetentionMode(res.Retention.Mode)
+		retention.RetainUntil = aws.TimeValue(res.Retention.RetainUntilDate)
+	}
+
+	hold, err := i.client.GetObjectLegalHold(&s3.GetObjectLegalHoldInput{
+		Bucket: aws.String(i.container.name),
+		Key:    aws.String(i.ID()),
+	})
+	if err != nil && !isNoObjectLock(err) {
+		return retention, errors.Wrap(err, "getting object legal hold")
+	}
+	if err == nil && hold.LegalHold != nil {
+		retention.LegalHold = aws.StringValue(hold.LegalHold.Status) == s3.ObjectLockLegalHoldStatusOn
+	}
+	return retention, nil
+}
+
+// SetRetention sets the Object Lock retention of the object. The bucket
+// must have Object Lock enabled. Removing a governance mode retention
+// bypasses it, which requires the s3:BypassGovernanceRetention permission.
+func (i *item) SetRetention(mode stow.RetentionMode, retainUntil time.Time) error {
+	params := &s3.PutObjectRetentionInput{
+		Bucket:    aws.String(i.container.name),
+		Key:       aws.String(i.ID()),
+		Retention: &s3.ObjectLockRetention{},
+	}
+	if retainUntil.IsZero() {
+		params.BypassGovernanceRetention = aws.Bool(true)
+	} else {
+		m, err := nativeRetentionMode(mode)
+		if err != nil {
+			return err
+		}
+		params.Retention.Mode = aws.String(m)
+		params.Retention.RetainUntilDate = aws.Time(retainUntil)
+	}
+	if _, err := i.client.PutObjectRetention(params); err != nil {
+		return errors.Wrap(err, "setting object retention")
+	}
+	return nil
+}
+
+// SetLegalHold turns the Object Lock legal hold of the object on or off.
+func (i *item) SetLegalHold(hold bool) error {
+	status := s3.ObjectLockLegalHoldStatusOff
+	if hold {
+		status = s3.ObjectLockLegalHoldStatusOn
+	}
+	_, err := i.client.PutObjectLegalHold(&s3.PutObjectLegalHoldInput{
+		Bucket:    aws.String(i.container.name),
+		Key:       aws.String(i.ID()),
+		LegalHold: &s3.ObjectLockLegalHold{Status: aws.String(status)},
+	})
+	if err != nil {
+		return errors.Wrap(err, "setting object legal hold")
+	}
+	return nil
+}
+
+const day = 24 * time.Hour
+
+// days gets the duration of n days.
+func days(n int64) time.Duration {
+	return time.Duration(n) * day
+}
+
+// DefaultRetention gets the default retention of the Object Lock
+// configuration of the bucket.
+func (c *container) DefaultRetention() (stow.DefaultRetention, error) {
+	var retention stow.DefaultRetention
+	res, err := c.client.GetObjectLockConfiguration(&s3.GetObjectLockConfigurationInput{
+		Bucket: aws.String(c.name),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "ObjectLockConfigurationNotFoundError" {
+			return retention, nil
+		}
+		return retention, errors.Wrap(err, "getting object lock configuration")
+	}
+	config := res.ObjectLockConfiguration
+	if config == nil || config.Rule == nil || config.Rule.DefaultRetention == nil {
+		return retention, nil
+	}
+	def := config.Rule.DefaultRetention
+	retention.Mode = parseRetentionMode(def.Mode)
+	retention.Period = days(aws.Int64Value(def.Days) + 365*aws.Int64Value(def.Years))
+	return retention, nil
+}
+
+// SetDefaultRetention sets the default retention of the Object Lock
+// configuration of the bucket. S3 retains objects for whole days, so the
+// period is rounded up. Object Lock can only be enabled on buckets created
+// with it.
+func (c *container) SetDefaultRetention(retention stow.DefaultRetention) error {
+	config := &s3.ObjectLockConfiguration{
+		ObjectLockEnabled: aws.String(s3.ObjectLockEnabledEnabled),
+	}
+	if retention.Period > 0 {
+		mode, err := nativeRetentionMode(retention.Mode)
+		if err != nil {
+			return err
+		}
+		n := int64((retention.Period + day - 1) / day)
+		config.Rule = &s3.ObjectLockRule{
+			DefaultRetention: &s3.DefaultRetention{
+				Mode: aws.String(mode),
+				Days: aws.Int64(n),
+			},
+		}
+	}
+	_, err := c.client.PutObjectLockConfiguration(&s3.PutObjectLockConfigurationInput{
+		Bucket:                  aws.String(c.name),
+		ObjectLockConfiguration: config,
+	})
+	if err != nil {
+		return errors.Wrap(err, "setting object lock configuration")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestRetention(t *testing.T) {
+	is := is.New(t)
+
+	bodies := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		_, retention := query["retention"]
+		_, legalHold := query["legal-hold"]
+		_, objectLock := query["object-lock"]
+		switch {
+		case r.Method == http.MethodDelete && r.URL.Path == "/bucket/locked":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`<Error><Code>AccessDenied</Code><Message>Access Denied because object protected by object lock.</Message></Error>`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`))
+		case r.Method == http.MethodHead:
+			w.Header().Set("ETag", `"etag"`)
+		case r.Method == http.MethodPut:
+			b, _ := ioutil.ReadAll(r.Body)
+			bodies[r.URL.RawQuery] = string(b)
+		case retention:
+			w.Write([]byte(`<Retention><Mode>COMPLIANCE</Mode><RetainUntilDate>2030-01-02T03:04:05Z</RetainUntilDate></Retention>`))
+		case legalHold:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchObjectLockConfiguration</Code></Error>`))
+		case objectLock:
+			w.Write([]byte(`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>GOVERNANCE</Mode><Days>30</Days></DefaultRetention></Rule></ObjectLockConfiguration>`))
+		}
+	}))
+	defer server.Close()
+
+	location, err := stow.Dial(Kind, stow.ConfigMap{
+		ConfigAccessKeyID: "access-key",
+		ConfigSecretKey:   "secret-key",
+		ConfigRegion:      "do-not-care",
+		ConfigEndpoint:    server.URL,
+	})
+	is.NoErr(err)
+	container, err := location.Container("bucket")
+	is.NoErr(err)
+	item, err := container.Item("locked")
+	is.NoErr(err)
+	retainer := item.(stow.ItemRetainer)
+
+	retention, err := retainer.Retention()
+	is.NoErr(err)
+	is.Equal(retention.Mode, stow.RetentionCompliance)
+	is.True(retention.RetainUntil.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)))
+	is.False(retention.LegalHold)
+
+	is.NoErr(retainer.SetRetention(stow.RetentionGovernance, time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)))
+	is.True(strings.Contains(bodies["retention="], "<Mode>GOVERNANCE</Mode>"))
+	is.True(strings.Contains(bodies["retention="], "<RetainUntilDate>2030-01-02T03:04:05Z</RetainUntilDate>"))
+	is.NoErr(retainer.SetLegalHold(true))
+	is.True(strings.Contains(bodies["legal-hold="], "<Status>ON</Status>"))
+	is.Err(retainer.SetRetention("forever", time.Now()))
+
+	// only denials because of Object Lock are locks
+	is.Equal(container.RemoveItem("locked"), stow.ErrLocked)
+	err = container.RemoveItem("denied")
+	is.Err(err)
+	is.NotEqual(err, stow.ErrLocked)
+
+	def, err := container.(stow.ContainerRetainer).DefaultRetention()
+	is.NoErr(err)
+	is.Equal(def, stow.DefaultRetention{Mode: stow.RetentionGovernance, Period: 30 * 24 * time.Hour})
+
+	// periods are rounded up to whole days
+	err = container.(stow.ContainerRetainer).SetDefaultRetention(stow.DefaultRetention{
+		Mode:   stow.RetentionCompliance,
+		Period: 36 * time.Hour,
+	})
+	is.NoErr(err)
+	is.True(strings.Contains(bodies["object-lock="], "<Days>2</Days>"))
+	is.True(strings.Contains(bodies["object-lock="], "<Mode>COMPLIANCE</Mode>"))
+}
@@ -2,6 +2,7 @@
 package s3
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -10,10 +11,12 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/cheekybits/is"
 	"github.com/graymeta/stow"
 	"github.com/graymeta/stow/test"
@@ -205,3 +208,161 @@ func TestWillRequestRegionWhenConfigured(t *testing.T) {
 	// Make sure that this is an error
 	is.NoErr(err)
 }
+
+func TestPutWithOptions(t *testing.T) {
+	is := is.New(t)
+
+	var putHeaders http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			putHeaders = r.Header
+		case http.MethodHead:
+			for _, h := range []string{"Content-Type", "Content-Encoding", "Content-Language", "Cache-Control", "Content-Disposition"} {
+				w.Header().Set(h, putHeaders.Get(h))
+			}
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := stow.ConfigMap{
+		"access_key_id": "access-key",
+		"secret_key":    "secret-key",
+		"region":        "do-not-care",
+		"endpoint":      server.URL,
+	}
+
+	location, err := stow.Dial("s3", config)
+	is.NoErr(err)
+	container, err := location.Container("bucket")
+	is.NoErr(err)
+
+	options := &stow.PutOptions{
+		ContentProperties: stow.ContentProperties{
+			ContentEncoding:    "gzip",
+			ContentLanguage:    "en-US",
+			CacheControl:       "max-age=3600",
+			ContentDisposition: `attachment; filename="report.pdf"`,
+		},
+	}
+	_, err = stow.PutWithOptions(container, "report.pdf", strings.NewReader("%PDF-1.4"), 8, nil, options)
+	is.NoErr(err)
+	is.Equal(putHeaders.Get("Content-Type"), "application/pdf")
+	is.Equal(putHeaders.Get("Content-Encoding"), "gzip")
+	is.Equal(putHeaders.Get("Content-Language"), "en-US")
+	is.Equal(putHeaders.Get("Cache-Control"), "max-age=3600")
+	is.Equal(putHeaders.Get("Content-Disposition"), `attachment; filename="report.pdf"`)
+
+	item, err := container.Item("report.pdf")
+	is.NoErr(err)
+	props, err := item.(stow.ContentDescriber).ContentProperties()
+	is.NoErr(err)
+	options.ContentType = "application/pdf"
+	is.Equal(props, options.ContentProperties)
+
+	// without options the content type is sniffed
+	_, err = container.Put("noext", strings.NewReader("<html><body></body></html>"), 26, nil)
+	is.NoErr(err)
+	is.Equal(putHeaders.Get("Content-Type"), "text/html; charset=utf-8")
+}
+
+func TestPutWithStorageOptions(t *testing.T) {
+	is := is.New(t)
+
+	var putHeaders http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			putHeaders = r.Header
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := stow.ConfigMap{
+		"access_key_id": "access-key",
+		"secret_key":    "secret-key",
+		"region":        "do-not-care",
+		"endpoint":      server.URL,
+	}
+
+	location, err := stow.Dial("s3", config)
+	is.NoErr(err)
+	container, err := location.Container("bucket")
+	is.NoErr(err)
+
+	options := &stow.PutOptions{
+		StorageClass: stow.StorageClassCool,
+		ACL:          stow.ACLPublicRead,
+		Encryption: &stow.Encryption{
+			Mode:     stow.EncryptionKMS,
+			KMSKeyID: "arn:aws:kms:us-east-1:111122223333:key/key-id",
+		},
+	}
+	_, err = stow.PutWithOptions(container, "item", strings.NewReader("item"), 4, nil, options)
+	is.NoErr(err)
+	is.Equal(putHeaders.Get("X-Amz-Storage-Class"), "STANDARD_IA")
+	is.Equal(putHeaders.Get("X-Amz-Acl"), "public-read")
+	is.Equal(putHeaders.Get("X-Amz-Server-Side-Encryption"), "aws:kms")
+	is.Equal(putHeaders.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"), options.Encryption.KMSKeyID)
+
+	// native storage class names are passed through
+	options = &stow.PutOptions{
+		StorageClass: "ONEZONE_IA",
+		Encryption:   &stow.Encryption{Mode: stow.EncryptionProvider},
+	}
+	_, err = stow.PutWithOptions(container, "item", strings.NewReader("item"), 4, nil, options)
+	is.NoErr(err)
+	is.Equal(putHeaders.Get("X-Amz-Storage-Class"), "ONEZONE_IA")
+	is.Equal(putHeaders.Get("X-Amz-Acl"), "")
+	is.Equal(putHeaders.Get("X-Amz-Server-Side-Encryption"), "AES256")
+
+	options = &stow.PutOptions{
+		Encryption: &stow.Encryption{Mode: stow.EncryptionCustomerKey, CustomerKey: []byte("short")},
+	}
+	_, err = stow.PutWithOptions(container, "item", strings.NewReader("item"), 4, nil, options)
+	is.Err(err)
+}
+
+func TestPutWithTransferOptions(t *testing.T) {
+	is := is.New(t)
+
+	var mu sync.Mutex
+	var parts []int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		q := r.URL.Query()
+		_, isStart := q["uploads"]
+		switch {
+		case r.Method == http.MethodPost && isStart:
+			w.Write([]byte(`<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`))
+		case r.Method == http.MethodPut && q.Get("partNumber") != "":
+			parts = append(parts, r.ContentLength)
+			w.Header().Set("ETag", `"etag"`)
+		case r.Method == http.MethodPost:
+			w.Write([]byte(`<CompleteMultipartUploadResult><ETag>"done"</ETag></CompleteMultipartUploadResult>`))
+		}
+	}))
+	defer server.Close()
+
+	location, err := stow.Dial(Kind, stow.ConfigMap{
+		ConfigAccessKeyID: "access-key",
+		ConfigSecretKey:   "secret-key",
+		ConfigRegion:      "do-not-care",
+		ConfigEndpoint:    server.URL,
+	})
+	is.NoErr(err)
+	container, err := location.Container("bucket")
+	is.NoErr(err)
+
+	// the default part size would upload three parts
+	partSize := s3manager.MinUploadPartSize + 1024*1024
+	data := bytes.Repeat([]byte("x"), int(2*partSize))
+	options := &stow.PutOptions{Transfer: stow.TransferOptions{PartSize: partSize, Concurrency: 2}}
+	_, err = stow.PutWithOptions(container, "item", bytes.NewReader(data), int64(len(data)), nil, options)
+	is.NoErr(err)
+	is.Equal(parts, []int64{partSize, partSize})
+}
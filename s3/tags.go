@@ -0,0 +1,89 @@
+package s3
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var _ stow.TagSetter = (*item)(nil)
+
+// SetTags replaces the tag set of the object.
+func (i *item) SetTags(tags map[string]interface{}) error {
+	values, err := stow.TagValues(tags)
+	if err != nil {
+		return err
+	}
+	tagSet := make([]*s3.Tag, 0, len(values))
+	for _, key := range sortedKeys(values) {
+		tagSet = append(tagSet, &s3.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(values[key]),
+		})
+	}
+	_, err = i.client.PutObjectTagging(&s3.PutObjectTaggingInput{
+		Bucket:  aws.String(i.container.name),
+		Key:     aws.String(i.ID()),
+		Tagging: &s3.Tagging{TagSet: tagSet},
+	})
+	if err != nil {
+		return errors.Wrap(err, "putObjectTagging")
+	}
+	i.setCachedTags(values)
+	return nil
+}
+
+// DeleteTags removes the tag set of the object.
+func (i *item) DeleteTags() error {
+	_, err := i.client.DeleteObjectTagging(&s3.DeleteObjectTaggingInput{
+		Bucket: aws.String(i.container.name),
+		Key:    aws.String(i.ID()),
+	})
+	if err != nil {
+		return errors.Wrap(err, "deleteObjectTagging")
+	}
+	i.setCachedTags(nil)
+	return nil
+}
+
+// setCachedTags makes Tags return the tags that were just set rather than
+// the ones it may have fetched before.
+func (i *item) setCachedTags(values map[string]string) {
+	i.tagsOnce.Do(func() {})
+	i.tags = make(map[string]interface{}, len(values))
+	for key, value := range values {
+		i.tags[key] = value
+	}
+	i.tagsErr = nil
+}
+
+// encodeTagging encodes the tags as URL query parameters, the way S3
+// expects them when putting an object.
+func encodeTagging(tags map[string]interface{}) (*string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	values, err := stow.TagValues(tags)
+	if err != nil {
+		return nil, err
+	}
+	query := make([]string, 0, len(values))
+	for _, key := range sortedKeys(values) {
+		query = append(query, url.QueryEscape(key)+"="+url.QueryEscape(values[key]))
+	}
+	return aws.String(strings.Join(query, "&")), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
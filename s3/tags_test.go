@@ -0,0 +1,79 @@
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestTags(t *testing.T) {
+	is := is.New(t)
+
+	var tagging string
+	requests := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, isTagging := r.URL.Query()["tagging"]
+		switch {
+		case r.Method == http.MethodHead:
+			w.Header().Set("ETag", `"etag"`)
+		case r.Method == http.MethodPut && isTagging:
+			b, _ := ioutil.ReadAll(r.Body)
+			requests["put tagging"] = string(b)
+		case r.Method == http.MethodPut:
+			tagging = r.Header.Get("X-Amz-Tagging")
+			w.Header().Set("ETag", `"etag"`)
+		case r.Method == http.MethodDelete && isTagging:
+			requests["delete tagging"] = r.URL.Path
+			w.WriteHeader(http.StatusNoContent)
+		case isTagging:
+			w.Write([]byte(`<Tagging><TagSet><Tag><Key>team</Key><Value>media</Value></Tag></TagSet></Tagging>`))
+		}
+	}))
+	defer server.Close()
+
+	location, err := stow.Dial(Kind, stow.ConfigMap{
+		ConfigAccessKeyID: "access-key",
+		ConfigSecretKey:   "secret-key",
+		ConfigRegion:      "do-not-care",
+		ConfigEndpoint:    server.URL,
+	})
+	is.NoErr(err)
+	container, err := location.Container("bucket")
+	is.NoErr(err)
+
+	_, err = stow.PutWithOptions(container, "tagged.txt", strings.NewReader("hello"), 5, nil, &stow.PutOptions{
+		Tags: map[string]interface{}{"team": "media", "stage": "raw data"},
+	})
+	is.NoErr(err)
+	is.Equal(tagging, "stage=raw+data&team=media")
+
+	_, err = stow.PutWithOptions(container, "tagged.txt", strings.NewReader("hello"), 5, nil, &stow.PutOptions{
+		Tags: map[string]interface{}{"count": 1},
+	})
+	is.Err(err)
+
+	item, err := container.Item("tagged.txt")
+	is.NoErr(err)
+	tags, err := item.(stow.Taggable).Tags()
+	is.NoErr(err)
+	is.Equal(tags, map[string]interface{}{"team": "media"})
+
+	setter := item.(stow.TagSetter)
+	is.NoErr(setter.SetTags(map[string]interface{}{"team": "sound"}))
+	is.True(strings.Contains(requests["put tagging"], "<Key>team</Key>"))
+	is.True(strings.Contains(requests["put tagging"], "<Value>sound</Value>"))
+	tags, err = item.(stow.Taggable).Tags()
+	is.NoErr(err)
+	is.Equal(tags, map[string]interface{}{"team": "sound"})
+
+	is.NoErr(setter.DeleteTags())
+	is.Equal(requests["delete tagging"], "/bucket/tagged.txt")
+	tags, err = item.(stow.Taggable).Tags()
+	is.NoErr(err)
+	is.Equal(len(tags), 0)
+}
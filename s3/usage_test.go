@@ -0,0 +1,56 @@
+package s3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestItemsDelimited(t *testing.T) {
+	is := is.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("delimiter") != "/" || q.Get("prefix") != "logs/" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if q.Get("continuation-token") == "" {
+			w.Write([]byte(`<ListBucketResult><IsTruncated>true</IsTruncated><NextContinuationToken>next</NextContinuationToken>` +
+				`<Contents><Key>logs/a</Key><ETag>"a"</ETag><Size>5</Size></Contents>` +
+				`<CommonPrefixes><Prefix>logs/2019/</Prefix></CommonPrefixes>` +
+				`</ListBucketResult>`))
+			return
+		}
+		w.Write([]byte(`<ListBucketResult><IsTruncated>false</IsTruncated>` +
+			`<Contents><Key>logs/b</Key><ETag>"b"</ETag><Size>7</Size><StorageClass>GLACIER</StorageClass></Contents>` +
+			`<CommonPrefixes><Prefix>logs/2020/</Prefix></CommonPrefixes>` +
+			`</ListBucketResult>`))
+	}))
+	defer server.Close()
+
+	location, err := stow.Dial(Kind, stow.ConfigMap{
+		ConfigAccessKeyID: "access-key",
+		ConfigSecretKey:   "secret-key",
+		ConfigRegion:      "do-not-care",
+		ConfigEndpoint:    server.URL,
+	})
+	is.NoErr(err)
+	container, err := location.Container("bucket")
+	is.NoErr(err)
+
+	items, prefixes, err := stow.ListDelimited(container, "logs/", "/")
+	is.NoErr(err)
+	is.Equal(len(items), 2)
+	is.Equal(prefixes, []string{"logs/2019/", "logs/2020/"})
+
+	class, err := items[0].(stow.StorageClassDescriber).StorageClass()
+	is.NoErr(err)
+	is.Equal(class, stow.StorageClassHot)
+	class, err = items[1].(stow.StorageClassDescriber).StorageClass()
+	is.NoErr(err)
+	is.Equal(class, stow.StorageClassArchive)
+}
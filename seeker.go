@@ -0,0 +1,274 @@
+package stow
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ReadSeekCloser gives random access to the contents of an Item.
+type ReadSeekCloser interface {
+	io.ReadSeeker
+	io.ReaderAt
+	io.Closer
+}
+
+// ReaderAtCloser reads the contents of an Item at any offset.
+type ReaderAtCloser interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// ItemSeeker represents an Item that can be read at any offset natively,
+// such as a file.
+type ItemSeeker interface {
+	// OpenSeeker opens the Item for random access.
+	OpenSeeker() (ReadSeekCloser, error)
+}
+
+// sizes of the blocks cached by OpenSeeker
+const (
+	seekerBlockSize = 1024 * 1024
+	seekerBlocks    = 16
+)
+
+// OpenSeeker opens the Item for random access. Items that are ItemSeekers
+// are opened natively. The contents of ItemRangers are fetched in blocks,
+// which are cached, and Read fetches the block after the one it reads in
+// the background. Other Items give a NotSupported error.
+// Calling code must close the ReadSeekCloser.
+func OpenSeeker(item Item) (ReadSeekCloser, error) {
+	if seeker, ok := item.(ItemSeeker); ok {
+		return seeker.OpenSeeker()
+	}
+	ranger, ok := item.(ItemRanger)
+	if !ok {
+		return nil, NotSupported("random access")
+	}
+	size, err := item.Size()
+	if err != nil {
+		return nil, err
+	}
+	return newBlockReader(ranger, size, seekerBlockSize, seekerBlocks), nil
+}
+
+// OpenReaderAt opens the Item for reading at any offset, like OpenSeeker.
+// Calling code must close the ReaderAtCloser.
+func OpenReaderAt(item Item) (ReaderAtCloser, error) {
+	return OpenSeeker(item)
+}
+
+var (
+	errSeekerClosed   = errors.New("reader is closed")
+	errNegativeOffset = errors.New("negative offset")
+)
+
+// blockReader reads an ItemRanger in blocks, keeping the ones used most
+// recently.
+type blockReader struct {
+	ranger    ItemRanger
+	size      int64
+	blockSize int64
+	capacity  int
+
+	mu      sync.Mutex
+	blocks  map[int64]*block
+	tick    uint64
+	pos     int64
+	closed  bool
+	pending sync.WaitGroup
+}
+
+// block is a block of an Item, which is ready once done is closed.
+type block struct {
+	done chan struct{}
+	data []byte
+	err  error
+	used uint64
+}
+
+func newBlockReader(ranger ItemRanger, size, blockSize int64, capacity int) *blockReader {
+	return &blockReader{
+		ranger:    ranger,
+		size:      size,
+		blockSize: blockSize,
+		capacity:  capacity,
+		blocks:    map[int64]*block{},
+	}
+}
+
+// block gets block n, fetching it unless it is cached or being fetched.
+func (r *blockReader) block(n int64) (*block, error) {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, errSeekerClosed
+	}
+	b, fetch := r.use(n)
+	r.mu.Unlock()
+	if fetch {
+		r.fetch(n, b)
+	}
+	<-b.done
+	return b, b.err
+}
+
+// readAhead fetches block n in the background, unless it is past the end
+// or already cached.
+func (r *blockReader) readAhead(n int64) {
+	if n*r.blockSize >= r.size {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.blocks[n] != nil {
+		return
+	}
+	b, _ := r.use(n)
+	r.pending.Add(1)
+	go func() {
+		defer r.pending.Done()
+		r.fetch(n, b)
+	}()
+}
+
+// use marks block n as used, adding it to the cache when missing, and
+// tells if it is to be fetched. It must be called with r.mu held.
+func (r *blockReader) use(n int64) (*block, bool) {
+	r.tick++
+	if b, ok := r.blocks[n]; ok {
+		b.used = r.tick
+		return b, false
+	}
+	if len(r.blocks) >= r.capacity {
+		r.evict()
+	}
+	b := &block{done: make(chan struct{}), used: r.tick}
+	r.blocks[n] = b
+	return b, true
+}
+
+// evict drops the block used least recently, leaving the ones still being
+// fetched. It must be called with r.mu held.
+func (r *blockReader) evict() {
+	var oldest int64 = -1
+	for n, b := range r.blocks {
+		select {
+		case <-b.done:
+		default:
+			continue
+		}
+		if oldest < 0 || b.used < r.blocks[oldest].used {
+			oldest = n
+		}
+	}
+	if oldest >= 0 {
+		delete(r.blocks, oldest)
+	}
+}
+
+// fetch reads block n into b. Blocks that fail are dropped from the cache,
+// so that they are fetched again when next used.
+func (r *blockReader) fetch(n int64, b *block) {
+	defer close(b.done)
+	start := n * r.blockSize
+	length := r.size - start
+	if length > r.blockSize {
+		length = r.blockSize
+	}
+	b.data, b.err = r.readRange(start, length)
+	if b.err != nil {
+		r.mu.Lock()
+		if r.blocks[n] == b {
+			delete(r.blocks, n)
+		}
+		r.mu.Unlock()
+	}
+}
+
+func (r *blockReader) readRange(start, length int64) ([]byte, error) {
+	rc, err := r.ranger.OpenRange(uint64(start), uint64(start+length-1))
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data := make([]byte, length)
+	if _, err := io.ReadFull(rc, data); err != nil {
+		return nil, fmt.Errorf("reading bytes %d-%d: %v", start, start+length-1, err)
+	}
+	return data, nil
+}
+
+// ReadAt reads len(p) bytes at the offset, fetching the blocks they are in.
+func (r *blockReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errNegativeOffset
+	}
+	n := 0
+	for n < len(p) {
+		if off >= r.size {
+			return n, io.EOF
+		}
+		b, err := r.block(off / r.blockSize)
+		if err != nil {
+			return n, err
+		}
+		copied := copy(p[n:], b.data[off%r.blockSize:])
+		n += copied
+		off += int64(copied)
+	}
+	return n, nil
+}
+
+// Read reads from the current offset. Reading sequentially fetches the next
+// block ahead of time.
+func (r *blockReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	pos := r.pos
+	r.mu.Unlock()
+	if pos >= r.size {
+		return 0, io.EOF
+	}
+	if rest := r.size - pos; int64(len(p)) > rest {
+		p = p[:rest]
+	}
+	n, err := r.ReadAt(p, pos)
+	r.mu.Lock()
+	r.pos = pos + int64(n)
+	r.mu.Unlock()
+	if err == nil && n > 0 {
+		r.readAhead((pos+int64(n)-1)/r.blockSize + 1)
+	}
+	return n, err
+}
+
+// Seek sets the offset of the next Read.
+func (r *blockReader) Seek(offset int64, whence int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, fmt.Errorf("bad whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, errNegativeOffset
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+// Close drops the cached blocks, once those being read ahead are fetched.
+func (r *blockReader) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	r.blocks = nil
+	r.mu.Unlock()
+	r.pending.Wait()
+	return nil
+}
@@ -0,0 +1,93 @@
+package stow_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestOpenSeeker(t *testing.T) {
+	is := is.New(t)
+
+	const block = 1024 * 1024
+	data := make([]byte, 2*block+10)
+	for i := range data {
+		data[i] = byte(i / 7)
+	}
+	item := &rangedItem{dataItem: dataItem{testItem: testItem{name: "item"}, data: data}, failStart: -1}
+
+	rs, err := stow.OpenSeeker(item)
+	is.NoErr(err)
+	defer rs.Close()
+
+	// reading across blocks fetches each of them once
+	b := make([]byte, 20)
+	n, err := rs.ReadAt(b, block-10)
+	is.NoErr(err)
+	is.Equal(n, 20)
+	is.Equal(b, data[block-10:block+10])
+	n, err = rs.ReadAt(b, block-5)
+	is.NoErr(err)
+	is.Equal(b, data[block-5:block+15])
+	is.Equal(item.takeOpened(), []int{0, block})
+
+	// reading at the end gives what is left
+	n, err = rs.ReadAt(b, int64(len(data))-5)
+	is.Equal(err, io.EOF)
+	is.Equal(n, 5)
+	is.Equal(b[:5], data[len(data)-5:])
+	item.takeOpened()
+
+	// reading the first block reads the next one ahead
+	rs, err = stow.OpenSeeker(item)
+	is.NoErr(err)
+	defer rs.Close()
+	pos, err := rs.Seek(10, io.SeekStart)
+	is.NoErr(err)
+	is.Equal(pos, 10)
+	n, err = rs.Read(b)
+	is.NoErr(err)
+	is.Equal(b, data[10:30])
+	is.True(item.waitOpened(block))
+	is.Equal(item.takeOpened(), []int{0, block})
+	rest, err := ioutil.ReadAll(rs)
+	is.NoErr(err)
+	is.True(bytes.Equal(rest, data[30:]))
+	is.Equal(item.takeOpened(), []int{2 * block})
+
+	pos, err = rs.Seek(-3, io.SeekEnd)
+	is.NoErr(err)
+	is.Equal(pos, len(data)-3)
+	_, err = rs.Seek(-1, io.SeekStart)
+	is.Err(err)
+}
+
+func TestOpenSeekerRetries(t *testing.T) {
+	is := is.New(t)
+
+	item := &rangedItem{dataItem: dataItem{testItem: testItem{name: "item"}, data: []byte("abcdefghij")}, failStart: 0}
+	r, err := stow.OpenReaderAt(item)
+	is.NoErr(err)
+	defer r.Close()
+
+	b := make([]byte, 4)
+	_, err = r.ReadAt(b, 2)
+	is.Err(err)
+
+	// failed blocks are not cached
+	item.failStart = -1
+	_, err = r.ReadAt(b, 2)
+	is.NoErr(err)
+	is.Equal(string(b), "cdef")
+	is.Equal(item.takeOpened(), []int{0})
+}
+
+func TestOpenSeekerNotSupported(t *testing.T) {
+	is := is.New(t)
+	_, err := stow.OpenSeeker(&dataItem{testItem: testItem{name: "plain"}})
+	is.True(stow.IsNotSupported(err))
+}
@@ -108,6 +108,9 @@ func (c *container) getFolderItems(entries []entry, prefix, relPath, id, cursor
 		}
 
 		if file.IsDir() {
+			if file.Name() == sidecarDir {
+				continue
+			}
 			var err error
 			var retCursor string
 			entries, retCursor, err = c.getFolderItems(entries, prefix, fileRelPath, filepath.Join(id, file.Name()), cursor, limit, true)
@@ -159,18 +162,52 @@ func (c *container) getFolderItems(entries []entry, prefix, relPath, id, cursor
 	return entries, "", nil
 }
 
-// RemoveItem removes a file from the remote server.
+// filePath gets the path on the server of the file with the name.
+func (c *container) filePath(name string) string {
+	return filepath.Join(c.location.config.basePath, c.name, filepath.FromSlash(name))
+}
+
+// RemoveItem removes a file from the remote server, along with its sidecar
+// record.
 func (c *container) RemoveItem(id string) error {
-	return c.location.sftpClient.Remove(filepath.Join(c.location.config.basePath, c.name, filepath.FromSlash(id)))
+	if err := c.location.sftpClient.Remove(c.filePath(id)); err != nil {
+		return err
+	}
+	return c.writeRecord(c.filePath(id), record{})
 }
 
 // Put sends a request to upload content to the container.
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutWithOptions(name, r, size, metadata, nil)
+}
+
+// PutWithOptions uploads a file like Put, recording the content properties
+// and tags given in the options in a sidecar next to it.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, metadata map[string]interface{}, options *stow.PutOptions) (stow.Item, error) {
 	if len(metadata) > 0 {
 		return nil, stow.NotSupported("metadata")
 	}
+	var rec record
+	if options != nil {
+		switch {
+		case options.StorageClass != "":
+			return nil, stow.NotSupported("storage class")
+		case options.ACL != "":
+			return nil, stow.NotSupported("ACL")
+		case options.Encryption != nil:
+			return nil, stow.NotSupported("encryption")
+		}
+		var err error
+		if rec.Tags, err = stow.TagValues(options.Tags); err != nil {
+			return nil, err
+		}
+		if !options.ContentProperties.IsZero() {
+			props := options.ContentProperties
+			rec.Content = &props
+		}
+	}
 
-	path := filepath.Join(c.location.config.basePath, c.name, filepath.FromSlash(name))
+	path := c.filePath(name)
 	item := &item{
 		container: c,
 		path:      name,
@@ -200,5 +237,26 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 	item.modTime = info.ModTime()
 	item.md = getFileMetadata(info)
 
+	// the record of a replaced file goes with it
+	if err := c.writeRecord(path, rec); err != nil {
+		return nil, err
+	}
 	return item, nil
 }
+
+// Stat describes the directory of the container. Created is the time the
+// directory was last modified, as SFTP has no creation times.
+func (c *container) Stat() (stow.ContainerInfo, error) {
+	info, err := c.location.sftpClient.Stat(filepath.Join(c.location.config.basePath, c.name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return stow.ContainerInfo{}, stow.ErrNotFound
+		}
+		return stow.ContainerInfo{}, err
+	}
+	return stow.ContainerInfo{
+		Created:    info.ModTime(),
+		Versioning: stow.VersioningDisabled,
+		Metadata:   getFileMetadata(info),
+	}, nil
+}
@@ -6,9 +6,17 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
+	"github.com/graymeta/stow"
 	"github.com/graymeta/stow/local"
+	"github.com/pkg/sftp"
+)
+
+var (
+	_ stow.ItemSeeker = (*item)(nil)
+	_ stow.ItemRanger = (*item)(nil)
 )
 
 type item struct {
@@ -59,6 +67,95 @@ func (i *item) Open() (io.ReadCloser, error) {
 	)
 }
 
+// OpenRange opens the file for reading starting at byte start and ending
+// at byte end, or at the end of the file when it is shorter.
+func (i *item) OpenRange(start, end uint64) (io.ReadCloser, error) {
+	if end < start {
+		return nil, fmt.Errorf("bad range %d-%d", start, end)
+	}
+	f, err := i.container.location.sftpClient.Open(i.fullPath())
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if start >= uint64(info.Size()) {
+		f.Close()
+		return nil, fmt.Errorf("range %d-%d starts past the end of the file", start, end)
+	}
+	if _, err := f.Seek(int64(start), io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &rangeReader{Reader: io.LimitReader(f, int64(end-start+1)), Closer: f}, nil
+}
+
+// rangeReader reads a range of a file, closing the file.
+type rangeReader struct {
+	io.Reader
+	io.Closer
+}
+
+// OpenSeeker opens the file for random access.
+func (i *item) OpenSeeker() (stow.ReadSeekCloser, error) {
+	f, err := i.container.location.sftpClient.Open(i.fullPath())
+	if err != nil {
+		return nil, err
+	}
+	return &seekableFile{f: f}, nil
+}
+
+// seekableFile adds ReadAt to a remote file, which can only seek. Calls
+// are serialized, and ReadAt leaves the offset of Read where it was.
+type seekableFile struct {
+	mu sync.Mutex
+	f  *sftp.File
+}
+
+func (s *seekableFile) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Read(p)
+}
+
+func (s *seekableFile) Seek(offset int64, whence int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Seek(offset, whence)
+}
+
+func (s *seekableFile) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pos, err := s.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.f.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(s.f, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	if _, serr := s.f.Seek(pos, io.SeekStart); err == nil {
+		err = serr
+	}
+	return n, err
+}
+
+func (s *seekableFile) Close() error {
+	return s.f.Close()
+}
+
+// fullPath gets the path of the file on the server.
+func (i *item) fullPath() string {
+	return i.container.filePath(i.path)
+}
+
 // LastMod returns the last modified date of the item.
 func (i *item) LastMod() (time.Time, error) {
 	return i.modTime, nil
@@ -69,9 +166,24 @@ func (i *item) ETag() (string, error) {
 	return i.modTime.String(), nil
 }
 
-// Metadata returns some item level metadata about the item.
+// Metadata returns some item level metadata about the item, along with the
+// metadata set with SetMetadata or MergeMetadata.
 func (i *item) Metadata() (map[string]interface{}, error) {
-	return i.md, nil
+	rec, err := i.container.readRecord(i.fullPath())
+	if err != nil {
+		return nil, err
+	}
+	if len(rec.Metadata) == 0 {
+		return i.md, nil
+	}
+	md := make(map[string]interface{}, len(i.md)+len(rec.Metadata))
+	for key, value := range rec.Metadata {
+		md[key] = value
+	}
+	for key, value := range i.md {
+		md[key] = value
+	}
+	return md, nil
 }
 
 func getFileMetadata(info os.FileInfo) map[string]interface{} {
@@ -0,0 +1,74 @@
+package sftp
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/require"
+)
+
+// pipeConn joins the ends of two pipes into the connection of a server.
+type pipeConn struct {
+	io.Reader
+	io.WriteCloser
+}
+
+// newPipeClient gets a client of an SFTP server in the process, serving
+// the local file system.
+func newPipeClient(t *testing.T) *sftp.Client {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	server, err := sftp.NewServer(pipeConn{serverR, serverW})
+	require.NoError(t, err)
+	go server.Serve()
+	client, err := sftp.NewClientPipe(clientR, clientW)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		// closing the server ends the reads of the client
+		server.Close()
+		client.Close()
+	})
+	return client
+}
+
+func TestSeekableFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stow-sftp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(path, []byte("0123456789"), 0644))
+
+	f, err := newPipeClient(t).Open(path)
+	require.NoError(t, err)
+	s := &seekableFile{f: f}
+	defer s.Close()
+
+	p := make([]byte, 3)
+	n, err := s.Read(p)
+	require.NoError(t, err)
+	require.Equal(t, "012", string(p[:n]))
+
+	// ReadAt leaves the offset of Read where it was
+	n, err = s.ReadAt(p, 6)
+	require.NoError(t, err)
+	require.Equal(t, "678", string(p[:n]))
+	n, err = s.Read(p)
+	require.NoError(t, err)
+	require.Equal(t, "345", string(p[:n]))
+
+	// reading past the end gives what there is with io.EOF
+	p = make([]byte, 4)
+	n, err = s.ReadAt(p, 8)
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, "89", string(p[:n]))
+	n, err = s.ReadAt(p, 10)
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, 0, n)
+	n, err = s.Read(p[:1])
+	require.NoError(t, err)
+	require.Equal(t, "6", string(p[:n]))
+}
@@ -0,0 +1,86 @@
+package sftp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+// sidecarDir is the name of the directories holding the records kept for
+// the files next to them, the same as the local kind uses. They are left
+// out of listings.
+const sidecarDir = ".stow"
+
+// record is the information kept in the sidecar of a file.
+type record struct {
+	Tags map[string]string `json:"tags,omitempty"`
+	// Content is set when the file was put with content properties.
+	Content *stow.ContentProperties `json:"content,omitempty"`
+	// Metadata is the metadata set with MetadataSetter.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func (rec record) isZero() bool {
+	return len(rec.Tags) == 0 && rec.Content == nil && len(rec.Metadata) == 0
+}
+
+// recordPath gets the path of the record kept for the file at p.
+func recordPath(p string) string {
+	dir, name := filepath.Split(p)
+	return filepath.Join(dir, sidecarDir, name+".json")
+}
+
+// readRecord reads the record of the file at p. A missing record gives a
+// zero value.
+func (c *container) readRecord(p string) (record, error) {
+	var rec record
+	f, err := c.location.sftpClient.Open(recordPath(p))
+	if os.IsNotExist(err) {
+		return rec, nil
+	}
+	if err != nil {
+		return rec, errors.Wrap(err, "opening record")
+	}
+	defer f.Close()
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return rec, errors.Wrap(err, "reading record")
+	}
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return rec, errors.Wrap(err, "decoding record")
+	}
+	return rec, nil
+}
+
+// writeRecord writes the record of the file at p, removing it when it holds
+// nothing.
+func (c *container) writeRecord(p string, rec record) error {
+	client := c.location.sftpClient
+	if rec.isZero() {
+		err := client.Remove(recordPath(p))
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "removing record")
+		}
+		return nil
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "encoding record")
+	}
+	if err := client.MkdirAll(filepath.Dir(recordPath(p))); err != nil {
+		return errors.Wrap(err, "creating sidecar directory")
+	}
+	f, err := client.Create(recordPath(p))
+	if err != nil {
+		return errors.Wrap(err, "creating record")
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return errors.Wrap(err, "writing record")
+	}
+	return f.Close()
+}
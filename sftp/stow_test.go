@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/graymeta/stow"
+	"github.com/graymeta/stow/local"
 	"github.com/graymeta/stow/test"
 	"github.com/stretchr/testify/require"
 )
@@ -138,6 +139,53 @@ func TestStow(t *testing.T) {
 				require.Equal(t, "", cursor)
 			})
 		})
+
+		t.Run("tags", func(t *testing.T) {
+			cont, err := location.CreateContainer("stowtest" + randName(10))
+			require.NoError(t, err)
+			defer location.RemoveContainer(cont.ID())
+
+			item, err := stow.PutWithOptions(cont, "dir/tagged.txt", strings.NewReader("tagged"), 6, nil, &stow.PutOptions{
+				Tags: map[string]interface{}{"team": "media"},
+			})
+			require.NoError(t, err)
+			tags, err := item.(stow.Taggable).Tags()
+			require.NoError(t, err)
+			require.Equal(t, map[string]interface{}{"team": "media"}, tags)
+
+			// the sidecar is not listed as an item
+			items, _, err := cont.Items(stow.NoPrefix, stow.CursorStart, 10)
+			require.NoError(t, err)
+			require.Len(t, items, 1)
+
+			require.NoError(t, item.(stow.TagSetter).SetTags(map[string]interface{}{"team": "sound"}))
+			tags, err = item.(stow.Taggable).Tags()
+			require.NoError(t, err)
+			require.Equal(t, map[string]interface{}{"team": "sound"}, tags)
+
+			require.NoError(t, item.(stow.TagSetter).DeleteTags())
+			tags, err = item.(stow.Taggable).Tags()
+			require.NoError(t, err)
+			require.Empty(t, tags)
+		})
+
+		t.Run("metadata", func(t *testing.T) {
+			cont, err := location.CreateContainer("stowtest" + randName(10))
+			require.NoError(t, err)
+			defer location.RemoveContainer(cont.ID())
+
+			item, err := cont.Put("described.txt", strings.NewReader("described"), 9, nil)
+			require.NoError(t, err)
+			setter := item.(stow.MetadataSetter)
+			require.NoError(t, setter.SetMetadata(map[string]interface{}{"one": "1", "two": "2"}))
+			require.NoError(t, setter.MergeMetadata(map[string]interface{}{"one": nil}))
+
+			md, err := item.Metadata()
+			require.NoError(t, err)
+			require.Equal(t, "2", md["two"])
+			require.NotContains(t, md, "one")
+			require.Error(t, setter.SetMetadata(map[string]interface{}{local.MetadataSize: "big"}))
+		})
 	})
 
 	t.Run("stow_tests - with base path", func(t *testing.T) {
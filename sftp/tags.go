@@ -0,0 +1,92 @@
+package sftp
+
+import (
+	"os"
+
+	"github.com/graymeta/stow"
+)
+
+var (
+	_ stow.Taggable         = (*item)(nil)
+	_ stow.TagSetter        = (*item)(nil)
+	_ stow.ContentDescriber = (*item)(nil)
+)
+
+// checkExists returns stow.ErrNotFound if the file is gone.
+func (i *item) checkExists() error {
+	if _, err := i.container.location.sftpClient.Stat(i.fullPath()); err != nil {
+		if os.IsNotExist(err) {
+			return stow.ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Tags gets the tags of the file from its sidecar record.
+func (i *item) Tags() (map[string]interface{}, error) {
+	if err := i.checkExists(); err != nil {
+		return nil, err
+	}
+	rec, err := i.container.readRecord(i.fullPath())
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]interface{}, len(rec.Tags))
+	for key, value := range rec.Tags {
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// SetTags records the tags of the file, replacing any it had.
+func (i *item) SetTags(tags map[string]interface{}) error {
+	values, err := stow.TagValues(tags)
+	if err != nil {
+		return err
+	}
+	return i.setTags(values)
+}
+
+// DeleteTags removes the tags of the file.
+func (i *item) DeleteTags() error {
+	return i.setTags(nil)
+}
+
+func (i *item) setTags(values map[string]string) error {
+	if err := i.checkExists(); err != nil {
+		return err
+	}
+	rec, err := i.container.readRecord(i.fullPath())
+	if err != nil {
+		return err
+	}
+	rec.Tags = values
+	return i.container.writeRecord(i.fullPath(), rec)
+}
+
+// ContentProperties gets the content properties the file was put with. A
+// missing content type is detected from the name and contents of the file.
+func (i *item) ContentProperties() (stow.ContentProperties, error) {
+	var props stow.ContentProperties
+	rec, err := i.container.readRecord(i.fullPath())
+	if err != nil {
+		return props, err
+	}
+	if rec.Content != nil {
+		props = *rec.Content
+	}
+	if props.ContentType != "" {
+		return props, nil
+	}
+	f, err := i.Open()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return props, stow.ErrNotFound
+		}
+		return props, err
+	}
+	defer f.Close()
+	props.ContentType, _, err = stow.DetectContentType(i.path, f)
+	return props, err
+}
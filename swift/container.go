@@ -59,12 +59,48 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 }
 
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutWithOptions(name, r, size, metadata, nil)
+}
+
+// PutWithOptions creates or updates an object like Put, also setting the
+// content headers given in the options.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, metadata map[string]interface{}, options *stow.PutOptions) (stow.Item, error) {
 	mdPrepped, err := prepMetadata(metadata)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create or update Item, preparing metadata")
 	}
 
-	headers, err := c.client.ObjectPut(c.id, name, r, false, "", "", mdPrepped)
+	if options != nil {
+		// storage policies and access are set on the container,
+		// encryption is up to the cluster, and objects have no tags.
+		switch {
+		case options.StorageClass != "":
+			return nil, stow.NotSupported("storage class")
+		case options.ACL != "":
+			return nil, stow.NotSupported("ACL")
+		case options.Encryption != nil:
+			return nil, stow.NotSupported("encryption")
+		case len(options.Tags) > 0:
+			return nil, stow.NotSupported("tags")
+		}
+	}
+
+	content, r, err := stow.PrepareContentProperties(name, r, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create or update Item, detecting content type")
+	}
+	addContentHeaders(mdPrepped, content)
+
+	var transfer stow.TransferOptions
+	if options != nil {
+		transfer = options.Transfer
+	}
+	var headers swift.Headers
+	if large, segmentSize := largeObject(size, transfer); large {
+		headers, err = c.putLargeObject(name, r, size, content.ContentType, mdPrepped, segmentSize, transfer.Concurrency)
+	} else {
+		headers, err = c.client.ObjectPut(c.id, name, r, false, "", content.ContentType, mdPrepped)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create or update Item")
 	}
@@ -80,12 +116,15 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 		client:    c.client,
 		size:      size,
 		metadata:  mdParsed,
+		content:   &content,
 	}
 	return item, nil
 }
 
+// RemoveItem removes the object, along with its segments when it is a
+// large object.
 func (c *container) RemoveItem(id string) error {
-	return c.client.ObjectDelete(c.id, id)
+	return c.client.LargeObjectDelete(c.id, id)
 }
 
 func (c *container) getItem(id string) (*item, error) {
@@ -110,10 +149,37 @@ func (c *container) getItem(id string) (*item, error) {
 		size:         info.Bytes,
 		lastModified: info.LastModified,
 		metadata:     md,
+		content:      parseContentProperties(info, headers),
 	}
 	return item, nil
 }
 
+// addContentHeaders adds the headers for the content properties other than
+// the type, which is passed to ObjectPut separately.
+func addContentHeaders(headers map[string]string, content stow.ContentProperties) {
+	for key, value := range map[string]string{
+		"Content-Encoding":    content.ContentEncoding,
+		"Content-Language":    content.ContentLanguage,
+		"Cache-Control":       content.CacheControl,
+		"Content-Disposition": content.ContentDisposition,
+	} {
+		if value != "" {
+			headers[key] = value
+		}
+	}
+}
+
+// parseContentProperties gets the content properties of an object.
+func parseContentProperties(info swift.Object, headers swift.Headers) *stow.ContentProperties {
+	return &stow.ContentProperties{
+		ContentType:        info.ContentType,
+		ContentEncoding:    headers["Content-Encoding"],
+		ContentLanguage:    headers["Content-Language"],
+		CacheControl:       headers["Cache-Control"],
+		ContentDisposition: headers["Content-Disposition"],
+	}
+}
+
 // Keys are returned as all lowercase, dashes are allowed
 func parseMetadata(md swift.Headers) (map[string]interface{}, error) {
 	m := make(map[string]interface{}, len(md))
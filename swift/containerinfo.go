@@ -0,0 +1,45 @@
+package swift
+
+import (
+	"strings"
+
+	"github.com/ncw/swift"
+	"github.com/pkg/errors"
+
+	"github.com/graymeta/stow"
+)
+
+// Stat describes the container from its headers. The storage policy is
+// returned as the storage class, and the container is public if its read
+// ACL has a referrer rule.
+func (c *container) Stat() (stow.ContainerInfo, error) {
+	_, headers, err := c.client.Container(c.id)
+	if err != nil {
+		if err == swift.ContainerNotFound {
+			return stow.ContainerInfo{}, stow.ErrNotFound
+		}
+		return stow.ContainerInfo{}, errors.Wrap(err, "getting container")
+	}
+	return parseContainerInfo(headers, c.client.Region), nil
+}
+
+func parseContainerInfo(headers swift.Headers, region string) stow.ContainerInfo {
+	info := stow.ContainerInfo{
+		Region:       region,
+		StorageClass: stow.StorageClass(headers["X-Storage-Policy"]),
+		Versioning:   stow.VersioningDisabled,
+		PublicAccess: strings.Contains(headers["X-Container-Read"], ".r:"),
+	}
+	if created, err := swift.FloatStringToTime(headers["X-Timestamp"]); err == nil {
+		info.Created = created
+	}
+	if headers["X-Versions-Location"] != "" || headers["X-History-Location"] != "" {
+		info.Versioning = stow.VersioningEnabled
+	}
+	metadata := headers.ContainerMetadata()
+	info.Metadata = make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		info.Metadata[k] = v
+	}
+	return info
+}
@@ -0,0 +1,129 @@
+package swift
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/graymeta/stow"
+	"github.com/ncw/swift"
+	"github.com/pkg/errors"
+)
+
+// limits of static large objects
+const (
+	maxObjectSize      = 5 * 1024 * 1024 * 1024
+	defaultSegmentSize = 100 * 1024 * 1024
+	maxSegments        = 1000
+	defaultConcurrency = 4
+)
+
+// segmentContainer gets the container the segments of large objects in
+// the container are kept in, which is the convention of the swift client.
+func (c *container) segmentContainer() string {
+	return c.id + "_segments"
+}
+
+// largeObject tells if an object of the size is uploaded as a static large
+// object, and gets the size of its segments.
+func largeObject(size int64, transfer stow.TransferOptions) (bool, int64) {
+	segmentSize := int64(defaultSegmentSize)
+	if transfer.PartSize > 0 {
+		segmentSize = transfer.PartSize
+	}
+	if segmentSize > maxObjectSize {
+		segmentSize = maxObjectSize
+	}
+	if least := (size + maxSegments - 1) / maxSegments; segmentSize < least {
+		segmentSize = least
+	}
+	return size > maxObjectSize || (transfer.PartSize > 0 && size > segmentSize), segmentSize
+}
+
+// manifestSegment is a segment in the manifest of a static large object.
+type manifestSegment struct {
+	Path      string `json:"path"`
+	ETag      string `json:"etag"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// putLargeObject uploads the segments of a static large object
+// concurrently, then puts its manifest.
+func (c *container) putLargeObject(name string, r io.Reader, size int64, contentType string, headers map[string]string, segmentSize int64, concurrency int) (swift.Headers, error) {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	segments := c.segmentContainer()
+	if err := c.client.ContainerCreate(segments, nil); err != nil {
+		return nil, errors.Wrap(err, "creating segment container")
+	}
+	prefix := fmt.Sprintf("%s/slo/%d/%d/%d", name, time.Now().UnixNano(), size, segmentSize)
+
+	var mu sync.Mutex
+	manifest := map[int]manifestSegment{}
+	n, err := stow.UploadParts(r, segmentSize, concurrency, func(number int, data []byte) error {
+		segment := fmt.Sprintf("%s/%08d", prefix, number)
+		h, err := c.client.ObjectPut(segments, segment, bytes.NewReader(data), false, "", "", swift.Headers{
+			"Content-Length": strconv.Itoa(len(data)),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "uploading segment %d", number)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		manifest[number] = manifestSegment{
+			Path:      "/" + segments + "/" + segment,
+			ETag:      h["Etag"],
+			SizeBytes: int64(len(data)),
+		}
+		return nil
+	})
+	if err != nil {
+		c.removeSegments(manifest)
+		return nil, err
+	}
+
+	list := make([]manifestSegment, n)
+	for number, segment := range manifest {
+		list[number-1] = segment
+	}
+	body, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	h := swift.Headers{"Content-Type": contentType}
+	for key, value := range headers {
+		h[key] = value
+	}
+	_, h, err = c.client.Call(c.client.StorageUrl, swift.RequestOpts{
+		Container:  c.id,
+		ObjectName: name,
+		Operation:  "PUT",
+		Parameters: url.Values{"multipart-manifest": {"put"}},
+		Headers:    h,
+		Body:       bytes.NewReader(body),
+		NoResponse: true,
+		OnReAuth: func() (string, error) {
+			return c.client.StorageUrl, nil
+		},
+	})
+	if err != nil {
+		c.removeSegments(manifest)
+		return nil, errors.Wrap(err, "putting manifest")
+	}
+	return h, nil
+}
+
+// removeSegments removes the segments of a large object that could not be
+// put, ignoring errors as the upload has already failed.
+func (c *container) removeSegments(manifest map[int]manifestSegment) {
+	prefix := "/" + c.segmentContainer() + "/"
+	for _, segment := range manifest {
+		c.client.ObjectDelete(c.segmentContainer(), segment.Path[len(prefix):])
+	}
+}
@@ -0,0 +1,166 @@
+package swift
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+	"github.com/ncw/swift"
+)
+
+// sloServer takes segments and manifests of static large objects, and
+// lists the objects of the files container.
+type sloServer struct {
+	url      string
+	mu       sync.Mutex
+	segments map[string]string
+	manifest []manifestSegment
+	headers  http.Header
+	// objects are the names listed, in order.
+	objects []string
+}
+
+func (s *sloServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Path == "/auth/v1.0" {
+		w.Header().Set("X-Storage-Url", s.url+"/v1/AUTH_test")
+		w.Header().Set("X-Auth-Token", "token")
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/v1/AUTH_test/")
+	switch {
+	case r.Method == http.MethodGet && path == "files":
+		s.list(w, r)
+	case r.Method != http.MethodPut || r.Header.Get("X-Auth-Token") != "token":
+		w.WriteHeader(http.StatusBadRequest)
+	case path == "files_segments":
+		w.WriteHeader(http.StatusAccepted)
+	case strings.HasPrefix(path, "files_segments/"):
+		b, _ := ioutil.ReadAll(r.Body)
+		sum := md5.Sum(b)
+		s.segments["/"+path] = string(b)
+		w.Header().Set("Etag", hex.EncodeToString(sum[:]))
+		w.WriteHeader(http.StatusCreated)
+	case path == "files/big.bin" && r.URL.Query().Get("multipart-manifest") == "put":
+		json.NewDecoder(r.Body).Decode(&s.manifest)
+		s.headers = r.Header
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// list lists the objects after the marker, up to the limit.
+func (s *sloServer) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	var objects []swift.Object
+	for _, name := range s.objects {
+		if name > query.Get("marker") && strings.HasPrefix(name, query.Get("prefix")) && len(objects) < limit {
+			objects = append(objects, swift.Object{Name: name, Bytes: 1})
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(objects)
+}
+
+func TestPutLargeObject(t *testing.T) {
+	is := is.New(t)
+
+	s := &sloServer{segments: map[string]string{}}
+	server := httptest.NewServer(s)
+	defer server.Close()
+	s.url = server.URL
+
+	c := &container{
+		id: "files",
+		client: &swift.Connection{
+			UserName: "user",
+			ApiKey:   "key",
+			AuthUrl:  server.URL + "/auth/v1.0",
+		},
+	}
+	data := []byte(strings.Repeat("abcdefghij", 10) + "xyz")
+	options := &stow.PutOptions{Transfer: stow.TransferOptions{PartSize: 10, Concurrency: 3}}
+	item, err := c.PutWithOptions("big.bin", bytes.NewReader(data), int64(len(data)), map[string]interface{}{"a": "b"}, options)
+	is.NoErr(err)
+	is.Equal(item.Name(), "big.bin")
+
+	is.Equal(len(s.manifest), 11)
+	var joined string
+	for _, segment := range s.manifest {
+		joined += s.segments[segment.Path]
+		sum := md5.Sum([]byte(s.segments[segment.Path]))
+		is.Equal(segment.ETag, hex.EncodeToString(sum[:]))
+		is.Equal(segment.SizeBytes, int64(len(s.segments[segment.Path])))
+	}
+	is.Equal(joined, string(data))
+	is.Equal(s.headers.Get("X-Object-Meta-a"), "b")
+	is.Equal(s.headers.Get("Content-Type"), "application/octet-stream")
+}
+
+func TestLargeObject(t *testing.T) {
+	is := is.New(t)
+
+	large, segmentSize := largeObject(10, stow.TransferOptions{})
+	is.False(large)
+	is.Equal(segmentSize, int64(defaultSegmentSize))
+
+	large, _ = largeObject(maxObjectSize+1, stow.TransferOptions{})
+	is.True(large)
+
+	large, segmentSize = largeObject(10, stow.TransferOptions{PartSize: 4})
+	is.True(large)
+	is.Equal(segmentSize, int64(4))
+
+	// segments are made larger to stay under the segment limit
+	_, segmentSize = largeObject(maxSegments*10+1, stow.TransferOptions{PartSize: 4})
+	is.Equal(segmentSize, int64(11))
+}
+
+func TestItemsMarker(t *testing.T) {
+	is := is.New(t)
+
+	s := &sloServer{objects: []string{"a", "b", "c", "d", "e"}}
+	server := httptest.NewServer(s)
+	defer server.Close()
+	s.url = server.URL
+
+	c := &container{
+		id: "files",
+		client: &swift.Connection{
+			UserName: "user",
+			ApiKey:   "key",
+			AuthUrl:  server.URL + "/auth/v1.0",
+		},
+	}
+	// a full page gives the name of its last object as the marker
+	items, cursor, err := c.Items(stow.NoPrefix, stow.CursorStart, 2)
+	is.NoErr(err)
+	is.Equal(len(items), 2)
+	is.Equal(cursor, "b")
+	// and a short one ends the listing
+	items, cursor, err = c.Items(stow.NoPrefix, "d", 2)
+	is.NoErr(err)
+	is.Equal(len(items), 1)
+	is.True(stow.IsCursorEnd(cursor))
+
+	var names []string
+	it := stow.NewItemIterator(c, stow.NoPrefix, stow.CursorStart, 2)
+	for it.Next() {
+		names = append(names, it.Item().Name())
+	}
+	is.NoErr(it.Err())
+	is.Equal(names, s.objects)
+}
@@ -0,0 +1,54 @@
+package swift
+
+import (
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+var _ stow.MetadataSetter = (*item)(nil)
+
+// SetMetadata replaces the metadata of the object with a POST request.
+func (i *item) SetMetadata(metadata map[string]interface{}) error {
+	mdPrepped, err := prepMetadata(metadata)
+	if err != nil {
+		return errors.Wrap(err, "unable to update Item metadata, preparing metadata")
+	}
+	current, err := i.container.getItem(i.id)
+	if err != nil {
+		return err
+	}
+	return i.postMetadata(current, mdPrepped)
+}
+
+// MergeMetadata changes the given keys in the metadata of the object. A
+// POST request replaces all metadata, so the current metadata is read
+// first.
+func (i *item) MergeMetadata(changes map[string]interface{}) error {
+	current, err := i.container.getItem(i.id)
+	if err != nil {
+		return err
+	}
+	mdPrepped, err := prepMetadata(stow.MergedMetadata(current.metadata, changes))
+	if err != nil {
+		return errors.Wrap(err, "unable to update Item metadata, preparing metadata")
+	}
+	return i.postMetadata(current, mdPrepped)
+}
+
+// postMetadata sets the metadata of the object. The POST request replaces
+// the content headers too, so those of the current object are sent again.
+func (i *item) postMetadata(current *item, metadata map[string]string) error {
+	addContentHeaders(metadata, *current.content)
+	if current.content.ContentType != "" {
+		metadata["Content-Type"] = current.content.ContentType
+	}
+	if err := i.client.ObjectUpdate(i.container.id, i.id, metadata); err != nil {
+		return errors.Wrap(err, "unable to update Item metadata")
+	}
+	md, err := parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	i.metadata = md
+	return nil
+}
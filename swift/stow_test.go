@@ -4,10 +4,12 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/cheekybits/is"
 	"github.com/graymeta/stow"
 	"github.com/graymeta/stow/test"
+	"github.com/ncw/swift"
 )
 
 func TestStow(t *testing.T) {
@@ -67,3 +69,21 @@ func TestPrepMetadataFailureWithNonStringValues(t *testing.T) {
 	_, err := prepMetadata(m)
 	is.Err(err)
 }
+
+func TestParseContainerInfo(t *testing.T) {
+	is := is.New(t)
+	info := parseContainerInfo(swift.Headers{
+		"X-Timestamp":              "1580702706.00000",
+		"X-Storage-Policy":         "gold",
+		"X-Container-Read":         ".r:*,.rlistings",
+		"X-History-Location":       "archive",
+		"X-Container-Meta-Team":    "media",
+		"X-Container-Object-Count": "3",
+	}, "LON")
+	is.True(info.Created.Equal(time.Unix(1580702706, 0)))
+	is.Equal(info.Region, "LON")
+	is.Equal(info.StorageClass, stow.StorageClass("gold"))
+	is.Equal(info.Versioning, stow.VersioningEnabled)
+	is.True(info.PublicAccess)
+	is.Equal(info.Metadata, map[string]interface{}{"team": "media"})
+}
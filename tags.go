@@ -0,0 +1,44 @@
+package stow
+
+import "fmt"
+
+// TagSetter represents an Item whose tags can be changed.
+// Tags are key/value pairs kept apart from the metadata, which services
+// can usually change without rewriting the Item and use to find it.
+type TagSetter interface {
+	// SetTags replaces the tags of the Item. Values must be strings.
+	SetTags(tags map[string]interface{}) error
+	// DeleteTags removes all tags from the Item.
+	DeleteTags() error
+}
+
+// TagLister represents a Container that can find Items by their tags.
+type TagLister interface {
+	// ItemsByTags gets a page of Items having all of the given tags.
+	// Use CursorStart for the first page, as with Container.Items.
+	ItemsByTags(tags map[string]string, cursor string, count int) ([]Item, string, error)
+}
+
+// TagValues gets the tags as strings, which is how all implementations
+// keep them. Values that are not strings give an error.
+func TagValues(tags map[string]interface{}) (map[string]string, error) {
+	values := make(map[string]string, len(tags))
+	for key, value := range tags {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value of tag %q is %T, not a string", key, value)
+		}
+		values[key] = s
+	}
+	return values, nil
+}
+
+// MatchTags gets whether tags has all of the wanted tags.
+func MatchTags(tags map[string]interface{}, want map[string]string) bool {
+	for key, value := range want {
+		if v, ok := tags[key].(string); !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
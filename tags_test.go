@@ -0,0 +1,27 @@
+package stow_test
+
+import (
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestTagValues(t *testing.T) {
+	is := is.New(t)
+	values, err := stow.TagValues(map[string]interface{}{"team": "media"})
+	is.NoErr(err)
+	is.Equal(values, map[string]string{"team": "media"})
+	_, err = stow.TagValues(map[string]interface{}{"count": 1})
+	is.Err(err)
+}
+
+func TestMatchTags(t *testing.T) {
+	is := is.New(t)
+	tags := map[string]interface{}{"team": "media", "stage": "raw"}
+	is.True(stow.MatchTags(tags, nil))
+	is.True(stow.MatchTags(tags, map[string]string{"team": "media"}))
+	is.True(stow.MatchTags(tags, map[string]string{"team": "media", "stage": "raw"}))
+	is.False(stow.MatchTags(tags, map[string]string{"team": "sound"}))
+	is.False(stow.MatchTags(tags, map[string]string{"owner": "media"}))
+}
@@ -147,12 +147,30 @@ func All(t *testing.T, kind string, config stow.Config) {
 	is.NoErr(acceptableTime(t, is, items[0], item1))
 
 	if ir, ok := item1.(stow.ItemRanger); ok {
-		rc, err := ir.OpenRange(0, 3)
-		is.NoErr(err)
-		defer rc.Close()
-		b, err := ioutil.ReadAll(rc)
-		is.NoErr(err)
-		is.Equal(b, []byte("item"))
+		// the end byte is included, and ranges running past the end of
+		// the item stop there
+		for _, r := range []struct {
+			start, end uint64
+			contents   string
+		}{
+			{0, 3, "item"},
+			{5, 7, "one"},
+			{7, 7, "e"},
+			{5, 100, "one"},
+		} {
+			rc, err := ir.OpenRange(r.start, r.end)
+			is.NoErr(err)
+			b, err := ioutil.ReadAll(rc)
+			is.NoErr(err)
+			is.NoErr(rc.Close())
+			is.Equal(string(b), r.contents)
+		}
+		// ranges starting past the end cannot be read
+		if rc, err := ir.OpenRange(8, 10); err == nil {
+			_, err = ioutil.ReadAll(rc)
+			rc.Close()
+			is.Err(err)
+		}
 	}
 
 	is.OK(item2.ID())
@@ -171,17 +189,101 @@ func All(t *testing.T, kind string, config stow.Config) {
 	is.Equal(readItemContents(is, item3), "item three")
 	is.NoErr(acceptableTime(t, is, items[2], item3))
 
+	// change the tags of an item, if the implementation allows
+	if setter, ok := item1.(stow.TagSetter); ok {
+		tags := map[string]interface{}{"stowtest": "tags"}
+		is.NoErr(setter.SetTags(tags))
+		got, err := item1.(stow.Taggable).Tags()
+		is.NoErr(err)
+		is.Equal(got, tags)
+		is.NoErr(setter.DeleteTags())
+		got, err = item1.(stow.Taggable).Tags()
+		is.NoErr(err)
+		is.Equal(len(got), 0)
+	}
+
+	// change the metadata of an item in place, if the implementation allows
+	if setter, ok := item2.(stow.MetadataSetter); ok {
+		is.NoErr(setter.SetMetadata(map[string]interface{}{"stowmetadata": "bar"}))
+		is.NoErr(setter.MergeMetadata(map[string]interface{}{"stowmetadata": nil, "stowother": "baz"}))
+		item2copy, err := c1.Item(item2.ID())
+		is.NoErr(err)
+		md, err := item2copy.Metadata()
+		is.NoErr(err)
+		is.Equal(md["stowother"], "baz")
+		_, ok := md["stowmetadata"]
+		is.False(ok)
+		is.Equal(readItemContents(is, item2copy), "item two")
+	}
+
 	// check ETags from items retrieved by the Items() method
 	is.OK(etag(t, is, items[0]))
 	is.OK(etag(t, is, items[1]))
 	is.OK(etag(t, is, items[2]))
 
+	// put an item with content headers, assert if the implementation allows
+	if _, ok := c2.(stow.OptionsPutter); ok {
+		content := stow.ContentProperties{
+			ContentType:        "text/plain",
+			ContentEncoding:    "identity",
+			ContentLanguage:    "en-US",
+			CacheControl:       "max-age=60",
+			ContentDisposition: `attachment; filename="item.txt"`,
+		}
+		options := &stow.PutOptions{ContentProperties: content}
+		item, err := stow.PutWithOptions(c2, "with_options", strings.NewReader("item"), 4, nil, options)
+		is.NoErr(err)
+		itemcopy, err := c2.Item(item.ID())
+		is.NoErr(err)
+		props, err := itemcopy.(stow.ContentDescriber).ContentProperties()
+		is.NoErr(err)
+		is.Equal(props, content)
+		is.NoErr(c2.RemoveItem(item.ID()))
+
+		// storage options either work or are reported as not supported
+		options = &stow.PutOptions{
+			StorageClass: stow.StorageClassHot,
+			Encryption:   &stow.Encryption{Mode: stow.EncryptionProvider},
+		}
+		item, err = stow.PutWithOptions(c2, "with_storage_options", strings.NewReader("item"), 4, nil, options)
+		if !stow.IsNotSupported(err) {
+			is.NoErr(err)
+			is.Equal(readItemContents(is, item), "item")
+			is.NoErr(c2.RemoveItem(item.ID()))
+		}
+	}
+
+	// put an item in parts, if the implementation allows
+	if _, ok := c2.(stow.ResumableUploader); ok {
+		store := stow.NewMemoryCheckpointStore()
+		options := &stow.ResumableOptions{Store: store}
+		item, err := stow.PutResumable(c2, "resumable", strings.NewReader("resumable item"), 14, nil, options)
+		is.NoErr(err)
+		itemcopy, err := c2.Item(item.ID())
+		is.NoErr(err)
+		is.Equal(readItemContents(is, itemcopy), "resumable item")
+		_, err = store.Load(stow.CheckpointKey(c2, "resumable"))
+		is.Equal(err, stow.ErrNotFound)
+		is.NoErr(c2.RemoveItem(item.ID()))
+	}
+
 	// get container by ID
 	c1copy, err := location.Container(c1.ID())
 	is.NoErr(err)
 	is.OK(c1copy)
 	is.Equal(c1copy.ID(), c1.ID())
 
+	// describe the container, if the implementation allows
+	if stater, ok := c1copy.(stow.ContainerStater); ok {
+		info, err := stater.Stat()
+		is.NoErr(err)
+		switch info.Versioning {
+		case "", stow.VersioningDisabled, stow.VersioningEnabled, stow.VersioningSuspended:
+		default:
+			is.Failf("unexpected versioning state %q", info.Versioning)
+		}
+	}
+
 	// get container by name
 	c1copy2, err := location.Container(c1Name)
 	is.NoErr(err)
@@ -0,0 +1,97 @@
+package stow
+
+import (
+	"io"
+	"sync"
+)
+
+// TransferOptions tune how an Item is uploaded in parts. They only change
+// how fast the Item is put and how much memory it takes, so they are hints:
+// implementations that put Items in a single request ignore them, and
+// sizes that break a limit of the service are adjusted.
+type TransferOptions struct {
+	// PartSize is the size of the parts. Zero uses the default of the
+	// implementation.
+	PartSize int64
+	// Concurrency is the number of parts uploaded at once. Zero uses the
+	// default of the implementation.
+	Concurrency int
+}
+
+// partBuffers holds the buffers of UploadParts for reuse.
+var partBuffers sync.Pool
+
+// getPartBuffer gets a buffer of the size from the pool, or a new one
+// when the pool has none large enough.
+func getPartBuffer(size int64) *[]byte {
+	if b, ok := partBuffers.Get().(*[]byte); ok && int64(cap(*b)) >= size {
+		*b = (*b)[:size]
+		return b
+	}
+	b := make([]byte, size)
+	return &b
+}
+
+// UploadParts reads r in parts of partSize bytes, and calls upload for each
+// of them with up to concurrency calls running at once. Parts are numbered
+// from 1, and only the last one may be shorter. An empty reader gives a
+// single empty part. The data is only valid until upload returns, as the
+// buffers are reused.
+// It stops reading once an upload fails, and returns the number of parts
+// read along with the first error.
+func UploadParts(r io.Reader, partSize int64, concurrency int, upload func(number int, data []byte) error) (int, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+
+	n := 0
+	for !failed() {
+		sem <- struct{}{}
+		buf := getPartBuffer(partSize)
+		read, err := io.ReadFull(r, *buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			<-sem
+			partBuffers.Put(buf)
+			fail(err)
+			break
+		}
+		if read == 0 && n > 0 {
+			<-sem
+			partBuffers.Put(buf)
+			break
+		}
+		n++
+		wg.Add(1)
+		go func(number int, buf *[]byte, data []byte) {
+			defer func() {
+				partBuffers.Put(buf)
+				<-sem
+				wg.Done()
+			}()
+			if err := upload(number, data); err != nil {
+				fail(err)
+			}
+		}(n, buf, (*buf)[:read])
+		if int64(read) < partSize {
+			break
+		}
+	}
+	wg.Wait()
+	return n, firstErr
+}
@@ -0,0 +1,66 @@
+package stow_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+func TestUploadParts(t *testing.T) {
+	is := is.New(t)
+
+	var mu sync.Mutex
+	parts := map[int]string{}
+	running, maxRunning := 0, 0
+	n, err := stow.UploadParts(strings.NewReader("abcdefghij"), 3, 2, func(number int, data []byte) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		parts[number] = string(data)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	is.NoErr(err)
+	is.Equal(n, 4)
+	is.Equal(parts, map[int]string{1: "abc", 2: "def", 3: "ghi", 4: "j"})
+	is.Equal(maxRunning, 2)
+
+	// a reader ending on a part boundary has no empty last part
+	n, err = stow.UploadParts(strings.NewReader("abcdef"), 3, 1, func(number int, data []byte) error {
+		is.Equal(len(data), 3)
+		return nil
+	})
+	is.NoErr(err)
+	is.Equal(n, 2)
+
+	n, err = stow.UploadParts(strings.NewReader(""), 3, 0, func(number int, data []byte) error {
+		is.Equal(len(data), 0)
+		return nil
+	})
+	is.NoErr(err)
+	is.Equal(n, 1)
+}
+
+func TestUploadPartsError(t *testing.T) {
+	is := is.New(t)
+	failure := errors.New("failure")
+	n, err := stow.UploadParts(strings.NewReader(strings.Repeat("x", 100)), 1, 1, func(number int, data []byte) error {
+		if number == 3 {
+			return failure
+		}
+		return nil
+	})
+	is.Equal(err, failure)
+	is.True(n < 100)
+}
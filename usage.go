@@ -0,0 +1,324 @@
+package stow
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// DelimiterLister represents a Container that can list Items as if the
+// delimiter separated directories in their names.
+type DelimiterLister interface {
+	// ItemsDelimited gets a page of the Items with the prefix that have no
+	// delimiter after it, and the distinct prefixes of the other Items up
+	// to and including the delimiter after the prefix. Cursors work like
+	// those of Container.Items.
+	ItemsDelimited(prefix, delimiter, cursor string, count int) ([]Item, []string, string, error)
+}
+
+// StorageClassDescriber represents an Item that knows the storage class it
+// is kept in without another request.
+type StorageClassDescriber interface {
+	// StorageClass gets the storage class of the Item.
+	StorageClass() (StorageClass, error)
+}
+
+// ListDelimited gets the Items with the prefix that have no delimiter after
+// it, and the prefixes of the other Items up to and including the next
+// delimiter. It lists a level at a time if the container is a
+// DelimiterLister, and walks all the Items with the prefix otherwise.
+func ListDelimited(container Container, prefix, delimiter string) ([]Item, []string, error) {
+	var items []Item
+	var prefixes []string
+	if d, ok := container.(DelimiterLister); ok {
+		cursor := CursorStart
+		for {
+			page, pagePrefixes, next, err := d.ItemsDelimited(prefix, delimiter, cursor, 1000)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, page...)
+			prefixes = append(prefixes, pagePrefixes...)
+			if IsCursorEnd(next) {
+				return items, prefixes, nil
+			}
+			cursor = next
+		}
+	}
+	seen := make(map[string]bool)
+	err := Walk(container, prefix, 1000, func(item Item, err error) error {
+		if err != nil {
+			return err
+		}
+		rest := strings.TrimPrefix(item.Name(), prefix)
+		i := strings.Index(rest, delimiter)
+		if i < 0 {
+			items = append(items, item)
+			return nil
+		}
+		p := prefix + rest[:i+len(delimiter)]
+		if !seen[p] {
+			seen[p] = true
+			prefixes = append(prefixes, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(prefixes)
+	return items, prefixes, nil
+}
+
+// WalkParallel walks the Items with each of the prefixes, walking up to
+// concurrency prefixes at once, or four when it is zero. The WalkFunc may
+// be called from several goroutines at the same time. The first error it
+// returns stops the walk and is returned.
+func WalkParallel(container Container, prefixes []string, pageSize, concurrency int, fn WalkFunc) error {
+	if concurrency <= 0 {
+		concurrency = defaultWalkConcurrency
+	}
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+	work := make(chan string)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for prefix := range work {
+				err := Walk(container, prefix, pageSize, func(item Item, err error) error {
+					if failed() {
+						return errWalkStopped
+					}
+					return fn(item, err)
+				})
+				if err != nil && err != errWalkStopped {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, prefix := range prefixes {
+		if failed() {
+			break
+		}
+		work <- prefix
+	}
+	close(work)
+	wg.Wait()
+	return firstErr
+}
+
+// defaultWalkConcurrency is the number of prefixes Usage walks at once.
+const defaultWalkConcurrency = 4
+
+// errWalkStopped stops the other walks of WalkParallel once one failed.
+var errWalkStopped = errors.New("stow: walk stopped")
+
+// UsageCount is how many Items there are and how many bytes they hold.
+type UsageCount struct {
+	Objects int64
+	Bytes   int64
+}
+
+func (u *UsageCount) add(size int64) {
+	u.Objects++
+	u.Bytes += size
+}
+
+// PrefixUsage is the usage of the Items under a prefix.
+type PrefixUsage struct {
+	// Prefix is the prefix the Items share.
+	Prefix string
+	// Depth is the number of delimiters in the prefix past the one Usage
+	// was given, zero for the total.
+	Depth int
+	UsageCount
+	// Classes breaks the usage down by storage class. Items of Containers
+	// that cannot tell the storage class without fetching each Item are
+	// counted under the empty class.
+	Classes map[StorageClass]UsageCount
+}
+
+// Usage gets the number of Items and bytes with the prefix, for the prefix
+// and for each prefix ending in a slash below it down to depth slashes,
+// much like du. The total comes first, followed by the prefixes in order.
+// It lists the levels above depth a level at a time if the Container is a
+// DelimiterLister and walks the prefixes at depth in parallel, or walks all
+// Items otherwise.
+func Usage(container Container, prefix string, depth int) ([]PrefixUsage, error) {
+	tally := newUsageTally(prefix, depth)
+	if _, ok := container.(DelimiterLister); !ok || depth <= 0 {
+		err := Walk(container, prefix, 1000, func(item Item, err error) error {
+			if err != nil {
+				return err
+			}
+			return tally.add(item)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return tally.result(), nil
+	}
+	level := []string{prefix}
+	for d := 0; d < depth && len(level) > 0; d++ {
+		var next []string
+		for _, p := range level {
+			items, prefixes, err := ListDelimited(container, p, "/")
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range items {
+				if err := tally.add(item); err != nil {
+					return nil, err
+				}
+			}
+			next = append(next, prefixes...)
+		}
+		level = next
+	}
+	err := WalkParallel(container, level, 1000, defaultWalkConcurrency, func(item Item, err error) error {
+		if err != nil {
+			return err
+		}
+		return tally.add(item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tally.result(), nil
+}
+
+// usageTally adds up the usage of Items by prefix.
+type usageTally struct {
+	prefix string
+	depth  int
+	mu     sync.Mutex
+	usage  map[string]*PrefixUsage
+}
+
+func newUsageTally(prefix string, depth int) *usageTally {
+	return &usageTally{
+		prefix: prefix,
+		depth:  depth,
+		usage:  make(map[string]*PrefixUsage),
+	}
+}
+
+// add counts the Item in the total and each of its prefixes.
+func (t *usageTally) add(item Item) error {
+	size, err := item.Size()
+	if err != nil {
+		return err
+	}
+	var class StorageClass
+	if d, ok := item.(StorageClassDescriber); ok {
+		class, err = d.StorageClass()
+		if err != nil {
+			return err
+		}
+	}
+	parts := strings.Split(strings.TrimPrefix(item.Name(), t.prefix), "/")
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := t.prefix
+	for d := 0; d <= t.depth && d < len(parts); d++ {
+		if d > 0 {
+			p += parts[d-1] + "/"
+		}
+		u, ok := t.usage[p]
+		if !ok {
+			u = &PrefixUsage{Prefix: p, Depth: d, Classes: make(map[StorageClass]UsageCount)}
+			t.usage[p] = u
+		}
+		u.add(size)
+		c := u.Classes[class]
+		c.add(size)
+		u.Classes[class] = c
+	}
+	return nil
+}
+
+// result gets the usage ordered by prefix, total first.
+func (t *usageTally) result() []PrefixUsage {
+	if _, ok := t.usage[t.prefix]; !ok {
+		t.usage[t.prefix] = &PrefixUsage{Prefix: t.prefix, Classes: make(map[StorageClass]UsageCount)}
+	}
+	result := make([]PrefixUsage, 0, len(t.usage))
+	for _, u := range t.usage {
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Prefix < result[j].Prefix
+	})
+	return result
+}
+
+// WriteUsage writes the usage as du would, a line per prefix with the
+// total last. The lines give the size, the number of Items and the prefix,
+// followed by the size in each storage class when the Items are in more
+// than one.
+func WriteUsage(w io.Writer, usage []PrefixUsage) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	write := func(u PrefixUsage) {
+		prefix := u.Prefix
+		if prefix == "" {
+			prefix = "."
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s", FormatBytes(u.Bytes), u.Objects, prefix)
+		if len(u.Classes) > 1 {
+			var classes []string
+			for class, count := range u.Classes {
+				if class == "" {
+					class = "unknown"
+				}
+				classes = append(classes, fmt.Sprintf("%s=%s", class, FormatBytes(count.Bytes)))
+			}
+			sort.Strings(classes)
+			fmt.Fprintf(tw, "\t%s", strings.Join(classes, " "))
+		}
+		fmt.Fprintln(tw)
+	}
+	for _, u := range usage {
+		if u.Depth > 0 {
+			write(u)
+		}
+	}
+	for _, u := range usage {
+		if u.Depth == 0 {
+			write(u)
+		}
+	}
+	return tw.Flush()
+}
+
+// FormatBytes formats the number of bytes in binary units, such as
+// "1.5 GiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
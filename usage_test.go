@@ -0,0 +1,178 @@
+package stow_test
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+// classedItem is an Item in a storage class.
+type classedItem struct {
+	dataItem
+	class stow.StorageClass
+}
+
+func (i *classedItem) StorageClass() (stow.StorageClass, error) { return i.class, nil }
+
+// usageContainer is a Container of Items of different sizes and classes,
+// recording the prefixes listed.
+type usageContainer struct {
+	pagedContainer
+	items  []stow.Item
+	mu     sync.Mutex
+	listed []string
+}
+
+func newUsageContainer() *usageContainer {
+	c := &usageContainer{}
+	for _, item := range []struct {
+		name  string
+		size  int
+		class stow.StorageClass
+	}{
+		{"a/1", 10, stow.StorageClassHot},
+		{"a/b/1", 20, stow.StorageClassHot},
+		{"a/b/2", 30, stow.StorageClassArchive},
+		{"a/c/d/1", 40, stow.StorageClassHot},
+		{"e/1", 50, stow.StorageClassCool},
+		{"top", 60, stow.StorageClassHot},
+	} {
+		c.items = append(c.items, &classedItem{
+			dataItem: dataItem{testItem: testItem{name: item.name}, data: make([]byte, item.size)},
+			class:    item.class,
+		})
+	}
+	return c
+}
+
+func (c *usageContainer) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	c.mu.Lock()
+	c.listed = append(c.listed, prefix)
+	c.mu.Unlock()
+	var items []stow.Item
+	for _, item := range c.items {
+		if strings.HasPrefix(item.Name(), prefix) {
+			items = append(items, item)
+		}
+	}
+	return items, "", nil
+}
+
+func (c *usageContainer) Put(name string, r io.Reader, size int64, md map[string]interface{}) (stow.Item, error) {
+	return nil, stow.NotSupported("Put")
+}
+
+func (c *usageContainer) takeListed() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	listed := c.listed
+	sort.Strings(listed)
+	c.listed = nil
+	return listed
+}
+
+// delimitedContainer is a usageContainer that lists a level at a time.
+type delimitedContainer struct {
+	*usageContainer
+}
+
+func (c delimitedContainer) ItemsDelimited(prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	c.mu.Lock()
+	c.listed = append(c.listed, "delimited:"+prefix)
+	c.mu.Unlock()
+	items, prefixes, err := stow.ListDelimited(&usageContainer{items: c.items}, prefix, delimiter)
+	return items, prefixes, "", err
+}
+
+func TestUsage(t *testing.T) {
+	is := is.New(t)
+
+	check := func(usage []stow.PrefixUsage) {
+		is.Equal(len(usage), 5)
+		is.Equal(usage[0].Prefix, "")
+		is.Equal(usage[0].Depth, 0)
+		is.Equal(usage[0].Objects, 6)
+		is.Equal(usage[0].Bytes, 210)
+		is.Equal(usage[0].Classes[stow.StorageClassHot], stow.UsageCount{Objects: 4, Bytes: 130})
+		is.Equal(usage[0].Classes[stow.StorageClassArchive], stow.UsageCount{Objects: 1, Bytes: 30})
+		is.Equal(usage[1].Prefix, "a/")
+		is.Equal(usage[1].Depth, 1)
+		is.Equal(usage[1].Objects, 4)
+		is.Equal(usage[1].Bytes, 100)
+		is.Equal(usage[2].Prefix, "a/b/")
+		is.Equal(usage[2].Depth, 2)
+		is.Equal(usage[2].Bytes, 50)
+		is.Equal(usage[3].Prefix, "a/c/")
+		is.Equal(usage[3].Objects, 1)
+		is.Equal(usage[4].Prefix, "e/")
+		is.Equal(usage[4].Classes[stow.StorageClassCool], stow.UsageCount{Objects: 1, Bytes: 50})
+	}
+
+	c := newUsageContainer()
+	usage, err := stow.Usage(c, "a/", 1)
+	is.NoErr(err)
+	is.Equal(len(usage), 3)
+	is.Equal(usage[0].Prefix, "a/")
+	is.Equal(usage[0].Objects, 4)
+	is.Equal(c.takeListed(), []string{"a/"})
+
+	usage, err = stow.Usage(c, stow.NoPrefix, 2)
+	is.NoErr(err)
+	check(usage)
+
+	// containers that list by delimiter are listed a level at a time,
+	// and the prefixes at depth are walked
+	d := delimitedContainer{newUsageContainer()}
+	usage, err = stow.Usage(d, stow.NoPrefix, 2)
+	is.NoErr(err)
+	check(usage)
+	is.Equal(d.takeListed(), []string{"a/b/", "a/c/", "delimited:", "delimited:a/", "delimited:e/"})
+
+	var buf bytes.Buffer
+	is.NoErr(stow.WriteUsage(&buf, usage))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	is.Equal(len(lines), 5)
+	is.True(strings.HasPrefix(lines[0], "100 B"))
+	is.True(strings.Contains(lines[0], "a/"))
+	is.True(strings.Contains(lines[0], "archive=30 B hot=70 B"))
+	is.True(strings.Contains(lines[4], "210 B  6"))
+	is.True(strings.HasSuffix(lines[4], "."+"  archive=30 B cool=50 B hot=130 B"))
+}
+
+func TestWalkParallel(t *testing.T) {
+	is := is.New(t)
+
+	c := newUsageContainer()
+	var mu sync.Mutex
+	var names []string
+	err := stow.WalkParallel(c, []string{"a/", "e/", "top"}, 10, 2, func(item stow.Item, err error) error {
+		is.NoErr(err)
+		mu.Lock()
+		defer mu.Unlock()
+		names = append(names, item.Name())
+		return nil
+	})
+	is.NoErr(err)
+	sort.Strings(names)
+	is.Equal(names, []string{"a/1", "a/b/1", "a/b/2", "a/c/d/1", "e/1", "top"})
+
+	stop := stow.NotSupported("stop")
+	err = stow.WalkParallel(c, []string{"a/", "e/", "top"}, 10, 2, func(item stow.Item, err error) error {
+		return stop
+	})
+	is.Equal(err, stop)
+}
+
+func TestFormatBytes(t *testing.T) {
+	is := is.New(t)
+	is.Equal(stow.FormatBytes(0), "0 B")
+	is.Equal(stow.FormatBytes(1023), "1023 B")
+	is.Equal(stow.FormatBytes(1536), "1.5 KiB")
+	is.Equal(stow.FormatBytes(5*1024*1024*1024), "5.0 GiB")
+}
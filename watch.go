@@ -0,0 +1,329 @@
+package stow
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// EventType is the kind of change an Event describes.
+type EventType string
+
+const (
+	// EventCreated is sent for Items that were not there before.
+	EventCreated EventType = "created"
+	// EventUpdated is sent for Items whose contents changed.
+	EventUpdated EventType = "updated"
+	// EventDeleted is sent for Items that were removed.
+	EventDeleted EventType = "deleted"
+)
+
+// Event describes a change to an Item in a watched Container.
+type Event struct {
+	// Type is the kind of change.
+	Type EventType
+	// ID is the ID of the Item.
+	ID string
+	// Item is the Item after the change, nil when it was deleted.
+	Item Item
+	// Version is the version of the Item after the change, as kept in
+	// Snapshots. It is empty when the Item was deleted.
+	Version string
+}
+
+// Snapshot records the version of each Item in a Container by ID, to tell
+// what changed since it was taken. It is the checkpoint a watch resumes
+// from, and can be stored as JSON.
+type Snapshot map[string]string
+
+// ItemVersion gets the version of the Item kept in Snapshots, made from its
+// ETag and last modified time.
+func ItemVersion(item Item) (string, error) {
+	etag, err := item.ETag()
+	if err != nil {
+		return "", err
+	}
+	lastMod, err := item.LastMod()
+	if err != nil {
+		return "", err
+	}
+	return etag + "|" + lastMod.UTC().Format(time.RFC3339Nano), nil
+}
+
+// TakeSnapshot gets the Items in the Container with the prefix by ID,
+// along with their Snapshot.
+func TakeSnapshot(container Container, prefix string) (Snapshot, map[string]Item, error) {
+	snapshot := make(Snapshot)
+	items := make(map[string]Item)
+	err := Walk(container, prefix, 1000, func(item Item, err error) error {
+		if err != nil {
+			return err
+		}
+		version, err := ItemVersion(item)
+		if err != nil {
+			return err
+		}
+		snapshot[item.ID()] = version
+		items[item.ID()] = item
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return snapshot, items, nil
+}
+
+// Change gets the Event turning the version of the Item in the snapshot
+// into the Item, which is nil when it is gone. It returns false when
+// nothing changed.
+func (s Snapshot) Change(id string, item Item) (Event, bool, error) {
+	old, ok := s[id]
+	if item == nil {
+		if !ok {
+			return Event{}, false, nil
+		}
+		return Event{Type: EventDeleted, ID: id}, true, nil
+	}
+	version, err := ItemVersion(item)
+	if err != nil {
+		return Event{}, false, err
+	}
+	event := Event{Type: EventCreated, ID: id, Item: item, Version: version}
+	if ok {
+		if old == version {
+			return Event{}, false, nil
+		}
+		event.Type = EventUpdated
+	}
+	return event, true, nil
+}
+
+// Diff gets the Events turning the snapshot into the Items, which are by
+// ID, ordered by ID.
+func (s Snapshot) Diff(items map[string]Item) ([]Event, error) {
+	ids := make([]string, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
+	for id := range s {
+		if _, ok := items[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	var events []Event
+	for _, id := range ids {
+		event, changed, err := s.Change(id, items[id])
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// apply records the change of the Event in the snapshot.
+func (s Snapshot) apply(event Event) {
+	if event.Type == EventDeleted {
+		delete(s, event.ID)
+		return
+	}
+	s[event.ID] = event.Version
+}
+
+// WatchOptions configure watching a Container.
+type WatchOptions struct {
+	// Checkpoint is the Snapshot to resume from, usually one a previous
+	// Watch of the same prefix gave. The changes since it was taken are
+	// sent first. When nil, the watch starts from the Items in the
+	// Container now.
+	Checkpoint Snapshot
+	// Interval is how often polling watches list the Container. Defaults
+	// to a minute.
+	Interval time.Duration
+}
+
+// Watch is a running watch on a Container.
+type Watch interface {
+	// Events gets the channel the Events are sent on. It is closed when
+	// the watch stops.
+	Events() <-chan Event
+	// Checkpoint gets a Snapshot of the Items as of the Events received
+	// from Events, to resume from later. It takes in every Event received
+	// once the Events channel is closed.
+	Checkpoint() Snapshot
+	// Err gets the error that stopped the watch, if any.
+	Err() error
+	// Close stops the watch.
+	Close() error
+}
+
+// Watcher represents a Container that can watch for changes to its Items
+// itself, rather than by polling.
+type Watcher interface {
+	// Watch watches the Items with the prefix.
+	Watch(prefix string, options *WatchOptions) (Watch, error)
+}
+
+// WatchContainer watches the Items in the Container with the prefix. It
+// uses the Container's own notifications if it is a Watcher, and polls it
+// otherwise.
+func WatchContainer(container Container, prefix string, options *WatchOptions) (Watch, error) {
+	if w, ok := container.(Watcher); ok {
+		return w.Watch(prefix, options)
+	}
+	return PollWatch(container, prefix, options)
+}
+
+// PollWatch watches the Items in the Container with the prefix by listing
+// them at the interval of the options, and comparing their versions to the
+// previous listing. It works with any Container.
+func PollWatch(container Container, prefix string, options *WatchOptions) (Watch, error) {
+	if options == nil {
+		options = &WatchOptions{}
+	}
+	interval := options.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	checkpoint, items := options.Checkpoint, map[string]Item(nil)
+	if checkpoint == nil {
+		var err error
+		checkpoint, items, err = TakeSnapshot(container, prefix)
+		if err != nil {
+			return nil, err
+		}
+	}
+	stream := NewEventStream(checkpoint)
+	go func() {
+		for {
+			if items == nil {
+				var err error
+				_, items, err = TakeSnapshot(container, prefix)
+				if err != nil {
+					stream.Stop(err)
+					return
+				}
+			}
+			events, err := stream.Checkpoint().Diff(items)
+			if err != nil {
+				stream.Stop(err)
+				return
+			}
+			for _, event := range events {
+				if !stream.Send(event) {
+					return
+				}
+			}
+			items = nil
+			select {
+			case <-stream.Done():
+				stream.Stop(nil)
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+	return stream, nil
+}
+
+// EventStream is a Watch sending Events on a channel and keeping the
+// checkpoint as they are received. It is for implementations of Watcher.
+type EventStream struct {
+	events     chan Event
+	done       chan struct{}
+	closeOnce  sync.Once
+	stopOnce   sync.Once
+	mu         sync.Mutex
+	checkpoint Snapshot
+	err        error
+}
+
+var _ Watch = (*EventStream)(nil)
+
+// NewEventStream makes an EventStream starting from the checkpoint.
+func NewEventStream(checkpoint Snapshot) *EventStream {
+	s := &EventStream{
+		events:     make(chan Event),
+		done:       make(chan struct{}),
+		checkpoint: make(Snapshot, len(checkpoint)),
+	}
+	for id, version := range checkpoint {
+		s.checkpoint[id] = version
+	}
+	return s
+}
+
+// Send sends the Event, waiting for it to be received. It returns false
+// when the watch was closed instead, after which nothing more should be
+// sent.
+func (s *EventStream) Send(event Event) bool {
+	select {
+	case s.events <- event:
+		s.mu.Lock()
+		s.checkpoint.apply(event)
+		s.mu.Unlock()
+		return true
+	case <-s.done:
+		s.Stop(nil)
+		return false
+	}
+}
+
+// Stop ends the watch with the error, closing the Events channel. It must
+// be called by the sender once it is done.
+func (s *EventStream) Stop(err error) {
+	s.stopOnce.Do(func() {
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+		close(s.events)
+	})
+}
+
+// Done gets a channel that is closed when the watch is closed.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Events gets the channel the Events are sent on.
+func (s *EventStream) Events() <-chan Event {
+	return s.events
+}
+
+// Checkpoint gets a copy of the Snapshot as of the last Event received.
+func (s *EventStream) Checkpoint() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	checkpoint := make(Snapshot, len(s.checkpoint))
+	for id, version := range s.checkpoint {
+		checkpoint[id] = version
+	}
+	return checkpoint
+}
+
+// Version gets the version of the Item with the ID as of the last Event
+// received, and whether there is one, without copying the checkpoint.
+func (s *EventStream) Version(id string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	version, ok := s.checkpoint[id]
+	return version, ok
+}
+
+// Err gets the error that stopped the watch.
+func (s *EventStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close stops the watch.
+func (s *EventStream) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
@@ -0,0 +1,169 @@
+package stow_test
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+)
+
+// changingContainer is a Container whose Items can be changed while it is
+// watched.
+type changingContainer struct {
+	pagedContainer
+	mu    sync.Mutex
+	items map[string]*dataItem
+}
+
+func newChangingContainer(names ...string) *changingContainer {
+	c := &changingContainer{items: make(map[string]*dataItem)}
+	for _, name := range names {
+		c.set(name, "1")
+	}
+	return c
+}
+
+func (c *changingContainer) set(name, etag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[name] = &dataItem{testItem: testItem{name: name}, etag: etag}
+}
+
+func (c *changingContainer) remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, name)
+}
+
+func (c *changingContainer) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var items []stow.Item
+	for name, item := range c.items {
+		if strings.HasPrefix(name, prefix) {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID() < items[j].ID() })
+	return items, "", nil
+}
+
+func (c *changingContainer) Put(name string, r io.Reader, size int64, md map[string]interface{}) (stow.Item, error) {
+	return nil, stow.NotSupported("Put")
+}
+
+// nextEvent waits for an Event of the watch.
+func nextEvent(is is.I, w stow.Watch) stow.Event {
+	select {
+	case event, ok := <-w.Events():
+		is.True(ok)
+		return event
+	case <-time.After(5 * time.Second):
+		is.Fail("no event")
+	}
+	return stow.Event{}
+}
+
+// closeWatch closes the watch and waits for it to stop.
+func closeWatch(is is.I, w stow.Watch) {
+	is.NoErr(w.Close())
+	for range w.Events() {
+	}
+	is.NoErr(w.Err())
+}
+
+func TestPollWatch(t *testing.T) {
+	is := is.New(t)
+
+	c := newChangingContainer("a/1", "a/2", "b/1")
+	w, err := stow.WatchContainer(c, "a/", &stow.WatchOptions{Interval: 10 * time.Millisecond})
+	is.NoErr(err)
+
+	c.set("a/3", "1")
+	c.set("b/2", "1")
+	event := nextEvent(is, w)
+	is.Equal(event.Type, stow.EventCreated)
+	is.Equal(event.ID, "a/3")
+	is.Equal(event.Item.Name(), "a/3")
+
+	c.set("a/1", "2")
+	c.remove("a/2")
+	event = nextEvent(is, w)
+	is.Equal(event.Type, stow.EventUpdated)
+	is.Equal(event.ID, "a/1")
+	event = nextEvent(is, w)
+	is.Equal(event.Type, stow.EventDeleted)
+	is.Equal(event.ID, "a/2")
+	is.Nil(event.Item)
+
+	closeWatch(is, w)
+	checkpoint := w.Checkpoint()
+	is.Equal(len(checkpoint), 2)
+	version, err := stow.ItemVersion(c.items["a/1"])
+	is.NoErr(err)
+	is.Equal(checkpoint["a/1"], version)
+
+	// resuming from the checkpoint sends what changed while not watching
+	c.set("a/3", "2")
+	c.set("a/4", "1")
+	w, err = stow.PollWatch(c, "a/", &stow.WatchOptions{Interval: time.Hour, Checkpoint: checkpoint})
+	is.NoErr(err)
+	event = nextEvent(is, w)
+	is.Equal(event.Type, stow.EventUpdated)
+	is.Equal(event.ID, "a/3")
+	event = nextEvent(is, w)
+	is.Equal(event.Type, stow.EventCreated)
+	is.Equal(event.ID, "a/4")
+	closeWatch(is, w)
+	is.Equal(len(w.Checkpoint()), 3)
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	is := is.New(t)
+
+	c := newChangingContainer("1", "2")
+	snapshot, _, err := stow.TakeSnapshot(c, stow.NoPrefix)
+	is.NoErr(err)
+	c.set("2", "2")
+	c.set("3", "1")
+	c.remove("1")
+	_, items, err := stow.TakeSnapshot(c, stow.NoPrefix)
+	is.NoErr(err)
+
+	events, err := snapshot.Diff(items)
+	is.NoErr(err)
+	is.Equal(len(events), 3)
+	is.Equal(events[0].Type, stow.EventDeleted)
+	is.Equal(events[1].Type, stow.EventUpdated)
+	is.Equal(events[2].Type, stow.EventCreated)
+
+	events, err = snapshot.Diff(map[string]stow.Item{"1": &dataItem{testItem: testItem{name: "1"}, etag: "1"}, "2": &dataItem{testItem: testItem{name: "2"}, etag: "1"}})
+	is.NoErr(err)
+	is.Equal(len(events), 0)
+}
+
+func TestEventStreamVersion(t *testing.T) {
+	is := is.New(t)
+	s := stow.NewEventStream(stow.Snapshot{"1": "a"})
+	defer s.Close()
+	go func() {
+		s.Send(stow.Event{Type: stow.EventCreated, ID: "2", Version: "b"})
+		s.Send(stow.Event{Type: stow.EventDeleted, ID: "1"})
+		s.Stop(nil)
+	}()
+	version, ok := s.Version("1")
+	is.True(ok)
+	is.Equal(version, "a")
+	for range s.Events() {
+	}
+	version, ok = s.Version("2")
+	is.True(ok)
+	is.Equal(version, "b")
+	_, ok = s.Version("1")
+	is.False(ok)
+}
@@ -0,0 +1,232 @@
+package webdav
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+// client makes the WebDAV requests of a location. Paths are the
+// unescaped paths of resources on the server, and those of collections
+// end in a slash.
+type client struct {
+	base     *url.URL
+	http     *http.Client
+	username string
+	password string
+}
+
+// resource is a file or a collection described by PROPFIND.
+type resource struct {
+	name    string
+	dir     bool
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+// propfindBody asks for the properties a resource is made from.
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:"><D:prop>
+<D:resourcetype/><D:getcontentlength/><D:getlastmodified/><D:getetag/>
+</D:prop></D:propfind>`
+
+type multistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Propstats []struct {
+			Prop struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"DAV: collection"`
+				} `xml:"DAV: resourcetype"`
+				ContentLength string `xml:"DAV: getcontentlength"`
+				LastModified  string `xml:"DAV: getlastmodified"`
+				ETag          string `xml:"DAV: getetag"`
+			} `xml:"DAV: prop"`
+			Status string `xml:"DAV: status"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+func (c *client) url(p string) string {
+	u := *c.base
+	u.Path = p
+	u.RawPath = ""
+	return u.String()
+}
+
+// do sends a request, giving an error for unexpected status codes. A
+// status of 404 Not Found gives stow.ErrNotFound.
+func (c *client) do(method, p string, header http.Header, body io.Reader, size int64, ok ...int) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.url(p), body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case body == nil:
+	case size == 0:
+		req.Body = http.NoBody
+	default:
+		req.ContentLength = size
+		// keep the client from closing the reader given to Put
+		req.Body = ioutil.NopCloser(body)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range ok {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, stow.ErrNotFound
+	}
+	return nil, errors.Errorf("webdav: %s %s: %s", method, p, resp.Status)
+}
+
+// propfind describes the resource at the path, and with a depth of 1 the
+// members of a collection after it. self is whether the resource itself
+// was described, first, rather than only its members.
+func (c *client) propfind(p string, depth int) (resources []resource, self bool, err error) {
+	header := http.Header{
+		"Depth":        {strconv.Itoa(depth)},
+		"Content-Type": {`application/xml; charset="utf-8"`},
+	}
+	resp, err := c.do("PROPFIND", p, header, strings.NewReader(propfindBody), int64(len(propfindBody)), http.StatusMultiStatus)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, false, errors.Wrapf(err, "webdav: PROPFIND %s: parsing response", p)
+	}
+
+	// the resource itself is listed first by most servers, but not all,
+	// and some leave it out of listings
+	selfPath := strings.TrimSuffix(p, "/")
+	resources = make([]resource, 0, len(ms.Responses))
+	for _, r := range ms.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "webdav: PROPFIND %s: parsing href", p)
+		}
+		hrefPath := strings.TrimSuffix(href.Path, "/")
+		res := resource{name: path.Base(hrefPath)}
+		for _, ps := range r.Propstats {
+			if !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			prop := ps.Prop
+			if prop.ResourceType.Collection != nil {
+				res.dir = true
+			}
+			if prop.ContentLength != "" {
+				res.size, _ = strconv.ParseInt(prop.ContentLength, 10, 64)
+			}
+			if prop.LastModified != "" {
+				res.modTime, _ = http.ParseTime(prop.LastModified)
+			}
+			if prop.ETag != "" {
+				res.etag = prop.ETag
+			}
+		}
+		if hrefPath == selfPath && !self {
+			self = true
+			resources = append([]resource{res}, resources...)
+			continue
+		}
+		resources = append(resources, res)
+	}
+	return resources, self, nil
+}
+
+// stat describes the resource at the path.
+func (c *client) stat(p string) (resource, error) {
+	resources, _, err := c.propfind(p, 0)
+	if err != nil {
+		return resource{}, err
+	}
+	// without members, what is described is the resource, whatever its href
+	if len(resources) == 0 {
+		return resource{}, stow.ErrNotFound
+	}
+	return resources[0], nil
+}
+
+// readDir describes the members of the collection at the path.
+func (c *client) readDir(p string) ([]resource, error) {
+	resources, self, err := c.propfind(p, 1)
+	if err != nil {
+		return nil, err
+	}
+	if self {
+		resources = resources[1:]
+	}
+	return resources, nil
+}
+
+// mkcol makes the collection at the path.
+func (c *client) mkcol(p string) error {
+	resp, err := c.do("MKCOL", p, nil, nil, 0, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// mkcolAll makes the collection at the path, along with the collections
+// above it that are missing.
+func (c *client) mkcolAll(p string) error {
+	if p == c.base.Path {
+		return nil
+	}
+	if _, err := c.stat(p); err == nil {
+		return nil
+	} else if err != stow.ErrNotFound {
+		return err
+	}
+	parent := path.Dir(strings.TrimSuffix(p, "/")) + "/"
+	if err := c.mkcolAll(parent); err != nil {
+		return err
+	}
+	return c.mkcol(p)
+}
+
+// delete removes the resource at the path, which for a collection
+// removes everything in it.
+func (c *client) delete(p string) error {
+	resp, err := c.do(http.MethodDelete, p, nil, nil, 0, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// put writes the file at the path.
+func (c *client) put(p string, r io.Reader, size int64) error {
+	resp, err := c.do(http.MethodPut, p, nil, r, size, http.StatusOK, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
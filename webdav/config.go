@@ -0,0 +1,105 @@
+package webdav
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+// Kind represents the name of the location/storage type.
+const Kind = "webdav"
+
+const (
+	// ConfigURL is the http or https URL of the WebDAV server, including
+	// the path it serves WebDAV under, such as
+	// https://cloud.example.com/remote.php/dav/files/someuser.
+	ConfigURL = "url"
+
+	// ConfigUsername is the username to authenticate with, using basic
+	// authentication. If not set, requests are not authenticated.
+	ConfigUsername = "username"
+
+	// ConfigPassword is the password to authenticate with.
+	ConfigPassword = "password"
+
+	// ConfigBasePath is the path of the collection below the URL whose
+	// collections are the containers. If not set, or set to an empty
+	// string, the collection at the URL is used.
+	ConfigBasePath = "base_path"
+)
+
+type conf struct {
+	url      *url.URL
+	username string
+	password string
+}
+
+func parseConfig(config stow.Config) (*conf, error) {
+	var c conf
+
+	rawURL, ok := config.Config(ConfigURL)
+	if !ok || rawURL == "" {
+		return nil, errors.New("url not specified")
+	}
+	var err error
+	c.url, err = url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing url")
+	}
+	if c.url.Scheme != "http" && c.url.Scheme != "https" || c.url.Host == "" {
+		return nil, errors.New("invalid url, expected an http or https URL")
+	}
+
+	c.username, _ = config.Config(ConfigUsername)
+	c.password, _ = config.Config(ConfigPassword)
+
+	// the base path is kept in the path of the URL, which always ends in
+	// a slash so that the names of containers can be resolved against it
+	basePath, _ := config.Config(ConfigBasePath)
+	c.url.Path = strings.TrimSuffix(c.url.Path, "/") + "/"
+	if basePath = strings.Trim(basePath, "/"); basePath != "" {
+		c.url.Path += basePath + "/"
+	}
+	c.url.RawPath = ""
+	c.url.RawQuery = ""
+	c.url.Fragment = ""
+
+	return &c, nil
+}
+
+func init() {
+	validatefn := func(config stow.Config) error {
+		_, err := parseConfig(config)
+		return err
+	}
+	makefn := func(config stow.Config) (stow.Location, error) {
+		c, err := parseConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		loc := &location{
+			config: c,
+			client: &client{
+				base:     c.url,
+				http:     &http.Client{},
+				username: c.username,
+				password: c.password,
+			},
+		}
+		// check the base collection is there, so that bad configuration
+		// fails when dialing
+		if _, err := loc.client.stat(c.url.Path); err != nil {
+			return nil, errors.Wrap(err, "checking base path")
+		}
+		return loc, nil
+	}
+
+	kindfn := func(u *url.URL) bool {
+		return u.Scheme == Kind
+	}
+
+	stow.Register(Kind, makefn, kindfn, validatefn)
+}
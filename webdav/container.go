@@ -0,0 +1,169 @@
+package webdav
+
+import (
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/graymeta/stow"
+)
+
+type container struct {
+	name     string
+	location *location
+}
+
+// ID returns a string value which represents the name of the container.
+func (c *container) ID() string {
+	return c.name
+}
+
+// Name returns a string value which represents the name of the container.
+func (c *container) Name() string {
+	return c.name
+}
+
+// filePath gets the path on the server of the file with the name.
+func (c *container) filePath(name string) string {
+	return c.location.collectionPath(c.name) + strings.TrimPrefix(name, "/")
+}
+
+// Item returns a stow.Item instance of a container based on the name of the
+// container and the file.
+func (c *container) Item(id string) (stow.Item, error) {
+	r, err := c.location.client.stat(c.filePath(id))
+	if err != nil {
+		return nil, err
+	}
+	if r.dir {
+		return nil, stow.ErrNotFound
+	}
+	return c.newItem(id, r), nil
+}
+
+func (c *container) newItem(name string, r resource) *item {
+	return &item{
+		container: c,
+		path:      name,
+		size:      r.size,
+		modTime:   r.modTime,
+		etag:      r.etag,
+	}
+}
+
+// Items sends a request to retrieve a list of items that are prepended with
+// the prefix argument. The 'cursor' variable facilitates pagination.
+//
+// The collections of the container are walked with one PROPFIND of depth 1
+// each, as many servers refuse infinite depth, and only those that can
+// hold names after the cursor with the prefix are looked into.
+func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	w := walker{
+		container: c,
+		prefix:    prefix,
+		cursor:    cursor,
+		// one more item than asked for tells whether there are more
+		limit: count + 1,
+	}
+	if err := w.walk(""); err != nil {
+		return nil, "", err
+	}
+	if len(w.items) > count {
+		return w.items[:count], w.items[count-1].Name(), nil
+	}
+	return w.items, "", nil
+}
+
+// walker collects the items of a container in the order of their names.
+type walker struct {
+	container *container
+	prefix    string
+	cursor    string
+	limit     int
+	items     []stow.Item
+}
+
+// walk collects the items in the directory, whose name is empty or ends
+// with a slash, until it has enough.
+func (w *walker) walk(dir string) error {
+	resources, err := w.container.location.client.readDir(w.container.filePath(dir))
+	if err != nil {
+		return err
+	}
+
+	// names of directories sort with their slash, which keeps the order
+	// of the walk that of the full names
+	names := make([]string, len(resources))
+	for i, r := range resources {
+		names[i] = dir + r.name
+		if r.dir {
+			names[i] += "/"
+		}
+	}
+	sort.Sort(byName{names, resources})
+
+	for i, r := range resources {
+		name := names[i]
+		if r.dir {
+			if !w.within(name) {
+				continue
+			}
+			if err := w.walk(name); err != nil {
+				return err
+			}
+		} else if name > w.cursor && strings.HasPrefix(name, w.prefix) {
+			w.items = append(w.items, w.container.newItem(name, r))
+		}
+		if len(w.items) == w.limit {
+			return nil
+		}
+	}
+	return nil
+}
+
+// within gets whether the directory can hold names after the cursor with
+// the prefix.
+func (w *walker) within(dir string) bool {
+	if !strings.HasPrefix(dir, w.prefix) && !strings.HasPrefix(w.prefix, dir) {
+		return false
+	}
+	// every name in the directory sorts before a cursor that is after the
+	// directory but not in it
+	return w.cursor < dir || strings.HasPrefix(w.cursor, dir)
+}
+
+// byName sorts resources by their names.
+type byName struct {
+	names     []string
+	resources []resource
+}
+
+func (s byName) Len() int           { return len(s.names) }
+func (s byName) Less(i, j int) bool { return s.names[i] < s.names[j] }
+func (s byName) Swap(i, j int) {
+	s.names[i], s.names[j] = s.names[j], s.names[i]
+	s.resources[i], s.resources[j] = s.resources[j], s.resources[i]
+}
+
+// RemoveItem removes a file from the server.
+func (c *container) RemoveItem(id string) error {
+	return c.location.client.delete(c.filePath(id))
+}
+
+// Put sends a request to upload content to the container. The collections
+// the name is in are made first, as servers do not make them for PUT.
+func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	if len(metadata) > 0 {
+		return nil, stow.NotSupported("metadata")
+	}
+	p := c.filePath(name)
+	if err := c.location.client.mkcolAll(path.Dir(p) + "/"); err != nil {
+		return nil, err
+	}
+	if err := c.location.client.put(p, r, size); err != nil {
+		return nil, err
+	}
+	// the server has the modification time and ETag of the new file
+	return c.Item(name)
+}
@@ -0,0 +1,8 @@
+/*
+Package webdav provides an abstraction of a WebDAV server, such as Nextcloud or another server sharing files over WebDAV. A Stow Container is a top-level collection, and a Stow Item is a file within it, at any depth.
+
+stow.Dial requires the Kind ("webdav") and a stow.Config instance with a key of webdav.ConfigURL holding the http or https URL the server serves WebDAV under. Requests are authenticated with basic authentication when webdav.ConfigUsername and webdav.ConfigPassword are given. webdav.ConfigBasePath names a collection below the URL whose collections are the Containers.
+
+Containers are made with MKCOL, listed with PROPFIND and removed, with everything in them, with DELETE. Items are listed by walking the collections of a Container with PROPFIND, put with PUT after making the collections they are in, opened with GET, in ranges where the server supports them, and removed with DELETE. Putting Items with metadata gives an error satisfying stow.IsNotSupported.
+*/
+package webdav
@@ -0,0 +1,110 @@
+package webdav
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/graymeta/stow"
+)
+
+var _ stow.ItemRanger = (*item)(nil)
+
+type item struct {
+	container *container
+	path      string
+	size      int64
+	modTime   time.Time
+	etag      string
+}
+
+// ID returns a string value that represents the name of a file.
+func (i *item) ID() string {
+	return i.path
+}
+
+// Name returns a string value that represents the name of the file.
+func (i *item) Name() string {
+	return i.path
+}
+
+// Size returns the size of an item in bytes.
+func (i *item) Size() (int64, error) {
+	return i.size, nil
+}
+
+// URL returns a formatted string identifying this asset.
+// Format is: webdav://<host>/<container>/<path to file>
+func (i *item) URL() *url.URL {
+	genericURL := fmt.Sprintf("/%s/%s", i.container.Name(), i.Name())
+	return &url.URL{
+		Scheme: Kind,
+		Path:   genericURL,
+		Host:   i.container.location.config.url.Host,
+	}
+}
+
+// Open retrieves the contents of the file with a GET request.
+func (i *item) Open() (io.ReadCloser, error) {
+	resp, err := i.container.location.client.do(http.MethodGet, i.container.filePath(i.path), nil, nil, 0, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// OpenRange opens the file for reading starting at byte start and ending
+// at byte end, or at the end of the file when it is shorter. Servers that
+// ignore the Range header send the whole file, which is cut down to the
+// range here.
+func (i *item) OpenRange(start, end uint64) (io.ReadCloser, error) {
+	if end < start {
+		return nil, fmt.Errorf("bad range %d-%d", start, end)
+	}
+	header := http.Header{"Range": {fmt.Sprintf("bytes=%d-%d", start, end)}}
+	resp, err := i.container.location.client.do(http.MethodGet, i.container.filePath(i.path), header, nil, 0, http.StatusOK, http.StatusPartialContent)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		return resp.Body, nil
+	}
+	if _, err := io.CopyN(ioutil.Discard, resp.Body, int64(start)); err != nil {
+		resp.Body.Close()
+		if err == io.EOF {
+			return nil, fmt.Errorf("range %d-%d starts past the end of the file", start, end)
+		}
+		return nil, err
+	}
+	return &rangeReader{Reader: io.LimitReader(resp.Body, int64(end-start+1)), Closer: resp.Body}, nil
+}
+
+// rangeReader reads a range of a response, closing the response.
+type rangeReader struct {
+	io.Reader
+	io.Closer
+}
+
+// LastMod returns the last modified date of the item.
+func (i *item) LastMod() (time.Time, error) {
+	return i.modTime, nil
+}
+
+// ETag returns the ETag the server gave for the file, without its
+// quotes, or its last modified time when it gave none.
+func (i *item) ETag() (string, error) {
+	if etag := strings.Trim(strings.TrimPrefix(i.etag, "W/"), `"`); etag != "" {
+		return etag, nil
+	}
+	return i.modTime.String(), nil
+}
+
+// Metadata returns an empty map, as WebDAV files have no metadata of
+// their own.
+func (i *item) Metadata() (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
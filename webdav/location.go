@@ -0,0 +1,117 @@
+package webdav
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/graymeta/stow"
+	"github.com/pkg/errors"
+)
+
+type location struct {
+	// we keep config here so that we can access the server information
+	// when constructing the item urls.
+	config *conf
+	client *client
+}
+
+// collectionPath gets the path on the server of the collection of the
+// container with the name.
+func (l *location) collectionPath(name string) string {
+	return l.config.url.Path + name + "/"
+}
+
+// CreateContainer creates a new container, in this case a collection on the
+// server.
+func (l *location) CreateContainer(containerName string) (stow.Container, error) {
+	if err := l.client.mkcol(l.collectionPath(containerName)); err != nil {
+		return nil, err
+	}
+
+	return &container{
+		location: l,
+		name:     containerName,
+	}, nil
+}
+
+// Containers returns a slice of the Container interface, a cursor, and an error.
+func (l *location) Containers(prefix, cursor string, count int) ([]stow.Container, string, error) {
+	resources, err := l.client.readDir(l.config.url.Path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var names []string
+	for _, r := range resources {
+		if r.dir && strings.HasPrefix(r.name, prefix) && r.name > cursor {
+			names = append(names, r.name)
+		}
+	}
+	sort.Strings(names)
+
+	var cont []stow.Container
+	for _, name := range names {
+		if len(cont) == count {
+			return cont, cont[len(cont)-1].Name(), nil
+		}
+		cont = append(cont, &container{
+			location: l,
+			name:     name,
+		})
+	}
+
+	return cont, "", nil
+}
+
+// Close does nothing, as requests to the server are not kept open.
+func (l *location) Close() error {
+	return nil
+}
+
+// Container retrieves a stow.Container based on its name which must be exact.
+func (l *location) Container(id string) (stow.Container, error) {
+	r, err := l.client.stat(l.collectionPath(id))
+	if err != nil {
+		return nil, err
+	}
+	if !r.dir {
+		return nil, stow.ErrNotFound
+	}
+	return &container{
+		location: l,
+		name:     id,
+	}, nil
+}
+
+// RemoveContainer removes a container by name, along with everything in
+// it.
+func (l *location) RemoveContainer(id string) error {
+	return l.client.delete(l.collectionPath(id))
+}
+
+// ItemByURL retrieves a stow.Item by parsing the URL.
+func (l *location) ItemByURL(u *url.URL) (stow.Item, error) {
+	// expect webdav://<host>/<container>/<path to file>
+	// example: webdav://cloud.example.com/foo/blah/baz.txt
+
+	urlParts := strings.Split(u.Path, "/")
+	if len(urlParts) < 3 {
+		return nil, errors.New("parsing ItemByURL unexpected length")
+	}
+
+	containerName := urlParts[1]
+	itemName := strings.Join(urlParts[2:], "/")
+
+	c, err := l.Container(containerName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ItemByURL, getting container %q", containerName)
+	}
+
+	i, err := c.Item(itemName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ItemByURL, getting item %q", itemName)
+	}
+
+	return i, nil
+}
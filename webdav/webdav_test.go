@@ -0,0 +1,192 @@
+package webdav
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/graymeta/stow"
+	"github.com/graymeta/stow/test"
+	"golang.org/x/net/webdav"
+)
+
+// newServer serves an in-memory WebDAV file system under /dav, with a
+// base collection at /dav/files, for the username and password.
+func newServer(t *testing.T, ranges bool) (*httptest.Server, stow.ConfigMap) {
+	is := is.New(t)
+	fs := webdav.NewMemFS()
+	is.NoErr(fs.Mkdir(context.Background(), "/files", 0755))
+	handler := &webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: fs,
+		LockSystem: webdav.NewMemLS(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !ranges {
+			r.Header.Del("Range")
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	config := stow.ConfigMap{
+		ConfigURL:      server.URL + "/dav",
+		ConfigUsername: "user",
+		ConfigPassword: "secret",
+		ConfigBasePath: "files",
+	}
+	return server, config
+}
+
+func TestStow(t *testing.T) {
+	server, config := newServer(t, true)
+	defer server.Close()
+	test.All(t, Kind, config)
+}
+
+func put(is is.I, c stow.Container, name, content string) {
+	_, err := c.Put(name, strings.NewReader(content), int64(len(content)), nil)
+	is.NoErr(err)
+}
+
+func TestItems(t *testing.T) {
+	is := is.New(t)
+	server, config := newServer(t, true)
+	defer server.Close()
+	location, err := stow.Dial(Kind, config)
+	is.NoErr(err)
+	defer location.Close()
+
+	c, err := location.CreateContainer("walk")
+	is.NoErr(err)
+	names := []string{"a-b", "a/b/c", "a/b/d", "a/e", "a0", "b", "empty/", "z/y/x"}
+	for _, name := range names {
+		if strings.HasSuffix(name, "/") {
+			is.NoErr(c.(*container).location.client.mkcol(c.(*container).filePath(name)))
+			continue
+		}
+		put(is, c, name, name)
+	}
+	want := []string{"a-b", "a/b/c", "a/b/d", "a/e", "a0", "b", "z/y/x"}
+
+	// paging with any count gets every item once, in order
+	for count := 1; count <= len(want)+1; count++ {
+		var got []string
+		cursor := stow.CursorStart
+		for {
+			items, next, err := c.Items(stow.NoPrefix, cursor, count)
+			is.NoErr(err)
+			is.True(len(items) <= count)
+			for _, item := range items {
+				got = append(got, item.Name())
+			}
+			if stow.IsCursorEnd(next) {
+				break
+			}
+			cursor = next
+		}
+		is.Equal(got, want)
+	}
+
+	items, _, err := c.Items("a/b", stow.CursorStart, 10)
+	is.NoErr(err)
+	is.Equal(len(items), 2)
+	is.Equal(items[0].Name(), "a/b/c")
+	is.Equal(items[1].Name(), "a/b/d")
+
+	// a collection is not an item
+	_, err = c.Item("a/b")
+	is.Equal(err, stow.ErrNotFound)
+
+	_, err = c.Put("meta", strings.NewReader("x"), 1, map[string]interface{}{"a": "b"})
+	is.True(stow.IsNotSupported(err))
+
+	// removing the container removes everything in it
+	is.NoErr(location.RemoveContainer(c.ID()))
+	_, err = location.Container(c.ID())
+	is.Equal(err, stow.ErrNotFound)
+}
+
+func TestRangeIgnored(t *testing.T) {
+	is := is.New(t)
+	server, config := newServer(t, false)
+	defer server.Close()
+	location, err := stow.Dial(Kind, config)
+	is.NoErr(err)
+	defer location.Close()
+
+	c, err := location.CreateContainer("ranges")
+	is.NoErr(err)
+	put(is, c, "item", "0123456789")
+	item, err := c.Item("item")
+	is.NoErr(err)
+
+	rc, err := item.(stow.ItemRanger).OpenRange(3, 5)
+	is.NoErr(err)
+	b, err := ioutil.ReadAll(rc)
+	is.NoErr(err)
+	is.NoErr(rc.Close())
+	is.Equal(string(b), "345")
+
+	_, err = item.(stow.ItemRanger).OpenRange(20, 30)
+	is.Err(err)
+}
+
+func TestConfig(t *testing.T) {
+	is := is.New(t)
+	server, config := newServer(t, true)
+	defer server.Close()
+
+	is.Err(stow.Validate(Kind, stow.ConfigMap{}))
+	is.Err(stow.Validate(Kind, stow.ConfigMap{ConfigURL: "ftp://example.com"}))
+
+	wrong := stow.ConfigMap{}
+	for key, value := range config {
+		wrong[key] = value
+	}
+	wrong[ConfigPassword] = "wrong"
+	_, err := stow.Dial(Kind, wrong)
+	is.Err(err)
+
+	missing := stow.ConfigMap{}
+	for key, value := range config {
+		missing[key] = value
+	}
+	missing[ConfigBasePath] = "missing"
+	_, err = stow.Dial(Kind, missing)
+	is.Err(err)
+}
+
+func TestReadDirWithoutSelf(t *testing.T) {
+	is := is.New(t)
+	// a server listing the members of a collection without the collection
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Method, "PROPFIND")
+		w.WriteHeader(http.StatusMultiStatus)
+		io.WriteString(w, `<?xml version="1.0" encoding="utf-8"?>
+<D:multistatus xmlns:D="DAV:">
+<D:response><D:href>/dav/files/a</D:href><D:propstat><D:prop><D:resourcetype/><D:getcontentlength>1</D:getcontentlength></D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>
+<D:response><D:href>/dav/files/b/</D:href><D:propstat><D:prop><D:resourcetype><D:collection/></D:resourcetype></D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>
+</D:multistatus>`)
+	}))
+	defer server.Close()
+	base, err := url.Parse(server.URL)
+	is.NoErr(err)
+	c := &client{base: base, http: http.DefaultClient}
+
+	resources, err := c.readDir("/dav/files/")
+	is.NoErr(err)
+	is.Equal(len(resources), 2)
+	is.Equal(resources[0].name, "a")
+	is.Equal(resources[0].size, 1)
+	is.Equal(resources[1].name, "b")
+	is.True(resources[1].dir)
+}